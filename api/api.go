@@ -1,14 +1,119 @@
 package api
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/audit"
+	"github.com/Hunterlemming/golang-microservice-example/api/blob"
+	"github.com/Hunterlemming/golang-microservice-example/api/collab"
+	"github.com/Hunterlemming/golang-microservice-example/api/collection"
+	"github.com/Hunterlemming/golang-microservice-example/api/duplicate"
+	"github.com/Hunterlemming/golang-microservice-example/api/event"
+	"github.com/Hunterlemming/golang-microservice-example/api/genre"
+	"github.com/Hunterlemming/golang-microservice-example/api/gql"
 	"github.com/Hunterlemming/golang-microservice-example/api/model"
 	"github.com/Hunterlemming/golang-microservice-example/api/movie"
+	"github.com/Hunterlemming/golang-microservice-example/api/openapi"
+	"github.com/Hunterlemming/golang-microservice-example/api/person"
+	"github.com/Hunterlemming/golang-microservice-example/api/poster"
+	"github.com/Hunterlemming/golang-microservice-example/api/release"
+	"github.com/Hunterlemming/golang-microservice-example/api/review"
+	"github.com/Hunterlemming/golang-microservice-example/api/similar"
+	"github.com/Hunterlemming/golang-microservice-example/api/stream"
+	"github.com/Hunterlemming/golang-microservice-example/api/tag"
+	"github.com/Hunterlemming/golang-microservice-example/api/translation"
+	"github.com/Hunterlemming/golang-microservice-example/api/watchlist"
+	"github.com/Hunterlemming/golang-microservice-example/api/webhook"
 
 	"github.com/gorilla/mux"
 )
 
-func Start() model.Api {
-	api := model.Api{Router: mux.NewRouter(), DB: getDatabaseConnection()}
-	movie.InitializeMoviesPipeline(&api)
-	return api
+// How long the requests in flight may take to finish once the server is stopped
+const shutdownTimeout = 10 * time.Second
+
+// Serve runs the REST and gRPC APIs on the migrated database until the context is done,
+// then stops them gracefully. It returns early if either of them fails.
+func Serve(ctx context.Context, db *sql.DB) error {
+	api := model.Api{Router: mux.NewRouter(), DB: db}
+	api.Router.Use(requestContext(getApiTokens(), getActorRoles()), openapi.RequestValidator(api.Router, getMaxBodySize()))
+
+	broker := event.NewBroker(replayBufferSize)
+	recommender := similar.NewSimilarService(db)
+	duplicates := duplicate.NewDuplicateService(db)
+	posters := poster.NewPosterService(db, blob.NewFileStore(getBlobDir()))
+	initializePipelines(&api, broker, recommender, duplicates, posters)
+
+	lis, err := net.Listen("tcp", getGrpcAddress())
+	if err != nil {
+		return err
+	}
+	grpcServer := newGrpcServer(&api, broker)
+	httpServer := &http.Server{Addr: getHttpAddress(), Handler: api.Router}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	startOutboxRelay(ctx, &api, broker)
+	startSimilarRebuilder(ctx, recommender, broker)
+	startDuplicateJob(ctx, duplicates)
+	startPosterSweeper(ctx, posters)
+
+	errs := make(chan error, 2)
+	go func() { errs <- grpcServer.Serve(lis) }()
+	go func() { errs <- httpServer.ListenAndServe() }()
+	log.Printf("Serving REST on %s and gRPC on %s", httpServer.Addr, lis.Addr())
+
+	select {
+	case <-ctx.Done():
+	case err = <-errs:
+	}
+
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancelShutdown()
+	if shutdownErr := httpServer.Shutdown(shutdownCtx); shutdownErr != nil {
+		log.Println("[HTTP] ", shutdownErr.Error())
+	}
+	// Streaming calls never finish on their own, those are cut once the timeout passes
+	stopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-shutdownCtx.Done():
+		grpcServer.Stop()
+	}
+
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+func initializePipelines(api *model.Api, broker *event.Broker, recommender similar.SimilarService,
+	duplicates duplicate.DuplicateService, posters poster.PosterService) {
+	stream.InitializeStreamPipeline(api, broker)
+	collab.InitializeCollabPipeline(api, broker)
+	duplicate.InitializeDuplicatesPipeline(api, duplicates)
+	movie.InitializeMoviesPipeline(api)
+	translation.InitializeTranslationsPipeline(api)
+	release.InitializeReleasesPipeline(api)
+	person.InitializePeoplePipeline(api)
+	genre.InitializeGenresPipeline(api)
+	tag.InitializeTagsPipeline(api)
+	review.InitializeReviewsPipeline(api)
+	watchlist.InitializeWatchlistsPipeline(api)
+	collection.InitializeCollectionsPipeline(api)
+	poster.InitializePostersPipeline(api, posters)
+	similar.InitializeSimilarPipeline(api, recommender)
+	audit.InitializeAuditPipeline(api)
+	webhook.InitializeWebhooksPipeline(api)
+	gql.InitializeGraphqlPipeline(api)
+	openapi.InitializeOpenApiPipeline(api)
 }
@@ -0,0 +1,118 @@
+package audit
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	defaultLimit = 100
+	maxLimit     = 1000
+)
+
+type controller struct {
+	service AuditService
+}
+
+type AuditController interface {
+	GetAuditLog(w http.ResponseWriter, r *http.Request)
+	GetMovieHistory(w http.ResponseWriter, r *http.Request)
+}
+
+func NewAuditController(s AuditService) AuditController {
+	return &controller{
+		service: s,
+	}
+}
+
+func (c *controller) GetAuditLog(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to GetAuditLog", r.Method))
+		return
+	}
+
+	// Extracting the filters from the query parameters
+	f, err := parseFilter(r)
+	if err != nil {
+		util.HandleBadRequest(w, "Invalid filter", err.Error())
+		return
+	}
+
+	enc, err := util.NewResponseEncoder(r, true)
+	if err != nil {
+		util.HandleNotAcceptable(w, err)
+		return
+	}
+
+	entries, err := c.service.GetEntries(f)
+	if err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	util.WriteResponse(w, enc, http.StatusOK, entries)
+}
+
+func (c *controller) GetMovieHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to GetMovieHistory", r.Method))
+		return
+	}
+
+	// Converting the ID to an integer
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 0)
+	if err != nil {
+		util.HandleBadRequest(w, "Invalid ID", err.Error())
+		return
+	}
+
+	enc, err := util.NewResponseEncoder(r, true)
+	if err != nil {
+		util.HandleNotAcceptable(w, err)
+		return
+	}
+
+	entries, err := c.service.GetHistory(EntityMovie, int(id))
+	if err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	util.WriteResponse(w, enc, http.StatusOK, entries)
+}
+
+func parseFilter(r *http.Request) (model.AuditFilter, error) {
+	query := r.URL.Query()
+	f := model.AuditFilter{Actor: query.Get("actor"), Limit: defaultLimit}
+
+	if from := query.Get("from"); from != "" {
+		parsed, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			return model.AuditFilter{}, err
+		}
+		f.From = parsed
+	}
+	if to := query.Get("to"); to != "" {
+		parsed, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			return model.AuditFilter{}, err
+		}
+		f.To = parsed
+	}
+	if limit := query.Get("limit"); limit != "" {
+		parsed, err := strconv.ParseInt(limit, 10, 0)
+		if err != nil || parsed < 1 || parsed > maxLimit {
+			return model.AuditFilter{}, fmt.Errorf("limit [%s] out of range", limit)
+		}
+		f.Limit = int(parsed)
+	}
+
+	return f, nil
+}
@@ -0,0 +1,115 @@
+package audit_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/audit"
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Defining the mock AuditService
+type mockServiceStruct struct {
+	mock.Mock
+}
+
+func (s *mockServiceStruct) GetHistories(entity string, ids []int) (map[int][]model.AuditEntry, error) {
+	args := s.Called(entity, ids)
+	return args.Get(0).(map[int][]model.AuditEntry), args.Error(1)
+}
+
+func (s *mockServiceStruct) GetHistory(entity string, id int) ([]model.AuditEntry, error) {
+	args := s.Called(entity, id)
+	return args.Get(0).([]model.AuditEntry), args.Error(1)
+}
+
+func (s *mockServiceStruct) GetEntries(f model.AuditFilter) ([]model.AuditEntry, error) {
+	args := s.Called(f)
+	return args.Get(0).([]model.AuditEntry), args.Error(1)
+}
+
+// Setting up mockService and controller
+var mockService = new(mockServiceStruct)
+var controller = audit.NewAuditController(mockService)
+
+func TestControllerGetAuditLog(t *testing.T) {
+	entries := []model.AuditEntry{newEntry(1, "create")}
+	from := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	filter := model.AuditFilter{Actor: "tester", From: from, Limit: 5}
+	mockService.On("GetEntries", filter).Return(entries, nil).Once()
+
+	req, _ := http.NewRequest("GET", "/audit?actor=tester&from=2022-01-01T00:00:00Z&limit=5", nil)
+	rr := execute("/audit", []string{"GET"}, req, controller.GetAuditLog)
+
+	if !mockService.AssertCalled(t, "GetEntries", filter) {
+		t.Error("The service should be called")
+	}
+	status := http.StatusOK
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+	assert.Equal(t, jsonString(entries), rr.Body.String())
+}
+
+func TestControllerGetAuditLogInvalidMethodError(t *testing.T) {
+	req, _ := http.NewRequest("POST", "/audit", nil)
+	rr := execute("/audit", []string{"POST"}, req, controller.GetAuditLog)
+
+	status := http.StatusMethodNotAllowed
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerGetAuditLogFilterParsingError(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/audit?from=yesterday", nil)
+	rr := execute("/audit", []string{"GET"}, req, controller.GetAuditLog)
+
+	status := http.StatusBadRequest
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerGetAuditLogServiceError(t *testing.T) {
+	mockService.On("GetEntries", mock.Anything).Return([]model.AuditEntry{}, errors.New("test-error-message")).Once()
+
+	req, _ := http.NewRequest("GET", "/audit", nil)
+	rr := execute("/audit", []string{"GET"}, req, controller.GetAuditLog)
+
+	status := http.StatusInternalServerError
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerGetMovieHistory(t *testing.T) {
+	entries := []model.AuditEntry{newEntry(1, "create"), newEntry(2, "update")}
+	mockService.On("GetHistory", "movie", 1).Return(entries, nil).Once()
+
+	req, _ := http.NewRequest("GET", "/movies/1/history", nil)
+	rr := execute("/movies/{id}/history", []string{"GET"}, req, controller.GetMovieHistory)
+
+	if !mockService.AssertCalled(t, "GetHistory", "movie", 1) {
+		t.Error("The service should be called")
+	}
+	status := http.StatusOK
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+	assert.Equal(t, jsonString(entries), rr.Body.String())
+}
+
+func TestControllerGetMovieHistoryIdParsingError(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/movies/not-an-int/history", nil)
+	rr := execute("/movies/{id}/history", []string{"GET"}, req, controller.GetMovieHistory)
+
+	status := http.StatusBadRequest
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func execute(route string, methods []string, req *http.Request, handler func(w http.ResponseWriter, r *http.Request)) *httptest.ResponseRecorder {
+	r := mux.NewRouter()
+	r.HandleFunc(route, handler).Methods(methods...)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	return rr
+}
@@ -0,0 +1,21 @@
+package audit
+
+import (
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+
+	"github.com/gorilla/mux"
+)
+
+func InitializeAuditPipeline(api *model.Api) {
+	s := NewAuditService(api.DB)
+	c := NewAuditController(s)
+	setRouting(api.Router, c)
+}
+
+func setRouting(main *mux.Router, c AuditController) {
+	main.HandleFunc("/audit", c.GetAuditLog).
+		Methods("GET")
+
+	main.HandleFunc("/movies/{id}/history", c.GetMovieHistory).
+		Methods("GET")
+}
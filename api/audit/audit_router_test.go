@@ -0,0 +1,42 @@
+package audit_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/audit"
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInitializeAuditPipeline(t *testing.T) {
+	db, mock := initNewDB(t)
+	api := model.Api{Router: mux.NewRouter(), DB: db}
+	audit.InitializeAuditPipeline(&api)
+
+	testIntegrationGetHistory(t, mock, api.Router)
+}
+
+func testIntegrationGetHistory(t *testing.T, mock sqlmock.Sqlmock, r *mux.Router) {
+	entries := []model.AuditEntry{newEntry(1, "create")}
+	mock.ExpectQuery(HistoryQuery).WithArgs("movie", 1).WillReturnRows(newRows(&entries))
+
+	req, _ := http.NewRequest("GET", "/movies/1/history", nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, jsonString(entries), rr.Body.String())
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func jsonString(obj interface{}) string {
+	res, _ := json.Marshal(obj)
+	return string(res)
+}
@@ -0,0 +1,209 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+
+	"github.com/lib/pq"
+)
+
+const (
+	EntityMovie  = "movie"
+	EntityPerson = "person"
+	EntityCredit = "credit"
+	EntityGenre  = "genre"
+	EntityTag    = "tag"
+	EntityReview = "review"
+	// Posters are recorded by the ID of their movie
+	EntityPoster = "poster"
+	// Translations are recorded by the ID of their movie, the locale being part of the record
+	EntityTranslation = "translation"
+	// Releases are recorded by the ID of their movie along with their windows, the region being part of the record
+	EntityRelease = "release"
+	// Watchlists are recorded without their entries
+	EntityWatchlist = "watchlist"
+	// Entries are recorded by the ID of their list along with the movie and its position, reorders along with the order of the movies
+	EntityWatchlistEntry = "watchlist_entry"
+	// Collections are recorded without their movies
+	EntityCollection = "collection"
+	// The links of the movies are recorded by the ID of the movie along with the linked record
+	EntityMovieGenre = "movie_genre"
+	EntityMovieTag   = "movie_tag"
+	// Memberships are recorded by the ID of the movie along with the reference to the collection
+	EntityMovieCollection = "movie_collection"
+
+	ActionCreate  = "create"
+	ActionUpdate  = "update"
+	ActionDelete  = "delete"
+	ActionRestore = "restore"
+	ActionPurge   = "purge"
+	// Merged movies are recorded along with the movie they were merged into
+	ActionMerge = "merge"
+)
+
+const columns = "id, entity, entity_id, action, actor, request_id, created_at, before, after, diff"
+
+type service struct {
+	db *sql.DB
+}
+
+type AuditService interface {
+	GetHistory(entity string, id int) ([]model.AuditEntry, error)
+	GetHistories(entity string, ids []int) (map[int][]model.AuditEntry, error)
+	GetEntries(f model.AuditFilter) ([]model.AuditEntry, error)
+}
+
+func NewAuditService(db *sql.DB) AuditService {
+	return &service{
+		db: db,
+	}
+}
+
+// Record writes an audit entry in the transaction of the change it describes,
+// so the change and its entry are either both committed or neither of them.
+// The actor and the request ID are taken from the context.
+func Record(ctx context.Context, tx *sql.Tx, entity string, id int, action string, before, after interface{}) error {
+	b, err := marshalState(before)
+	if err != nil {
+		return err
+	}
+	a, err := marshalState(after)
+	if err != nil {
+		return err
+	}
+	d, err := Diff(b, a)
+	if err != nil {
+		return err
+	}
+
+	const q = "INSERT INTO audit_log (entity, entity_id, action, actor, request_id, before, after, diff) " +
+		"VALUES ($1, $2, $3, $4, $5, $6, $7, $8)"
+	_, err = tx.ExecContext(ctx, q, entity, id, action,
+		util.ActorFromContext(ctx), util.RequestIDFromContext(ctx), nullJSON(b), nullJSON(a), nullJSON(d))
+	return err
+}
+
+// Diff returns the fields which differ between the two JSON objects, mapped to their old and new values
+func Diff(before, after json.RawMessage) (json.RawMessage, error) {
+	b, a := map[string]interface{}{}, map[string]interface{}{}
+	if before != nil {
+		if err := json.Unmarshal(before, &b); err != nil {
+			return nil, err
+		}
+	}
+	if after != nil {
+		if err := json.Unmarshal(after, &a); err != nil {
+			return nil, err
+		}
+	}
+
+	changes := make(map[string]model.FieldChange)
+	for field, value := range b {
+		if !reflect.DeepEqual(value, a[field]) {
+			changes[field] = model.FieldChange{From: value, To: a[field]}
+		}
+	}
+	for field, value := range a {
+		if _, ok := b[field]; !ok {
+			changes[field] = model.FieldChange{From: nil, To: value}
+		}
+	}
+	return json.Marshal(changes)
+}
+
+func (s *service) GetHistory(entity string, id int) ([]model.AuditEntry, error) {
+	const q = "SELECT " + columns + " FROM audit_log WHERE entity = $1 AND entity_id = $2 ORDER BY created_at, id"
+	return s.queryEntries(q, entity, id)
+}
+
+// GetHistories loads the histories of several records at once, each in the order of GetHistory
+func (s *service) GetHistories(entity string, ids []int) (map[int][]model.AuditEntry, error) {
+	keys := make(pq.Int64Array, 0, len(ids))
+	for _, id := range ids {
+		keys = append(keys, int64(id))
+	}
+
+	const q = "SELECT " + columns + " FROM audit_log WHERE entity = $1 AND entity_id = ANY($2) ORDER BY created_at, id"
+	entries, err := s.queryEntries(q, entity, keys)
+	if err != nil {
+		return map[int][]model.AuditEntry{}, err
+	}
+
+	result := make(map[int][]model.AuditEntry)
+	for _, e := range entries {
+		result[e.EntityID] = append(result[e.EntityID], e)
+	}
+	return result, nil
+}
+
+func (s *service) GetEntries(f model.AuditFilter) ([]model.AuditEntry, error) {
+	conditions := make([]string, 0)
+	args := make([]interface{}, 0)
+	addCondition := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if f.Actor != "" {
+		addCondition("actor = $%d", f.Actor)
+	}
+	if !f.From.IsZero() {
+		addCondition("created_at >= $%d", f.From)
+	}
+	if !f.To.IsZero() {
+		addCondition("created_at < $%d", f.To)
+	}
+
+	q := "SELECT " + columns + " FROM audit_log"
+	if len(conditions) > 0 {
+		q += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, f.Limit)
+	q += fmt.Sprintf(" ORDER BY created_at DESC, id DESC LIMIT $%d", len(args))
+
+	return s.queryEntries(q, args...)
+}
+
+func (s *service) queryEntries(q string, args ...interface{}) ([]model.AuditEntry, error) {
+	qr, err := s.db.Query(q, args...)
+	if err != nil {
+		return []model.AuditEntry{}, err
+	}
+	defer qr.Close()
+
+	result := make([]model.AuditEntry, 0)
+	for qr.Next() {
+		e := model.AuditEntry{}
+		var before, after, diff []byte
+		err = qr.Scan(&e.ID, &e.Entity, &e.EntityID, &e.Action, &e.Actor, &e.RequestID, &e.CreatedAt, &before, &after, &diff)
+		if err != nil {
+			return []model.AuditEntry{}, err
+		}
+		e.Before, e.After, e.Diff = before, after, diff
+		result = append(result, e)
+	}
+
+	return result, qr.Err()
+}
+
+func marshalState(state interface{}) (json.RawMessage, error) {
+	if state == nil || (reflect.ValueOf(state).Kind() == reflect.Pointer && reflect.ValueOf(state).IsNil()) {
+		return nil, nil
+	}
+	return json.Marshal(state)
+}
+
+// nullJSON passes JSON as text, which Postgres casts to jsonb, and missing values as NULL
+func nullJSON(v json.RawMessage) interface{} {
+	if v == nil {
+		return nil
+	}
+	return string(v)
+}
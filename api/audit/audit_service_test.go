@@ -0,0 +1,191 @@
+package audit_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/audit"
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+const InsertQuery = `^INSERT INTO audit_log \(.+\) VALUES \(.+\)$`
+
+func TestRecord(t *testing.T) {
+	db, mock := initNewDB(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(InsertQuery).
+		WithArgs("movie", 1, "update", "tester", "test-request",
+			`{"id":1,"name":"old"}`, `{"id":1,"name":"new"}`, `{"name":{"from":"old","to":"new"}}`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	ctx := util.WithRequestID(util.WithActor(context.Background(), "tester"), "test-request")
+	tx, _ := db.Begin()
+	err := audit.Record(ctx, tx, audit.EntityMovie, 1, audit.ActionUpdate,
+		model.Movie{ID: 1, Name: "old"}, &model.Movie{ID: 1, Name: "new"})
+
+	assert.Equal(t, nil, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestRecordAnonymous(t *testing.T) {
+	db, mock := initNewDB(t)
+	defer db.Close()
+
+	var missing *model.Movie
+	mock.ExpectBegin()
+	mock.ExpectExec(InsertQuery).
+		WithArgs("movie", 1, "create", util.AnonymousActor, "", nil, `{"id":1,"name":"new"}`, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	tx, _ := db.Begin()
+	err := audit.Record(context.Background(), tx, audit.EntityMovie, 1, audit.ActionCreate,
+		missing, model.Movie{ID: 1, Name: "new"})
+
+	assert.Equal(t, nil, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	diff, err := audit.Diff(json.RawMessage(`{"id":1,"name":"old","gone":true}`), json.RawMessage(`{"id":1,"name":"new","added":2}`))
+
+	assert.Equal(t, nil, err)
+	assert.JSONEq(t, `{"name":{"from":"old","to":"new"},"gone":{"from":true,"to":null},"added":{"from":null,"to":2}}`, string(diff))
+}
+
+func TestDiffOfCreation(t *testing.T) {
+	diff, err := audit.Diff(nil, json.RawMessage(`{"id":1}`))
+
+	assert.Equal(t, nil, err)
+	assert.JSONEq(t, `{"id":{"from":null,"to":1}}`, string(diff))
+}
+
+const HistoryQuery = `^SELECT .+ FROM audit_log WHERE entity = \$1 AND entity_id = \$2 ORDER BY created_at, id$`
+
+func TestServiceGetHistory(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	entries := []model.AuditEntry{newEntry(1, "create"), newEntry(2, "update")}
+	mock.ExpectQuery(HistoryQuery).WithArgs("movie", 1).WillReturnRows(newRows(&entries))
+
+	res, err := service.GetHistory(audit.EntityMovie, 1)
+
+	assert.Equal(t, entries, res)
+	assert.Equal(t, nil, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+const HistoriesQuery = `^SELECT .+ FROM audit_log WHERE entity = \$1 AND entity_id = ANY\(\$2\) ORDER BY created_at, id$`
+
+func TestServiceGetHistories(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	first, second, other := newEntry(1, "create"), newEntry(2, "update"), newEntry(3, "create")
+	other.EntityID = 2
+	entries := []model.AuditEntry{first, other, second}
+	mock.ExpectQuery(HistoriesQuery).WithArgs("movie", "{1,2,3}").WillReturnRows(newRows(&entries))
+
+	res, err := service.GetHistories(audit.EntityMovie, []int{1, 2, 3})
+
+	assert.Equal(t, map[int][]model.AuditEntry{1: {first, second}, 2: {other}}, res)
+	assert.Equal(t, nil, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceGetHistoryQueryError(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	queryError := errors.New("test-error-message")
+	mock.ExpectQuery(HistoryQuery).WillReturnError(queryError)
+
+	res, err := service.GetHistory(audit.EntityMovie, 1)
+
+	assert.Equal(t, []model.AuditEntry{}, res)
+	assert.Equal(t, queryError, err)
+}
+
+func TestServiceGetEntries(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	from := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	entries := []model.AuditEntry{newEntry(1, "create")}
+	const q = `^SELECT .+ FROM audit_log WHERE actor = \$1 AND created_at >= \$2 AND created_at < \$3 ORDER BY created_at DESC, id DESC LIMIT \$4$`
+	mock.ExpectQuery(q).WithArgs("tester", from, to, 10).WillReturnRows(newRows(&entries))
+
+	res, err := service.GetEntries(model.AuditFilter{Actor: "tester", From: from, To: to, Limit: 10})
+
+	assert.Equal(t, entries, res)
+	assert.Equal(t, nil, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceGetEntriesUnfiltered(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	const q = `^SELECT .+ FROM audit_log ORDER BY created_at DESC, id DESC LIMIT \$1$`
+	mock.ExpectQuery(q).WithArgs(100).WillReturnRows(newRows(&[]model.AuditEntry{}))
+
+	res, err := service.GetEntries(model.AuditFilter{Limit: 100})
+
+	assert.Equal(t, []model.AuditEntry{}, res)
+	assert.Equal(t, nil, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func initNewDB(t *testing.T) (*sql.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	return db, mock
+}
+
+func initNewService(t *testing.T) (audit.AuditService, sqlmock.Sqlmock, *sql.DB) {
+	db, mock := initNewDB(t)
+	return audit.NewAuditService(db), mock, db
+}
+
+func newEntry(id int64, action string) model.AuditEntry {
+	return model.AuditEntry{
+		ID: id, Entity: "movie", EntityID: 1, Action: action, Actor: "tester", RequestID: "test-request",
+		CreatedAt: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+		After:     json.RawMessage(`{"id":1,"name":"test"}`),
+		Diff:      json.RawMessage(`{"name":{"from":null,"to":"test"}}`),
+	}
+}
+
+func newRows(entries *[]model.AuditEntry) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"id", "entity", "entity_id", "action", "actor", "request_id", "created_at", "before", "after", "diff"})
+	for _, e := range *entries {
+		var before, after, diff []byte = e.Before, e.After, e.Diff
+		rows.AddRow(e.ID, e.Entity, e.EntityID, e.Action, e.Actor, e.RequestID, e.CreatedAt, before, after, diff)
+	}
+	return rows
+}
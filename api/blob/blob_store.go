@@ -0,0 +1,45 @@
+package blob
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"path"
+	"strings"
+	"time"
+)
+
+// BlobStore keeps binary objects by their keys, which are slash-separated paths such as "posters/<hash>/small.jpg".
+// The keys and operations follow S3-compatible object stores, so one of those can take over from the file system.
+type BlobStore interface {
+	// Put stores the object, replacing any object of the key
+	Put(ctx context.Context, key string, data []byte) error
+	// Get returns the object, or NotExistingRecordError if there is none
+	Get(ctx context.Context, key string) (Blob, error)
+	// Delete removes the object, missing objects are ignored
+	Delete(ctx context.Context, key string) error
+}
+
+// Blob is an object of the store along with its metadata
+type Blob struct {
+	Data []byte
+	// Told by the extension of the key, objects without a known one are served as octet streams
+	ContentType string
+	ModTime     time.Time
+}
+
+// ContentType returns the media type of the objects of the key, told by its extension
+func ContentType(key string) string {
+	if t := mime.TypeByExtension(path.Ext(key)); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
+
+// validKey rejects keys which could leave the store, such as absolute ones or ones with ".." segments
+func validKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || path.Clean(key) != key || strings.HasPrefix(key, "../") || key == ".." {
+		return fmt.Errorf("invalid blob key [%s]", key)
+	}
+	return nil
+}
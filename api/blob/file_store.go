@@ -0,0 +1,88 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+)
+
+// FileStore keeps the objects as files below its root directory, the keys being their relative paths
+type FileStore struct {
+	root string
+}
+
+var _ BlobStore = (*FileStore)(nil)
+
+func NewFileStore(root string) *FileStore {
+	return &FileStore{
+		root: root,
+	}
+}
+
+// Put writes the object to a temporary file first, so readers never see a partial object
+func (s *FileStore) Put(ctx context.Context, key string, data []byte) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
+
+func (s *FileStore) Get(ctx context.Context, key string) (Blob, error) {
+	name, err := s.path(key)
+	if err != nil {
+		return Blob{}, err
+	}
+
+	info, err := os.Stat(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return Blob{}, &util.NotExistingRecordError{Identification: fmt.Sprintf("blob: %s", key)}
+	}
+	if err != nil {
+		return Blob{}, err
+	}
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return Blob{}, err
+	}
+	return Blob{Data: data, ContentType: ContentType(key), ModTime: info.ModTime()}, nil
+}
+
+func (s *FileStore) Delete(ctx context.Context, key string) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *FileStore) path(key string) (string, error) {
+	if err := validKey(key); err != nil {
+		return "", err
+	}
+	return filepath.Join(s.root, filepath.FromSlash(key)), nil
+}
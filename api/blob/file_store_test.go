@@ -0,0 +1,43 @@
+package blob_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/blob"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileStorePutGet(t *testing.T) {
+	store := blob.NewFileStore(t.TempDir())
+	ctx := context.Background()
+
+	assert.Nil(t, store.Put(ctx, "posters/abc/small.jpg", []byte("first")))
+	assert.Nil(t, store.Put(ctx, "posters/abc/small.jpg", []byte("second")))
+	b, err := store.Get(ctx, "posters/abc/small.jpg")
+
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("second"), b.Data, "Putting an object again should replace it")
+	assert.Equal(t, "image/jpeg", b.ContentType)
+}
+
+func TestFileStoreDelete(t *testing.T) {
+	store := blob.NewFileStore(t.TempDir())
+	ctx := context.Background()
+	store.Put(ctx, "posters/abc/original.png", []byte("image"))
+
+	assert.Nil(t, store.Delete(ctx, "posters/abc/original.png"))
+	assert.Nil(t, store.Delete(ctx, "posters/abc/original.png"), "Deleting a missing object should succeed")
+	_, err := store.Get(ctx, "posters/abc/original.png")
+	assert.IsType(t, &util.NotExistingRecordError{}, err)
+}
+
+func TestFileStoreInvalidKeyError(t *testing.T) {
+	store := blob.NewFileStore(t.TempDir())
+
+	for _, key := range []string{"", "/etc/passwd", "../outside", "posters/../../outside", "posters//abc"} {
+		assert.NotNil(t, store.Put(context.Background(), key, []byte("x")), "The key [%s] should be rejected", key)
+	}
+}
@@ -0,0 +1,56 @@
+package collab
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/event"
+	"github.com/Hunterlemming/golang-microservice-example/api/movie"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+
+	"github.com/gorilla/websocket"
+)
+
+type controller struct {
+	service  movie.MovieService
+	broker   *event.Broker
+	upgrader websocket.Upgrader
+}
+
+type CollabController interface {
+	EditMovies(w http.ResponseWriter, r *http.Request)
+}
+
+func NewCollabController(s movie.MovieService, b *event.Broker) CollabController {
+	return &controller{
+		service: s,
+		broker:  b,
+	}
+}
+
+// EditMovies upgrades the request to a WebSocket, over which clients subscribe to movies,
+// receive their changes and send their own updates. Only authenticated clients may connect.
+func (c *controller) EditMovies(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to EditMovies", r.Method))
+		return
+	}
+
+	if util.ActorFromContext(r.Context()) == util.AnonymousActor {
+		util.HandleUnauthorized(w, "anonymous connection to EditMovies")
+		return
+	}
+
+	// The upgrader responds with the matching error itself
+	conn, err := c.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+
+	// Clients receive a snapshot of every movie they subscribe to, so no events are replayed
+	sub, _ := c.broker.Subscribe(math.MaxInt64, subscriberBuffer)
+	defer c.broker.Unsubscribe(sub)
+
+	newSession(conn, c.service, sub).run(r.Context())
+}
@@ -0,0 +1,149 @@
+package collab_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/collab"
+	"github.com/Hunterlemming/golang-microservice-example/api/event"
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/movie"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestControllerEditMoviesSubscribe(t *testing.T) {
+	broker := event.NewBroker(10)
+	conn := connect(t, newServer(t, broker, model.Movie{ID: 1, Name: "test1"}, model.Movie{ID: 2, Name: "test2"}))
+
+	send(t, conn, model.SocketMessage{Type: model.SocketSubscribe, Ref: "s1", IDs: []int{1, 3}})
+
+	assert.Equal(t, model.SocketMessage{Type: model.SocketSnapshot, Ref: "s1", Movie: &model.Movie{ID: 1, Name: "test1", Version: 1}}, receive(t, conn))
+	res := receive(t, conn)
+	assert.Equal(t, model.SocketError, res.Type)
+	assert.Equal(t, http.StatusNotFound, res.Status, "Subscribing to a missing movie should fail")
+
+	broker.Publish(context.Background(), model.Event{ID: 1, Type: event.TypeMovieUpdated, MovieID: 2})
+	broker.Publish(context.Background(), model.Event{ID: 2, Type: event.TypeMovieUpdated, MovieID: 1})
+
+	res = receive(t, conn)
+	assert.Equal(t, model.SocketEvent, res.Type)
+	assert.Equal(t, int64(2), res.Event.ID, "Only events of subscribed movies should be forwarded")
+}
+
+func TestControllerEditMoviesUnsubscribe(t *testing.T) {
+	broker := event.NewBroker(10)
+	conn := connect(t, newServer(t, broker, model.Movie{ID: 1, Name: "test1"}))
+
+	send(t, conn, model.SocketMessage{Type: model.SocketSubscribe, IDs: []int{1}})
+	receive(t, conn)
+	send(t, conn, model.SocketMessage{Type: model.SocketUnsubscribe, Ref: "u1", IDs: []int{1}})
+
+	assert.Equal(t, model.SocketMessage{Type: model.SocketUnsubscribed, Ref: "u1", IDs: []int{1}}, receive(t, conn))
+	broker.Publish(context.Background(), model.Event{ID: 1, Type: event.TypeMovieUpdated, MovieID: 1})
+	send(t, conn, model.SocketMessage{Type: "ping", Ref: "p1"})
+	assert.Equal(t, "p1", receive(t, conn).Ref, "No event should arrive after unsubscribing")
+}
+
+func TestControllerEditMoviesUpdate(t *testing.T) {
+	conn := connect(t, newServer(t, event.NewBroker(10), model.Movie{ID: 1, Name: "test1"}))
+
+	send(t, conn, model.SocketMessage{Type: model.SocketUpdate, Ref: "u1", Movie: &model.Movie{ID: 1, Name: "updated", Version: 1}})
+	assert.Equal(t, model.SocketMessage{Type: model.SocketUpdated, Ref: "u1", Movie: &model.Movie{ID: 1, Name: "updated", Version: 2}}, receive(t, conn))
+
+	send(t, conn, model.SocketMessage{Type: model.SocketUpdate, Ref: "u2", Movie: &model.Movie{ID: 1, Name: "concurrent", Version: 1}})
+	res := receive(t, conn)
+	assert.Equal(t, model.SocketError, res.Type)
+	assert.Equal(t, "u2", res.Ref)
+	assert.Equal(t, http.StatusConflict, res.Status, "Updates of an outdated version should be rejected")
+}
+
+func TestControllerEditMoviesUpdateValidationError(t *testing.T) {
+	conn := connect(t, newServer(t, event.NewBroker(10), model.Movie{ID: 1, Name: "test1"}))
+
+	send(t, conn, model.SocketMessage{Type: model.SocketUpdate, Ref: "u1", Movie: &model.Movie{ID: 1}})
+
+	res := receive(t, conn)
+	assert.Equal(t, model.SocketError, res.Type)
+	assert.Equal(t, http.StatusBadRequest, res.Status)
+}
+
+func TestControllerEditMoviesInvalidMessageError(t *testing.T) {
+	conn := connect(t, newServer(t, event.NewBroker(10)))
+
+	conn.WriteMessage(websocket.TextMessage, []byte("not-json"))
+
+	res := receive(t, conn)
+	assert.Equal(t, model.SocketError, res.Type)
+	assert.Equal(t, http.StatusBadRequest, res.Status)
+}
+
+func TestControllerEditMoviesAnonymousError(t *testing.T) {
+	c := collab.NewCollabController(movie.NewInMemoryMovieService(), event.NewBroker(10))
+	req, _ := http.NewRequest("GET", "/movies/live", nil)
+	rr := httptest.NewRecorder()
+
+	c.EditMovies(rr, req)
+
+	status := http.StatusUnauthorized
+	assert.Equal(t, status, rr.Code, "Anonymous clients should not be able to connect")
+}
+
+func TestControllerEditMoviesInvalidMethodError(t *testing.T) {
+	c := collab.NewCollabController(movie.NewInMemoryMovieService(), event.NewBroker(10))
+	req, _ := http.NewRequest("POST", "/movies/live", nil)
+	rr := httptest.NewRecorder()
+
+	c.EditMovies(rr, req)
+
+	status := http.StatusMethodNotAllowed
+	assert.Equal(t, status, rr.Code)
+}
+
+// newServer serves the socket to clients authenticated as "tester"
+func newServer(t *testing.T, broker *event.Broker, movies ...model.Movie) *httptest.Server {
+	c := collab.NewCollabController(movie.NewInMemoryMovieService(movies...), broker)
+	r := mux.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(util.WithActor(r.Context(), "tester")))
+		})
+	})
+	r.HandleFunc("/movies/live", c.EditMovies)
+
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func connect(t *testing.T, server *httptest.Server) *websocket.Conn {
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/movies/live"
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when connecting to the socket", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func send(t *testing.T, conn *websocket.Conn, msg model.SocketMessage) {
+	if err := conn.WriteJSON(msg); err != nil {
+		t.Fatalf("an error '%s' was not expected when sending a message", err)
+	}
+}
+
+func receive(t *testing.T, conn *websocket.Conn) model.SocketMessage {
+	var msg model.SocketMessage
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatalf("an error '%s' was not expected when receiving a message", err)
+	}
+	return msg
+}
@@ -0,0 +1,21 @@
+package collab
+
+import (
+	"github.com/Hunterlemming/golang-microservice-example/api/event"
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/movie"
+
+	"github.com/gorilla/mux"
+)
+
+// InitializeCollabPipeline has to run ahead of the movies pipeline, whose "/movies/{id}" would match the socket otherwise
+func InitializeCollabPipeline(api *model.Api, b *event.Broker) {
+	s := movie.NewMovieService(api.DB)
+	c := NewCollabController(s, b)
+	setRouting(api.Router, c)
+}
+
+func setRouting(main *mux.Router, c CollabController) {
+	main.HandleFunc("/movies/live", c.EditMovies).
+		Methods("GET")
+}
@@ -0,0 +1,38 @@
+package collab_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/collab"
+	"github.com/Hunterlemming/golang-microservice-example/api/event"
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/movie"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInitializeCollabPipeline(t *testing.T) {
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	api := model.Api{Router: mux.NewRouter(), DB: db}
+	collab.InitializeCollabPipeline(&api, event.NewBroker(10))
+	movie.InitializeMoviesPipeline(&api)
+
+	testIntegrationEditMovies(t, api.Router)
+}
+
+func testIntegrationEditMovies(t *testing.T, r *mux.Router) {
+	req, _ := http.NewRequest("GET", "/movies/live", nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	status := http.StatusUnauthorized
+	assert.Equal(t, status, rr.Code, "The socket should not be shadowed by \"/movies/{id}\"")
+}
@@ -0,0 +1,233 @@
+package collab
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/event"
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/movie"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// How many events may queue up in the broker for a slow client before it is disconnected
+	subscriberBuffer = 64
+	// How many messages may queue up for writing before the client is disconnected
+	sendBuffer = 64
+	// Largest message accepted from a client
+	maxMessageSize = 64 * 1024
+	// Time allowed to write a message to the client
+	writeWait = 10 * time.Second
+	// Clients not answering the heartbeat pings in time are disconnected
+	pongWait   = 60 * time.Second
+	pingPeriod = pongWait * 9 / 10
+)
+
+// session serves a single connection. Its writer goroutine is the only one writing to the connection,
+// the other goroutines queue their messages and close the session if the client does not keep up.
+type session struct {
+	conn    *websocket.Conn
+	service movie.MovieService
+	sub     *event.Subscriber
+	send    chan model.SocketMessage
+
+	mu  sync.RWMutex
+	ids map[int]bool
+
+	closeOnce   sync.Once
+	closeCode   int
+	closeReason string
+	quit        chan struct{}
+	done        chan struct{}
+}
+
+func newSession(conn *websocket.Conn, s movie.MovieService, sub *event.Subscriber) *session {
+	return &session{
+		conn:    conn,
+		service: s,
+		sub:     sub,
+		send:    make(chan model.SocketMessage, sendBuffer),
+		ids:     make(map[int]bool),
+		quit:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// run serves the connection until the client leaves or gets disconnected
+func (s *session) run(ctx context.Context) {
+	go s.writePump()
+	go s.forwardEvents()
+	s.readPump(ctx)
+	s.close(websocket.CloseNormalClosure, "")
+	<-s.done
+}
+
+// close makes the writer send a close message with the first code given and end the connection
+func (s *session) close(code int, reason string) {
+	s.closeOnce.Do(func() {
+		s.closeCode, s.closeReason = code, reason
+		close(s.quit)
+	})
+}
+
+// enqueue never blocks, clients not reading their messages fast enough are disconnected instead
+func (s *session) enqueue(msg model.SocketMessage) {
+	select {
+	case s.send <- msg:
+	default:
+		log.Println("[WebSocket] ", "dropping slow client ", s.conn.RemoteAddr())
+		s.close(websocket.CloseTryAgainLater, "too slow to keep up")
+	}
+}
+
+func (s *session) readPump(ctx context.Context) {
+	s.conn.SetReadLimit(maxMessageSize)
+	s.conn.SetReadDeadline(time.Now().Add(pongWait))
+	s.conn.SetPongHandler(func(string) error {
+		return s.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		_, data, err := s.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		var msg model.SocketMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			s.enqueue(errorMessage("", http.StatusBadRequest, "Invalid message"))
+			continue
+		}
+		s.handle(ctx, msg)
+	}
+}
+
+func (s *session) writePump() {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		s.conn.Close()
+		close(s.done)
+	}()
+
+	for {
+		select {
+		case msg := <-s.send:
+			s.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := s.conn.WriteJSON(msg); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if err := s.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait)); err != nil {
+				return
+			}
+		case <-s.quit:
+			msg := websocket.FormatCloseMessage(s.closeCode, s.closeReason)
+			s.conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(writeWait))
+			return
+		}
+	}
+}
+
+// forwardEvents passes the events of the subscribed movies on to the client
+func (s *session) forwardEvents() {
+	for {
+		select {
+		case e, open := <-s.sub.Events:
+			if !open {
+				log.Println("[WebSocket] ", "dropping slow client ", s.conn.RemoteAddr())
+				s.close(websocket.CloseTryAgainLater, "too slow to keep up")
+				return
+			}
+			if s.subscribed(e.MovieID) {
+				s.enqueue(model.SocketMessage{Type: model.SocketEvent, Event: &e})
+			}
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+func (s *session) handle(ctx context.Context, msg model.SocketMessage) {
+	switch msg.Type {
+	case model.SocketSubscribe:
+		s.subscribe(msg)
+	case model.SocketUnsubscribe:
+		s.mu.Lock()
+		for _, id := range msg.IDs {
+			delete(s.ids, id)
+		}
+		s.mu.Unlock()
+		s.enqueue(model.SocketMessage{Type: model.SocketUnsubscribed, Ref: msg.Ref, IDs: msg.IDs})
+	case model.SocketUpdate:
+		s.update(ctx, msg)
+	default:
+		s.enqueue(errorMessage(msg.Ref, http.StatusBadRequest, "Unknown message type"))
+	}
+}
+
+// subscribe starts forwarding the events of the movies before reading their snapshots,
+// so no change gets lost in between. Clients tell the order apart by the versions.
+func (s *session) subscribe(msg model.SocketMessage) {
+	for _, id := range msg.IDs {
+		s.mu.Lock()
+		s.ids[id] = true
+		s.mu.Unlock()
+
+		m, err := s.service.GetMovie(id)
+		if err != nil {
+			s.mu.Lock()
+			delete(s.ids, id)
+			s.mu.Unlock()
+			s.enqueue(serviceErrorMessage(msg.Ref, err))
+			continue
+		}
+		s.enqueue(model.SocketMessage{Type: model.SocketSnapshot, Ref: msg.Ref, Movie: &m})
+	}
+}
+
+// update goes through the same validation and version check as updates over HTTP
+func (s *session) update(ctx context.Context, msg model.SocketMessage) {
+	if msg.Movie == nil {
+		s.enqueue(errorMessage(msg.Ref, http.StatusBadRequest, "Movie is missing"))
+		return
+	}
+	m := *msg.Movie
+	if err := m.Validate(); err != nil {
+		s.enqueue(errorMessage(msg.Ref, http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	if err := s.service.UpdateMovie(ctx, m.ID, &m); err != nil {
+		s.enqueue(serviceErrorMessage(msg.Ref, err))
+		return
+	}
+	s.enqueue(model.SocketMessage{Type: model.SocketUpdated, Ref: msg.Ref, Movie: &m})
+}
+
+func (s *session) subscribed(id int) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.ids[id]
+}
+
+func errorMessage(ref string, status int, message string) model.SocketMessage {
+	return model.SocketMessage{Type: model.SocketError, Ref: ref, Status: status, Message: message}
+}
+
+// serviceErrorMessage maps the errors of the service like their HTTP counterparts, without exposing internal ones
+func serviceErrorMessage(ref string, err error) model.SocketMessage {
+	status := util.ServiceErrorStatus(err)
+	if status == http.StatusInternalServerError {
+		log.Println("[WebSocket] ", err.Error())
+		return errorMessage(ref, status, "Service unreachable")
+	}
+	return errorMessage(ref, status, err.Error())
+}
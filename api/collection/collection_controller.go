@@ -0,0 +1,285 @@
+package collection
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+
+	"github.com/gorilla/mux"
+)
+
+type controller struct {
+	service CollectionService
+}
+
+type CollectionController interface {
+	GetCollections(w http.ResponseWriter, r *http.Request)
+	GetCollection(w http.ResponseWriter, r *http.Request)
+	CreateCollection(w http.ResponseWriter, r *http.Request)
+	UpdateCollection(w http.ResponseWriter, r *http.Request)
+	DeleteCollection(w http.ResponseWriter, r *http.Request)
+	GetMovies(w http.ResponseWriter, r *http.Request)
+	AddMovie(w http.ResponseWriter, r *http.Request)
+	RemoveMovie(w http.ResponseWriter, r *http.Request)
+	ReorderMovies(w http.ResponseWriter, r *http.Request)
+}
+
+func NewCollectionController(s CollectionService) CollectionController {
+	return &controller{
+		service: s,
+	}
+}
+
+func (c *controller) GetCollections(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to GetCollections", r.Method))
+		return
+	}
+
+	enc, err := util.NewResponseEncoder(r, true)
+	if err != nil {
+		util.HandleNotAcceptable(w, err)
+		return
+	}
+
+	collections, err := c.service.GetCollections()
+	if err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	util.WriteResponse(w, enc, http.StatusOK, collections)
+}
+
+func (c *controller) GetCollection(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to GetCollection", r.Method))
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 0)
+	if err != nil {
+		util.HandleBadRequest(w, "Invalid ID", err.Error())
+		return
+	}
+
+	enc, err := util.NewResponseEncoder(r, false)
+	if err != nil {
+		util.HandleNotAcceptable(w, err)
+		return
+	}
+
+	collection, err := c.service.GetCollection(int(id))
+	if err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	util.WriteResponse(w, enc, http.StatusOK, collection)
+}
+
+func (c *controller) CreateCollection(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to CreateCollection", r.Method))
+		return
+	}
+
+	// Extracting Collection object from request-body
+	collection, err := parseValidCollection(r)
+	if err != nil {
+		util.HandleInvalidBody(w, err)
+		return
+	}
+
+	enc, err := util.NewResponseEncoder(r, false)
+	if err != nil {
+		util.HandleNotAcceptable(w, err)
+		return
+	}
+
+	if err := c.service.CreateCollection(r.Context(), collection); err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	util.WriteResponse(w, enc, http.StatusCreated, collection)
+}
+
+func (c *controller) UpdateCollection(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to UpdateCollection", r.Method))
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 0)
+	if err != nil {
+		util.HandleBadRequest(w, "Invalid ID", err.Error())
+		return
+	}
+
+	// Extracting Collection object from request-body
+	collection, err := parseValidCollection(r)
+	if err != nil {
+		util.HandleInvalidBody(w, err)
+		return
+	}
+
+	if err := c.service.UpdateCollection(r.Context(), int(id), collection); err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	fmt.Fprintln(w, "success")
+}
+
+func (c *controller) DeleteCollection(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to DeleteCollection", r.Method))
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 0)
+	if err != nil {
+		util.HandleBadRequest(w, "Invalid ID", err.Error())
+		return
+	}
+
+	if err := c.service.DeleteCollection(r.Context(), int(id)); err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *controller) GetMovies(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to GetMovies", r.Method))
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 0)
+	if err != nil {
+		util.HandleBadRequest(w, "Invalid ID", err.Error())
+		return
+	}
+
+	enc, err := util.NewResponseEncoder(r, true)
+	if err != nil {
+		util.HandleNotAcceptable(w, err)
+		return
+	}
+
+	movies, err := c.service.GetMovies(int(id))
+	if err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	util.WriteResponse(w, enc, http.StatusOK, movies)
+}
+
+// AddMovie appends the movie to the collection, answering 409 for movies of other collections
+func (c *controller) AddMovie(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to AddMovie", r.Method))
+		return
+	}
+
+	id, movieID, err := parseMember(r)
+	if err != nil {
+		util.HandleBadRequest(w, "Invalid ID", err.Error())
+		return
+	}
+
+	if err := c.service.AddMovie(r.Context(), id, movieID); err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *controller) RemoveMovie(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to RemoveMovie", r.Method))
+		return
+	}
+
+	id, movieID, err := parseMember(r)
+	if err != nil {
+		util.HandleBadRequest(w, "Invalid ID", err.Error())
+		return
+	}
+
+	if err := c.service.RemoveMovie(r.Context(), id, movieID); err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ReorderMovies answers 409 for orders not listing every movie of the collection once, such as outdated ones
+func (c *controller) ReorderMovies(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to ReorderMovies", r.Method))
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 0)
+	if err != nil {
+		util.HandleBadRequest(w, "Invalid ID", err.Error())
+		return
+	}
+
+	// Extracting CollectionOrder object from request-body
+	var order model.CollectionOrder
+	if err := util.DecodeRequest(r, &order); err != nil {
+		util.HandleInvalidBody(w, err)
+		return
+	}
+	if err := order.Validate(); err != nil {
+		util.HandleInvalidBody(w, fmt.Errorf("invalid order-object: %w", err))
+		return
+	}
+
+	if err := c.service.ReorderMovies(r.Context(), int(id), order.MovieIDs); err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// parseMember returns the IDs of the collection and the movie in the path
+func parseMember(r *http.Request) (int, int, error) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 0)
+	if err != nil {
+		return 0, 0, err
+	}
+	movieID, err := strconv.ParseInt(mux.Vars(r)["movieId"], 10, 0)
+	if err != nil {
+		return 0, 0, err
+	}
+	return int(id), int(movieID), nil
+}
+
+func parseValidCollection(r *http.Request) (*model.Collection, error) {
+	var c model.Collection
+
+	// Return if the request-body cannot be decoded into a Collection object
+	if err := util.DecodeRequest(r, &c); err != nil {
+		return nil, err
+	}
+
+	// Return if the requested Collection object is invalid
+	if err := c.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid collection-object: %w", err)
+	}
+
+	return &c, nil
+}
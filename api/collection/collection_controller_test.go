@@ -0,0 +1,234 @@
+package collection_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/collection"
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Defining the mock CollectionService
+type mockServiceStruct struct {
+	mock.Mock
+}
+
+func (s *mockServiceStruct) GetCollections() ([]model.Collection, error) {
+	args := s.Called()
+	return args.Get(0).([]model.Collection), args.Error(1)
+}
+
+func (s *mockServiceStruct) GetCollection(id int) (model.Collection, error) {
+	args := s.Called(id)
+	return args.Get(0).(model.Collection), args.Error(1)
+}
+
+func (s *mockServiceStruct) CreateCollection(ctx context.Context, c *model.Collection) error {
+	args := s.Called(c)
+	return args.Error(0)
+}
+
+func (s *mockServiceStruct) UpdateCollection(ctx context.Context, id int, c *model.Collection) error {
+	args := s.Called(id, c)
+	return args.Error(0)
+}
+
+func (s *mockServiceStruct) DeleteCollection(ctx context.Context, id int) error {
+	args := s.Called(id)
+	return args.Error(0)
+}
+
+func (s *mockServiceStruct) GetMovies(id int) ([]model.Movie, error) {
+	args := s.Called(id)
+	return args.Get(0).([]model.Movie), args.Error(1)
+}
+
+func (s *mockServiceStruct) AddMovie(ctx context.Context, id, movieID int) error {
+	args := s.Called(id, movieID)
+	return args.Error(0)
+}
+
+func (s *mockServiceStruct) RemoveMovie(ctx context.Context, id, movieID int) error {
+	args := s.Called(id, movieID)
+	return args.Error(0)
+}
+
+func (s *mockServiceStruct) ReorderMovies(ctx context.Context, id int, movieIDs []int) error {
+	args := s.Called(id, movieIDs)
+	return args.Error(0)
+}
+
+// Setting up mockService and controller
+var mockService = new(mockServiceStruct)
+var controller = collection.NewCollectionController(mockService)
+
+func TestControllerGetCollections(t *testing.T) {
+	collections := []model.Collection{trilogy}
+	mockService.On("GetCollections").Return(collections, nil).Once()
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	rr := execute("/", []string{"GET"}, req, controller.GetCollections)
+
+	status := http.StatusOK
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+	assert.Equal(t, jsonString(collections), rr.Body.String())
+}
+
+func TestControllerGetCollectionNotFoundError(t *testing.T) {
+	mockService.On("GetCollection", 3).Return(model.Collection{}, &util.NotExistingRecordError{Identification: "ID: 3"}).Once()
+
+	req, _ := http.NewRequest("GET", "/3", nil)
+	rr := execute("/{id}", []string{"GET"}, req, controller.GetCollection)
+
+	status := http.StatusNotFound
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerCreateCollection(t *testing.T) {
+	c := model.Collection{Name: "Trilogy", Description: "Three parts"}
+	cBytes, _ := json.Marshal(c)
+	mockService.On("CreateCollection", &c).Return(nil).Run(func(args mock.Arguments) {
+		args.Get(0).(*model.Collection).ID = 4
+	}).Once()
+
+	req, _ := http.NewRequest("POST", "/", bytes.NewBuffer(cBytes))
+	rr := execute("/", []string{"POST"}, req, controller.CreateCollection)
+
+	status := http.StatusCreated
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+	assert.Equal(t, jsonString(trilogy), rr.Body.String())
+}
+
+func TestControllerCreateCollectionBodyParsingError(t *testing.T) {
+	req, _ := http.NewRequest("POST", "/", bytes.NewBufferString(`{"description": "Three parts"}`))
+	rr := execute("/", []string{"POST"}, req, controller.CreateCollection)
+
+	status := http.StatusBadRequest
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerUpdateCollectionExistingError(t *testing.T) {
+	c := model.Collection{Name: "Saga"}
+	cBytes, _ := json.Marshal(c)
+	mockService.On("UpdateCollection", 4, &c).Return(&util.ExistingRecordError{Identification: "name: Saga"}).Once()
+
+	req, _ := http.NewRequest("PUT", "/4", bytes.NewBuffer(cBytes))
+	rr := execute("/{id}", []string{"PUT"}, req, controller.UpdateCollection)
+
+	status := http.StatusConflict
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerDeleteCollection(t *testing.T) {
+	mockService.On("DeleteCollection", 4).Return(nil).Once()
+
+	req, _ := http.NewRequest("DELETE", "/4", nil)
+	rr := execute("/{id}", []string{"DELETE"}, req, controller.DeleteCollection)
+
+	status := http.StatusNoContent
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerGetMovies(t *testing.T) {
+	movies := []model.Movie{{ID: 8, Name: "Part one", Collection: &model.CollectionRef{ID: 4, Name: "Trilogy", Position: 1}}}
+	mockService.On("GetMovies", 4).Return(movies, nil).Once()
+
+	req, _ := http.NewRequest("GET", "/collections/4/movies", nil)
+	rr := execute("/collections/{id}/movies", []string{"GET"}, req, controller.GetMovies)
+
+	status := http.StatusOK
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+	assert.Equal(t, `[{"id":8,"name":"Part one","collection":{"id":4,"name":"Trilogy","position":1}}]`, rr.Body.String())
+}
+
+func TestControllerAddMovie(t *testing.T) {
+	mockService.On("AddMovie", 4, 7).Return(nil).Once()
+
+	req, _ := http.NewRequest("PUT", "/collections/4/movies/7", nil)
+	rr := execute("/collections/{id}/movies/{movieId}", []string{"PUT"}, req, controller.AddMovie)
+
+	if !mockService.AssertCalled(t, "AddMovie", 4, 7) {
+		t.Error("The service should be called with the IDs of the path")
+	}
+	status := http.StatusNoContent
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerAddMovieExistingError(t *testing.T) {
+	mockService.On("AddMovie", 5, 7).Return(&util.ExistingRecordError{Identification: "movie ID: 7 in another collection"}).Once()
+
+	req, _ := http.NewRequest("PUT", "/collections/5/movies/7", nil)
+	rr := execute("/collections/{id}/movies/{movieId}", []string{"PUT"}, req, controller.AddMovie)
+
+	status := http.StatusConflict
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerAddMovieInvalidIDError(t *testing.T) {
+	req, _ := http.NewRequest("PUT", "/collections/4/movies/alien", nil)
+	rr := execute("/collections/{id}/movies/{movieId}", []string{"PUT"}, req, controller.AddMovie)
+
+	status := http.StatusBadRequest
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerRemoveMovieNotFoundError(t *testing.T) {
+	mockService.On("RemoveMovie", 4, 9).Return(&util.NotExistingRecordError{Identification: "collection ID: 4, movie ID: 9"}).Once()
+
+	req, _ := http.NewRequest("DELETE", "/collections/4/movies/9", nil)
+	rr := execute("/collections/{id}/movies/{movieId}", []string{"DELETE"}, req, controller.RemoveMovie)
+
+	status := http.StatusNotFound
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerReorderMovies(t *testing.T) {
+	mockService.On("ReorderMovies", 4, []int{8, 7}).Return(nil).Once()
+
+	req, _ := http.NewRequest("PUT", "/collections/4/order", bytes.NewBufferString(`{"movie_ids": [8, 7]}`))
+	rr := execute("/collections/{id}/order", []string{"PUT"}, req, controller.ReorderMovies)
+
+	status := http.StatusNoContent
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerReorderMoviesMismatchedError(t *testing.T) {
+	mockService.On("ReorderMovies", 4, []int{7}).Return(&util.MismatchedOrderError{Identification: "collection ID: 4"}).Once()
+
+	req, _ := http.NewRequest("PUT", "/collections/4/order", bytes.NewBufferString(`{"movie_ids": [7]}`))
+	rr := execute("/collections/{id}/order", []string{"PUT"}, req, controller.ReorderMovies)
+
+	status := http.StatusConflict
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerReorderMoviesBodyParsingError(t *testing.T) {
+	req, _ := http.NewRequest("PUT", "/collections/4/order", bytes.NewBufferString(`{"movie_ids": []}`))
+	rr := execute("/collections/{id}/order", []string{"PUT"}, req, controller.ReorderMovies)
+
+	status := http.StatusBadRequest
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func execute(route string, methods []string, req *http.Request, handler func(w http.ResponseWriter, r *http.Request)) *httptest.ResponseRecorder {
+	r := mux.NewRouter()
+	r.HandleFunc(route, handler).Methods(methods...)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	return rr
+}
+
+func jsonString(obj interface{}) string {
+	res, _ := json.Marshal(obj)
+	return string(res)
+}
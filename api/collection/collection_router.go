@@ -0,0 +1,44 @@
+package collection
+
+import (
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+
+	"github.com/gorilla/mux"
+)
+
+func InitializeCollectionsPipeline(api *model.Api) {
+	s := NewCollectionService(api.DB)
+	c := NewCollectionController(s)
+	setRouting(api.Router, c)
+}
+
+func setRouting(main *mux.Router, c CollectionController) {
+	sr := main.PathPrefix("/collections").Subrouter()
+
+	sr.HandleFunc("", c.GetCollections).
+		Methods("GET")
+
+	sr.HandleFunc("/{id}", c.GetCollection).
+		Methods("GET")
+
+	sr.HandleFunc("", c.CreateCollection).
+		Methods("POST")
+
+	sr.HandleFunc("/{id}", c.UpdateCollection).
+		Methods("PUT")
+
+	sr.HandleFunc("/{id}", c.DeleteCollection).
+		Methods("DELETE")
+
+	sr.HandleFunc("/{id}/movies", c.GetMovies).
+		Methods("GET")
+
+	sr.HandleFunc("/{id}/movies/{movieId}", c.AddMovie).
+		Methods("PUT")
+
+	sr.HandleFunc("/{id}/movies/{movieId}", c.RemoveMovie).
+		Methods("DELETE")
+
+	sr.HandleFunc("/{id}/order", c.ReorderMovies).
+		Methods("PUT")
+}
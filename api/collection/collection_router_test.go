@@ -0,0 +1,60 @@
+package collection_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/collection"
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInitializeCollectionsPipeline(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	api := model.Api{Router: mux.NewRouter(), DB: db}
+	collection.InitializeCollectionsPipeline(&api)
+
+	testIntegrationGetCollection(t, mock, api.Router)
+	testIntegrationGetMovies(t, mock, api.Router)
+}
+
+func testIntegrationGetCollection(t *testing.T, mock sqlmock.Sqlmock, r *mux.Router) {
+	mock.ExpectQuery(GetCollectionQuery).WithArgs(4).WillReturnRows(newRows(&[]model.Collection{trilogy}))
+
+	req, _ := http.NewRequest("GET", "/collections/4", nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, jsonString(trilogy), rr.Body.String())
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func testIntegrationGetMovies(t *testing.T, mock sqlmock.Sqlmock, r *mux.Router) {
+	mock.ExpectQuery(GetCollectionQuery).WithArgs(4).WillReturnRows(newRows(&[]model.Collection{trilogy}))
+	mock.ExpectQuery(GetMoviesQuery).WithArgs(4).WillReturnRows(
+		sqlmock.NewRows([]string{"id", "name", "synopsis", "version", "rating_average", "rating_count", "position"}).
+			AddRow(8, "Part one", "", 1, 0, 0, 1))
+
+	req, _ := http.NewRequest("GET", "/collections/4/movies", nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, jsonString([]model.Movie{
+		{ID: 8, Name: "Part one", Version: 1, Collection: &model.CollectionRef{ID: 4, Name: "Trilogy", Position: 1}},
+	}), rr.Body.String())
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
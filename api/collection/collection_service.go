@@ -0,0 +1,272 @@
+package collection
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/audit"
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/movie"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+
+	"github.com/lib/pq"
+)
+
+// Columns of the collections as scanned into model.Collection
+const columns = "id, name, description"
+
+type service struct {
+	db *sql.DB
+}
+
+// CollectionService manages the collections along with the order of their movies, a movie belongs to one collection at most
+type CollectionService interface {
+	GetCollections() ([]model.Collection, error)
+	GetCollection(id int) (model.Collection, error)
+	CreateCollection(ctx context.Context, c *model.Collection) error
+	UpdateCollection(ctx context.Context, id int, c *model.Collection) error
+	DeleteCollection(ctx context.Context, id int) error
+	GetMovies(id int) ([]model.Movie, error)
+	AddMovie(ctx context.Context, id, movieID int) error
+	RemoveMovie(ctx context.Context, id, movieID int) error
+	ReorderMovies(ctx context.Context, id int, movieIDs []int) error
+}
+
+func NewCollectionService(db *sql.DB) CollectionService {
+	return &service{
+		db: db,
+	}
+}
+
+func (s *service) GetCollections() ([]model.Collection, error) {
+	const q = "SELECT " + columns + " FROM collections ORDER BY id"
+	qr, err := s.db.Query(q)
+	if err != nil {
+		return []model.Collection{}, err
+	}
+	defer qr.Close()
+
+	result := make([]model.Collection, 0)
+	for qr.Next() {
+		c, err := scanCollection(qr)
+		if err != nil {
+			return []model.Collection{}, err
+		}
+		result = append(result, c)
+	}
+
+	return result, qr.Err()
+}
+
+func (s *service) GetCollection(id int) (model.Collection, error) {
+	return getCollection(s.db, id)
+}
+
+// CreateCollection inserts the collection and sets its generated ID, names are unique
+func (s *service) CreateCollection(ctx context.Context, c *model.Collection) error {
+	return util.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		const q = "INSERT INTO collections (name, description) VALUES ($1, $2) RETURNING id"
+		err := tx.QueryRowContext(ctx, q, c.Name, c.Description).Scan(&c.ID)
+		if util.IsViolation(err, util.UniqueViolation) {
+			return &util.ExistingRecordError{Identification: fmt.Sprintf("name: %s", c.Name)}
+		}
+		if err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx, audit.EntityCollection, c.ID, audit.ActionCreate, nil, c)
+	})
+}
+
+func (s *service) UpdateCollection(ctx context.Context, id int, c *model.Collection) error {
+	return util.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		before, err := lockCollection(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		const q = "UPDATE collections SET name = $1, description = $2 WHERE id = $3"
+		_, err = tx.ExecContext(ctx, q, c.Name, c.Description, id)
+		if util.IsViolation(err, util.UniqueViolation) {
+			return &util.ExistingRecordError{Identification: fmt.Sprintf("name: %s", c.Name)}
+		}
+		if err != nil {
+			return err
+		}
+
+		after := model.Collection{ID: id, Name: c.Name, Description: c.Description}
+		return audit.Record(ctx, tx, audit.EntityCollection, id, audit.ActionUpdate, before, after)
+	})
+}
+
+// DeleteCollection removes the collection, its movies are left without one
+func (s *service) DeleteCollection(ctx context.Context, id int) error {
+	return util.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		const q = "DELETE FROM collections WHERE id = $1 RETURNING " + columns
+		before, err := scanCollection(tx.QueryRowContext(ctx, q, id))
+		if err == sql.ErrNoRows {
+			return &util.NotExistingRecordError{Identification: fmt.Sprintf("ID: %v", id)}
+		}
+		if err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx, audit.EntityCollection, id, audit.ActionDelete, before, nil)
+	})
+}
+
+// GetMovies returns the movies of the collection in their order, leaving out the movies in the trash
+func (s *service) GetMovies(id int) ([]model.Movie, error) {
+	c, err := getCollection(s.db, id)
+	if err != nil {
+		return []model.Movie{}, err
+	}
+
+	const q = `SELECT m.id, m.name, m.synopsis, m.version, m.rating_average, m.rating_count, cm.position ` +
+		`FROM collection_movies cm JOIN movies m ON m.id = cm.movie_id ` +
+		`WHERE cm.collection_id = $1 AND m.deleted_at IS NULL ORDER BY cm.position, m.id`
+	qr, err := s.db.Query(q, id)
+	if err != nil {
+		return []model.Movie{}, err
+	}
+	defer qr.Close()
+
+	result := make([]model.Movie, 0)
+	for qr.Next() {
+		m := model.Movie{Collection: &model.CollectionRef{ID: c.ID, Name: c.Name}}
+		err = qr.Scan(&m.ID, &m.Name, &m.Synopsis, &m.Version, &m.RatingAverage, &m.RatingCount, &m.Collection.Position)
+		if err != nil {
+			return []model.Movie{}, err
+		}
+		result = append(result, m)
+	}
+
+	return result, qr.Err()
+}
+
+// AddMovie appends the movie to the collection, adding it again leaves it where it is.
+// Movies in the trash cannot be added, movies of other collections have to be removed from them first.
+func (s *service) AddMovie(ctx context.Context, id, movieID int) error {
+	return util.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		// The collection is locked while the next position is taken
+		c, err := lockCollection(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if err := movie.Exists(tx, movieID); err != nil {
+			return err
+		}
+
+		const q = "INSERT INTO collection_movies (collection_id, movie_id, position) " +
+			"SELECT $1, $2, COALESCE(MAX(position), 0) + 1 FROM collection_movies WHERE collection_id = $1 " +
+			"ON CONFLICT (collection_id, movie_id) DO NOTHING RETURNING position"
+		ref := model.CollectionRef{ID: c.ID, Name: c.Name}
+		err = tx.QueryRowContext(ctx, q, id, movieID).Scan(&ref.Position)
+		// Adding again leaves nothing to record
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if util.IsViolation(err, util.UniqueViolation) {
+			return &util.ExistingRecordError{Identification: fmt.Sprintf("movie ID: %v in another collection", movieID)}
+		}
+		if err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx, audit.EntityMovieCollection, movieID, audit.ActionCreate, nil, ref)
+	})
+}
+
+// RemoveMovie takes the movie out of the collection, the following movies move up to close the gap
+func (s *service) RemoveMovie(ctx context.Context, id, movieID int) error {
+	return util.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		c, err := lockCollection(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		const q = "DELETE FROM collection_movies WHERE collection_id = $1 AND movie_id = $2 RETURNING position"
+		before := model.CollectionRef{ID: c.ID, Name: c.Name}
+		err = tx.QueryRowContext(ctx, q, id, movieID).Scan(&before.Position)
+		if err == sql.ErrNoRows {
+			return &util.NotExistingRecordError{Identification: fmt.Sprintf("collection ID: %v, movie ID: %v", id, movieID)}
+		}
+		if err != nil {
+			return err
+		}
+
+		const uq = "UPDATE collection_movies SET position = position - 1 WHERE collection_id = $1 AND position > $2"
+		if _, err := tx.ExecContext(ctx, uq, id, before.Position); err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx, audit.EntityMovieCollection, movieID, audit.ActionDelete, before, nil)
+	})
+}
+
+// ReorderMovies moves the movies of the collection into the given order, which has to list every movie of the collection once
+func (s *service) ReorderMovies(ctx context.Context, id int, movieIDs []int) error {
+	return util.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		if _, err := lockCollection(ctx, tx, id); err != nil {
+			return err
+		}
+
+		const mq = "SELECT movie_id FROM collection_movies WHERE collection_id = $1"
+		qr, err := tx.QueryContext(ctx, mq, id)
+		if err != nil {
+			return err
+		}
+		members := make(map[int]bool)
+		for qr.Next() {
+			var movieID int
+			if err := qr.Scan(&movieID); err != nil {
+				qr.Close()
+				return err
+			}
+			members[movieID] = true
+		}
+		qr.Close()
+		if err := qr.Err(); err != nil {
+			return err
+		}
+
+		if len(movieIDs) != len(members) {
+			return &util.MismatchedOrderError{Identification: fmt.Sprintf("collection ID: %v", id)}
+		}
+		for _, movieID := range movieIDs {
+			if !members[movieID] {
+				return &util.MismatchedOrderError{Identification: fmt.Sprintf("collection ID: %v", id)}
+			}
+			// Listing a movie twice leaves another one out
+			delete(members, movieID)
+		}
+
+		const q = "UPDATE collection_movies cm SET position = o.position " +
+			"FROM unnest($2::integer[]) WITH ORDINALITY AS o(movie_id, position) " +
+			"WHERE cm.collection_id = $1 AND cm.movie_id = o.movie_id"
+		_, err = tx.ExecContext(ctx, q, id, pq.Array(movieIDs))
+		return err
+	})
+}
+
+// getCollection reads the collection from the database or from a transaction
+func getCollection(db util.QueryRower, id int) (model.Collection, error) {
+	const q = "SELECT " + columns + " FROM collections WHERE id = $1"
+	c := model.Collection{}
+	err := util.GetRecord(db, q, id, &c.ID, &c.Name, &c.Description)
+	return c, err
+}
+
+// lockCollection reads the collection, which stays locked until the transaction ends
+func lockCollection(ctx context.Context, tx *sql.Tx, id int) (model.Collection, error) {
+	const q = "SELECT " + columns + " FROM collections WHERE id = $1 FOR UPDATE"
+	c, err := scanCollection(tx.QueryRowContext(ctx, q, id))
+	if err == sql.ErrNoRows {
+		return model.Collection{}, &util.NotExistingRecordError{Identification: fmt.Sprintf("ID: %v", id)}
+	}
+	return c, err
+}
+
+// scanCollection reads a collection of the columns
+func scanCollection(row util.Scanner) (model.Collection, error) {
+	c := model.Collection{}
+	err := row.Scan(&c.ID, &c.Name, &c.Description)
+	return c, err
+}
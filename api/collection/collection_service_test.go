@@ -0,0 +1,378 @@
+package collection_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/collection"
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	GetCollectionsQuery   = `^SELECT id, name, description FROM collections ORDER BY id$`
+	GetCollectionQuery    = `^SELECT id, name, description FROM collections WHERE id = \$1$`
+	LockCollectionQuery   = `^SELECT id, name, description FROM collections WHERE id = \$1 FOR UPDATE$`
+	CreateCollectionQuery = `^INSERT INTO collections \(name, description\) VALUES \(\$1, \$2\) RETURNING id$`
+	UpdateCollectionQuery = `^UPDATE collections SET name = \$1, description = \$2 WHERE id = \$3$`
+	DeleteCollectionQuery = `^DELETE FROM collections WHERE id = \$1 RETURNING id, name, description$`
+	GetMoviesQuery        = `^SELECT m.id, .+, cm.position FROM collection_movies cm JOIN movies m .+ ` +
+		`WHERE cm.collection_id = \$1 AND m.deleted_at IS NULL ORDER BY cm.position, m.id$`
+	CheckMovieQuery  = `^SELECT id FROM movies WHERE id = \$1 AND deleted_at IS NULL$`
+	AddMovieQuery    = `^INSERT INTO collection_movies \(collection_id, movie_id, position\) SELECT \$1, \$2, COALESCE\(MAX\(position\), 0\) \+ 1 .+ RETURNING position$`
+	RemoveMovieQuery = `^DELETE FROM collection_movies WHERE collection_id = \$1 AND movie_id = \$2 RETURNING position$`
+	ClosePlacesQuery = `^UPDATE collection_movies SET position = position - 1 WHERE collection_id = \$1 AND position > \$2$`
+	MemberIDsQuery   = `^SELECT movie_id FROM collection_movies WHERE collection_id = \$1$`
+	ReorderQuery     = `^UPDATE collection_movies cm SET position = o.position FROM unnest\(\$2::integer\[\]\) WITH ORDINALITY .+$`
+	AuditQuery       = `^INSERT INTO audit_log \(.+\) VALUES \(.+\)$`
+	uniqueViolation  = "23505"
+)
+
+var trilogy = model.Collection{ID: 4, Name: "Trilogy", Description: "Three parts"}
+
+func TestServiceGetCollections(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	collections := []model.Collection{trilogy, {ID: 5, Name: "Saga"}}
+	mock.ExpectQuery(GetCollectionsQuery).WillReturnRows(newRows(&collections))
+
+	res, err := service.GetCollections()
+
+	assert.Equal(t, collections, res)
+	assert.Equal(t, nil, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceGetCollectionNotExistingError(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectQuery(GetCollectionQuery).WithArgs(3).WillReturnRows(newRows(&[]model.Collection{}))
+
+	_, err := service.GetCollection(3)
+
+	assert.IsType(t, &util.NotExistingRecordError{}, err)
+}
+
+func TestServiceCreateCollection(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(CreateCollectionQuery).WithArgs("Trilogy", "Three parts").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+	mock.ExpectExec(AuditQuery).
+		WithArgs("collection", 4, "create", "tester", "test-request", nil, `{"id":4,"name":"Trilogy","description":"Three parts"}`, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	c := &model.Collection{Name: "Trilogy", Description: "Three parts"}
+	err := service.CreateCollection(auditContext(), c)
+
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 4, c.ID, "The generated ID should be set")
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceCreateCollectionExistingError(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(CreateCollectionQuery).WillReturnError(&pq.Error{Code: uniqueViolation})
+	mock.ExpectRollback()
+
+	err := service.CreateCollection(auditContext(), &model.Collection{Name: "Trilogy"})
+
+	assert.IsType(t, &util.ExistingRecordError{}, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceUpdateCollection(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(LockCollectionQuery).WithArgs(4).WillReturnRows(newRows(&[]model.Collection{trilogy}))
+	mock.ExpectExec(UpdateCollectionQuery).WithArgs("Trilogy", "Four parts", 4).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(AuditQuery).
+		WithArgs("collection", 4, "update", "tester", "test-request", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err := service.UpdateCollection(auditContext(), 4, &model.Collection{Name: "Trilogy", Description: "Four parts"})
+
+	assert.Equal(t, nil, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceUpdateCollectionNotExistingError(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(LockCollectionQuery).WithArgs(3).WillReturnRows(newRows(&[]model.Collection{}))
+	mock.ExpectRollback()
+
+	err := service.UpdateCollection(auditContext(), 3, &model.Collection{Name: "Trilogy"})
+
+	assert.IsType(t, &util.NotExistingRecordError{}, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceDeleteCollection(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(DeleteCollectionQuery).WithArgs(4).WillReturnRows(newRows(&[]model.Collection{trilogy}))
+	mock.ExpectExec(AuditQuery).
+		WithArgs("collection", 4, "delete", "tester", "test-request", `{"id":4,"name":"Trilogy","description":"Three parts"}`, nil, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err := service.DeleteCollection(auditContext(), 4)
+
+	assert.Equal(t, nil, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceGetMovies(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectQuery(GetCollectionQuery).WithArgs(4).WillReturnRows(newRows(&[]model.Collection{trilogy}))
+	mock.ExpectQuery(GetMoviesQuery).WithArgs(4).WillReturnRows(
+		sqlmock.NewRows([]string{"id", "name", "synopsis", "version", "rating_average", "rating_count", "position"}).
+			AddRow(8, "Part one", "", 1, 0, 0, 1).
+			AddRow(7, "Part two", "", 1, 4.5, 2, 2))
+
+	res, err := service.GetMovies(4)
+
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []model.Movie{
+		{ID: 8, Name: "Part one", Version: 1, Collection: &model.CollectionRef{ID: 4, Name: "Trilogy", Position: 1}},
+		{ID: 7, Name: "Part two", Version: 1, RatingAverage: 4.5, RatingCount: 2, Collection: &model.CollectionRef{ID: 4, Name: "Trilogy", Position: 2}},
+	}, res)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceGetMoviesNotExistingError(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectQuery(GetCollectionQuery).WithArgs(3).WillReturnRows(newRows(&[]model.Collection{}))
+
+	res, err := service.GetMovies(3)
+
+	assert.Equal(t, []model.Movie{}, res)
+	assert.IsType(t, &util.NotExistingRecordError{}, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceAddMovie(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(LockCollectionQuery).WithArgs(4).WillReturnRows(newRows(&[]model.Collection{trilogy}))
+	mock.ExpectQuery(CheckMovieQuery).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectQuery(AddMovieQuery).WithArgs(4, 7).WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow(3))
+	mock.ExpectExec(AuditQuery).
+		WithArgs("movie_collection", 7, "create", "tester", "test-request", nil, `{"id":4,"name":"Trilogy","position":3}`, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err := service.AddMovie(auditContext(), 4, 7)
+
+	assert.Equal(t, nil, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceAddMovieAgain(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(LockCollectionQuery).WithArgs(4).WillReturnRows(newRows(&[]model.Collection{trilogy}))
+	mock.ExpectQuery(CheckMovieQuery).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectQuery(AddMovieQuery).WithArgs(4, 7).WillReturnRows(sqlmock.NewRows([]string{"position"}))
+	mock.ExpectCommit()
+
+	err := service.AddMovie(auditContext(), 4, 7)
+
+	assert.Equal(t, nil, err, "Adding again should leave the movie where it is")
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceAddMovieExistingError(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(LockCollectionQuery).WithArgs(4).WillReturnRows(newRows(&[]model.Collection{trilogy}))
+	mock.ExpectQuery(CheckMovieQuery).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectQuery(AddMovieQuery).WithArgs(4, 7).WillReturnError(&pq.Error{Code: uniqueViolation})
+	mock.ExpectRollback()
+
+	err := service.AddMovie(auditContext(), 4, 7)
+
+	assert.IsType(t, &util.ExistingRecordError{}, err, "Movies of other collections should not be added")
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceAddMovieMissingMovieError(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(LockCollectionQuery).WithArgs(4).WillReturnRows(newRows(&[]model.Collection{trilogy}))
+	mock.ExpectQuery(CheckMovieQuery).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+
+	err := service.AddMovie(auditContext(), 4, 7)
+
+	assert.IsType(t, &util.NotExistingRecordError{}, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceRemoveMovie(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(LockCollectionQuery).WithArgs(4).WillReturnRows(newRows(&[]model.Collection{trilogy}))
+	mock.ExpectQuery(RemoveMovieQuery).WithArgs(4, 7).WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow(2))
+	mock.ExpectExec(ClosePlacesQuery).WithArgs(4, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(AuditQuery).
+		WithArgs("movie_collection", 7, "delete", "tester", "test-request", `{"id":4,"name":"Trilogy","position":2}`, nil, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err := service.RemoveMovie(auditContext(), 4, 7)
+
+	assert.Equal(t, nil, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceRemoveMovieNotExistingError(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(LockCollectionQuery).WithArgs(4).WillReturnRows(newRows(&[]model.Collection{trilogy}))
+	mock.ExpectQuery(RemoveMovieQuery).WithArgs(4, 7).WillReturnRows(sqlmock.NewRows([]string{"position"}))
+	mock.ExpectRollback()
+
+	err := service.RemoveMovie(auditContext(), 4, 7)
+
+	assert.IsType(t, &util.NotExistingRecordError{}, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceReorderMovies(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(LockCollectionQuery).WithArgs(4).WillReturnRows(newRows(&[]model.Collection{trilogy}))
+	mock.ExpectQuery(MemberIDsQuery).WithArgs(4).WillReturnRows(sqlmock.NewRows([]string{"movie_id"}).AddRow(7).AddRow(8))
+	mock.ExpectExec(ReorderQuery).WithArgs(4, sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	err := service.ReorderMovies(auditContext(), 4, []int{8, 7})
+
+	assert.Equal(t, nil, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceReorderMoviesMismatchedError(t *testing.T) {
+	for _, order := range [][]int{{7}, {7, 7}, {7, 9}, {7, 8, 9}} {
+		service, mock, db := initNewService(t)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(LockCollectionQuery).WithArgs(4).WillReturnRows(newRows(&[]model.Collection{trilogy}))
+		mock.ExpectQuery(MemberIDsQuery).WithArgs(4).WillReturnRows(sqlmock.NewRows([]string{"movie_id"}).AddRow(7).AddRow(8))
+		mock.ExpectRollback()
+
+		err := service.ReorderMovies(auditContext(), 4, order)
+
+		assert.IsType(t, &util.MismatchedOrderError{}, err, "The order %v should not match the collection", order)
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+		db.Close()
+	}
+}
+
+func TestServiceGetCollectionsQueryError(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	queryError := errors.New("test-error-message")
+	mock.ExpectQuery(GetCollectionsQuery).WillReturnError(queryError)
+
+	res, err := service.GetCollections()
+
+	assert.Equal(t, []model.Collection{}, res)
+	assert.Equal(t, queryError, err)
+}
+
+func initNewService(t *testing.T) (collection.CollectionService, sqlmock.Sqlmock, *sql.DB) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	service := collection.NewCollectionService(db)
+	return service, mock, db
+}
+
+func auditContext() context.Context {
+	return util.WithRequestID(util.WithActor(context.Background(), "tester"), "test-request")
+}
+
+func newRows(collections *[]model.Collection) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"id", "name", "description"})
+	for _, c := range *collections {
+		rows.AddRow(c.ID, c.Name, c.Description)
+	}
+	return rows
+}
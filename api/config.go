@@ -0,0 +1,157 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	defaultHttpPort = "8080"
+	defaultBlobDir  = "data/blobs"
+	redacted        = "[redacted]"
+)
+
+// setting is a configuration key known to the server, with its default
+type setting struct {
+	key          string
+	defaultValue string
+	secret       bool
+}
+
+// settings lists every key the server reads, in the order check-config prints them
+var settings = []setting{
+	{key: "APP_PORT", defaultValue: defaultHttpPort},
+	{key: "APP_GRPC_PORT", defaultValue: defaultGrpcPort},
+	{key: "APP_DB_HOST", defaultValue: defaultDBHost},
+	{key: "APP_DB_PORT", defaultValue: defaultDBPort},
+	{key: "APP_DB_USERNAME"},
+	{key: "APP_DB_PASSWORD", secret: true},
+	{key: "APP_DB_NAME"},
+	{key: "APP_API_TOKENS", secret: true},
+	{key: "APP_MODERATORS"},
+	{key: "APP_MAX_BODY_SIZE", defaultValue: strconv.Itoa(defaultMaxBodySize)},
+	{key: "APP_BLOB_DIR", defaultValue: defaultBlobDir},
+	{key: "APP_EVENT_PUBLISHER", defaultValue: "log"},
+	{key: "APP_EVENT_FILE"},
+	{key: "APP_EVENT_WEBHOOK_URL"},
+}
+
+// Setting is a configuration key along with its effective value
+type Setting struct {
+	Key   string
+	Value string
+}
+
+// LoadConfig reads the configuration from the file, the environment variables taking precedence over it.
+// A missing file is no error, the server may be configured by the environment alone.
+func LoadConfig(file string) error {
+	for _, s := range settings {
+		viper.SetDefault(s.key, s.defaultValue)
+	}
+	viper.AutomaticEnv()
+
+	viper.SetConfigFile(file)
+	if err := viper.ReadInConfig(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("reading config [%s]: %w", file, err)
+	}
+	return nil
+}
+
+// EffectiveConfig returns the value of every setting, with the secrets redacted
+func EffectiveConfig() []Setting {
+	result := make([]Setting, 0, len(settings))
+	for _, s := range settings {
+		value := viper.GetString(s.key)
+		switch {
+		case s.key == "APP_API_TOKENS":
+			// The actors tell which tokens are configured, without giving them away
+			actors := make([]string, 0)
+			for _, actor := range getApiTokens() {
+				actors = append(actors, redacted+"="+actor)
+			}
+			sort.Strings(actors)
+			value = strings.Join(actors, ",")
+		case s.secret && value != "":
+			value = redacted
+		}
+		result = append(result, Setting{Key: s.key, Value: value})
+	}
+	return result
+}
+
+// CheckConfig validates the configuration, returning every problem found
+func CheckConfig() []error {
+	problems := make([]error, 0)
+	addProblem := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Errorf(format, args...))
+	}
+
+	for _, key := range []string{"APP_DB_USERNAME", "APP_DB_NAME"} {
+		if viper.GetString(key) == "" {
+			addProblem("%s is required", key)
+		}
+	}
+
+	for _, key := range []string{"APP_PORT", "APP_GRPC_PORT", "APP_DB_PORT"} {
+		if port, err := strconv.Atoi(viper.GetString(key)); err != nil || port < 1 || port > 65535 {
+			addProblem("%s [%s] is not a valid port", key, viper.GetString(key))
+		}
+	}
+	if viper.GetString("APP_PORT") == viper.GetString("APP_GRPC_PORT") {
+		addProblem("APP_PORT and APP_GRPC_PORT have to differ")
+	}
+
+	if size, err := strconv.ParseInt(viper.GetString("APP_MAX_BODY_SIZE"), 10, 64); err != nil || size <= 0 {
+		addProblem("APP_MAX_BODY_SIZE [%s] is not a positive number of bytes", viper.GetString("APP_MAX_BODY_SIZE"))
+	}
+
+	for _, pair := range strings.Split(viper.GetString("APP_API_TOKENS"), ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		if token, actor, found := strings.Cut(pair, "="); !found || token == "" || actor == "" {
+			addProblem("APP_API_TOKENS holds an entry which is not a token=actor pair")
+		}
+	}
+
+	switch publisher := viper.GetString("APP_EVENT_PUBLISHER"); publisher {
+	case "log":
+	case "file":
+		if viper.GetString("APP_EVENT_FILE") == "" {
+			addProblem("APP_EVENT_FILE is required by the file publisher")
+		}
+	case "webhook":
+		u, err := url.Parse(viper.GetString("APP_EVENT_WEBHOOK_URL"))
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			addProblem("APP_EVENT_WEBHOOK_URL [%s] is not a valid HTTP URL", viper.GetString("APP_EVENT_WEBHOOK_URL"))
+		}
+	default:
+		addProblem("APP_EVENT_PUBLISHER [%s] is unknown, expected log, file or webhook", publisher)
+	}
+
+	return problems
+}
+
+// getHttpAddress reads the port of the REST API from the APP_PORT key
+func getHttpAddress() string {
+	port := viper.GetString("APP_PORT")
+	if port == "" {
+		port = defaultHttpPort
+	}
+	return ":" + port
+}
+
+// getBlobDir reads the directory the uploaded files are kept in from the APP_BLOB_DIR key
+func getBlobDir() string {
+	if dir := viper.GetString("APP_BLOB_DIR"); dir != "" {
+		return dir
+	}
+	return defaultBlobDir
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadConfig(t *testing.T) {
+	defer viper.Reset()
+	file := writeConfig(t, "APP_DB_USERNAME=postgres\nAPP_DB_PASSWORD=secret\nAPP_DB_NAME=movies\nAPP_PORT=8081\n")
+	t.Setenv("APP_PORT", "8082")
+
+	err := LoadConfig(file)
+
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "postgres", viper.GetString("APP_DB_USERNAME"))
+	assert.Equal(t, ":8082", getHttpAddress(), "The environment should take precedence over the file")
+	assert.Equal(t, "localhost", viper.GetString("APP_DB_HOST"), "The defaults should apply to missing keys")
+	assert.Empty(t, CheckConfig())
+}
+
+func TestLoadConfigMissingFile(t *testing.T) {
+	defer viper.Reset()
+
+	err := LoadConfig(filepath.Join(t.TempDir(), ".env"))
+
+	assert.Equal(t, nil, err, "The environment alone may configure the server")
+	assert.Len(t, CheckConfig(), 2, "The database user and name should be required")
+}
+
+func TestEffectiveConfig(t *testing.T) {
+	defer viper.Reset()
+	file := writeConfig(t, "APP_DB_PASSWORD=secret\nAPP_API_TOKENS=t2=bob,t1=alice\n")
+	LoadConfig(file)
+
+	values := make(map[string]string)
+	for _, s := range EffectiveConfig() {
+		values[s.Key] = s.Value
+	}
+
+	assert.Equal(t, "[redacted]", values["APP_DB_PASSWORD"])
+	assert.Equal(t, "[redacted]=alice,[redacted]=bob", values["APP_API_TOKENS"])
+	assert.Equal(t, "5432", values["APP_DB_PORT"])
+	assert.Equal(t, "", values["APP_EVENT_FILE"])
+}
+
+func TestCheckConfig(t *testing.T) {
+	defer viper.Reset()
+	file := writeConfig(t, "APP_DB_USERNAME=postgres\nAPP_DB_NAME=movies\nAPP_GRPC_PORT=8080\nAPP_DB_PORT=postgres\n"+
+		"APP_API_TOKENS=secret\nAPP_MAX_BODY_SIZE=-1\nAPP_EVENT_PUBLISHER=webhook\nAPP_EVENT_WEBHOOK_URL=example.com\n")
+	LoadConfig(file)
+
+	problems := make([]string, 0)
+	for _, err := range CheckConfig() {
+		problems = append(problems, err.Error())
+	}
+
+	assert.Equal(t, []string{
+		"APP_DB_PORT [postgres] is not a valid port",
+		"APP_PORT and APP_GRPC_PORT have to differ",
+		"APP_MAX_BODY_SIZE [-1] is not a positive number of bytes",
+		"APP_API_TOKENS holds an entry which is not a token=actor pair",
+		"APP_EVENT_WEBHOOK_URL [example.com] is not a valid HTTP URL",
+	}, problems)
+}
+
+func writeConfig(t *testing.T, content string) string {
+	file := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(file, []byte(content), 0600); err != nil {
+		t.Fatalf("the config could not be written: %s", err)
+	}
+	return file
+}
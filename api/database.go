@@ -1,52 +1,55 @@
 package api
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
+	"time"
 
 	_ "github.com/lib/pq"
 	"github.com/spf13/viper"
 )
 
 const (
-	host = "localhost"
-	port = "5432"
+	defaultDBHost = "localhost"
+	defaultDBPort = "5432"
+	// How long to wait for the database to come up, e.g. when started along with it
+	connectTimeout = 30 * time.Second
+	connectBackoff = time.Second
 )
 
-var (
-	user   string
-	pw     string
-	dbname string
-)
-
-func getKeysFromEnvironment() {
-	viper.SetConfigFile(".env")
-	if err := viper.ReadInConfig(); err != nil {
-		log.Fatal("Configuration file not found")
-	}
-
-	user = viper.GetString("APP_DB_USERNAME")
-	pw = viper.GetString("APP_DB_PASSWORD")
-	dbname = viper.GetString("APP_DB_NAME")
-}
-
-func getDatabaseConnection() *sql.DB {
-	getKeysFromEnvironment()
-
-	cs := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable", host, port, user, pw, dbname)
-	db, err := sql.Open("postgres", cs)
-	checkError(err)
-
-	err = db.Ping()
-	checkError(err)
-
-	fmt.Println("Connected to Database!")
-	return db
+// getDataSource builds the connection string of the configured database
+func getDataSource() string {
+	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
+		viper.GetString("APP_DB_HOST"), viper.GetString("APP_DB_PORT"),
+		viper.GetString("APP_DB_USERNAME"), viper.GetString("APP_DB_PASSWORD"), viper.GetString("APP_DB_NAME"))
 }
 
-func checkError(err error) {
+// OpenDatabase connects to the configured database, retrying until it answers or the connect timeout passes
+func OpenDatabase(ctx context.Context) (*sql.DB, error) {
+	db, err := sql.Open("postgres", getDataSource())
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
+
+	ctx, cancel := context.WithTimeout(ctx, connectTimeout)
+	defer cancel()
+	for {
+		err = db.PingContext(ctx)
+		if err == nil {
+			break
+		}
+		log.Println("[Database] ", err.Error())
+
+		select {
+		case <-ctx.Done():
+			db.Close()
+			return nil, fmt.Errorf("connecting to the database: %w", err)
+		case <-time.After(connectBackoff):
+		}
+	}
+
+	log.Println("Connected to Database!")
+	return db, nil
 }
@@ -0,0 +1,103 @@
+package duplicate
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+
+	"github.com/gorilla/mux"
+)
+
+type controller struct {
+	service DuplicateService
+}
+
+type DuplicateController interface {
+	GetDuplicates(w http.ResponseWriter, r *http.Request)
+	MergeMovie(w http.ResponseWriter, r *http.Request)
+}
+
+func NewDuplicateController(s DuplicateService) DuplicateController {
+	return &controller{
+		service: s,
+	}
+}
+
+func (c *controller) GetDuplicates(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to GetDuplicates", r.Method))
+		return
+	}
+
+	enc, err := util.NewResponseEncoder(r, true)
+	if err != nil {
+		util.HandleNotAcceptable(w, err)
+		return
+	}
+
+	pairs, err := c.service.GetDuplicates()
+	if err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	util.WriteResponse(w, enc, http.StatusOK, pairs)
+}
+
+// MergeMovie merges the movie of the path into the one of the body, answering the surviving movie
+func (c *controller) MergeMovie(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to MergeMovie", r.Method))
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 0)
+	if err != nil {
+		util.HandleBadRequest(w, "Invalid ID", err.Error())
+		return
+	}
+
+	// Extracting MergeRequest object from request-body
+	m, err := parseValidMergeRequest(r)
+	if err != nil {
+		util.HandleInvalidBody(w, err)
+		return
+	}
+	if m.Into == int(id) {
+		util.HandleBadRequest(w, "Invalid merge", fmt.Sprintf("movie [%d] cannot be merged into itself", id))
+		return
+	}
+
+	enc, err := util.NewResponseEncoder(r, false)
+	if err != nil {
+		util.HandleNotAcceptable(w, err)
+		return
+	}
+
+	survivor, err := c.service.MergeMovie(r.Context(), int(id), m.Into)
+	if err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	util.WriteResponse(w, enc, http.StatusOK, survivor)
+}
+
+func parseValidMergeRequest(r *http.Request) (*model.MergeRequest, error) {
+	var m model.MergeRequest
+
+	// Return if the request-body cannot be decoded into a MergeRequest object
+	if err := util.DecodeRequest(r, &m); err != nil {
+		return nil, err
+	}
+
+	// Return if the requested MergeRequest object is invalid
+	if err := m.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid merge-object: %w", err)
+	}
+
+	return &m, nil
+}
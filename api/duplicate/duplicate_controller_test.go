@@ -0,0 +1,108 @@
+package duplicate_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/duplicate"
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Defining the mock DuplicateService
+type mockServiceStruct struct {
+	mock.Mock
+}
+
+func (s *mockServiceStruct) GetDuplicates() ([]model.DuplicatePair, error) {
+	args := s.Called()
+	return args.Get(0).([]model.DuplicatePair), args.Error(1)
+}
+
+func (s *mockServiceStruct) Detect() (int, error) {
+	args := s.Called()
+	return args.Int(0), args.Error(1)
+}
+
+func (s *mockServiceStruct) MergeMovie(ctx context.Context, id, into int) (model.Movie, error) {
+	args := s.Called(id, into)
+	return args.Get(0).(model.Movie), args.Error(1)
+}
+
+// Setting up mockService and controller
+var mockService = new(mockServiceStruct)
+var controller = duplicate.NewDuplicateController(mockService)
+
+func TestControllerGetDuplicates(t *testing.T) {
+	pairs := []model.DuplicatePair{{
+		Movie:     model.DuplicateMovie{ID: 1, Name: "The Matrix", Year: 1999},
+		Duplicate: model.DuplicateMovie{ID: 3, Name: "Matrix, The"},
+		Score:     1,
+	}}
+	mockService.On("GetDuplicates").Return(pairs, nil).Once()
+
+	req, _ := http.NewRequest("GET", "/movies/duplicates", nil)
+	rr := execute("/movies/duplicates", []string{"GET"}, req, controller.GetDuplicates)
+
+	status := http.StatusOK
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+	assert.Equal(t, jsonString(pairs), rr.Body.String())
+}
+
+func TestControllerMergeMovie(t *testing.T) {
+	survivor := model.Movie{ID: 1, Name: "The Matrix", Version: 2}
+	mockService.On("MergeMovie", 3, 1).Return(survivor, nil).Once()
+
+	req, _ := http.NewRequest("POST", "/movies/3:merge", bytes.NewBufferString(`{"into": 1}`))
+	rr := execute("/movies/{id}:merge", []string{"POST"}, req, controller.MergeMovie)
+
+	status := http.StatusOK
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+	assert.Equal(t, jsonString(survivor), rr.Body.String())
+}
+
+func TestControllerMergeMovieParsingError(t *testing.T) {
+	for path, body := range map[string]string{
+		"/movies/x:merge": `{"into": 1}`,
+		"/movies/3:merge": `{}`,
+		"/movies/1:merge": `{"into": 1}`,
+	} {
+		req, _ := http.NewRequest("POST", path, bytes.NewBufferString(body))
+		rr := execute("/movies/{id}:merge", []string{"POST"}, req, controller.MergeMovie)
+
+		status := http.StatusBadRequest
+		assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d] for %s %s", status, path, body))
+	}
+}
+
+func TestControllerMergeMovieNotFoundError(t *testing.T) {
+	mockService.On("MergeMovie", 3, 2).Return(model.Movie{}, &util.NotExistingRecordError{Identification: "ID: 2"}).Once()
+
+	req, _ := http.NewRequest("POST", "/movies/3:merge", bytes.NewBufferString(`{"into": 2}`))
+	rr := execute("/movies/{id}:merge", []string{"POST"}, req, controller.MergeMovie)
+
+	status := http.StatusNotFound
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func execute(route string, methods []string, req *http.Request, handler func(w http.ResponseWriter, r *http.Request)) *httptest.ResponseRecorder {
+	r := mux.NewRouter()
+	r.HandleFunc(route, handler).Methods(methods...)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	return rr
+}
+
+func jsonString(obj interface{}) string {
+	res, _ := json.Marshal(obj)
+	return string(res)
+}
@@ -0,0 +1,98 @@
+package duplicate
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+)
+
+// Score from which two titles are reported as duplicates
+const threshold = 0.85
+
+// Articles left out at either end of the titles, as imports often drop or move them ("Matrix, The")
+var articles = map[string]bool{"the": true, "a": true, "an": true}
+
+// The year imports commonly append to the titles, such as "Heat (1995)"
+var titleYearRegexp = regexp.MustCompile(`\s*\((\d{4})\)\s*$`)
+
+// Detect pairs up the movies whose normalized titles are similar by either their edit distance or their trigrams.
+// Movies of different years are told apart, as those are remakes rather than duplicates.
+// The pairs are ordered by their scores, the most certain ones first.
+func Detect(movies []model.DuplicateMovie) []model.DuplicatePair {
+	movies = append([]model.DuplicateMovie(nil), movies...)
+	titles := make([]string, len(movies))
+	for i := range movies {
+		movies[i].Year, titles[i] = yearOf(movies[i]), NormalizeTitle(movies[i].Name)
+	}
+
+	result := make([]model.DuplicatePair, 0)
+	for i := range movies {
+		for j := i + 1; j < len(movies); j++ {
+			a, b := movies[i], movies[j]
+			if a.Year != 0 && b.Year != 0 && a.Year != b.Year {
+				continue
+			}
+			score := Score(titles[i], titles[j])
+			if score < threshold {
+				continue
+			}
+			if b.ID < a.ID {
+				a, b = b, a
+			}
+			result = append(result, model.DuplicatePair{Movie: a, Duplicate: b, Score: score})
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Score != result[j].Score {
+			return result[i].Score > result[j].Score
+		}
+		if result[i].Movie.ID != result[j].Movie.ID {
+			return result[i].Movie.ID < result[j].Movie.ID
+		}
+		return result[i].Duplicate.ID < result[j].Duplicate.ID
+	})
+	return result
+}
+
+// NormalizeTitle lowercases the title down to its words, leaving out the appended year and the leading article
+func NormalizeTitle(title string) string {
+	words := util.Words(titleYearRegexp.ReplaceAllString(title, ""))
+	if len(words) > 1 && articles[words[0]] {
+		words = words[1:]
+	}
+	if n := len(words); n > 1 && articles[words[n-1]] {
+		words = words[:n-1]
+	}
+	return strings.Join(words, " ")
+}
+
+// Score rates the similarity of two normalized titles between 0 and 1, by the better of their edit distance and trigrams.
+// The edit distance catches typos in short titles, the trigrams words missing from or swapped in long ones.
+func Score(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+	longest := len([]rune(a))
+	if n := len([]rune(b)); n > longest {
+		longest = n
+	}
+	score := 1 - float64(util.Levenshtein(a, b))/float64(longest)
+	if trigrams := util.TrigramSimilarity(a, b); trigrams > score {
+		score = trigrams
+	}
+	return score
+}
+
+// yearOf prefers the year appended to the title over the one of the first release
+func yearOf(m model.DuplicateMovie) int {
+	if match := titleYearRegexp.FindStringSubmatch(m.Name); match != nil {
+		year, _ := strconv.Atoi(match[1])
+		return year
+	}
+	return m.Year
+}
@@ -0,0 +1,51 @@
+package duplicate_test
+
+import (
+	"testing"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/duplicate"
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeTitle(t *testing.T) {
+	assert.Equal(t, "matrix", duplicate.NormalizeTitle("The Matrix"))
+	assert.Equal(t, "matrix", duplicate.NormalizeTitle("Matrix, The"))
+	assert.Equal(t, "heat", duplicate.NormalizeTitle("HEAT (1995)"))
+	assert.Equal(t, "the", duplicate.NormalizeTitle("The"), "Titles made of an article only should be kept")
+}
+
+func TestScore(t *testing.T) {
+	assert.Equal(t, 1.0, duplicate.Score("heat", "heat"))
+	assert.InDelta(t, 12.0/13, duplicate.Score("spirited away", "spirted away"), 0.001, "Typos should be scored by the edit distance")
+	assert.Less(t, duplicate.Score("alien", "aliens"), 0.85)
+}
+
+func TestDetect(t *testing.T) {
+	pairs := duplicate.Detect([]model.DuplicateMovie{
+		{ID: 1, Name: "The Matrix", Year: 1999},
+		{ID: 2, Name: "Spirited Away"},
+		{ID: 3, Name: "Matrix, The"},
+		{ID: 4, Name: "Spirted Away (2001)"},
+		{ID: 5, Name: "Heat"},
+	})
+
+	assert.Equal(t, []model.DuplicatePair{
+		{Movie: model.DuplicateMovie{ID: 1, Name: "The Matrix", Year: 1999}, Duplicate: model.DuplicateMovie{ID: 3, Name: "Matrix, The"}, Score: 1},
+		{
+			Movie:     model.DuplicateMovie{ID: 2, Name: "Spirited Away"},
+			Duplicate: model.DuplicateMovie{ID: 4, Name: "Spirted Away (2001)", Year: 2001},
+			Score:     duplicate.Score("spirited away", "spirted away"),
+		},
+	}, pairs, "The most certain pairs should come first, the years of the titles should be picked up")
+}
+
+func TestDetectRemakes(t *testing.T) {
+	pairs := duplicate.Detect([]model.DuplicateMovie{
+		{ID: 1, Name: "Dune", Year: 1984},
+		{ID: 2, Name: "Dune (2021)"},
+	})
+
+	assert.Empty(t, pairs, "Movies of different years should not be paired")
+}
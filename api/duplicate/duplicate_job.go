@@ -0,0 +1,47 @@
+package duplicate
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Job detects the duplicates of the catalog every interval, off the request path.
+// Unlike the similar movies index it does not follow the changes, as imports arrive in batches and the report can wait.
+type Job struct {
+	service  DuplicateService
+	interval time.Duration
+}
+
+func NewJob(s DuplicateService, interval time.Duration) *Job {
+	return &Job{
+		service:  s,
+		interval: interval,
+	}
+}
+
+// Run detects the duplicates right away, then every interval until the context is cancelled
+func (j *Job) Run(ctx context.Context) {
+	j.detect()
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			j.detect()
+		}
+	}
+}
+
+func (j *Job) detect() {
+	started := time.Now()
+	n, err := j.service.Detect()
+	if err != nil {
+		log.Println("[Duplicate Job Error] ", err.Error())
+		return
+	}
+	log.Printf("[Duplicate Job] found %d duplicates in %s", n, time.Since(started))
+}
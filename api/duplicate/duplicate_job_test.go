@@ -0,0 +1,49 @@
+package duplicate_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/duplicate"
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// detectCounter signals every detection of the duplicates
+type detectCounter struct {
+	detections chan struct{}
+}
+
+func (s *detectCounter) GetDuplicates() ([]model.DuplicatePair, error) {
+	return []model.DuplicatePair{}, nil
+}
+
+func (s *detectCounter) Detect() (int, error) {
+	s.detections <- struct{}{}
+	return 0, nil
+}
+
+func (s *detectCounter) MergeMovie(ctx context.Context, id, into int) (model.Movie, error) {
+	return model.Movie{}, nil
+}
+
+func TestJobDetectsEveryInterval(t *testing.T) {
+	s := &detectCounter{detections: make(chan struct{}, 10)}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go duplicate.NewJob(s, 10*time.Millisecond).Run(ctx)
+
+	waitForDetection(t, s)
+	waitForDetection(t, s)
+}
+
+func waitForDetection(t *testing.T, s *detectCounter) {
+	select {
+	case <-s.detections:
+	case <-time.After(time.Second):
+		assert.Fail(t, "The duplicates should have been detected")
+	}
+}
@@ -0,0 +1,22 @@
+package duplicate
+
+import (
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+
+	"github.com/gorilla/mux"
+)
+
+// InitializeDuplicatesPipeline has to run ahead of the movies pipeline, whose "/movies/{id}" would match the report otherwise.
+// The report of the service is kept up to date by a Job.
+func InitializeDuplicatesPipeline(api *model.Api, s DuplicateService) {
+	c := NewDuplicateController(s)
+	setRouting(api.Router, c)
+}
+
+func setRouting(main *mux.Router, c DuplicateController) {
+	main.HandleFunc("/movies/duplicates", c.GetDuplicates).
+		Methods("GET")
+
+	main.HandleFunc("/movies/{id}:merge", c.MergeMovie).
+		Methods("POST")
+}
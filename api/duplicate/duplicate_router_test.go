@@ -0,0 +1,50 @@
+package duplicate_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/duplicate"
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/movie"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInitializeDuplicatesPipeline(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	// The report is routed ahead of the movies, whose "/movies/{id}" would match it otherwise
+	api := model.Api{Router: mux.NewRouter(), DB: db}
+	duplicate.InitializeDuplicatesPipeline(&api, duplicate.NewDuplicateService(db))
+	movie.InitializeMoviesPipeline(&api)
+
+	testIntegrationGetDuplicates(t, mock, api.Router)
+}
+
+func testIntegrationGetDuplicates(t *testing.T, mock sqlmock.Sqlmock, r *mux.Router) {
+	mock.ExpectQuery(CandidatesQuery).WillReturnRows(sqlmock.NewRows([]string{"id", "name", "year"}).
+		AddRow(1, "Heat", 1995).
+		AddRow(2, "Heat (1995)", 0))
+
+	req, _ := http.NewRequest("GET", "/movies/duplicates", nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	pairs := []model.DuplicatePair{{
+		Movie:     model.DuplicateMovie{ID: 1, Name: "Heat", Year: 1995},
+		Duplicate: model.DuplicateMovie{ID: 2, Name: "Heat (1995)", Year: 1995},
+		Score:     1,
+	}}
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, jsonString(pairs), rr.Body.String())
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,255 @@
+package duplicate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/audit"
+	"github.com/Hunterlemming/golang-microservice-example/api/event"
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/review"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+
+	"github.com/lib/pq"
+)
+
+// Columns of the movies as scanned into model.Movie
+const movieColumns = "id, name, synopsis, version, rating_average, rating_count"
+
+// The statements moving the records linked to the duplicate ($1) onto the surviving movie ($2).
+// Records the surviving movie already has a counterpart of stay behind, and are deleted along with the duplicate.
+var moveStatements = []string{
+	"UPDATE movie_credits c SET movie_id = $2 WHERE c.movie_id = $1 AND NOT EXISTS (SELECT 1 FROM movie_credits d " +
+		"WHERE d.movie_id = $2 AND d.person_id = c.person_id AND d.role = c.role AND d.character_name = c.character_name)",
+	"UPDATE movie_genres g SET movie_id = $2 WHERE g.movie_id = $1 AND NOT EXISTS (SELECT 1 FROM movie_genres d " +
+		"WHERE d.movie_id = $2 AND d.genre_id = g.genre_id)",
+	"UPDATE movie_tags t SET movie_id = $2 WHERE t.movie_id = $1 AND NOT EXISTS (SELECT 1 FROM movie_tags d " +
+		"WHERE d.movie_id = $2 AND d.tag_id = t.tag_id)",
+	// Users having reviewed both keep their review of the surviving movie
+	"UPDATE reviews r SET movie_id = $2 WHERE r.movie_id = $1 AND NOT EXISTS (SELECT 1 FROM reviews d " +
+		"WHERE d.movie_id = $2 AND d.user_name = r.user_name)",
+	// Entries keep their positions, so the lists stay in order
+	"UPDATE watchlist_entries e SET movie_id = $2 WHERE e.movie_id = $1 AND NOT EXISTS (SELECT 1 FROM watchlist_entries d " +
+		"WHERE d.movie_id = $2 AND d.watchlist_id = e.watchlist_id)",
+	// A poster left behind is deleted with the duplicate, its images are swept by the poster sweeper
+	"UPDATE movie_posters SET movie_id = $2 WHERE movie_id = $1 AND NOT EXISTS (SELECT 1 FROM movie_posters WHERE movie_id = $2)",
+	"UPDATE movie_translations t SET movie_id = $2 WHERE t.movie_id = $1 AND NOT EXISTS (SELECT 1 FROM movie_translations d " +
+		"WHERE d.movie_id = $2 AND d.locale = t.locale)",
+	// The surviving movie takes the place of the merged one in its collection, unless it belongs to one itself
+	"UPDATE collection_movies SET movie_id = $2 WHERE movie_id = $1 AND NOT EXISTS (SELECT 1 FROM collection_movies WHERE movie_id = $2)",
+	// Former duplicates of the duplicate now redirect to the surviving movie
+	"UPDATE movie_redirects SET movie_id = $2 WHERE movie_id = $1",
+}
+
+type service struct {
+	db *sql.DB
+
+	mu     sync.RWMutex
+	report []model.DuplicatePair
+}
+
+// DuplicateService reports the movies suspected to be duplicates by its last detection, and merges them.
+// Merged movies are redirected to the movie they were merged into.
+type DuplicateService interface {
+	GetDuplicates() ([]model.DuplicatePair, error)
+	Detect() (int, error)
+	MergeMovie(ctx context.Context, id, into int) (model.Movie, error)
+}
+
+func NewDuplicateService(db *sql.DB) DuplicateService {
+	return &service{
+		db: db,
+	}
+}
+
+// GetDuplicates returns the report of the last detection, requests arriving ahead of the first one run it themselves
+func (s *service) GetDuplicates() ([]model.DuplicatePair, error) {
+	s.mu.RLock()
+	report := s.report
+	s.mu.RUnlock()
+
+	if report == nil {
+		if _, err := s.Detect(); err != nil {
+			return []model.DuplicatePair{}, err
+		}
+		s.mu.RLock()
+		report = s.report
+		s.mu.RUnlock()
+	}
+	return report, nil
+}
+
+// Detect compares every movie which is not in the trash with the others, replacing the report.
+// It returns the number of pairs found.
+func (s *service) Detect() (int, error) {
+	// The year of a movie is the one of its first release in any region
+	const q = "SELECT m.id, m.name, COALESCE(EXTRACT(YEAR FROM MIN(r.released_at))::integer, 0) " +
+		"FROM movies m LEFT JOIN movie_releases r ON r.movie_id = m.id WHERE m.deleted_at IS NULL GROUP BY m.id ORDER BY m.id"
+	qr, err := s.db.Query(q)
+	if err != nil {
+		return 0, err
+	}
+	defer qr.Close()
+
+	movies := make([]model.DuplicateMovie, 0)
+	for qr.Next() {
+		m := model.DuplicateMovie{}
+		if err := qr.Scan(&m.ID, &m.Name, &m.Year); err != nil {
+			return 0, err
+		}
+		movies = append(movies, m)
+	}
+	if err := qr.Err(); err != nil {
+		return 0, err
+	}
+
+	report := Detect(movies)
+	s.mu.Lock()
+	s.report = report
+	s.mu.Unlock()
+	return len(report), nil
+}
+
+// MergeMovie moves the records linked to the movie onto the one it is merged into, then deletes it for good,
+// leaving a redirect behind. It returns the surviving movie, whose ratings are recounted.
+func (s *service) MergeMovie(ctx context.Context, id, into int) (model.Movie, error) {
+	var survivor model.Movie
+	err := util.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		// Locking both movies in the order of their IDs, so merges of the same pair in opposite directions cannot deadlock
+		const lq = "SELECT " + movieColumns + " FROM movies WHERE id = ANY($1) AND deleted_at IS NULL ORDER BY id FOR UPDATE"
+		movies, err := queryMovies(ctx, tx, lq, pq.Array([]int64{int64(id), int64(into)}))
+		if err != nil {
+			return err
+		}
+		merged, ok := movies[id]
+		if !ok {
+			return &util.NotExistingRecordError{Identification: fmt.Sprintf("ID: %v", id)}
+		}
+		if _, ok := movies[into]; !ok {
+			return &util.NotExistingRecordError{Identification: fmt.Sprintf("ID: %v", into)}
+		}
+
+		for _, q := range moveStatements {
+			if _, err := tx.ExecContext(ctx, q, id, into); err != nil {
+				return err
+			}
+		}
+		if err := moveReleases(ctx, tx, id, into); err != nil {
+			return err
+		}
+
+		// The surviving movie may have been created under the ID of a movie merged before,
+		// whose redirect would now lead back to itself
+		const sq = "DELETE FROM movie_redirects WHERE old_id = $1"
+		if _, err := tx.ExecContext(ctx, sq, into); err != nil {
+			return err
+		}
+		// Movies created under the ID of a merged one may be merged as well, replacing its redirect
+		const rq = "INSERT INTO movie_redirects (old_id, movie_id) VALUES ($1, $2) " +
+			"ON CONFLICT (old_id) DO UPDATE SET movie_id = EXCLUDED.movie_id, merged_at = now()"
+		if _, err := tx.ExecContext(ctx, rq, id, into); err != nil {
+			return err
+		}
+		const dq = "DELETE FROM movies WHERE id = $1"
+		if _, err := tx.ExecContext(ctx, dq, id); err != nil {
+			return err
+		}
+
+		// The moved reviews count towards the ratings of the surviving movie
+		if err := review.UpdateRating(ctx, tx, into); err != nil {
+			return err
+		}
+		const gq = "SELECT " + movieColumns + " FROM movies WHERE id = $1"
+		movies, err = queryMovies(ctx, tx, gq, into)
+		if err != nil {
+			return err
+		}
+		survivor = movies[into]
+
+		if err := audit.Record(ctx, tx, audit.EntityMovie, id, audit.ActionMerge, merged, survivor); err != nil {
+			return err
+		}
+		// For downstream services the duplicate is gone, while the surviving movie has changed
+		if err := event.Write(ctx, tx, event.TypeMovieDeleted, id, merged); err != nil {
+			return err
+		}
+		return event.Write(ctx, tx, event.TypeMovieUpdated, into, survivor)
+	})
+	if err != nil {
+		return model.Movie{}, err
+	}
+
+	s.forget(id)
+	return survivor, nil
+}
+
+// moveReleases moves the releases of the regions the surviving movie has not been released in, along with their windows
+func moveReleases(ctx context.Context, tx *sql.Tx, id, into int) error {
+	const q = "INSERT INTO movie_releases (movie_id, region, released_at, updated_at) " +
+		"SELECT $2, r.region, r.released_at, r.updated_at FROM movie_releases r WHERE r.movie_id = $1 " +
+		"AND NOT EXISTS (SELECT 1 FROM movie_releases d WHERE d.movie_id = $2 AND d.region = r.region) RETURNING region"
+	qr, err := tx.QueryContext(ctx, q, id, into)
+	if err != nil {
+		return err
+	}
+
+	// Reading all the rows first, as the transaction cannot be used while they are open
+	regions := make([]string, 0)
+	for qr.Next() {
+		var region string
+		if err := qr.Scan(&region); err != nil {
+			qr.Close()
+			return err
+		}
+		regions = append(regions, region)
+	}
+	qr.Close()
+	if err := qr.Err(); err != nil {
+		return err
+	}
+	if len(regions) == 0 {
+		return nil
+	}
+
+	const wq = "UPDATE movie_availability SET movie_id = $2 WHERE movie_id = $1 AND region = ANY($3)"
+	_, err = tx.ExecContext(ctx, wq, id, into, pq.Array(regions))
+	return err
+}
+
+// forget drops the pairs of the merged movie from the report, ahead of the next detection
+func (s *service) forget(id int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.report == nil {
+		return
+	}
+
+	report := make([]model.DuplicatePair, 0, len(s.report))
+	for _, p := range s.report {
+		if p.Movie.ID != id && p.Duplicate.ID != id {
+			report = append(report, p)
+		}
+	}
+	s.report = report
+}
+
+// queryMovies reads the movies returned by a statement by their IDs
+func queryMovies(ctx context.Context, tx *sql.Tx, q string, args ...interface{}) (map[int]model.Movie, error) {
+	qr, err := tx.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer qr.Close()
+
+	result := make(map[int]model.Movie)
+	for qr.Next() {
+		m := model.Movie{}
+		if err := qr.Scan(&m.ID, &m.Name, &m.Synopsis, &m.Version, &m.RatingAverage, &m.RatingCount); err != nil {
+			return nil, err
+		}
+		result[m.ID] = m
+	}
+	return result, qr.Err()
+}
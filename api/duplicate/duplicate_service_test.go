@@ -0,0 +1,171 @@
+package duplicate_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/duplicate"
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	CandidatesQuery     = `^SELECT m.id, m.name, .+ FROM movies m LEFT JOIN movie_releases r ON r.movie_id = m.id WHERE m.deleted_at IS NULL GROUP BY m.id ORDER BY m.id$`
+	LockMoviesQuery     = `^SELECT .+ FROM movies WHERE id = ANY\(\$1\) AND deleted_at IS NULL ORDER BY id FOR UPDATE$`
+	MoveQuery           = `^UPDATE (movie_credits|movie_genres|movie_tags|reviews|watchlist_entries|movie_posters|movie_translations|collection_movies|movie_redirects) .+`
+	MoveReleasesQuery   = `^INSERT INTO movie_releases \(movie_id, region, released_at, updated_at\) SELECT .+ RETURNING region$`
+	MoveWindowsQuery    = `^UPDATE movie_availability SET movie_id = \$2 WHERE movie_id = \$1 AND region = ANY\(\$3\)$`
+	DropRedirectQuery   = `^DELETE FROM movie_redirects WHERE old_id = \$1$`
+	InsertRedirectQuery = `^INSERT INTO movie_redirects \(old_id, movie_id\) VALUES \(\$1, \$2\) ON CONFLICT .+$`
+	DeleteMovieQuery    = `^DELETE FROM movies WHERE id = \$1$`
+	UpdateRatingQuery   = `^UPDATE movies SET rating_average = .+ WHERE movies.id = \$1$`
+	GetMovieQuery       = `^SELECT .+ FROM movies WHERE id = \$1$`
+	AuditQuery          = `^INSERT INTO audit_log \(.+\) VALUES \(.+\)$`
+	OutboxQuery         = `^INSERT INTO outbox \(.+\) VALUES \(.+\)$`
+)
+
+// The statements moving the linked records other than the releases
+const moveStatements = 9
+
+func TestServiceGetDuplicates(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectQuery(CandidatesQuery).WillReturnRows(sqlmock.NewRows([]string{"id", "name", "year"}).
+		AddRow(1, "The Matrix", 1999).
+		AddRow(2, "Heat", 0).
+		AddRow(3, "Matrix, The", 0))
+
+	res, err := service.GetDuplicates()
+
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []model.DuplicatePair{{
+		Movie:     model.DuplicateMovie{ID: 1, Name: "The Matrix", Year: 1999},
+		Duplicate: model.DuplicateMovie{ID: 3, Name: "Matrix, The"},
+		Score:     1,
+	}}, res)
+
+	// The report is kept until the next detection
+	res, err = service.GetDuplicates()
+	assert.Equal(t, nil, err)
+	assert.Len(t, res, 1)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceMergeMovie(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	merged := model.Movie{ID: 3, Name: "Matrix, The", Version: 1, RatingAverage: 5, RatingCount: 1}
+	survivor := model.Movie{ID: 1, Name: "The Matrix", Version: 2, RatingAverage: 4, RatingCount: 1}
+	recounted := model.Movie{ID: 1, Name: "The Matrix", Version: 2, RatingAverage: 4.5, RatingCount: 2}
+
+	// Detecting ahead of the merge, so its pairs are dropped from the report
+	mock.ExpectQuery(CandidatesQuery).WillReturnRows(sqlmock.NewRows([]string{"id", "name", "year"}).
+		AddRow(1, "The Matrix", 0).
+		AddRow(3, "Matrix, The", 0))
+	_, _ = service.Detect()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(LockMoviesQuery).WillReturnRows(newRows(&[]model.Movie{survivor, merged}))
+	for i := 0; i < moveStatements; i++ {
+		mock.ExpectExec(MoveQuery).WithArgs(3, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectQuery(MoveReleasesQuery).WithArgs(3, 1).WillReturnRows(sqlmock.NewRows([]string{"region"}).AddRow("DE"))
+	mock.ExpectExec(MoveWindowsQuery).WithArgs(3, 1, sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(DropRedirectQuery).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(InsertRedirectQuery).WithArgs(3, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(DeleteMovieQuery).WithArgs(3).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(UpdateRatingQuery).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(GetMovieQuery).WithArgs(1).WillReturnRows(newRows(&[]model.Movie{recounted}))
+	mock.ExpectExec(AuditQuery).
+		WithArgs("movie", 3, "merge", "tester", "test-request", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(OutboxQuery).WithArgs("MovieDeleted", 3, sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(OutboxQuery).WithArgs("MovieUpdated", 1, sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectCommit()
+
+	res, err := service.MergeMovie(auditContext(), 3, 1)
+
+	assert.Equal(t, nil, err)
+	assert.Equal(t, recounted, res, "The surviving movie should be answered with its ratings recounted")
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+	pairs, _ := service.GetDuplicates()
+	assert.Empty(t, pairs, "The pairs of the merged movie should be dropped from the report")
+}
+
+func TestServiceMergeMovieWithoutReleases(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(LockMoviesQuery).WillReturnRows(newRows(&[]model.Movie{{ID: 1, Name: "Heat"}, {ID: 2, Name: "Heat"}}))
+	for i := 0; i < moveStatements; i++ {
+		mock.ExpectExec(MoveQuery).WithArgs(2, 1).WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+	mock.ExpectQuery(MoveReleasesQuery).WithArgs(2, 1).WillReturnRows(sqlmock.NewRows([]string{"region"}))
+	mock.ExpectExec(DropRedirectQuery).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(InsertRedirectQuery).WithArgs(2, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(DeleteMovieQuery).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(UpdateRatingQuery).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(GetMovieQuery).WithArgs(1).WillReturnRows(newRows(&[]model.Movie{{ID: 1, Name: "Heat"}}))
+	mock.ExpectExec(AuditQuery).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(OutboxQuery).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(OutboxQuery).WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectCommit()
+
+	_, err := service.MergeMovie(auditContext(), 2, 1)
+
+	assert.Equal(t, nil, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceMergeMovieMissingError(t *testing.T) {
+	for _, movies := range [][]model.Movie{{{ID: 1, Name: "Heat"}}, {{ID: 2, Name: "Heat"}}} {
+		service, mock, db := initNewService(t)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(LockMoviesQuery).WillReturnRows(newRows(&movies))
+		mock.ExpectRollback()
+
+		_, err := service.MergeMovie(auditContext(), 2, 1)
+
+		assert.IsType(t, &util.NotExistingRecordError{}, err, "Both movies should exist")
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+		db.Close()
+	}
+}
+
+func initNewService(t *testing.T) (duplicate.DuplicateService, sqlmock.Sqlmock, *sql.DB) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	service := duplicate.NewDuplicateService(db)
+	return service, mock, db
+}
+
+func auditContext() context.Context {
+	return util.WithRequestID(util.WithActor(context.Background(), "tester"), "test-request")
+}
+
+func newRows(movies *[]model.Movie) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"id", "name", "synopsis", "version", "rating_average", "rating_count"})
+	for _, m := range *movies {
+		rows.AddRow(m.ID, m.Name, m.Synopsis, m.Version, m.RatingAverage, m.RatingCount)
+	}
+	return rows
+}
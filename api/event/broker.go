@@ -0,0 +1,107 @@
+package event
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+)
+
+// Broker fans the published events out to in-process subscribers and keeps the latest
+// ones in a bounded replay buffer, so subscribers can resume after reconnecting.
+type Broker struct {
+	mu     sync.Mutex
+	buffer []model.Event
+	// IDs of the buffered events, which are kept in the order they arrived rather than by their IDs
+	seen        map[int64]struct{}
+	size        int
+	subscribers map[*Subscriber]struct{}
+}
+
+// Subscriber receives the events published after it subscribed. If it falls behind by more
+// than its channel buffer, it is dropped and its channel closed, so it can resume from the replay buffer.
+type Subscriber struct {
+	Events <-chan model.Event
+	events chan model.Event
+}
+
+var _ Publisher = (*Broker)(nil)
+
+func NewBroker(size int) *Broker {
+	return &Broker{
+		buffer:      make([]model.Event, 0, size),
+		seen:        make(map[int64]struct{}, size),
+		size:        size,
+		subscribers: make(map[*Subscriber]struct{}),
+	}
+}
+
+func (b *Broker) Publish(ctx context.Context, e model.Event) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// The relay delivers at least once, redeliveries have already been fanned out.
+	// Events may arrive after ones of higher IDs, such as the retried events of a movie, those are fanned out as well.
+	if _, ok := b.seen[e.ID]; ok {
+		return nil
+	}
+
+	if len(b.buffer) == b.size {
+		delete(b.seen, b.buffer[0].ID)
+		b.buffer = append(b.buffer[:0], b.buffer[1:]...)
+	}
+	b.buffer = append(b.buffer, e)
+	b.seen[e.ID] = struct{}{}
+
+	for s := range b.subscribers {
+		select {
+		case s.events <- e:
+		default:
+			b.drop(s)
+		}
+	}
+	return nil
+}
+
+// Subscribe registers a new subscriber and returns the buffered events which arrived after the one of lastEventID,
+// without any event slipping through between the replay and the subscription. If that event is no longer
+// buffered, the events of higher IDs are returned.
+func (b *Broker) Subscribe(lastEventID int64, bufferSize int) (*Subscriber, []model.Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	replay := make([]model.Event, 0)
+	if _, ok := b.seen[lastEventID]; ok {
+		for i := range b.buffer {
+			if b.buffer[i].ID == lastEventID {
+				replay = append(replay, b.buffer[i+1:]...)
+				break
+			}
+		}
+	} else {
+		for _, e := range b.buffer {
+			if e.ID > lastEventID {
+				replay = append(replay, e)
+			}
+		}
+	}
+
+	events := make(chan model.Event, bufferSize)
+	s := &Subscriber{Events: events, events: events}
+	b.subscribers[s] = struct{}{}
+	return s, replay
+}
+
+func (b *Broker) Unsubscribe(s *Subscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.subscribers[s]; ok {
+		b.drop(s)
+	}
+}
+
+func (b *Broker) drop(s *Subscriber) {
+	delete(b.subscribers, s)
+	close(s.events)
+}
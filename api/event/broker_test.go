@@ -0,0 +1,82 @@
+package event_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/event"
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBrokerFanOut(t *testing.T) {
+	b := event.NewBroker(10)
+	first, _ := b.Subscribe(0, 1)
+	second, _ := b.Subscribe(0, 1)
+
+	assert.Nil(t, b.Publish(context.Background(), newEvent(1, 1)))
+
+	assert.Equal(t, int64(1), (<-first.Events).ID)
+	assert.Equal(t, int64(1), (<-second.Events).ID)
+}
+
+func TestBrokerReplay(t *testing.T) {
+	b := event.NewBroker(2)
+	for id := int64(1); id <= 3; id++ {
+		b.Publish(context.Background(), newEvent(id, 1))
+	}
+
+	_, replay := b.Subscribe(0, 1)
+	assert.Equal(t, []int64{2, 3}, eventIDs(replay), "Only the latest events should be buffered")
+
+	_, replay = b.Subscribe(2, 1)
+	assert.Equal(t, []int64{3}, eventIDs(replay), "Only the events after the last one seen should be replayed")
+}
+
+func TestBrokerIgnoresRedeliveries(t *testing.T) {
+	b := event.NewBroker(10)
+	sub, _ := b.Subscribe(0, 2)
+
+	b.Publish(context.Background(), newEvent(1, 1))
+	b.Publish(context.Background(), newEvent(1, 1))
+
+	assert.Equal(t, 1, len(sub.Events))
+}
+
+func TestBrokerFansOutLateEvents(t *testing.T) {
+	b := event.NewBroker(10)
+	sub, _ := b.Subscribe(0, 3)
+
+	b.Publish(context.Background(), newEvent(2, 1))
+	b.Publish(context.Background(), newEvent(1, 2))
+	b.Publish(context.Background(), newEvent(2, 1))
+
+	assert.Equal(t, 2, len(sub.Events), "An event arriving after one of a higher ID should not be dropped")
+	assert.Equal(t, int64(2), (<-sub.Events).ID)
+	assert.Equal(t, int64(1), (<-sub.Events).ID)
+
+	_, replay := b.Subscribe(2, 1)
+	assert.Equal(t, []int64{1}, eventIDs(replay), "The events arriving after the last one seen should be replayed")
+}
+
+func TestBrokerDropsSlowSubscribers(t *testing.T) {
+	b := event.NewBroker(10)
+	sub, _ := b.Subscribe(0, 1)
+
+	b.Publish(context.Background(), newEvent(1, 1))
+	b.Publish(context.Background(), newEvent(2, 1))
+
+	<-sub.Events
+	_, open := <-sub.Events
+	assert.False(t, open, "The channel of a subscriber falling behind should be closed")
+	b.Unsubscribe(sub)
+}
+
+func eventIDs(events []model.Event) []int64 {
+	ids := make([]int64, 0, len(events))
+	for _, e := range events {
+		ids = append(ids, e.ID)
+	}
+	return ids
+}
@@ -0,0 +1,27 @@
+package event
+
+import (
+	"context"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+)
+
+type multiPublisher struct {
+	publishers []Publisher
+}
+
+// NewMultiPublisher returns a Publisher handing every event to all the publishers.
+// The event counts as published only if all of them succeeded, the first error is returned otherwise.
+func NewMultiPublisher(publishers ...Publisher) Publisher {
+	return &multiPublisher{publishers: publishers}
+}
+
+func (p *multiPublisher) Publish(ctx context.Context, e model.Event) error {
+	var first error
+	for _, publisher := range p.publishers {
+		if err := publisher.Publish(ctx, e); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
@@ -0,0 +1,22 @@
+package event_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/event"
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMultiPublisher(t *testing.T) {
+	events := make(chan model.Event, 1)
+	failing := &failingPublisher{movieID: 1}
+	p := event.NewMultiPublisher(failing, event.NewChannelPublisher(events))
+
+	err := p.Publish(context.Background(), newEvent(1, 1))
+
+	assert.NotNil(t, err, "The event should not count as published if any publisher failed")
+	assert.Equal(t, newEvent(1, 1), <-events, "The remaining publishers should still receive the event")
+}
@@ -0,0 +1,29 @@
+package event
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+)
+
+const (
+	TypeMovieCreated = "MovieCreated"
+	TypeMovieUpdated = "MovieUpdated"
+	TypeMovieDeleted = "MovieDeleted"
+)
+
+// Types lists every event type written to the outbox
+var Types = []string{TypeMovieCreated, TypeMovieUpdated, TypeMovieDeleted}
+
+// Write stores the event in the outbox in the transaction of the change it describes,
+// from where the Relay publishes it once the transaction is committed.
+func Write(ctx context.Context, tx *sql.Tx, eventType string, movieID int, payload interface{}) error {
+	p, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	const q = "INSERT INTO outbox (event_type, movie_id, payload) VALUES ($1, $2, $3)"
+	_, err = tx.ExecContext(ctx, q, eventType, movieID, string(p))
+	return err
+}
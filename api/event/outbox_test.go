@@ -0,0 +1,52 @@
+package event_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/event"
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+const OutboxQuery = `^INSERT INTO outbox \(event_type, movie_id, payload\) VALUES \(\$1, \$2, \$3\)$`
+
+func TestWrite(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(OutboxQuery).WithArgs("MovieCreated", 1, `{"id":1,"name":"test"}`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	tx, _ := db.Begin()
+	err = event.Write(context.Background(), tx, event.TypeMovieCreated, 1, model.Movie{ID: 1, Name: "test"})
+
+	assert.Equal(t, nil, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestWriteInsertError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	insertError := errors.New("test-error-message")
+	mock.ExpectBegin()
+	mock.ExpectExec(OutboxQuery).WillReturnError(insertError)
+
+	tx, _ := db.Begin()
+	err = event.Write(context.Background(), tx, event.TypeMovieCreated, 1, model.Movie{ID: 1, Name: "test"})
+
+	assert.Equal(t, insertError, err)
+}
@@ -0,0 +1,125 @@
+package event
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+)
+
+// Publisher delivers events to the outside world. Publish returning nil means the event was delivered.
+type Publisher interface {
+	Publish(ctx context.Context, e model.Event) error
+}
+
+type logPublisher struct {
+	logger *log.Logger
+}
+
+// NewLogPublisher returns a Publisher writing the events to the logger, or to the standard logger if nil
+func NewLogPublisher(logger *log.Logger) Publisher {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &logPublisher{logger: logger}
+}
+
+func (p *logPublisher) Publish(ctx context.Context, e model.Event) error {
+	res, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	p.logger.Println("[Event] ", string(res))
+	return nil
+}
+
+type filePublisher struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFilePublisher returns a Publisher appending the events to the file as JSON lines
+func NewFilePublisher(path string) Publisher {
+	return &filePublisher{path: path}
+}
+
+func (p *filePublisher) Publish(ctx context.Context, e model.Event) error {
+	res, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	f, err := os.OpenFile(p.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(res, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+type webhookPublisher struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookPublisher returns a Publisher posting the events as JSON to the URL.
+// Any response other than 2xx counts as a failed delivery.
+func NewWebhookPublisher(url string, client *http.Client) Publisher {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &webhookPublisher{url: url, client: client}
+}
+
+func (p *webhookPublisher) Publish(ctx context.Context, e model.Event) error {
+	res, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(res))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with status [%d]", resp.StatusCode)
+	}
+	return nil
+}
+
+type channelPublisher struct {
+	events chan<- model.Event
+}
+
+// NewChannelPublisher returns a Publisher sending the events to the channel, meant for in-process consumers and tests
+func NewChannelPublisher(events chan<- model.Event) Publisher {
+	return &channelPublisher{events: events}
+}
+
+func (p *channelPublisher) Publish(ctx context.Context, e model.Event) error {
+	select {
+	case p.events <- e:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
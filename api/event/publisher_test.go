@@ -0,0 +1,103 @@
+package event_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/event"
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogPublisher(t *testing.T) {
+	var buf bytes.Buffer
+	p := event.NewLogPublisher(log.New(&buf, "", 0))
+
+	err := p.Publish(context.Background(), newEvent(1, 1))
+
+	assert.Nil(t, err)
+	assert.Contains(t, buf.String(), `"type":"MovieCreated"`)
+}
+
+func TestFilePublisher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	p := event.NewFilePublisher(path)
+
+	assert.Nil(t, p.Publish(context.Background(), newEvent(1, 1)))
+	assert.Nil(t, p.Publish(context.Background(), newEvent(2, 1)))
+
+	content, _ := os.ReadFile(path)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	assert.Equal(t, 2, len(lines), "Every event should be appended as a line")
+	assert.Equal(t, jsonString(newEvent(2, 1)), lines[1])
+}
+
+func TestWebhookPublisher(t *testing.T) {
+	var received model.Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &received)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	err := event.NewWebhookPublisher(server.URL, nil).Publish(context.Background(), newEvent(1, 1))
+
+	assert.Nil(t, err)
+	assert.Equal(t, newEvent(1, 1), received)
+}
+
+func TestWebhookPublisherStatusError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	err := event.NewWebhookPublisher(server.URL, nil).Publish(context.Background(), newEvent(1, 1))
+
+	assert.NotNil(t, err)
+}
+
+func TestChannelPublisher(t *testing.T) {
+	events := make(chan model.Event, 1)
+	p := event.NewChannelPublisher(events)
+
+	assert.Nil(t, p.Publish(context.Background(), newEvent(1, 1)))
+	assert.Equal(t, newEvent(1, 1), <-events)
+}
+
+func TestChannelPublisherCancelled(t *testing.T) {
+	p := event.NewChannelPublisher(make(chan model.Event))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := p.Publish(ctx, newEvent(1, 1))
+
+	assert.Equal(t, context.Canceled, err, "A full channel should not block a cancelled publish")
+}
+
+func newEvent(id int64, movieID int) model.Event {
+	return model.Event{
+		ID:        id,
+		Type:      event.TypeMovieCreated,
+		MovieID:   movieID,
+		Payload:   json.RawMessage(`{"id":1,"name":"test"}`),
+		CreatedAt: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func jsonString(obj interface{}) string {
+	res, _ := json.Marshal(obj)
+	return string(res)
+}
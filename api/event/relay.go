@@ -0,0 +1,153 @@
+package event
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+
+	"github.com/lib/pq"
+)
+
+// lockKey identifies the advisory lock which keeps concurrent relays, e.g. of several replicas, apart
+const lockKey = 7245002
+
+// How long the events of a movie are held back after one of them could not be published
+const defaultRetryDelay = 10 * time.Second
+
+// Relay publishes the events of the outbox in the order they were written.
+// An event is only marked as published after the Publisher accepted it, so every event
+// is delivered at least once. If an event cannot be published, the later events of the
+// same movie are held back until it is, keeping the order per movie ID, while the events
+// of the other movies go on.
+type Relay struct {
+	db         *sql.DB
+	publisher  Publisher
+	interval   time.Duration
+	batchSize  int
+	RetryDelay time.Duration
+
+	mu sync.Mutex
+	// The movies whose events are held back, until the time they are retried at
+	heldBack map[int]time.Time
+}
+
+func NewRelay(db *sql.DB, publisher Publisher, interval time.Duration, batchSize int) *Relay {
+	return &Relay{
+		db:         db,
+		publisher:  publisher,
+		interval:   interval,
+		batchSize:  batchSize,
+		RetryDelay: defaultRetryDelay,
+		heldBack:   make(map[int]time.Time),
+	}
+}
+
+// Run polls the outbox until the context is cancelled
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.ProcessBatch(ctx); err != nil {
+			log.Println("[Outbox Relay Error] ", err.Error())
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessBatch publishes the next batch of unpublished events and returns how many were published.
+// Only one relay processes a batch at a time, so concurrent relays cannot reorder the events, while no rows
+// stay locked during the publishing. Every event is marked as published on its own once it was.
+func (r *Relay) ProcessBatch(ctx context.Context) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", lockKey).Scan(&locked); err != nil {
+		return 0, err
+	}
+	if !locked {
+		// Another relay is processing the outbox
+		return 0, nil
+	}
+	defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", lockKey)
+
+	events, err := pendingEvents(ctx, conn, r.heldBackMovies(time.Now()), r.batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	published := 0
+	blocked := make(map[int]bool)
+	for _, e := range events {
+		if blocked[e.MovieID] {
+			continue
+		}
+		if err := r.publisher.Publish(ctx, e); err != nil {
+			log.Printf("[Outbox Relay Error] publishing event [%d]: %s", e.ID, err.Error())
+			blocked[e.MovieID] = true
+			r.heldBack[e.MovieID] = time.Now().Add(r.RetryDelay)
+			continue
+		}
+
+		const q = "UPDATE outbox SET published_at = now() WHERE id = $1"
+		if _, err := conn.ExecContext(ctx, q, e.ID); err != nil {
+			return published, err
+		}
+		published++
+	}
+
+	return published, nil
+}
+
+// heldBackMovies returns the IDs of the movies whose events are still held back at the time, forgetting the others
+func (r *Relay) heldBackMovies(now time.Time) []int64 {
+	ids := make([]int64, 0, len(r.heldBack))
+	for id, until := range r.heldBack {
+		if now.Before(until) {
+			ids = append(ids, int64(id))
+		} else {
+			delete(r.heldBack, id)
+		}
+	}
+	return ids
+}
+
+// pendingEvents returns the oldest unpublished events, leaving out the ones of the held back movies
+func pendingEvents(ctx context.Context, conn *sql.Conn, heldBack []int64, limit int) ([]model.Event, error) {
+	const q = "SELECT id, event_type, movie_id, payload, created_at FROM outbox " +
+		"WHERE published_at IS NULL AND movie_id <> ALL($1) ORDER BY id LIMIT $2"
+	qr, err := conn.QueryContext(ctx, q, pq.Array(heldBack), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer qr.Close()
+
+	result := make([]model.Event, 0)
+	for qr.Next() {
+		e := model.Event{}
+		var payload []byte
+		if err := qr.Scan(&e.ID, &e.Type, &e.MovieID, &payload, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		e.Payload = payload
+		result = append(result, e)
+	}
+
+	return result, qr.Err()
+}
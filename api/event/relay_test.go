@@ -0,0 +1,171 @@
+package event_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/event"
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	LockQuery      = `^SELECT pg_try_advisory_lock\(\$1\)$`
+	UnlockQuery    = `^SELECT pg_advisory_unlock\(\$1\)$`
+	PendingQuery   = `^SELECT .+ FROM outbox WHERE published_at IS NULL AND movie_id <> ALL\(\$1\) ORDER BY id LIMIT \$2$`
+	PublishedQuery = `^UPDATE outbox SET published_at = now\(\) WHERE id = \$1$`
+)
+
+// failingPublisher rejects the events of one movie and forwards the rest
+type failingPublisher struct {
+	movieID   int
+	published []int64
+}
+
+func (p *failingPublisher) Publish(ctx context.Context, e model.Event) error {
+	if e.MovieID == p.movieID {
+		return errors.New("test-error-message")
+	}
+	p.published = append(p.published, e.ID)
+	return nil
+}
+
+func TestRelayProcessBatch(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	events := make(chan model.Event, 2)
+	relay := event.NewRelay(db, event.NewChannelPublisher(events), 0, 10)
+	expectLock(mock, true)
+	mock.ExpectQuery(PendingQuery).WithArgs("{}", 10).WillReturnRows(newEventRows(newEvent(1, 1), newEvent(2, 2)))
+	mock.ExpectExec(PublishedQuery).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(PublishedQuery).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+	expectUnlock(mock)
+
+	published, err := relay.ProcessBatch(context.Background())
+
+	assert.Nil(t, err)
+	assert.Equal(t, 2, published)
+	assert.Equal(t, int64(1), (<-events).ID, "The events should be published in order")
+	assert.Equal(t, int64(2), (<-events).ID)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestRelayProcessBatchKeepsOrderPerMovie(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	publisher := &failingPublisher{movieID: 1}
+	relay := event.NewRelay(db, publisher, 0, 10)
+	expectLock(mock, true)
+	mock.ExpectQuery(PendingQuery).WithArgs("{}", 10).WillReturnRows(newEventRows(newEvent(1, 1), newEvent(2, 2), newEvent(3, 1)))
+	mock.ExpectExec(PublishedQuery).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+	expectUnlock(mock)
+	// The events of the failing movie are left out of the next batch, so they cannot fill it
+	expectLock(mock, true)
+	mock.ExpectQuery(PendingQuery).WithArgs("{1}", 10).WillReturnRows(newEventRows(newEvent(4, 2)))
+	mock.ExpectExec(PublishedQuery).WithArgs(4).WillReturnResult(sqlmock.NewResult(0, 1))
+	expectUnlock(mock)
+
+	published, err := relay.ProcessBatch(context.Background())
+
+	assert.Nil(t, err)
+	assert.Equal(t, 1, published)
+	assert.Equal(t, []int64{2}, publisher.published, "Later events of a failing movie should be held back")
+
+	published, err = relay.ProcessBatch(context.Background())
+
+	assert.Nil(t, err)
+	assert.Equal(t, 1, published)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestRelayProcessBatchRetriesHeldBackMovies(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	relay := event.NewRelay(db, &failingPublisher{movieID: 1}, 0, 10)
+	relay.RetryDelay = 0
+	expectLock(mock, true)
+	mock.ExpectQuery(PendingQuery).WithArgs("{}", 10).WillReturnRows(newEventRows(newEvent(1, 1)))
+	expectUnlock(mock)
+	expectLock(mock, true)
+	mock.ExpectQuery(PendingQuery).WithArgs("{}", 10).WillReturnRows(newEventRows(newEvent(1, 1)))
+	expectUnlock(mock)
+
+	relay.ProcessBatch(context.Background())
+	relay.ProcessBatch(context.Background())
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("the held back movie should be retried after the delay: %s", err)
+	}
+}
+
+func TestRelayProcessBatchLockedByOtherRelay(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	relay := event.NewRelay(db, event.NewLogPublisher(nil), 0, 10)
+	expectLock(mock, false)
+
+	published, err := relay.ProcessBatch(context.Background())
+
+	assert.Nil(t, err)
+	assert.Equal(t, 0, published, "Only one relay should process the outbox at a time")
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestRelayProcessBatchQueryError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	queryError := errors.New("test-error-message")
+	relay := event.NewRelay(db, event.NewLogPublisher(nil), 0, 10)
+	expectLock(mock, true)
+	mock.ExpectQuery(PendingQuery).WillReturnError(queryError)
+	expectUnlock(mock)
+
+	_, err = relay.ProcessBatch(context.Background())
+
+	assert.Equal(t, queryError, err)
+}
+
+func expectLock(mock sqlmock.Sqlmock, locked bool) {
+	mock.ExpectQuery(LockQuery).WillReturnRows(sqlmock.NewRows([]string{"pg_try_advisory_lock"}).AddRow(locked))
+}
+
+func expectUnlock(mock sqlmock.Sqlmock) {
+	mock.ExpectExec(UnlockQuery).WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+func newEventRows(events ...model.Event) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"id", "event_type", "movie_id", "payload", "created_at"})
+	for _, e := range events {
+		rows.AddRow(e.ID, e.Type, e.MovieID, []byte(e.Payload), e.CreatedAt)
+	}
+	return rows
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/duplicate"
+	"github.com/Hunterlemming/golang-microservice-example/api/event"
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/poster"
+	"github.com/Hunterlemming/golang-microservice-example/api/similar"
+	"github.com/Hunterlemming/golang-microservice-example/api/webhook"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	relayInterval  = time.Second
+	relayBatchSize = 100
+	// How many of the latest events SSE clients can resume from
+	replayBufferSize = 1000
+	// How long the similar movies index waits for a burst of changes to settle, and how often it catches up anyway
+	similarRebuildDelay    = 10 * time.Second
+	similarRebuildInterval = 15 * time.Minute
+	// How often the duplicates of the catalog are detected
+	duplicateDetectInterval = time.Hour
+	// How often the images no poster refers to anymore are deleted
+	posterSweepInterval = time.Hour
+)
+
+// getEventPublisher builds the Publisher selected by APP_EVENT_PUBLISHER, logging the events by default
+func getEventPublisher() event.Publisher {
+	switch viper.GetString("APP_EVENT_PUBLISHER") {
+	case "file":
+		return event.NewFilePublisher(viper.GetString("APP_EVENT_FILE"))
+	case "webhook":
+		client := &http.Client{Timeout: 10 * time.Second}
+		return event.NewWebhookPublisher(viper.GetString("APP_EVENT_WEBHOOK_URL"), client)
+	}
+	return event.NewLogPublisher(nil)
+}
+
+// startOutboxRelay publishes the events to the configured publisher, the webhook subscriptions and the SSE broker,
+// until the context is done. The webhook deliveries queued by the relay are attempted by the dispatcher on its own.
+func startOutboxRelay(ctx context.Context, api *model.Api, broker *event.Broker) {
+	dispatcher := webhook.NewDispatcher(webhook.NewWebhookService(api.DB), nil)
+	publisher := event.NewMultiPublisher(getEventPublisher(), dispatcher, broker)
+	relay := event.NewRelay(api.DB, publisher, relayInterval, relayBatchSize)
+	go relay.Run(ctx)
+	go dispatcher.Run(ctx)
+}
+
+// startSimilarRebuilder builds the similar movies index and rebuilds it on the changes of the catalog,
+// until the context is done
+func startSimilarRebuilder(ctx context.Context, s similar.SimilarService, broker *event.Broker) {
+	rebuilder := similar.NewRebuilder(s, broker, similarRebuildDelay, similarRebuildInterval)
+	go rebuilder.Run(ctx)
+}
+
+// startDuplicateJob detects the duplicates of the catalog every interval, until the context is done
+func startDuplicateJob(ctx context.Context, s duplicate.DuplicateService) {
+	job := duplicate.NewJob(s, duplicateDetectInterval)
+	go job.Run(ctx)
+}
+
+// startPosterSweeper deletes the images no poster refers to anymore every interval, until the context is done
+func startPosterSweeper(ctx context.Context, s poster.PosterService) {
+	sweeper := poster.NewSweeper(s, posterSweepInterval)
+	go sweeper.Run(ctx)
+}
@@ -0,0 +1,274 @@
+package genre
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+
+	"github.com/gorilla/mux"
+)
+
+type controller struct {
+	service GenreService
+}
+
+type GenreController interface {
+	GetGenres(w http.ResponseWriter, r *http.Request)
+	GetGenre(w http.ResponseWriter, r *http.Request)
+	CreateGenre(w http.ResponseWriter, r *http.Request)
+	UpdateGenre(w http.ResponseWriter, r *http.Request)
+	DeleteGenre(w http.ResponseWriter, r *http.Request)
+	GetGenreCounts(w http.ResponseWriter, r *http.Request)
+	GetMovieGenres(w http.ResponseWriter, r *http.Request)
+	AddMovieGenre(w http.ResponseWriter, r *http.Request)
+	RemoveMovieGenre(w http.ResponseWriter, r *http.Request)
+}
+
+func NewGenreController(s GenreService) GenreController {
+	return &controller{
+		service: s,
+	}
+}
+
+func (c *controller) GetGenres(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to GetGenres", r.Method))
+		return
+	}
+
+	enc, err := util.NewResponseEncoder(r, true)
+	if err != nil {
+		util.HandleNotAcceptable(w, err)
+		return
+	}
+
+	genres, err := c.service.GetGenres()
+	if err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	util.WriteResponse(w, enc, http.StatusOK, genres)
+}
+
+func (c *controller) GetGenre(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to GetGenre", r.Method))
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 0)
+	if err != nil {
+		util.HandleBadRequest(w, "Invalid ID", err.Error())
+		return
+	}
+
+	enc, err := util.NewResponseEncoder(r, false)
+	if err != nil {
+		util.HandleNotAcceptable(w, err)
+		return
+	}
+
+	genre, err := c.service.GetGenre(int(id))
+	if err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	util.WriteResponse(w, enc, http.StatusOK, genre)
+}
+
+func (c *controller) CreateGenre(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to CreateGenre", r.Method))
+		return
+	}
+
+	// Extracting Genre object from request-body
+	g, err := parseValidGenre(r)
+	if err != nil {
+		util.HandleInvalidBody(w, err)
+		return
+	}
+
+	enc, err := util.NewResponseEncoder(r, false)
+	if err != nil {
+		util.HandleNotAcceptable(w, err)
+		return
+	}
+
+	if err := c.service.CreateGenre(r.Context(), g); err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	util.WriteResponse(w, enc, http.StatusCreated, g)
+}
+
+func (c *controller) UpdateGenre(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to UpdateGenre", r.Method))
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 0)
+	if err != nil {
+		util.HandleBadRequest(w, "Invalid ID", err.Error())
+		return
+	}
+
+	// Extracting Genre object from request-body
+	g, err := parseValidGenre(r)
+	if err != nil {
+		util.HandleInvalidBody(w, err)
+		return
+	}
+
+	if err := c.service.UpdateGenre(r.Context(), int(id), g); err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	fmt.Fprintln(w, "success")
+}
+
+func (c *controller) DeleteGenre(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to DeleteGenre", r.Method))
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 0)
+	if err != nil {
+		util.HandleBadRequest(w, "Invalid ID", err.Error())
+		return
+	}
+
+	if err := c.service.DeleteGenre(r.Context(), int(id)); err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *controller) GetGenreCounts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to GetGenreCounts", r.Method))
+		return
+	}
+
+	enc, err := util.NewResponseEncoder(r, true)
+	if err != nil {
+		util.HandleNotAcceptable(w, err)
+		return
+	}
+
+	counts, err := c.service.GetGenreCounts()
+	if err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	util.WriteResponse(w, enc, http.StatusOK, counts)
+}
+
+func (c *controller) GetMovieGenres(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to GetMovieGenres", r.Method))
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 0)
+	if err != nil {
+		util.HandleBadRequest(w, "Invalid ID", err.Error())
+		return
+	}
+
+	enc, err := util.NewResponseEncoder(r, true)
+	if err != nil {
+		util.HandleNotAcceptable(w, err)
+		return
+	}
+
+	genres, err := c.service.GetMovieGenres(int(id))
+	if err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	util.WriteResponse(w, enc, http.StatusOK, genres)
+}
+
+// AddMovieGenre links the genre to the movie, linking it again succeeds as well
+func (c *controller) AddMovieGenre(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to AddMovieGenre", r.Method))
+		return
+	}
+
+	id, genreID, err := parseLink(r)
+	if err != nil {
+		util.HandleBadRequest(w, "Invalid ID", err.Error())
+		return
+	}
+
+	if err := c.service.AddMovieGenre(r.Context(), id, genreID); err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *controller) RemoveMovieGenre(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to RemoveMovieGenre", r.Method))
+		return
+	}
+
+	id, genreID, err := parseLink(r)
+	if err != nil {
+		util.HandleBadRequest(w, "Invalid ID", err.Error())
+		return
+	}
+
+	if err := c.service.RemoveMovieGenre(r.Context(), id, genreID); err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// parseLink returns the IDs of the movie and the genre in the path
+func parseLink(r *http.Request) (int, int, error) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 0)
+	if err != nil {
+		return 0, 0, err
+	}
+	genreID, err := strconv.ParseInt(mux.Vars(r)["genreId"], 10, 0)
+	if err != nil {
+		return 0, 0, err
+	}
+	return int(id), int(genreID), nil
+}
+
+func parseValidGenre(r *http.Request) (*model.Genre, error) {
+	var g model.Genre
+
+	// Return if the request-body cannot be decoded into a Genre object
+	if err := util.DecodeRequest(r, &g); err != nil {
+		return nil, err
+	}
+
+	// Return if the requested Genre object is invalid
+	if err := g.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid genre-object: %w", err)
+	}
+
+	return &g, nil
+}
@@ -0,0 +1,224 @@
+package genre_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/genre"
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Defining the mock GenreService
+type mockServiceStruct struct {
+	mock.Mock
+}
+
+func (s *mockServiceStruct) GetGenres() ([]model.Genre, error) {
+	args := s.Called()
+	return args.Get(0).([]model.Genre), args.Error(1)
+}
+
+func (s *mockServiceStruct) GetGenre(id int) (model.Genre, error) {
+	args := s.Called(id)
+	return args.Get(0).(model.Genre), args.Error(1)
+}
+
+func (s *mockServiceStruct) CreateGenre(ctx context.Context, g *model.Genre) error {
+	args := s.Called(g)
+	return args.Error(0)
+}
+
+func (s *mockServiceStruct) UpdateGenre(ctx context.Context, id int, g *model.Genre) error {
+	args := s.Called(id, g)
+	return args.Error(0)
+}
+
+func (s *mockServiceStruct) DeleteGenre(ctx context.Context, id int) error {
+	args := s.Called(id)
+	return args.Error(0)
+}
+
+func (s *mockServiceStruct) GetGenreCounts() ([]model.GenreCount, error) {
+	args := s.Called()
+	return args.Get(0).([]model.GenreCount), args.Error(1)
+}
+
+func (s *mockServiceStruct) GetMovieGenres(movieID int) ([]model.Genre, error) {
+	args := s.Called(movieID)
+	return args.Get(0).([]model.Genre), args.Error(1)
+}
+
+func (s *mockServiceStruct) AddMovieGenre(ctx context.Context, movieID, genreID int) error {
+	args := s.Called(movieID, genreID)
+	return args.Error(0)
+}
+
+func (s *mockServiceStruct) RemoveMovieGenre(ctx context.Context, movieID, genreID int) error {
+	args := s.Called(movieID, genreID)
+	return args.Error(0)
+}
+
+// Setting up mockService and controller
+var mockService = new(mockServiceStruct)
+var controller = genre.NewGenreController(mockService)
+
+func TestControllerGetGenres(t *testing.T) {
+	genres := []model.Genre{{ID: 1, Slug: "drama", Name: "Drama"}}
+	mockService.On("GetGenres").Return(genres, nil).Once()
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	rr := execute("/", []string{"GET"}, req, controller.GetGenres)
+
+	status := http.StatusOK
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+	assert.Equal(t, jsonString(genres), rr.Body.String())
+}
+
+func TestControllerGetGenreNotFoundError(t *testing.T) {
+	mockService.On("GetGenre", 2).Return(model.Genre{}, &util.NotExistingRecordError{Identification: "ID: 2"}).Once()
+
+	req, _ := http.NewRequest("GET", "/2", nil)
+	rr := execute("/{id}", []string{"GET"}, req, controller.GetGenre)
+
+	status := http.StatusNotFound
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerCreateGenre(t *testing.T) {
+	g := model.Genre{Slug: "science-fiction", Name: "Science fiction"}
+	gBytes, _ := json.Marshal(g)
+	mockService.On("CreateGenre", &g).Return(nil).Run(func(args mock.Arguments) {
+		args.Get(0).(*model.Genre).ID = 2
+	}).Once()
+
+	req, _ := http.NewRequest("POST", "/", bytes.NewBuffer(gBytes))
+	rr := execute("/", []string{"POST"}, req, controller.CreateGenre)
+
+	status := http.StatusCreated
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+	assert.Equal(t, jsonString(model.Genre{ID: 2, Slug: "science-fiction", Name: "Science fiction"}), rr.Body.String())
+}
+
+func TestControllerCreateGenreBodyParsingError(t *testing.T) {
+	for _, body := range []string{
+		`{"slug": "drama"}`,
+		`{"slug": "Science Fiction", "name": "Science fiction"}`,
+		`{"slug": "-drama", "name": "Drama"}`,
+	} {
+		req, _ := http.NewRequest("POST", "/", bytes.NewBufferString(body))
+		rr := execute("/", []string{"POST"}, req, controller.CreateGenre)
+
+		status := http.StatusBadRequest
+		assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d] for %s", status, body))
+	}
+}
+
+func TestControllerCreateGenreExistingError(t *testing.T) {
+	g := model.Genre{Slug: "drama", Name: "Drama"}
+	gBytes, _ := json.Marshal(g)
+	mockService.On("CreateGenre", &g).Return(&util.ExistingRecordError{Identification: "slug: drama"}).Once()
+
+	req, _ := http.NewRequest("POST", "/", bytes.NewBuffer(gBytes))
+	rr := execute("/", []string{"POST"}, req, controller.CreateGenre)
+
+	status := http.StatusConflict
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerUpdateGenre(t *testing.T) {
+	g := model.Genre{Slug: "drama", Name: "Drama"}
+	gBytes, _ := json.Marshal(g)
+	mockService.On("UpdateGenre", 2, &g).Return(nil).Once()
+
+	req, _ := http.NewRequest("PUT", "/2", bytes.NewBuffer(gBytes))
+	rr := execute("/{id}", []string{"PUT"}, req, controller.UpdateGenre)
+
+	status := http.StatusOK
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerDeleteGenre(t *testing.T) {
+	mockService.On("DeleteGenre", 2).Return(nil).Once()
+
+	req, _ := http.NewRequest("DELETE", "/2", nil)
+	rr := execute("/{id}", []string{"DELETE"}, req, controller.DeleteGenre)
+
+	status := http.StatusNoContent
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerGetGenreCounts(t *testing.T) {
+	counts := []model.GenreCount{{ID: 1, Slug: "drama", Name: "Drama", Movies: 3}, {ID: 2, Slug: "western", Name: "Western"}}
+	mockService.On("GetGenreCounts").Return(counts, nil).Once()
+
+	req, _ := http.NewRequest("GET", "/counts", nil)
+	rr := execute("/counts", []string{"GET"}, req, controller.GetGenreCounts)
+
+	status := http.StatusOK
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+	assert.Equal(t, jsonString(counts), rr.Body.String())
+}
+
+func TestControllerGetMovieGenresNotFoundError(t *testing.T) {
+	mockService.On("GetMovieGenres", 7).Return([]model.Genre{}, &util.NotExistingRecordError{Identification: "ID: 7"}).Once()
+
+	req, _ := http.NewRequest("GET", "/movies/7/genres", nil)
+	rr := execute("/movies/{id}/genres", []string{"GET"}, req, controller.GetMovieGenres)
+
+	status := http.StatusNotFound
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerAddMovieGenre(t *testing.T) {
+	mockService.On("AddMovieGenre", 7, 2).Return(nil).Once()
+
+	req, _ := http.NewRequest("PUT", "/movies/7/genres/2", nil)
+	rr := execute("/movies/{id}/genres/{genreId}", []string{"PUT"}, req, controller.AddMovieGenre)
+
+	if !mockService.AssertCalled(t, "AddMovieGenre", 7, 2) {
+		t.Error("The service should be called with the IDs of the path")
+	}
+	status := http.StatusNoContent
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerAddMovieGenreInvalidIDError(t *testing.T) {
+	req, _ := http.NewRequest("PUT", "/movies/7/genres/drama", nil)
+	rr := execute("/movies/{id}/genres/{genreId}", []string{"PUT"}, req, controller.AddMovieGenre)
+
+	status := http.StatusBadRequest
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerRemoveMovieGenreNotFoundError(t *testing.T) {
+	mockService.On("RemoveMovieGenre", 7, 2).Return(&util.NotExistingRecordError{Identification: "movie ID: 7, genre ID: 2"}).Once()
+
+	req, _ := http.NewRequest("DELETE", "/movies/7/genres/2", nil)
+	rr := execute("/movies/{id}/genres/{genreId}", []string{"DELETE"}, req, controller.RemoveMovieGenre)
+
+	status := http.StatusNotFound
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func execute(route string, methods []string, req *http.Request, handler func(w http.ResponseWriter, r *http.Request)) *httptest.ResponseRecorder {
+	r := mux.NewRouter()
+	r.HandleFunc(route, handler).Methods(methods...)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	return rr
+}
+
+func jsonString(obj interface{}) string {
+	res, _ := json.Marshal(obj)
+	return string(res)
+}
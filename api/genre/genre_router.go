@@ -0,0 +1,48 @@
+package genre
+
+import (
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+
+	"github.com/gorilla/mux"
+)
+
+func InitializeGenresPipeline(api *model.Api) {
+	s := NewGenreService(api.DB)
+	c := NewGenreController(s)
+	setRouting(api.Router, c)
+}
+
+func setRouting(main *mux.Router, c GenreController) {
+	sr := main.PathPrefix("/genres").Subrouter()
+
+	sr.HandleFunc("", c.GetGenres).
+		Methods("GET")
+
+	// Registered before the genres by ID, which would match it as well
+	sr.HandleFunc("/counts", c.GetGenreCounts).
+		Methods("GET")
+
+	sr.HandleFunc("/{id}", c.GetGenre).
+		Methods("GET")
+
+	sr.HandleFunc("", c.CreateGenre).
+		Methods("POST")
+
+	sr.HandleFunc("/{id}", c.UpdateGenre).
+		Methods("PUT")
+
+	sr.HandleFunc("/{id}", c.DeleteGenre).
+		Methods("DELETE")
+
+	// The genres of a movie are listed and linked under the movie
+	mr := main.PathPrefix("/movies/{id}/genres").Subrouter()
+
+	mr.HandleFunc("", c.GetMovieGenres).
+		Methods("GET")
+
+	mr.HandleFunc("/{genreId}", c.AddMovieGenre).
+		Methods("PUT")
+
+	mr.HandleFunc("/{genreId}", c.RemoveMovieGenre).
+		Methods("DELETE")
+}
@@ -0,0 +1,61 @@
+package genre_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/genre"
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/movie"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInitializeGenresPipeline(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	// The links share their prefix with the movies, which are routed first
+	api := model.Api{Router: mux.NewRouter(), DB: db}
+	movie.InitializeMoviesPipeline(&api)
+	genre.InitializeGenresPipeline(&api)
+
+	testIntegrationGetGenreCounts(t, mock, api.Router)
+	testIntegrationGetMovieGenres(t, mock, api.Router)
+}
+
+func testIntegrationGetGenreCounts(t *testing.T, mock sqlmock.Sqlmock, r *mux.Router) {
+	mock.ExpectQuery(GenreCountsQuery).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "slug", "name", "count"}).AddRow(1, "drama", "Drama", 3))
+
+	req, _ := http.NewRequest("GET", "/genres/counts", nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "The counts should not be taken for a genre")
+	assert.Equal(t, jsonString([]model.GenreCount{{ID: 1, Slug: "drama", Name: "Drama", Movies: 3}}), rr.Body.String())
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func testIntegrationGetMovieGenres(t *testing.T, mock sqlmock.Sqlmock, r *mux.Router) {
+	genres := []model.Genre{{ID: 1, Slug: "drama", Name: "Drama"}}
+	mock.ExpectQuery(CheckMovieQuery).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectQuery(MovieGenresQuery).WithArgs(7).WillReturnRows(newRows(&genres))
+
+	req, _ := http.NewRequest("GET", "/movies/7/genres", nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, jsonString(genres), rr.Body.String())
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
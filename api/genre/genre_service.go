@@ -0,0 +1,218 @@
+package genre
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/audit"
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/movie"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+)
+
+type service struct {
+	db *sql.DB
+}
+
+type GenreService interface {
+	GetGenres() ([]model.Genre, error)
+	GetGenre(id int) (model.Genre, error)
+	CreateGenre(ctx context.Context, g *model.Genre) error
+	UpdateGenre(ctx context.Context, id int, g *model.Genre) error
+	DeleteGenre(ctx context.Context, id int) error
+	GetGenreCounts() ([]model.GenreCount, error)
+	GetMovieGenres(movieID int) ([]model.Genre, error)
+	AddMovieGenre(ctx context.Context, movieID, genreID int) error
+	RemoveMovieGenre(ctx context.Context, movieID, genreID int) error
+}
+
+func NewGenreService(db *sql.DB) GenreService {
+	return &service{
+		db: db,
+	}
+}
+
+func (s *service) GetGenres() ([]model.Genre, error) {
+	const q = "SELECT id, slug, name FROM genres ORDER BY id"
+	qr, err := s.db.Query(q)
+	if err != nil {
+		return []model.Genre{}, err
+	}
+	defer qr.Close()
+
+	result := make([]model.Genre, 0)
+	for qr.Next() {
+		g := model.Genre{}
+		err = qr.Scan(&g.ID, &g.Slug, &g.Name)
+		if err != nil {
+			return []model.Genre{}, err
+		}
+		result = append(result, g)
+	}
+
+	return result, nil
+}
+
+func (s *service) GetGenre(id int) (model.Genre, error) {
+	return getGenre(s.db, id)
+}
+
+// CreateGenre inserts the genre and sets its generated ID, slugs are unique
+func (s *service) CreateGenre(ctx context.Context, g *model.Genre) error {
+	return util.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		const q = "INSERT INTO genres (slug, name) VALUES ($1, $2) RETURNING id"
+		err := tx.QueryRowContext(ctx, q, g.Slug, g.Name).Scan(&g.ID)
+		if util.IsViolation(err, util.UniqueViolation) {
+			return &util.ExistingRecordError{Identification: fmt.Sprintf("slug: %s", g.Slug)}
+		}
+		if err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx, audit.EntityGenre, g.ID, audit.ActionCreate, nil, g)
+	})
+}
+
+func (s *service) UpdateGenre(ctx context.Context, id int, g *model.Genre) error {
+	before, err := s.GetGenre(id)
+	if err != nil {
+		return err
+	}
+
+	return util.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		const q = "UPDATE genres SET slug = $1, name = $2 WHERE id = $3"
+		res, err := tx.ExecContext(ctx, q, g.Slug, g.Name, id)
+		if util.IsViolation(err, util.UniqueViolation) {
+			return &util.ExistingRecordError{Identification: fmt.Sprintf("slug: %s", g.Slug)}
+		}
+		if err != nil {
+			return err
+		}
+		if affected, err := res.RowsAffected(); err != nil {
+			return err
+		} else if affected == 0 {
+			return &util.NotExistingRecordError{Identification: fmt.Sprintf("ID: %v", id)}
+		}
+
+		after := model.Genre{ID: id, Slug: g.Slug, Name: g.Name}
+		return audit.Record(ctx, tx, audit.EntityGenre, id, audit.ActionUpdate, before, after)
+	})
+}
+
+// DeleteGenre removes the genre along with its links to the movies
+func (s *service) DeleteGenre(ctx context.Context, id int) error {
+	return util.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		const q = "DELETE FROM genres WHERE id = $1 RETURNING id, slug, name"
+		before := model.Genre{}
+		err := tx.QueryRowContext(ctx, q, id).Scan(&before.ID, &before.Slug, &before.Name)
+		if err == sql.ErrNoRows {
+			return &util.NotExistingRecordError{Identification: fmt.Sprintf("ID: %v", id)}
+		}
+		if err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx, audit.EntityGenre, id, audit.ActionDelete, before, nil)
+	})
+}
+
+// GetGenreCounts returns every genre with the number of its movies, leaving out the movies in the trash
+func (s *service) GetGenreCounts() ([]model.GenreCount, error) {
+	const q = `SELECT g.id, g.slug, g.name, COUNT(m.id) FROM genres g ` +
+		`LEFT JOIN movie_genres mg ON mg.genre_id = g.id ` +
+		`LEFT JOIN movies m ON m.id = mg.movie_id AND m.deleted_at IS NULL ` +
+		`GROUP BY g.id ORDER BY g.id`
+	qr, err := s.db.Query(q)
+	if err != nil {
+		return []model.GenreCount{}, err
+	}
+	defer qr.Close()
+
+	result := make([]model.GenreCount, 0)
+	for qr.Next() {
+		c := model.GenreCount{}
+		err = qr.Scan(&c.ID, &c.Slug, &c.Name, &c.Movies)
+		if err != nil {
+			return []model.GenreCount{}, err
+		}
+		result = append(result, c)
+	}
+
+	return result, qr.Err()
+}
+
+func (s *service) GetMovieGenres(movieID int) ([]model.Genre, error) {
+	if err := movie.Exists(s.db, movieID); err != nil {
+		return []model.Genre{}, err
+	}
+
+	const q = `SELECT g.id, g.slug, g.name FROM movie_genres mg JOIN genres g ON g.id = mg.genre_id ` +
+		`WHERE mg.movie_id = $1 ORDER BY g.id`
+	qr, err := s.db.Query(q, movieID)
+	if err != nil {
+		return []model.Genre{}, err
+	}
+	defer qr.Close()
+
+	result := make([]model.Genre, 0)
+	for qr.Next() {
+		g := model.Genre{}
+		err = qr.Scan(&g.ID, &g.Slug, &g.Name)
+		if err != nil {
+			return []model.Genre{}, err
+		}
+		result = append(result, g)
+	}
+
+	return result, qr.Err()
+}
+
+// AddMovieGenre links the genre to the movie, linking it again changes nothing.
+// Both have to exist, movies in the trash cannot be linked.
+func (s *service) AddMovieGenre(ctx context.Context, movieID, genreID int) error {
+	return util.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := movie.Exists(tx, movieID); err != nil {
+			return err
+		}
+		g, err := getGenre(tx, genreID)
+		if err != nil {
+			return err
+		}
+
+		const q = "INSERT INTO movie_genres (movie_id, genre_id) VALUES ($1, $2) ON CONFLICT DO NOTHING"
+		res, err := tx.ExecContext(ctx, q, movieID, genreID)
+		if err != nil {
+			return err
+		}
+		// Linking again leaves nothing to record
+		if affected, err := res.RowsAffected(); err != nil {
+			return err
+		} else if affected == 0 {
+			return nil
+		}
+		return audit.Record(ctx, tx, audit.EntityMovieGenre, movieID, audit.ActionCreate, nil, g)
+	})
+}
+
+func (s *service) RemoveMovieGenre(ctx context.Context, movieID, genreID int) error {
+	return util.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		const q = `DELETE FROM movie_genres mg USING genres g ` +
+			`WHERE g.id = mg.genre_id AND mg.movie_id = $1 AND mg.genre_id = $2 RETURNING g.id, g.slug, g.name`
+		before := model.Genre{}
+		err := tx.QueryRowContext(ctx, q, movieID, genreID).Scan(&before.ID, &before.Slug, &before.Name)
+		if err == sql.ErrNoRows {
+			return &util.NotExistingRecordError{Identification: fmt.Sprintf("movie ID: %v, genre ID: %v", movieID, genreID)}
+		}
+		if err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx, audit.EntityMovieGenre, movieID, audit.ActionDelete, before, nil)
+	})
+}
+
+// getGenre reads the genre from the database or from a transaction
+func getGenre(db util.QueryRower, id int) (model.Genre, error) {
+	const q = "SELECT id, slug, name FROM genres WHERE id = $1"
+	result := model.Genre{}
+	err := util.GetRecord(db, q, id, &result.ID, &result.Slug, &result.Name)
+	return result, err
+}
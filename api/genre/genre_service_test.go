@@ -0,0 +1,275 @@
+package genre_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/genre"
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	GetGenresQuery        = `^SELECT id, slug, name FROM genres ORDER BY id$`
+	GetGenreQuery         = `^SELECT id, slug, name FROM genres WHERE id = \$1$`
+	CreateGenreQuery      = `^INSERT INTO genres \(slug, name\) VALUES \(\$1, \$2\) RETURNING id$`
+	UpdateGenreQuery      = `^UPDATE genres SET slug = \$1, name = \$2 WHERE id = \$3$`
+	DeleteGenreQuery      = `^DELETE FROM genres WHERE id = \$1 RETURNING id, slug, name$`
+	GenreCountsQuery      = `^SELECT g.id, g.slug, g.name, COUNT\(m.id\) FROM genres g LEFT JOIN .+ AND m.deleted_at IS NULL GROUP BY g.id ORDER BY g.id$`
+	CheckMovieQuery       = `^SELECT id FROM movies WHERE id = \$1 AND deleted_at IS NULL$`
+	MovieGenresQuery      = `^SELECT g.id, g.slug, g.name FROM movie_genres mg JOIN genres g .+ WHERE mg.movie_id = \$1 ORDER BY g.id$`
+	AddMovieGenreQuery    = `^INSERT INTO movie_genres \(movie_id, genre_id\) VALUES \(\$1, \$2\) ON CONFLICT DO NOTHING$`
+	RemoveMovieGenreQuery = `^DELETE FROM movie_genres mg USING genres g WHERE .+ AND mg.movie_id = \$1 AND mg.genre_id = \$2 RETURNING .+$`
+	AuditQuery            = `^INSERT INTO audit_log \(.+\) VALUES \(.+\)$`
+	uniqueViolation       = "23505"
+)
+
+func TestServiceGetGenres(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	genres := []model.Genre{{ID: 1, Slug: "drama", Name: "Drama"}, {ID: 2, Slug: "western", Name: "Western"}}
+	mock.ExpectQuery(GetGenresQuery).WillReturnRows(newRows(&genres))
+
+	res, err := service.GetGenres()
+
+	assert.Equal(t, genres, res)
+	assert.Equal(t, nil, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceGetGenreNotExistingError(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectQuery(GetGenreQuery).WithArgs(3).WillReturnRows(newRows(&[]model.Genre{}))
+
+	_, err := service.GetGenre(3)
+
+	assert.IsType(t, &util.NotExistingRecordError{}, err)
+}
+
+func TestServiceCreateGenre(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(CreateGenreQuery).WithArgs("drama", "Drama").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	mock.ExpectExec(AuditQuery).
+		WithArgs("genre", 2, "create", "tester", "test-request", nil, `{"id":2,"slug":"drama","name":"Drama"}`, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	g := &model.Genre{Slug: "drama", Name: "Drama"}
+	err := service.CreateGenre(auditContext(), g)
+
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, g.ID, "The generated ID should be set")
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceCreateGenreExistingError(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(CreateGenreQuery).WillReturnError(&pq.Error{Code: uniqueViolation})
+	mock.ExpectRollback()
+
+	err := service.CreateGenre(auditContext(), &model.Genre{Slug: "drama", Name: "Drama"})
+
+	assert.IsType(t, &util.ExistingRecordError{}, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceUpdateGenre(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectQuery(GetGenreQuery).WithArgs(2).WillReturnRows(newRows(&[]model.Genre{{ID: 2, Slug: "scifi", Name: "Sci-fi"}}))
+	mock.ExpectBegin()
+	mock.ExpectExec(UpdateGenreQuery).WithArgs("science-fiction", "Science fiction", 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(AuditQuery).
+		WithArgs("genre", 2, "update", "tester", "test-request", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err := service.UpdateGenre(auditContext(), 2, &model.Genre{Slug: "science-fiction", Name: "Science fiction"})
+
+	assert.Equal(t, nil, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceDeleteGenre(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(DeleteGenreQuery).WithArgs(2).WillReturnRows(newRows(&[]model.Genre{{ID: 2, Slug: "drama", Name: "Drama"}}))
+	mock.ExpectExec(AuditQuery).
+		WithArgs("genre", 2, "delete", "tester", "test-request", `{"id":2,"slug":"drama","name":"Drama"}`, nil, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err := service.DeleteGenre(auditContext(), 2)
+
+	assert.Equal(t, nil, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceGetGenreCounts(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	counts := []model.GenreCount{{ID: 1, Slug: "drama", Name: "Drama", Movies: 3}, {ID: 2, Slug: "western", Name: "Western"}}
+	mock.ExpectQuery(GenreCountsQuery).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "slug", "name", "count"}).AddRow(1, "drama", "Drama", 3).AddRow(2, "western", "Western", 0))
+
+	res, err := service.GetGenreCounts()
+
+	assert.Equal(t, counts, res)
+	assert.Equal(t, nil, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceGetMovieGenresTrashedMovieError(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectQuery(CheckMovieQuery).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	res, err := service.GetMovieGenres(7)
+
+	assert.Equal(t, []model.Genre{}, res)
+	assert.IsType(t, &util.NotExistingRecordError{}, err)
+}
+
+func TestServiceAddMovieGenre(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(CheckMovieQuery).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectQuery(GetGenreQuery).WithArgs(2).WillReturnRows(newRows(&[]model.Genre{{ID: 2, Slug: "drama", Name: "Drama"}}))
+	mock.ExpectExec(AddMovieGenreQuery).WithArgs(7, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(AuditQuery).
+		WithArgs("movie_genre", 7, "create", "tester", "test-request", nil, `{"id":2,"slug":"drama","name":"Drama"}`, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err := service.AddMovieGenre(auditContext(), 7, 2)
+
+	assert.Equal(t, nil, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceAddMovieGenreAgain(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(CheckMovieQuery).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectQuery(GetGenreQuery).WithArgs(2).WillReturnRows(newRows(&[]model.Genre{{ID: 2, Slug: "drama", Name: "Drama"}}))
+	mock.ExpectExec(AddMovieGenreQuery).WithArgs(7, 2).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	err := service.AddMovieGenre(auditContext(), 7, 2)
+
+	assert.Equal(t, nil, err, "Linking again should succeed without being recorded")
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceAddMovieGenreNotExistingError(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(CheckMovieQuery).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectQuery(GetGenreQuery).WithArgs(2).WillReturnRows(newRows(&[]model.Genre{}))
+	mock.ExpectRollback()
+
+	err := service.AddMovieGenre(auditContext(), 7, 2)
+
+	assert.IsType(t, &util.NotExistingRecordError{}, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceRemoveMovieGenre(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(RemoveMovieGenreQuery).WithArgs(7, 2).WillReturnRows(newRows(&[]model.Genre{{ID: 2, Slug: "drama", Name: "Drama"}}))
+	mock.ExpectExec(AuditQuery).
+		WithArgs("movie_genre", 7, "delete", "tester", "test-request", `{"id":2,"slug":"drama","name":"Drama"}`, nil, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err := service.RemoveMovieGenre(auditContext(), 7, 2)
+
+	assert.Equal(t, nil, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceRemoveMovieGenreQueryError(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	queryError := errors.New("test-error-message")
+	mock.ExpectBegin()
+	mock.ExpectQuery(RemoveMovieGenreQuery).WillReturnError(queryError)
+	mock.ExpectRollback()
+
+	err := service.RemoveMovieGenre(auditContext(), 7, 2)
+
+	assert.Equal(t, queryError, err)
+}
+
+func initNewService(t *testing.T) (genre.GenreService, sqlmock.Sqlmock, *sql.DB) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	service := genre.NewGenreService(db)
+	return service, mock, db
+}
+
+func auditContext() context.Context {
+	return util.WithRequestID(util.WithActor(context.Background(), "tester"), "test-request")
+}
+
+func newRows(genres *[]model.Genre) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"id", "slug", "name"})
+	for _, g := range *genres {
+		rows.AddRow(g.ID, g.Slug, g.Name)
+	}
+	return rows
+}
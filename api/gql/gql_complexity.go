@@ -0,0 +1,106 @@
+package gql
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/vektah/gqlparser/ast"
+	"github.com/vektah/gqlparser/parser"
+)
+
+const (
+	// Highest cost of a query, where every field resolved for every item of the enclosing lists costs 1
+	maxComplexity = 5000
+	// Deepest nesting of selections accepted
+	maxDepth = 10
+)
+
+// listField describes how many items a list field resolves to, either given by an argument or estimated
+type listField struct {
+	sizeArgument string
+	defaultSize  int
+}
+
+var listFields = map[string]listField{
+	"movies":  {sizeArgument: "first", defaultSize: defaultPageSize},
+	"history": {defaultSize: 10},
+}
+
+// checkComplexity rejects operations which would resolve too many fields, before any of them is executed.
+// Documents which do not parse pass, their syntax errors are reported by the execution.
+func checkComplexity(query, operationName string, variables map[string]interface{}) error {
+	doc, parseErr := parser.ParseQuery(&ast.Source{Input: query})
+	if parseErr != nil {
+		return nil
+	}
+	op := doc.Operations.ForName(operationName)
+	if op == nil {
+		return nil
+	}
+
+	c := complexityCounter{doc: doc, variables: variables, visiting: make(map[string]bool)}
+	if cost := c.count(op.SelectionSet, 1); cost > maxComplexity {
+		return fmt.Errorf("query complexity of %d exceeds the limit of %d", cost, maxComplexity)
+	}
+	return nil
+}
+
+type complexityCounter struct {
+	doc       *ast.QueryDocument
+	variables map[string]interface{}
+	visiting  map[string]bool
+}
+
+func (c *complexityCounter) count(set ast.SelectionSet, multiplier int) int {
+	cost := 0
+	for _, selection := range set {
+		// Stopping early, so huge page sizes cannot overflow the count
+		if cost > maxComplexity {
+			return cost
+		}
+
+		switch s := selection.(type) {
+		case *ast.Field:
+			cost += multiplier + c.count(s.SelectionSet, multiplier*c.size(s))
+		case *ast.InlineFragment:
+			cost += c.count(s.SelectionSet, multiplier)
+		case *ast.FragmentSpread:
+			// Cyclic fragments are invalid anyway, they are left to the validation
+			f := c.doc.Fragments.ForName(s.Name)
+			if f == nil || c.visiting[s.Name] {
+				continue
+			}
+			c.visiting[s.Name] = true
+			cost += c.count(f.SelectionSet, multiplier)
+			delete(c.visiting, s.Name)
+		}
+	}
+	return cost
+}
+
+// size returns how many items the field resolves to, 1 for fields which are no lists
+func (c *complexityCounter) size(f *ast.Field) int {
+	list, ok := listFields[f.Name]
+	if !ok {
+		return 1
+	}
+	if list.sizeArgument == "" {
+		return list.defaultSize
+	}
+
+	arg := f.Arguments.ForName(list.sizeArgument)
+	if arg == nil || arg.Value == nil {
+		return list.defaultSize
+	}
+	switch arg.Value.Kind {
+	case ast.IntValue:
+		if size, err := strconv.Atoi(arg.Value.Raw); err == nil && size >= 0 {
+			return size
+		}
+	case ast.Variable:
+		if size, ok := c.variables[arg.Value.Raw].(float64); ok && size >= 0 {
+			return int(size)
+		}
+	}
+	return list.defaultSize
+}
@@ -0,0 +1,79 @@
+package gql
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/audit"
+	"github.com/Hunterlemming/golang-microservice-example/api/movie"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/errors"
+)
+
+//go:embed schema.graphql
+var schemaDefinition string
+
+type controller struct {
+	schema *graphql.Schema
+	movies movie.MovieService
+	audit  audit.AuditService
+}
+
+type GraphqlController interface {
+	Query(w http.ResponseWriter, r *http.Request)
+}
+
+func NewGraphqlController(m movie.MovieService, a audit.AuditService) GraphqlController {
+	return &controller{
+		schema: graphql.MustParseSchema(schemaDefinition, &resolver{movies: m, audit: a}, graphql.MaxDepth(maxDepth)),
+		movies: m,
+		audit:  a,
+	}
+}
+
+type request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// Query executes a GraphQL operation. Errors of the operation are reported in the response body,
+// only requests which cannot be executed at all are answered with an error status.
+func (c *controller) Query(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to Query", r.Method))
+		return
+	}
+
+	var req request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.HandleInvalidBody(w, err)
+		return
+	}
+
+	if err := checkComplexity(req.Query, req.OperationName, req.Variables); err != nil {
+		log.Println("[400 - Bad Request] ", err.Error())
+		writeResponse(w, http.StatusBadRequest, &graphql.Response{Errors: []*errors.QueryError{errors.Errorf("%s", err)}})
+		return
+	}
+
+	ctx := withLoaders(r.Context(), newLoaders(c.audit, c.movies))
+	writeResponse(w, http.StatusOK, c.schema.Exec(ctx, req.Query, req.OperationName, req.Variables))
+}
+
+func writeResponse(w http.ResponseWriter, status int, res *graphql.Response) {
+	body, err := json.Marshal(res)
+	if err != nil {
+		util.HandleServerError(w, err, "Response encoding failed")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write(body)
+}
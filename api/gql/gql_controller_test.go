@@ -0,0 +1,245 @@
+package gql_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/gql"
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/movie"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockAuditServiceStruct struct {
+	mock.Mock
+}
+
+func (s *mockAuditServiceStruct) GetHistory(entity string, id int) ([]model.AuditEntry, error) {
+	args := s.Called(entity, id)
+	return args.Get(0).([]model.AuditEntry), args.Error(1)
+}
+
+func (s *mockAuditServiceStruct) GetHistories(entity string, ids []int) (map[int][]model.AuditEntry, error) {
+	args := s.Called(entity, ids)
+	return args.Get(0).(map[int][]model.AuditEntry), args.Error(1)
+}
+
+func (s *mockAuditServiceStruct) GetEntries(f model.AuditFilter) ([]model.AuditEntry, error) {
+	args := s.Called(f)
+	return args.Get(0).([]model.AuditEntry), args.Error(1)
+}
+
+// collectionMovieService places the second movie into a collection
+type collectionMovieService struct {
+	movie.MovieService
+	calls int
+}
+
+func (s *collectionMovieService) AttachCollections(movies []model.Movie) error {
+	s.calls++
+	for i := range movies {
+		if movies[i].ID == 2 {
+			movies[i].Collection = &model.CollectionRef{ID: 5, Name: "The Matrix Trilogy", Position: 2}
+		}
+	}
+	return nil
+}
+
+func TestControllerQueryMovie(t *testing.T) {
+	c := newController(&mockAuditServiceStruct{})
+
+	rr := execute(c, `{ movie(id: 1) { id name version } missing: movie(id: 9) { id } }`, nil)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `{"data":{"movie":{"id":1,"name":"The Matrix","version":1},"missing":null}}`, rr.Body.String())
+}
+
+func TestControllerQueryMoviesPagination(t *testing.T) {
+	c := newController(&mockAuditServiceStruct{})
+	const q = `query($after: String) {
+		movies(first: 2, after: $after) { totalCount edges { node { id } } pageInfo { endCursor hasNextPage } }
+	}`
+
+	res := decode(t, execute(c, q, nil))
+	assert.Equal(t, 3.0, path(res, "data", "movies", "totalCount"))
+	assert.Equal(t, true, path(res, "data", "movies", "pageInfo", "hasNextPage"))
+
+	after := path(res, "data", "movies", "pageInfo", "endCursor")
+	res = decode(t, execute(c, q, map[string]interface{}{"after": after}))
+	edges := path(res, "data", "movies", "edges").([]interface{})
+	assert.Equal(t, 1, len(edges), "The second page should hold the rest of the movies")
+	assert.Equal(t, 3.0, path(edges[0], "node", "id"))
+	assert.Equal(t, false, path(res, "data", "movies", "pageInfo", "hasNextPage"))
+}
+
+func TestControllerQueryMoviesFilter(t *testing.T) {
+	c := newController(&mockAuditServiceStruct{})
+
+	rr := execute(c, `{
+		byName: movies(filter: {name: "MATRIX"}) { edges { node { id } } }
+		bySearch: movies(filter: {search: "alien"}) { edges { node { id } } }
+	}`, nil)
+
+	assert.Equal(t, `{"data":{"byName":{"edges":[{"node":{"id":1}},{"node":{"id":2}}]},"bySearch":{"edges":[{"node":{"id":3}}]}}}`, rr.Body.String())
+}
+
+func TestControllerQueryMoviesSearchPagination(t *testing.T) {
+	c := newController(&mockAuditServiceStruct{})
+	const q = `query($after: String) {
+		movies(filter: {search: "matrix"}, first: 1, after: $after) { totalCount edges { node { id } } pageInfo { endCursor hasNextPage } }
+	}`
+
+	res := decode(t, execute(c, q, nil))
+	assert.Equal(t, 2.0, path(res, "data", "movies", "totalCount"))
+	assert.Equal(t, true, path(res, "data", "movies", "pageInfo", "hasNextPage"))
+	first := path(res, "data", "movies", "edges").([]interface{})
+
+	res = decode(t, execute(c, q, map[string]interface{}{"after": path(res, "data", "movies", "pageInfo", "endCursor")}))
+	second := path(res, "data", "movies", "edges").([]interface{})
+	assert.Equal(t, 1, len(second), "The second page should follow the ranked results")
+	assert.NotEqual(t, path(first[0], "node", "id"), path(second[0], "node", "id"))
+	assert.Equal(t, false, path(res, "data", "movies", "pageInfo", "hasNextPage"))
+}
+
+func TestControllerQueryMoviesSearchFields(t *testing.T) {
+	movies := movie.NewInMemoryMovieService(
+		model.Movie{ID: 1, Name: "The Matrix", Synopsis: "A hacker wakes up.", Version: 3, RatingAverage: 4.5, RatingCount: 2},
+	)
+	c := gql.NewGraphqlController(movies, &mockAuditServiceStruct{})
+
+	res := decode(t, execute(c, `{ movies(filter: {search: "matrix"}) { edges { node { synopsis version ratingAverage ratingCount } } } }`, nil))
+
+	edges := path(res, "data", "movies", "edges").([]interface{})
+	expected := map[string]interface{}{"synopsis": "A hacker wakes up.", "version": 3.0, "ratingAverage": 4.5, "ratingCount": 2.0}
+	assert.Equal(t, expected, path(edges[0], "node"), "The search hits should resolve the whole movies")
+}
+
+func TestControllerQueryMoviesInvalidCursorError(t *testing.T) {
+	c := newController(&mockAuditServiceStruct{})
+
+	res := decode(t, execute(c, `{ movies(after: "guessed") { totalCount } }`, nil))
+
+	assert.NotNil(t, res["errors"])
+}
+
+func TestControllerQueryHistoryBatched(t *testing.T) {
+	audit := &mockAuditServiceStruct{}
+	c := newController(audit)
+	entry := model.AuditEntry{ID: 7, Entity: "movie", EntityID: 2, Action: "create", Actor: "tester"}
+	audit.On("GetHistories", "movie", mock.MatchedBy(func(ids []int) bool { return len(ids) == 3 })).
+		Return(map[int][]model.AuditEntry{2: {entry}}, nil).Once()
+
+	res := decode(t, execute(c, `{ movies { edges { node { id history { id action actor } } } } }`, nil))
+
+	edges := path(res, "data", "movies", "edges").([]interface{})
+	assert.Equal(t, []interface{}{}, path(edges[0], "node", "history"))
+	assert.Equal(t, []interface{}{map[string]interface{}{"id": "7", "action": "create", "actor": "tester"}}, path(edges[1], "node", "history"))
+	audit.AssertNumberOfCalls(t, "GetHistories", 1)
+}
+
+func TestControllerQueryCollectionBatched(t *testing.T) {
+	movies := &collectionMovieService{MovieService: movie.NewInMemoryMovieService(
+		model.Movie{ID: 1, Name: "The Matrix"},
+		model.Movie{ID: 2, Name: "The Matrix Reloaded"},
+	)}
+	c := gql.NewGraphqlController(movies, &mockAuditServiceStruct{})
+
+	res := decode(t, execute(c, `{ movies { edges { node { id collection { id name position } } } } }`, nil))
+
+	edges := path(res, "data", "movies", "edges").([]interface{})
+	assert.Nil(t, path(edges[0], "node", "collection"))
+	assert.Equal(t, map[string]interface{}{"id": 5.0, "name": "The Matrix Trilogy", "position": 2.0}, path(edges[1], "node", "collection"))
+	assert.Equal(t, 1, movies.calls, "The collections should be attached in a single batch")
+}
+
+func TestControllerMutations(t *testing.T) {
+	c := newController(&mockAuditServiceStruct{})
+
+	rr := execute(c, `mutation {
+		createMovie(input: {id: 4, name: "Aliens"}) { id version }
+		updateMovie(id: 1, input: {name: "The Matrix Reloaded", version: 1}) { name version }
+		deleteMovie(id: 3)
+	}`, nil)
+
+	assert.Equal(t, `{"data":{"createMovie":{"id":4,"version":1},"updateMovie":{"name":"The Matrix Reloaded","version":2},"deleteMovie":true}}`, rr.Body.String())
+}
+
+func TestControllerMutationServiceErrors(t *testing.T) {
+	c := newController(&mockAuditServiceStruct{})
+
+	res := decode(t, execute(c, `mutation { updateMovie(id: 1, input: {name: "concurrent", version: 7}) { id } }`, nil))
+
+	errors := res["errors"].([]interface{})
+	assert.Equal(t, "CONFLICT", path(errors[0], "extensions", "code"), "Updates of an outdated version should be rejected")
+}
+
+func TestControllerQueryComplexityError(t *testing.T) {
+	c := newController(&mockAuditServiceStruct{})
+
+	rr := execute(c, `query($n: Int) { movies(first: $n) { edges { node { history { id action actor requestId createdAt diff } } } } }`,
+		map[string]interface{}{"n": 100})
+
+	status := http.StatusBadRequest
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerQueryInvalidMethodError(t *testing.T) {
+	c := newController(&mockAuditServiceStruct{})
+	req, _ := http.NewRequest("GET", "/graphql", nil)
+	rr := httptest.NewRecorder()
+
+	c.Query(rr, req)
+
+	status := http.StatusMethodNotAllowed
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerQueryBodyParsingError(t *testing.T) {
+	c := newController(&mockAuditServiceStruct{})
+	req, _ := http.NewRequest("POST", "/graphql", bytes.NewBufferString("not-json"))
+	rr := httptest.NewRecorder()
+
+	c.Query(rr, req)
+
+	status := http.StatusBadRequest
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func newController(audit *mockAuditServiceStruct) gql.GraphqlController {
+	movies := movie.NewInMemoryMovieService(
+		model.Movie{ID: 1, Name: "The Matrix"},
+		model.Movie{ID: 2, Name: "The Matrix Reloaded"},
+		model.Movie{ID: 3, Name: "Alien"},
+	)
+	return gql.NewGraphqlController(movies, audit)
+}
+
+func execute(c gql.GraphqlController, query string, variables map[string]interface{}) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+	req, _ := http.NewRequest("POST", "/graphql", bytes.NewBuffer(body))
+	rr := httptest.NewRecorder()
+	c.Query(rr, req)
+	return rr
+}
+
+func decode(t *testing.T, rr *httptest.ResponseRecorder) map[string]interface{} {
+	var res map[string]interface{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &res); err != nil {
+		t.Fatalf("an error '%s' was not expected when decoding the response", err)
+	}
+	return res
+}
+
+// path follows the keys into nested JSON objects
+func path(v interface{}, keys ...string) interface{} {
+	for _, key := range keys {
+		v = v.(map[string]interface{})[key]
+	}
+	return v
+}
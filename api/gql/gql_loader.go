@@ -0,0 +1,114 @@
+package gql
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/audit"
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/movie"
+
+	"github.com/graph-gophers/dataloader"
+)
+
+type loadersKey struct{}
+
+// loaders batch the lookups of related entities, which are resolved concurrently for every movie of a response.
+// They live as long as a single request, so no change is hidden behind their cache.
+type loaders struct {
+	historyLoader    *dataloader.Loader
+	collectionLoader *dataloader.Loader
+}
+
+func newLoaders(a audit.AuditService, m movie.MovieService) *loaders {
+	return &loaders{
+		historyLoader:    dataloader.NewBatchedLoader(historyBatch(a)),
+		collectionLoader: dataloader.NewBatchedLoader(collectionBatch(m)),
+	}
+}
+
+func withLoaders(ctx context.Context, l *loaders) context.Context {
+	return context.WithValue(ctx, loadersKey{}, l)
+}
+
+func loadersFromContext(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
+
+func (l *loaders) history(ctx context.Context, movieID int) ([]model.AuditEntry, error) {
+	v, err := l.historyLoader.Load(ctx, dataloader.StringKey(strconv.Itoa(movieID)))()
+	if err != nil {
+		return nil, err
+	}
+	return v.([]model.AuditEntry), nil
+}
+
+// collection returns the collection of the movie, or nil if it belongs to none
+func (l *loaders) collection(ctx context.Context, movieID int) (*model.CollectionRef, error) {
+	v, err := l.collectionLoader.Load(ctx, dataloader.StringKey(strconv.Itoa(movieID)))()
+	if err != nil {
+		return nil, err
+	}
+	return v.(*model.CollectionRef), nil
+}
+
+// historyBatch loads the histories of all the movies requested in the same batch with a single query
+func historyBatch(a audit.AuditService) dataloader.BatchFunc {
+	return func(ctx context.Context, keys dataloader.Keys) []*dataloader.Result {
+		results := make([]*dataloader.Result, len(keys))
+		ids := make([]int, len(keys))
+		for i, key := range keys {
+			id, err := strconv.Atoi(key.String())
+			if err != nil {
+				results[i] = &dataloader.Result{Error: fmt.Errorf("invalid movie ID [%s]", key.String())}
+			}
+			ids[i] = id
+		}
+
+		histories, err := a.GetHistories(audit.EntityMovie, ids)
+		for i := range keys {
+			if results[i] != nil {
+				continue
+			}
+			if err != nil {
+				results[i] = &dataloader.Result{Error: err}
+				continue
+			}
+			entries := histories[ids[i]]
+			if entries == nil {
+				entries = []model.AuditEntry{}
+			}
+			results[i] = &dataloader.Result{Data: entries}
+		}
+		return results
+	}
+}
+
+// collectionBatch attaches the collections of all the movies requested in the same batch with a single query
+func collectionBatch(m movie.MovieService) dataloader.BatchFunc {
+	return func(ctx context.Context, keys dataloader.Keys) []*dataloader.Result {
+		results := make([]*dataloader.Result, len(keys))
+		movies := make([]model.Movie, len(keys))
+		for i, key := range keys {
+			id, err := strconv.Atoi(key.String())
+			if err != nil {
+				results[i] = &dataloader.Result{Error: fmt.Errorf("invalid movie ID [%s]", key.String())}
+			}
+			movies[i].ID = id
+		}
+
+		err := m.AttachCollections(movies)
+		for i := range keys {
+			if results[i] != nil {
+				continue
+			}
+			if err != nil {
+				results[i] = &dataloader.Result{Error: err}
+				continue
+			}
+			results[i] = &dataloader.Result{Data: movies[i].Collection}
+		}
+		return results
+	}
+}
@@ -0,0 +1,382 @@
+package gql
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/audit"
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/movie"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+	cursorPrefix    = "movie:"
+)
+
+// resolver is the root of both the queries and the mutations
+type resolver struct {
+	movies movie.MovieService
+	audit  audit.AuditService
+}
+
+type movieFilter struct {
+	Name   *string
+	Search *string
+}
+
+func (r *resolver) Movie(args struct{ ID int32 }) (*movieResolver, error) {
+	m, err := r.movies.GetMovie(int(args.ID))
+	var notExisting *util.NotExistingRecordError
+	if errors.As(err, &notExisting) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, serviceError(err)
+	}
+	return &movieResolver{m: m}, nil
+}
+
+// Movies pages through the movies by their IDs, the cursors being the ID of the movie of the edge.
+// Searches page through the ranked results instead, which are limited to the maximal page size.
+func (r *resolver) Movies(args struct {
+	Filter *movieFilter
+	First  int32
+	After  *string
+}) (*movieConnectionResolver, error) {
+	first := int(args.First)
+	if first < 0 || first > maxPageSize {
+		return nil, fmt.Errorf("first has to be between 0 and %d", maxPageSize)
+	}
+
+	afterID := 0
+	if args.After != nil {
+		var err error
+		if afterID, err = decodeCursor(*args.After); err != nil {
+			return nil, err
+		}
+	}
+
+	f := model.MovieFilter{}
+	if args.Filter != nil && args.Filter.Name != nil {
+		f.Name = *args.Filter.Name
+	}
+	if args.Filter != nil && args.Filter.Search != nil {
+		return r.searchMovies(*args.Filter.Search, f.Name, afterID, first)
+	}
+
+	// Fetching one more movie tells whether another page follows
+	movies, err := r.movies.GetMoviesPage(f, afterID, first+1)
+	if err != nil {
+		return nil, serviceError(err)
+	}
+	c := &movieConnectionResolver{count: func() (int, error) { return r.movies.CountMovies(f) }}
+	if len(movies) > first {
+		movies, c.hasNextPage = movies[:first], true
+	}
+	c.page = movies
+	return c, nil
+}
+
+// searchMovies pages through the results of the search whose name contains the part, following the movie of afterID
+func (r *resolver) searchMovies(query, name string, afterID, first int) (*movieConnectionResolver, error) {
+	results, err := r.movies.Search(query, maxPageSize)
+	if err != nil {
+		return nil, serviceError(err)
+	}
+
+	movies := make([]model.Movie, 0, len(results))
+	for _, res := range results {
+		if strings.Contains(strings.ToLower(res.Movie.Name), strings.ToLower(name)) {
+			movies = append(movies, res.Movie)
+		}
+	}
+	total := len(movies)
+
+	if afterID != 0 {
+		// Movies which dropped out of the results since have no page to follow
+		start := len(movies)
+		for i := range movies {
+			if movies[i].ID == afterID {
+				start = i + 1
+				break
+			}
+		}
+		movies = movies[start:]
+	}
+	c := &movieConnectionResolver{count: func() (int, error) { return total, nil }}
+	if len(movies) > first {
+		movies, c.hasNextPage = movies[:first], true
+	}
+	c.page = movies
+	return c, nil
+}
+
+func (r *resolver) CreateMovie(ctx context.Context, args struct {
+	Input struct {
+		ID       int32
+		Name     string
+		Synopsis *string
+	}
+}) (*movieResolver, error) {
+	m := model.Movie{ID: int(args.Input.ID), Name: args.Input.Name}
+	if args.Input.Synopsis != nil {
+		m.Synopsis = *args.Input.Synopsis
+	}
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := r.movies.CreateMovie(ctx, &m); err != nil {
+		return nil, serviceError(err)
+	}
+	return &movieResolver{m: m}, nil
+}
+
+func (r *resolver) UpdateMovie(ctx context.Context, args struct {
+	ID    int32
+	Input struct {
+		Name     string
+		Synopsis *string
+		Version  *int32
+	}
+}) (*movieResolver, error) {
+	m := model.Movie{ID: int(args.ID), Name: args.Input.Name}
+	if args.Input.Version != nil {
+		m.Version = int(*args.Input.Version)
+	}
+	if args.Input.Synopsis != nil {
+		m.Synopsis = *args.Input.Synopsis
+	} else if current, err := r.movies.GetMovie(m.ID); err == nil {
+		m.Synopsis = current.Synopsis
+	}
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := r.movies.UpdateMovie(ctx, m.ID, &m); err != nil {
+		return nil, serviceError(err)
+	}
+	return &movieResolver{m: m}, nil
+}
+
+func (r *resolver) DeleteMovie(ctx context.Context, args struct{ ID int32 }) (bool, error) {
+	if err := r.movies.DeleteMovie(ctx, int(args.ID)); err != nil {
+		return false, serviceError(err)
+	}
+	return true, nil
+}
+
+type movieResolver struct {
+	m model.Movie
+}
+
+func (r *movieResolver) ID() int32 {
+	return int32(r.m.ID)
+}
+
+func (r *movieResolver) Name() string {
+	return r.m.Name
+}
+
+func (r *movieResolver) Synopsis() string {
+	return r.m.Synopsis
+}
+
+func (r *movieResolver) Version() int32 {
+	return int32(r.m.Version)
+}
+
+func (r *movieResolver) RatingAverage() float64 {
+	return r.m.RatingAverage
+}
+
+func (r *movieResolver) RatingCount() int32 {
+	return int32(r.m.RatingCount)
+}
+
+// History is batched across all the movies of a response, see loaders
+func (r *movieResolver) History(ctx context.Context) ([]*auditEntryResolver, error) {
+	entries, err := loadersFromContext(ctx).history(ctx, r.m.ID)
+	if err != nil {
+		return nil, serviceError(err)
+	}
+
+	result := make([]*auditEntryResolver, 0, len(entries))
+	for _, e := range entries {
+		result = append(result, &auditEntryResolver{e: e})
+	}
+	return result, nil
+}
+
+// Collection is batched across all the movies of a response, see loaders
+func (r *movieResolver) Collection(ctx context.Context) (*collectionRefResolver, error) {
+	ref, err := loadersFromContext(ctx).collection(ctx, r.m.ID)
+	if err != nil {
+		return nil, serviceError(err)
+	}
+	if ref == nil {
+		return nil, nil
+	}
+	return &collectionRefResolver{c: *ref}, nil
+}
+
+type collectionRefResolver struct {
+	c model.CollectionRef
+}
+
+func (r *collectionRefResolver) ID() int32 {
+	return int32(r.c.ID)
+}
+
+func (r *collectionRefResolver) Name() string {
+	return r.c.Name
+}
+
+func (r *collectionRefResolver) Position() int32 {
+	return int32(r.c.Position)
+}
+
+type auditEntryResolver struct {
+	e model.AuditEntry
+}
+
+func (r *auditEntryResolver) ID() graphql.ID {
+	return graphql.ID(strconv.FormatInt(r.e.ID, 10))
+}
+
+func (r *auditEntryResolver) Action() string {
+	return r.e.Action
+}
+
+func (r *auditEntryResolver) Actor() string {
+	return r.e.Actor
+}
+
+func (r *auditEntryResolver) RequestID() string {
+	return r.e.RequestID
+}
+
+func (r *auditEntryResolver) CreatedAt() string {
+	return r.e.CreatedAt.Format(time.RFC3339)
+}
+
+func (r *auditEntryResolver) Diff() *string {
+	if r.e.Diff == nil {
+		return nil
+	}
+	diff := string(r.e.Diff)
+	return &diff
+}
+
+type movieConnectionResolver struct {
+	// Counting the movies only if asked for
+	count       func() (int, error)
+	page        []model.Movie
+	hasNextPage bool
+}
+
+func (r *movieConnectionResolver) TotalCount() (int32, error) {
+	total, err := r.count()
+	if err != nil {
+		return 0, serviceError(err)
+	}
+	return int32(total), nil
+}
+
+func (r *movieConnectionResolver) Edges() []*movieEdgeResolver {
+	edges := make([]*movieEdgeResolver, 0, len(r.page))
+	for _, m := range r.page {
+		edges = append(edges, &movieEdgeResolver{cursor: encodeCursor(m.ID), m: m})
+	}
+	return edges
+}
+
+func (r *movieConnectionResolver) PageInfo() *pageInfoResolver {
+	p := &pageInfoResolver{hasNextPage: r.hasNextPage}
+	if len(r.page) > 0 {
+		cursor := encodeCursor(r.page[len(r.page)-1].ID)
+		p.endCursor = &cursor
+	}
+	return p
+}
+
+type movieEdgeResolver struct {
+	cursor string
+	m      model.Movie
+}
+
+func (r *movieEdgeResolver) Cursor() string {
+	return r.cursor
+}
+
+func (r *movieEdgeResolver) Node() *movieResolver {
+	return &movieResolver{m: r.m}
+}
+
+type pageInfoResolver struct {
+	endCursor   *string
+	hasNextPage bool
+}
+
+func (r *pageInfoResolver) EndCursor() *string {
+	return r.endCursor
+}
+
+func (r *pageInfoResolver) HasNextPage() bool {
+	return r.hasNextPage
+}
+
+// Cursors are opaque to clients, they encode the ID of the movie of the edge
+func encodeCursor(id int) string {
+	return base64.StdEncoding.EncodeToString([]byte(cursorPrefix + strconv.Itoa(id)))
+}
+
+func decodeCursor(cursor string) (int, error) {
+	decoded, err := base64.StdEncoding.DecodeString(cursor)
+	if err == nil && strings.HasPrefix(string(decoded), cursorPrefix) {
+		if id, err := strconv.Atoi(strings.TrimPrefix(string(decoded), cursorPrefix)); err == nil && id > 0 {
+			return id, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid cursor [%s]", cursor)
+}
+
+// resolverError carries the status of a service error as the "code" extension of the GraphQL error
+type resolverError struct {
+	message string
+	code    string
+}
+
+func (e *resolverError) Error() string {
+	return e.message
+}
+
+func (e *resolverError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": e.code}
+}
+
+// serviceError maps the errors of the service like their HTTP counterparts, without exposing internal ones
+func serviceError(err error) error {
+	switch util.ServiceErrorStatus(err) {
+	case http.StatusNotFound:
+		return &resolverError{message: err.Error(), code: "NOT_FOUND"}
+	case http.StatusConflict:
+		return &resolverError{message: err.Error(), code: "CONFLICT"}
+	case http.StatusForbidden:
+		return &resolverError{message: err.Error(), code: "FORBIDDEN"}
+	}
+	log.Println("[GraphQL - Internal] ", err.Error())
+	return &resolverError{message: "Service unreachable", code: "INTERNAL"}
+}
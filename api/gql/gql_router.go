@@ -0,0 +1,19 @@
+package gql
+
+import (
+	"github.com/Hunterlemming/golang-microservice-example/api/audit"
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/movie"
+
+	"github.com/gorilla/mux"
+)
+
+func InitializeGraphqlPipeline(api *model.Api) {
+	c := NewGraphqlController(movie.NewMovieService(api.DB), audit.NewAuditService(api.DB))
+	setRouting(api.Router, c)
+}
+
+func setRouting(main *mux.Router, c GraphqlController) {
+	main.HandleFunc("/graphql", c.Query).
+		Methods("POST")
+}
@@ -0,0 +1,47 @@
+package gql_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/gql"
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	GetPageQuery = `^SELECT [\p{L}\p{N}_, ]+ FROM movies WHERE deleted_at IS NULL AND id > \$1 ORDER BY id LIMIT \$2$`
+	CountQuery   = `^SELECT COUNT\(\*\) FROM movies WHERE deleted_at IS NULL$`
+)
+
+func TestInitializeGraphqlPipeline(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	api := model.Api{Router: mux.NewRouter(), DB: db}
+	gql.InitializeGraphqlPipeline(&api)
+
+	testIntegrationQuery(t, mock, api.Router)
+}
+
+func testIntegrationQuery(t *testing.T, mock sqlmock.Sqlmock, r *mux.Router) {
+	rows := sqlmock.NewRows([]string{"id", "name", "synopsis", "version", "rating_average", "rating_count"}).AddRow(1, "test1", "", 1, 0, 0)
+	mock.ExpectQuery(GetPageQuery).WithArgs(0, 21).WillReturnRows(rows)
+	mock.ExpectQuery(CountQuery).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	req, _ := http.NewRequest("POST", "/graphql", bytes.NewBufferString(`{"query": "{ movies { totalCount edges { node { id } } } }"}`))
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, `{"data":{"movies":{"totalCount":1,"edges":[{"node":{"id":1}}]}}}`, rr.Body.String())
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,26 @@
+package api
+
+import (
+	"github.com/Hunterlemming/golang-microservice-example/api/event"
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/movie"
+	"github.com/Hunterlemming/golang-microservice-example/api/rpc"
+
+	"github.com/spf13/viper"
+	"google.golang.org/grpc"
+)
+
+const defaultGrpcPort = "9090"
+
+// getGrpcAddress reads the port of the gRPC API from the APP_GRPC_PORT key, which is separate from the REST port
+func getGrpcAddress() string {
+	port := viper.GetString("APP_GRPC_PORT")
+	if port == "" {
+		port = defaultGrpcPort
+	}
+	return ":" + port
+}
+
+func newGrpcServer(api *model.Api, broker *event.Broker) *grpc.Server {
+	return rpc.NewServer(movie.NewMovieService(api.DB), broker, getApiTokens())
+}
@@ -0,0 +1,17 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetGrpcAddress(t *testing.T) {
+	assert.Equal(t, ":9090", getGrpcAddress(), "The default port should be used if none is configured")
+
+	viper.Set("APP_GRPC_PORT", "9191")
+	defer viper.Set("APP_GRPC_PORT", "")
+
+	assert.Equal(t, ":9191", getGrpcAddress())
+}
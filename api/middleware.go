@@ -0,0 +1,82 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/spf13/viper"
+)
+
+const (
+	requestIDHeader = "X-Request-ID"
+	// Bodies above this size are refused, unless configured otherwise
+	defaultMaxBodySize = 1 << 20
+)
+
+// getMaxBodySize reads the limit of request bodies in bytes from the APP_MAX_BODY_SIZE key
+func getMaxBodySize() int64 {
+	if size := viper.GetInt64("APP_MAX_BODY_SIZE"); size > 0 {
+		return size
+	}
+	return defaultMaxBodySize
+}
+
+// getApiTokens reads the "token=actor" pairs of the comma-separated APP_API_TOKENS key
+func getApiTokens() map[string]string {
+	tokens := make(map[string]string)
+	for _, pair := range strings.Split(viper.GetString("APP_API_TOKENS"), ",") {
+		token, actor, found := strings.Cut(strings.TrimSpace(pair), "=")
+		if found && token != "" && actor != "" {
+			tokens[token] = actor
+		}
+	}
+	return tokens
+}
+
+// getActorRoles reads the actors granted the moderator role from the comma-separated APP_MODERATORS key
+func getActorRoles() map[string][]string {
+	roles := make(map[string][]string)
+	for _, actor := range strings.Split(viper.GetString("APP_MODERATORS"), ",") {
+		if actor = strings.TrimSpace(actor); actor != "" {
+			roles[actor] = append(roles[actor], util.RoleModerator)
+		}
+	}
+	return roles
+}
+
+// requestContext stores the request ID, the authenticated actor and its roles in the request context.
+// Requests without a bearer token are served anonymously, unknown tokens are rejected.
+// WebSocket handshakes may pass the token as access_token query parameter instead.
+func requestContext(tokens map[string]string, roles map[string][]string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestID := r.Header.Get(requestIDHeader)
+			if requestID == "" {
+				requestID = util.NewRequestID()
+			}
+			w.Header().Set(requestIDHeader, requestID)
+			ctx := util.WithRequestID(r.Context(), requestID)
+
+			auth := r.Header.Get("Authorization")
+			// Browsers cannot set headers on WebSocket handshakes, those pass the token as a query parameter
+			if token := r.URL.Query().Get("access_token"); auth == "" && token != "" && websocket.IsWebSocketUpgrade(r) {
+				auth = "Bearer " + token
+			}
+			if auth != "" {
+				actor, ok := tokens[strings.TrimPrefix(auth, "Bearer ")]
+				if !ok || !strings.HasPrefix(auth, "Bearer ") {
+					util.HandleUnauthorized(w, fmt.Sprintf("unknown token for request %s", requestID))
+					return
+				}
+				ctx = util.WithRoles(util.WithActor(ctx, actor), roles[actor])
+			}
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
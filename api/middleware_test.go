@@ -0,0 +1,101 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+
+	"github.com/gorilla/mux"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetApiTokens(t *testing.T) {
+	viper.Set("APP_API_TOKENS", "secret1=alice, secret2=bob,invalid")
+	defer viper.Set("APP_API_TOKENS", "")
+
+	assert.Equal(t, map[string]string{"secret1": "alice", "secret2": "bob"}, getApiTokens())
+}
+
+func TestGetActorRoles(t *testing.T) {
+	viper.Set("APP_MODERATORS", "alice, ,bob")
+	defer viper.Set("APP_MODERATORS", "")
+
+	assert.Equal(t, map[string][]string{"alice": {util.RoleModerator}, "bob": {util.RoleModerator}}, getActorRoles())
+}
+
+func TestRequestContextAuthenticatedActor(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set(requestIDHeader, "test-request")
+
+	rr, actor, requestID := executeWithContext(req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "alice", actor)
+	assert.Equal(t, "test-request", requestID)
+	assert.Equal(t, "test-request", rr.Header().Get(requestIDHeader))
+}
+
+func TestRequestContextAnonymousActor(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/", nil)
+
+	rr, actor, requestID := executeWithContext(req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, util.AnonymousActor, actor)
+	assert.Equal(t, 32, len(requestID), "A request ID should be generated")
+}
+
+func TestRequestContextUnknownTokenError(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "Bearer guessed")
+
+	rr, _, _ := executeWithContext(req)
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
+
+func TestRequestContextWebSocketToken(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/?access_token=secret", nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+
+	rr, actor, _ := executeWithContext(req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "alice", actor)
+}
+
+func TestRequestContextQueryTokenIgnored(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/?access_token=secret", nil)
+
+	_, actor, _ := executeWithContext(req)
+
+	assert.Equal(t, util.AnonymousActor, actor, "Only WebSocket handshakes may pass the token as query parameter")
+}
+
+func TestGetMaxBodySize(t *testing.T) {
+	assert.Equal(t, int64(1<<20), getMaxBodySize(), "The default limit should be used if none is configured")
+
+	viper.Set("APP_MAX_BODY_SIZE", "2048")
+	defer viper.Set("APP_MAX_BODY_SIZE", "")
+
+	assert.Equal(t, int64(2048), getMaxBodySize())
+}
+
+func executeWithContext(req *http.Request) (*httptest.ResponseRecorder, string, string) {
+	var actor, requestID string
+	r := mux.NewRouter()
+	r.Use(requestContext(map[string]string{"secret": "alice"}, nil))
+	r.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		actor = util.ActorFromContext(r.Context())
+		requestID = util.RequestIDFromContext(r.Context())
+	})
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	return rr, actor, requestID
+}
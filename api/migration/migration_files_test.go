@@ -0,0 +1,60 @@
+package migration_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/migration"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrations(t *testing.T) {
+	migrations, err := migration.Migrations()
+
+	assert.Equal(t, nil, err)
+	assert.NotEmpty(t, migrations)
+	for i, m := range migrations {
+		assert.Equal(t, i+1, m.Version, "The versions should be contiguous")
+	}
+	assert.Equal(t, "0001_create_movies", migrations[0].String())
+}
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0002_second.up.sql":   {Data: []byte("up 2")},
+		"0002_second.down.sql": {Data: []byte("down 2")},
+		"0001_first.up.sql":    {Data: []byte("up 1")},
+		"0001_first.down.sql":  {Data: []byte("down 1")},
+		"README.md":            {Data: []byte("ignored")},
+	}
+
+	migrations, err := migration.Load(fsys)
+
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []migration.Migration{
+		{Version: 1, Name: "first", Up: "up 1", Down: "down 1"},
+		{Version: 2, Name: "second", Up: "up 2", Down: "down 2"},
+	}, migrations)
+}
+
+func TestLoadMissingDownError(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0001_first.up.sql": {Data: []byte("up 1")},
+	}
+
+	_, err := migration.Load(fsys)
+
+	assert.EqualError(t, err, "migration 0001_first needs both an up and a down file")
+}
+
+func TestLoadNameMismatchError(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0001_first.down.sql": {Data: []byte("down 1")},
+		"0001_other.up.sql":   {Data: []byte("up 1")},
+	}
+
+	_, err := migration.Load(fsys)
+
+	assert.EqualError(t, err, "migration 1 is named both [first] and [other]")
+}
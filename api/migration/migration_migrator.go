@@ -0,0 +1,177 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// lockKey identifies the advisory lock which keeps concurrent migrators, e.g. of several replicas, apart
+const lockKey = 7245001
+
+type migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// Status tells whether a migration is applied, AppliedAt is nil for pending ones
+type Status struct {
+	Migration Migration
+	AppliedAt *time.Time
+}
+
+// Migrator applies the migrations to the database, recording the applied versions in the schema_migrations table
+type Migrator interface {
+	Up(ctx context.Context) ([]Migration, error)
+	Down(ctx context.Context, steps int) ([]Migration, error)
+	Status(ctx context.Context) ([]Status, error)
+}
+
+func NewMigrator(db *sql.DB, migrations []Migration) Migrator {
+	return &migrator{
+		db:         db,
+		migrations: migrations,
+	}
+}
+
+// Up applies the pending migrations in the order of their versions, each one in its own transaction.
+// The migrations applied before a failing one stay applied, and are returned along with the error.
+func (m *migrator) Up(ctx context.Context) ([]Migration, error) {
+	applied := make([]Migration, 0)
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		versions, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := versions[migration.Version]; ok {
+				continue
+			}
+			const q = "INSERT INTO schema_migrations (version, name) VALUES ($1, $2)"
+			if err := run(ctx, conn, migration, migration.Up, q, migration.Version, migration.Name); err != nil {
+				return err
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down reverts the latest steps applied migrations, the latest one first
+func (m *migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	reverted := make([]Migration, 0, steps)
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		versions, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		// Reverting past a version this build does not know would leave its schema behind
+		for version := range versions {
+			if len(m.migrations) == 0 || version > m.migrations[len(m.migrations)-1].Version {
+				return fmt.Errorf("migration %d is applied, but unknown to this build", version)
+			}
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			migration := m.migrations[i]
+			if _, ok := versions[migration.Version]; !ok {
+				continue
+			}
+			const q = "DELETE FROM schema_migrations WHERE version = $1"
+			if err := run(ctx, conn, migration, migration.Down, q, migration.Version); err != nil {
+				return err
+			}
+			reverted = append(reverted, migration)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Status returns every migration of the build, telling when it was applied
+func (m *migrator) Status(ctx context.Context) ([]Status, error) {
+	result := make([]Status, 0, len(m.migrations))
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		versions, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			s := Status{Migration: migration}
+			if appliedAt, ok := versions[migration.Version]; ok {
+				s.AppliedAt = &appliedAt
+			}
+			result = append(result, s)
+		}
+		return nil
+	})
+	return result, err
+}
+
+// withLock runs f on a single connection, holding the advisory lock of the migrations
+func (m *migrator) withLock(ctx context.Context, f func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockKey); err != nil {
+		return err
+	}
+	defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", lockKey)
+
+	const q = `CREATE TABLE IF NOT EXISTS schema_migrations
+(
+    version integer NOT NULL,
+    name character varying NOT NULL,
+    applied_at timestamp with time zone NOT NULL DEFAULT now(),
+    PRIMARY KEY (version)
+)`
+	if _, err := conn.ExecContext(ctx, q); err != nil {
+		return err
+	}
+	return f(conn)
+}
+
+func appliedVersions(ctx context.Context, conn *sql.Conn) (map[int]time.Time, error) {
+	const q = "SELECT version, applied_at FROM schema_migrations"
+	qr, err := conn.QueryContext(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	defer qr.Close()
+
+	versions := make(map[int]time.Time)
+	for qr.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := qr.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		versions[version] = appliedAt
+	}
+	return versions, qr.Err()
+}
+
+// run executes the statements of the migration and records it in the same transaction
+func run(ctx context.Context, conn *sql.Conn, migration Migration, statements, record string, args ...interface{}) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, statements); err != nil {
+		return fmt.Errorf("migration %s: %w", migration, err)
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,170 @@
+package migration_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/migration"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	LockQuery          = `^SELECT pg_advisory_lock\(\$1\)$`
+	UnlockQuery        = `^SELECT pg_advisory_unlock\(\$1\)$`
+	CreateTableQuery   = `^CREATE TABLE IF NOT EXISTS schema_migrations`
+	AppliedQuery       = `^SELECT version, applied_at FROM schema_migrations$`
+	InsertVersionQuery = `^INSERT INTO schema_migrations \(version, name\) VALUES \(\$1, \$2\)$`
+	DeleteVersionQuery = `^DELETE FROM schema_migrations WHERE version = \$1$`
+)
+
+var testMigrations = []migration.Migration{
+	{Version: 1, Name: "first", Up: "CREATE first", Down: "DROP first"},
+	{Version: 2, Name: "second", Up: "CREATE second", Down: "DROP second"},
+	{Version: 3, Name: "third", Up: "CREATE third", Down: "DROP third"},
+}
+
+func TestMigratorUp(t *testing.T) {
+	db, mock := initNewDB(t)
+	defer db.Close()
+
+	expectLocked(mock, 1)
+	mock.ExpectBegin()
+	mock.ExpectExec("^CREATE second$").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(InsertVersionQuery).WithArgs(2, "second").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec("^CREATE third$").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(InsertVersionQuery).WithArgs(3, "third").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectExec(UnlockQuery).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	applied, err := migration.NewMigrator(db, testMigrations).Up(context.Background())
+
+	assert.Equal(t, nil, err)
+	assert.Equal(t, testMigrations[1:], applied)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestMigratorUpStatementError(t *testing.T) {
+	db, mock := initNewDB(t)
+	defer db.Close()
+
+	expectLocked(mock, 1)
+	mock.ExpectBegin()
+	mock.ExpectExec("^CREATE second$").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(InsertVersionQuery).WithArgs(2, "second").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec("^CREATE third$").WillReturnError(errors.New("syntax error"))
+	mock.ExpectRollback()
+	mock.ExpectExec(UnlockQuery).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	applied, err := migration.NewMigrator(db, testMigrations).Up(context.Background())
+
+	assert.EqualError(t, err, "migration 0003_third: syntax error")
+	assert.Equal(t, testMigrations[1:2], applied, "The migrations before the failing one should stay applied")
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestMigratorUpLockError(t *testing.T) {
+	db, mock := initNewDB(t)
+	defer db.Close()
+
+	mock.ExpectExec(LockQuery).WillReturnError(errors.New("connection lost"))
+
+	applied, err := migration.NewMigrator(db, testMigrations).Up(context.Background())
+
+	assert.EqualError(t, err, "connection lost")
+	assert.Empty(t, applied)
+}
+
+func TestMigratorDown(t *testing.T) {
+	db, mock := initNewDB(t)
+	defer db.Close()
+
+	expectLocked(mock, 1, 2, 3)
+	mock.ExpectBegin()
+	mock.ExpectExec("^DROP third$").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(DeleteVersionQuery).WithArgs(3).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec("^DROP second$").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(DeleteVersionQuery).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectExec(UnlockQuery).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	reverted, err := migration.NewMigrator(db, testMigrations).Down(context.Background(), 2)
+
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []migration.Migration{testMigrations[2], testMigrations[1]}, reverted)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestMigratorDownUnknownVersionError(t *testing.T) {
+	db, mock := initNewDB(t)
+	defer db.Close()
+
+	expectLocked(mock, 1, 2, 3, 4)
+	mock.ExpectExec(UnlockQuery).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	reverted, err := migration.NewMigrator(db, testMigrations).Down(context.Background(), 1)
+
+	assert.EqualError(t, err, "migration 4 is applied, but unknown to this build")
+	assert.Empty(t, reverted)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestMigratorStatus(t *testing.T) {
+	db, mock := initNewDB(t)
+	defer db.Close()
+
+	appliedAt := expectLocked(mock, 1)
+	mock.ExpectExec(UnlockQuery).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	status, err := migration.NewMigrator(db, testMigrations).Status(context.Background())
+
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []migration.Status{
+		{Migration: testMigrations[0], AppliedAt: &appliedAt},
+		{Migration: testMigrations[1]},
+		{Migration: testMigrations[2]},
+	}, status)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+// expectLocked expects the lock to be taken and the applied versions to be read, returning the time they were applied at
+func expectLocked(mock sqlmock.Sqlmock, versions ...int) time.Time {
+	appliedAt := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	rows := sqlmock.NewRows([]string{"version", "applied_at"})
+	for _, version := range versions {
+		rows.AddRow(version, appliedAt)
+	}
+
+	mock.ExpectExec(LockQuery).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(CreateTableQuery).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(AppliedQuery).WillReturnRows(rows)
+	return appliedAt
+}
+
+func initNewDB(t *testing.T) (*sql.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	return db, mock
+}
@@ -0,0 +1,7 @@
+-- Databases set up before the migrations already hold the table
+CREATE TABLE IF NOT EXISTS public.movies
+(
+    id integer NOT NULL,
+    name character varying NOT NULL,
+    PRIMARY KEY (id)
+);
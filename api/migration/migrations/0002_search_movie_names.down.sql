@@ -0,0 +1,4 @@
+-- The extension stays, other schemas may depend on it
+DROP INDEX IF EXISTS public.movies_name_trgm_idx;
+
+DROP INDEX IF EXISTS public.movies_name_fts_idx;
@@ -0,0 +1,8 @@
+-- Full-text and fuzzy search over the movie names
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS movies_name_fts_idx
+    ON public.movies USING GIN (to_tsvector('english', name));
+
+CREATE INDEX IF NOT EXISTS movies_name_trgm_idx
+    ON public.movies USING GIN (name gin_trgm_ops);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS public.movies_deleted_at_idx;
+
+ALTER TABLE IF EXISTS public.movies
+    DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,6 @@
+-- Soft delete: trashed movies are excluded from reads until they get restored or purged
+ALTER TABLE IF EXISTS public.movies
+    ADD COLUMN IF NOT EXISTS deleted_at timestamp with time zone;
+
+CREATE INDEX IF NOT EXISTS movies_deleted_at_idx
+    ON public.movies (deleted_at) WHERE deleted_at IS NOT NULL;
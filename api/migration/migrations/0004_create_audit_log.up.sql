@@ -0,0 +1,21 @@
+-- Audit log of every change, written in the transaction of the change
+CREATE TABLE IF NOT EXISTS public.audit_log
+(
+    id bigserial NOT NULL,
+    entity character varying NOT NULL,
+    entity_id integer NOT NULL,
+    action character varying NOT NULL,
+    actor character varying NOT NULL,
+    request_id character varying NOT NULL,
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    before jsonb,
+    after jsonb,
+    diff jsonb,
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_entity_idx
+    ON public.audit_log (entity, entity_id, created_at);
+
+CREATE INDEX IF NOT EXISTS audit_log_actor_idx
+    ON public.audit_log (actor, created_at);
@@ -0,0 +1,14 @@
+-- Transactional outbox of the domain events, published by the relay
+CREATE TABLE IF NOT EXISTS public.outbox
+(
+    id bigserial NOT NULL,
+    event_type character varying NOT NULL,
+    movie_id integer NOT NULL,
+    payload jsonb NOT NULL,
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    published_at timestamp with time zone,
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS outbox_unpublished_idx
+    ON public.outbox (id) WHERE published_at IS NULL;
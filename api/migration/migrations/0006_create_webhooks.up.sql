@@ -0,0 +1,30 @@
+-- Webhook subscriptions of partners and the log of the deliveries to them
+CREATE TABLE IF NOT EXISTS public.webhook_subscriptions
+(
+    id serial NOT NULL,
+    url character varying NOT NULL,
+    event_types character varying[] NOT NULL,
+    secret character varying NOT NULL,
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS public.webhook_deliveries
+(
+    id bigserial NOT NULL,
+    subscription_id integer NOT NULL REFERENCES public.webhook_subscriptions (id) ON DELETE CASCADE,
+    event_id bigint NOT NULL,
+    event_type character varying NOT NULL,
+    status character varying NOT NULL,
+    attempts integer NOT NULL,
+    response_code integer NOT NULL,
+    error character varying NOT NULL,
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_subscription_idx
+    ON public.webhook_deliveries (subscription_id, id);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_dead_idx
+    ON public.webhook_deliveries (id) WHERE status = 'dead';
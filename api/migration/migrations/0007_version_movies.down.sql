@@ -0,0 +1,2 @@
+ALTER TABLE IF EXISTS public.movies
+    DROP COLUMN IF EXISTS version;
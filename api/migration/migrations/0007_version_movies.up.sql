@@ -0,0 +1,3 @@
+-- Optimistic concurrency: updates only apply to the version of the movie the client has read
+ALTER TABLE IF EXISTS public.movies
+    ADD COLUMN IF NOT EXISTS version integer NOT NULL DEFAULT 1;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS public.movie_credits;
+
+DROP TABLE IF EXISTS public.people;
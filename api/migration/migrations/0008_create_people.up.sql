@@ -0,0 +1,22 @@
+-- People and the credits linking them to the movies. Purged movies take their credits along,
+-- while people cannot be deleted as long as they are credited.
+CREATE TABLE IF NOT EXISTS public.people
+(
+    id serial NOT NULL,
+    name character varying NOT NULL,
+    PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS public.movie_credits
+(
+    id serial NOT NULL,
+    movie_id integer NOT NULL REFERENCES public.movies (id) ON DELETE CASCADE,
+    person_id integer NOT NULL REFERENCES public.people (id) ON DELETE RESTRICT,
+    role character varying NOT NULL CHECK (role IN ('director', 'actor', 'writer')),
+    character_name character varying NOT NULL DEFAULT '',
+    PRIMARY KEY (id),
+    UNIQUE (movie_id, person_id, role, character_name)
+);
+
+CREATE INDEX IF NOT EXISTS movie_credits_person_idx
+    ON public.movie_credits (person_id);
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS public.movie_tags;
+
+DROP TABLE IF EXISTS public.tags;
+
+DROP TABLE IF EXISTS public.movie_genres;
+
+DROP TABLE IF EXISTS public.genres;
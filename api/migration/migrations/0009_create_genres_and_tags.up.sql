@@ -0,0 +1,35 @@
+-- Genres and editorial tags, linked to the movies many-to-many. The links go along with either side.
+CREATE TABLE IF NOT EXISTS public.genres
+(
+    id serial NOT NULL,
+    slug character varying NOT NULL UNIQUE,
+    name character varying NOT NULL,
+    PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS public.movie_genres
+(
+    movie_id integer NOT NULL REFERENCES public.movies (id) ON DELETE CASCADE,
+    genre_id integer NOT NULL REFERENCES public.genres (id) ON DELETE CASCADE,
+    PRIMARY KEY (movie_id, genre_id)
+);
+
+CREATE INDEX IF NOT EXISTS movie_genres_genre_idx
+    ON public.movie_genres (genre_id);
+
+CREATE TABLE IF NOT EXISTS public.tags
+(
+    id serial NOT NULL,
+    slug character varying NOT NULL UNIQUE,
+    PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS public.movie_tags
+(
+    movie_id integer NOT NULL REFERENCES public.movies (id) ON DELETE CASCADE,
+    tag_id integer NOT NULL REFERENCES public.tags (id) ON DELETE CASCADE,
+    PRIMARY KEY (movie_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS movie_tags_tag_idx
+    ON public.movie_tags (tag_id);
@@ -0,0 +1,22 @@
+-- Reviews of the movies, one per user and movie. The average rating and the number of ratings
+-- are kept on the movies, rejected reviews are not counted.
+CREATE TABLE IF NOT EXISTS public.reviews
+(
+    id serial NOT NULL,
+    movie_id integer NOT NULL REFERENCES public.movies (id) ON DELETE CASCADE,
+    user_name character varying NOT NULL,
+    rating smallint NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    body text NOT NULL DEFAULT '',
+    status character varying NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected')),
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    updated_at timestamp with time zone NOT NULL DEFAULT now(),
+    PRIMARY KEY (id),
+    UNIQUE (movie_id, user_name)
+);
+
+CREATE INDEX IF NOT EXISTS reviews_status_idx
+    ON public.reviews (status, id);
+
+ALTER TABLE public.movies
+    ADD COLUMN IF NOT EXISTS rating_average numeric(3, 2) NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS rating_count integer NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS public.watchlist_entries;
+
+DROP TABLE IF EXISTS public.watchlists;
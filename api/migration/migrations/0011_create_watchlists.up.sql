@@ -0,0 +1,24 @@
+-- Lists of movies owned by the users. Movies in the trash stay on the lists until they are purged,
+-- which takes the entries along. Shared lists are readable by anyone knowing their token.
+CREATE TABLE IF NOT EXISTS public.watchlists
+(
+    id serial NOT NULL,
+    owner character varying NOT NULL,
+    name character varying NOT NULL,
+    share_token character varying UNIQUE,
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    PRIMARY KEY (id),
+    UNIQUE (owner, name)
+);
+
+CREATE TABLE IF NOT EXISTS public.watchlist_entries
+(
+    watchlist_id integer NOT NULL REFERENCES public.watchlists (id) ON DELETE CASCADE,
+    movie_id integer NOT NULL REFERENCES public.movies (id) ON DELETE CASCADE,
+    position integer NOT NULL,
+    added_at timestamp with time zone NOT NULL DEFAULT now(),
+    PRIMARY KEY (watchlist_id, movie_id)
+);
+
+CREATE INDEX IF NOT EXISTS watchlist_entries_movie_idx
+    ON public.watchlist_entries (movie_id);
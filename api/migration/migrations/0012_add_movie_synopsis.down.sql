@@ -0,0 +1,2 @@
+ALTER TABLE public.movies
+    DROP COLUMN IF EXISTS synopsis;
@@ -0,0 +1,3 @@
+-- The synopsis is plain text, movies without one have it empty
+ALTER TABLE public.movies
+    ADD COLUMN IF NOT EXISTS synopsis text NOT NULL DEFAULT '';
@@ -0,0 +1,16 @@
+-- The posters of the movies, whose images are kept in the blob store by the hash of the upload.
+-- Purged movies take their poster along, their images are left in the store.
+CREATE TABLE IF NOT EXISTS public.movie_posters
+(
+    movie_id integer NOT NULL REFERENCES public.movies (id) ON DELETE CASCADE,
+    hash character(64) NOT NULL,
+    content_type character varying NOT NULL,
+    width integer NOT NULL,
+    height integer NOT NULL,
+    size integer NOT NULL,
+    updated_at timestamp with time zone NOT NULL DEFAULT now(),
+    PRIMARY KEY (movie_id)
+);
+
+CREATE INDEX IF NOT EXISTS movie_posters_hash_idx
+    ON public.movie_posters (hash);
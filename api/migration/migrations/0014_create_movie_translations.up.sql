@@ -0,0 +1,17 @@
+-- The titles and synopses of the movies by locale, the columns of the movies being the untranslated ones
+CREATE TABLE IF NOT EXISTS public.movie_translations
+(
+    movie_id integer NOT NULL REFERENCES public.movies (id) ON DELETE CASCADE,
+    locale character varying NOT NULL,
+    name character varying NOT NULL,
+    synopsis text NOT NULL DEFAULT '',
+    updated_at timestamp with time zone NOT NULL DEFAULT now(),
+    PRIMARY KEY (movie_id, locale)
+);
+
+-- Translated titles are searched without stemming, which is specific to a language
+CREATE INDEX IF NOT EXISTS movie_translations_name_fts_idx
+    ON public.movie_translations USING GIN (to_tsvector('simple', name));
+
+CREATE INDEX IF NOT EXISTS movie_translations_name_trgm_idx
+    ON public.movie_translations USING GIN (name gin_trgm_ops);
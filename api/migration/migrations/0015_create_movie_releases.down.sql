@@ -0,0 +1,4 @@
+-- The extension stays, other schemas may depend on it
+DROP TABLE IF EXISTS public.movie_availability;
+
+DROP TABLE IF EXISTS public.movie_releases;
@@ -0,0 +1,30 @@
+-- Overlapping windows are excluded by a GiST index over the regions and kinds
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+-- The releases of the movies by region, an ISO 3166-1 alpha-2 code
+CREATE TABLE IF NOT EXISTS public.movie_releases
+(
+    movie_id integer NOT NULL REFERENCES public.movies (id) ON DELETE CASCADE,
+    region character(2) NOT NULL,
+    released_at timestamp with time zone NOT NULL,
+    updated_at timestamp with time zone NOT NULL DEFAULT now(),
+    PRIMARY KEY (movie_id, region)
+);
+
+-- The windows a released movie is available in, open-ended ones having no end.
+-- Windows of the same kind may not overlap in a region, windows of different kinds may.
+CREATE TABLE IF NOT EXISTS public.movie_availability
+(
+    id serial NOT NULL,
+    movie_id integer NOT NULL,
+    region character(2) NOT NULL,
+    kind character varying NOT NULL CHECK (kind IN ('theatrical', 'streaming', 'rental')),
+    starts_at timestamp with time zone NOT NULL,
+    ends_at timestamp with time zone CHECK (ends_at > starts_at),
+    PRIMARY KEY (id),
+    FOREIGN KEY (movie_id, region) REFERENCES public.movie_releases (movie_id, region) ON DELETE CASCADE,
+    EXCLUDE USING gist (movie_id WITH =, region WITH =, kind WITH =, tstzrange(starts_at, ends_at) WITH &&)
+);
+
+CREATE INDEX IF NOT EXISTS movie_availability_region_idx
+    ON public.movie_availability (region, starts_at);
@@ -0,0 +1,12 @@
+-- The IDs of the movies merged into others, which are redirected to the surviving movie.
+-- Redirects of merged movies are pointed on when those are merged themselves, and go along with the movie they point to.
+CREATE TABLE IF NOT EXISTS public.movie_redirects
+(
+    old_id integer NOT NULL,
+    movie_id integer NOT NULL REFERENCES public.movies (id) ON DELETE CASCADE,
+    merged_at timestamp with time zone NOT NULL DEFAULT now(),
+    PRIMARY KEY (old_id)
+);
+
+CREATE INDEX IF NOT EXISTS movie_redirects_movie_idx
+    ON public.movie_redirects (movie_id);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS public.collection_movies;
+
+DROP TABLE IF EXISTS public.collections;
@@ -0,0 +1,17 @@
+-- Collections of movies such as series and franchises, whose movies are kept in order.
+-- A movie belongs to one collection at most, the memberships go along with either side.
+CREATE TABLE IF NOT EXISTS public.collections
+(
+    id serial NOT NULL,
+    name character varying NOT NULL UNIQUE,
+    description text NOT NULL DEFAULT '',
+    PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS public.collection_movies
+(
+    collection_id integer NOT NULL REFERENCES public.collections (id) ON DELETE CASCADE,
+    movie_id integer NOT NULL UNIQUE REFERENCES public.movies (id) ON DELETE CASCADE,
+    position integer NOT NULL,
+    PRIMARY KEY (collection_id, movie_id)
+);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS public.webhook_deliveries_pending_idx;
+
+ALTER TABLE public.webhook_deliveries
+    DROP COLUMN IF EXISTS next_attempt_at,
+    DROP COLUMN IF EXISTS payload;
@@ -0,0 +1,8 @@
+-- Deliveries are queued by the outbox relay and attempted by the dispatcher on its own,
+-- the pending ones keeping their payload until they are delivered or run out of retries
+ALTER TABLE public.webhook_deliveries
+    ADD COLUMN IF NOT EXISTS payload jsonb,
+    ADD COLUMN IF NOT EXISTS next_attempt_at timestamp with time zone;
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_pending_idx
+    ON public.webhook_deliveries (next_attempt_at) WHERE status = 'pending';
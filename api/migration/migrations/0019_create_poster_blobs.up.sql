@@ -0,0 +1,13 @@
+-- The images kept in the blob store by the hash of their upload, so the ones no poster refers to anymore,
+-- such as those of purged or merged movies, can be swept. Images left behind before are not tracked.
+CREATE TABLE IF NOT EXISTS public.poster_blobs
+(
+    hash character(64) NOT NULL,
+    content_type character varying NOT NULL,
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    PRIMARY KEY (hash)
+);
+
+INSERT INTO public.poster_blobs (hash, content_type)
+    SELECT DISTINCT hash, content_type FROM public.movie_posters
+    ON CONFLICT (hash) DO NOTHING;
@@ -0,0 +1,33 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type AuditEntry struct {
+	ID        int64           `json:"id" xml:"id"`
+	Entity    string          `json:"entity" xml:"entity"`
+	EntityID  int             `json:"entity_id" xml:"entity_id"`
+	Action    string          `json:"action" xml:"action"`
+	Actor     string          `json:"actor" xml:"actor"`
+	RequestID string          `json:"request_id" xml:"request_id"`
+	CreatedAt time.Time       `json:"created_at" xml:"created_at"`
+	Before    json.RawMessage `json:"before,omitempty" xml:"before,omitempty"`
+	After     json.RawMessage `json:"after,omitempty" xml:"after,omitempty"`
+	Diff      json.RawMessage `json:"diff,omitempty" xml:"diff,omitempty"`
+}
+
+// AuditFilter narrows down the audit log, zero values are not filtered on
+type AuditFilter struct {
+	Actor string
+	From  time.Time
+	To    time.Time
+	Limit int
+}
+
+// FieldChange is the entry of an audit diff for a single changed field
+type FieldChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
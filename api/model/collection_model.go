@@ -0,0 +1,36 @@
+package model
+
+import "errors"
+
+// Collection groups movies such as the parts of a series, in their order
+type Collection struct {
+	ID          int    `json:"id" xml:"id" yaml:"id"`
+	Name        string `json:"name" xml:"name" yaml:"name" validate:"required"`
+	Description string `json:"description,omitempty" xml:"description,omitempty" yaml:"description,omitempty"`
+}
+
+// CollectionRef refers to the collection of a movie along with the place of the movie in it, counted from 1
+type CollectionRef struct {
+	ID       int    `json:"id" xml:"id" yaml:"id"`
+	Name     string `json:"name" xml:"name" yaml:"name"`
+	Position int    `json:"position" xml:"position" yaml:"position"`
+}
+
+// CollectionOrder lists every movie of a collection in their new order
+type CollectionOrder struct {
+	MovieIDs []int `json:"movie_ids" xml:"movie_ids>movie_id" yaml:"movie_ids" validate:"required"`
+}
+
+func (c *Collection) Validate() error {
+	if c.Name == "" {
+		return errors.New("Name is missing")
+	}
+	return nil
+}
+
+func (o *CollectionOrder) Validate() error {
+	if len(o.MovieIDs) == 0 {
+		return errors.New("MovieIDs are missing")
+	}
+	return nil
+}
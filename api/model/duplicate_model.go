@@ -0,0 +1,31 @@
+package model
+
+import "errors"
+
+// DuplicateMovie is a movie as its duplicates are detected by, the year being the one of its title or its first release.
+// Movies of unknown years have none.
+type DuplicateMovie struct {
+	ID   int    `json:"id" xml:"id" yaml:"id"`
+	Name string `json:"name" xml:"name" yaml:"name"`
+	Year int    `json:"year,omitempty" xml:"year,omitempty" yaml:"year,omitempty"`
+}
+
+// DuplicatePair is a movie suspected to duplicate another one, scored between 0 and 1 by the similarity of their titles.
+// The movie with the lower ID is the one suggested to survive the merge.
+type DuplicatePair struct {
+	Movie     DuplicateMovie `json:"movie" xml:"movie" yaml:"movie"`
+	Duplicate DuplicateMovie `json:"duplicate" xml:"duplicate" yaml:"duplicate"`
+	Score     float64        `json:"score" xml:"score" yaml:"score"`
+}
+
+// MergeRequest names the movie a duplicate is merged into
+type MergeRequest struct {
+	Into int `json:"into" xml:"into" yaml:"into" validate:"required"`
+}
+
+func (m *MergeRequest) Validate() error {
+	if m.Into == 0 {
+		return errors.New("Into is missing")
+	}
+	return nil
+}
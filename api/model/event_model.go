@@ -0,0 +1,15 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Event is a domain event describing a change of a movie, as stored in the outbox
+type Event struct {
+	ID        int64           `json:"id" xml:"id"`
+	Type      string          `json:"type" xml:"type"`
+	MovieID   int             `json:"movie_id" xml:"movie_id"`
+	Payload   json.RawMessage `json:"payload" xml:"payload"`
+	CreatedAt time.Time       `json:"created_at" xml:"created_at"`
+}
@@ -0,0 +1,35 @@
+package model
+
+import (
+	"errors"
+	"regexp"
+)
+
+// SlugPattern matches the slugs identifying the genres and tags in URLs, such as "science-fiction"
+const SlugPattern = `^[a-z0-9]+(-[a-z0-9]+)*$`
+
+var slugRegexp = regexp.MustCompile(SlugPattern)
+
+type Genre struct {
+	ID   int    `json:"id" xml:"id" yaml:"id"`
+	Slug string `json:"slug" xml:"slug" yaml:"slug" validate:"required,slug"`
+	Name string `json:"name" xml:"name" yaml:"name" validate:"required"`
+}
+
+func (g *Genre) Validate() error {
+	if !slugRegexp.MatchString(g.Slug) {
+		return errors.New("Slug is invalid")
+	}
+	if g.Name == "" {
+		return errors.New("Name is missing")
+	}
+	return nil
+}
+
+// GenreCount is a genre along with the number of movies in it
+type GenreCount struct {
+	ID     int    `json:"id" xml:"id"`
+	Slug   string `json:"slug" xml:"slug"`
+	Name   string `json:"name" xml:"name"`
+	Movies int    `json:"movies" xml:"movies"`
+}
@@ -1,10 +1,33 @@
 package model
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
 
 type Movie struct {
-	ID   int    `json:"id"`
-	Name string `json:"name"`
+	ID        int        `json:"id" xml:"id" yaml:"id"`
+	Name      string     `json:"name" xml:"name" yaml:"name" validate:"required"`
+	Synopsis  string     `json:"synopsis,omitempty" xml:"synopsis,omitempty" yaml:"synopsis,omitempty"`
+	Version   int        `json:"version,omitempty" xml:"version,omitempty" yaml:"version,omitempty"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty" xml:"deleted_at,omitempty" yaml:"deleted_at,omitempty"`
+	// Aggregated from the ratings of the reviews, which maintain them
+	RatingAverage float64 `json:"rating_average,omitempty" xml:"rating_average,omitempty" yaml:"rating_average,omitempty"`
+	RatingCount   int     `json:"rating_count,omitempty" xml:"rating_count,omitempty" yaml:"rating_count,omitempty"`
+	// Set on the responses of the movies belonging to a collection, which maintains it
+	Collection *CollectionRef `json:"collection,omitempty" xml:"collection,omitempty" yaml:"collection,omitempty"`
+}
+
+// MovieFilter narrows down the movies to the ones in a genre and with a tag, zero values are not filtered on
+type MovieFilter struct {
+	Genre string
+	Tag   string
+	// Case-insensitive part of the name
+	Name string
+}
+
+type PurgeResult struct {
+	Purged int64 `json:"purged" xml:"purged"`
 }
 
 func (m *Movie) Validate() error {
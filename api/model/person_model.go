@@ -0,0 +1,49 @@
+package model
+
+import "errors"
+
+const (
+	RoleDirector = "director"
+	RoleActor    = "actor"
+	RoleWriter   = "writer"
+)
+
+type Person struct {
+	ID   int    `json:"id" xml:"id" yaml:"id"`
+	Name string `json:"name" xml:"name" yaml:"name" validate:"required"`
+}
+
+func (p *Person) Validate() error {
+	if p.Name == "" {
+		return errors.New("Name is missing")
+	}
+	return nil
+}
+
+// Credit links a person to a movie in a role, actors are credited along with the character they play.
+// The names of the movie and the person are filled in when the credits are read.
+type Credit struct {
+	ID         int    `json:"id" xml:"id"`
+	MovieID    int    `json:"movie_id" xml:"movie_id"`
+	MovieName  string `json:"movie_name,omitempty" xml:"movie_name,omitempty"`
+	PersonID   int    `json:"person_id" xml:"person_id" validate:"required"`
+	PersonName string `json:"person_name,omitempty" xml:"person_name,omitempty"`
+	Role       string `json:"role" xml:"role" validate:"required,oneof=director actor writer"`
+	Character  string `json:"character,omitempty" xml:"character,omitempty"`
+}
+
+func (c *Credit) Validate() error {
+	if c.PersonID <= 0 {
+		return errors.New("PersonID is missing")
+	}
+	switch c.Role {
+	case RoleDirector, RoleWriter:
+		if c.Character != "" {
+			return errors.New("Character is only credited to actors")
+		}
+	case RoleActor:
+	default:
+		return errors.New("Role is invalid")
+	}
+	return nil
+}
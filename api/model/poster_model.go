@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+const (
+	// Uploads above this many bytes are refused
+	MaxPosterSize = 5 << 20
+	// Images wider or higher than this many pixels are refused before they are decoded
+	MaxPosterDimension = 6000
+)
+
+// Poster is the artwork of a movie. The images are addressed by the SHA-256 hash of the upload,
+// so their paths change along with the poster.
+type Poster struct {
+	MovieID     int           `json:"movie_id" xml:"movie_id" yaml:"movie_id"`
+	Hash        string        `json:"hash" xml:"hash" yaml:"hash"`
+	ContentType string        `json:"content_type" xml:"content_type" yaml:"content_type"`
+	Width       int           `json:"width" xml:"width" yaml:"width"`
+	Height      int           `json:"height" xml:"height" yaml:"height"`
+	Size        int           `json:"size" xml:"size" yaml:"size"`
+	UpdatedAt   time.Time     `json:"updated_at" xml:"updated_at" yaml:"updated_at"`
+	Images      []PosterImage `json:"images" xml:"images>image" yaml:"images"`
+}
+
+// PosterImage is the upload itself, named "original", or one of the thumbnails generated from it
+type PosterImage struct {
+	Name  string `json:"name" xml:"name" yaml:"name"`
+	Width int    `json:"width" xml:"width" yaml:"width"`
+	Path  string `json:"path" xml:"path" yaml:"path"`
+}
@@ -0,0 +1,83 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Kinds of the availability windows
+const (
+	WindowTheatrical = "theatrical"
+	WindowStreaming  = "streaming"
+	WindowRental     = "rental"
+)
+
+// Release is the release of a movie in a region, an ISO 3166-1 alpha-2 code such as "DE", along with the windows
+// it is available in there. The movie and the region are identified by the path, the timestamp is set by the service.
+type Release struct {
+	MovieID    int       `json:"movie_id" xml:"movie_id" yaml:"movie_id"`
+	Region     string    `json:"region" xml:"region" yaml:"region"`
+	ReleasedAt time.Time `json:"released_at" xml:"released_at" yaml:"released_at" validate:"required"`
+	Windows    []Window  `json:"windows" xml:"windows>window" yaml:"windows"`
+	UpdatedAt  time.Time `json:"updated_at" xml:"updated_at" yaml:"updated_at"`
+}
+
+// Window is a period a movie is available in, open-ended windows have no end.
+// The start is part of the window, the end is not.
+type Window struct {
+	Kind     string     `json:"kind" xml:"kind" yaml:"kind" validate:"required,oneof=theatrical streaming rental"`
+	StartsAt time.Time  `json:"starts_at" xml:"starts_at" yaml:"starts_at" validate:"required"`
+	EndsAt   *time.Time `json:"ends_at,omitempty" xml:"ends_at,omitempty" yaml:"ends_at,omitempty"`
+}
+
+// AvailabilityFilter narrows down the movies to the ones available in a region at a time, in a window of a kind.
+// Without a kind every window counts.
+type AvailabilityFilter struct {
+	Region string
+	At     time.Time
+	Kind   string
+}
+
+// Validate rejects windows before the release, windows ending before they start
+// and windows overlapping another one of the same kind. Windows of different kinds may overlap.
+func (r *Release) Validate() error {
+	if r.ReleasedAt.IsZero() {
+		return errors.New("ReleasedAt is missing")
+	}
+	for i, w := range r.Windows {
+		if !IsWindowKind(w.Kind) {
+			return fmt.Errorf("Kind of window %d is invalid", i)
+		}
+		if w.StartsAt.IsZero() {
+			return fmt.Errorf("StartsAt of window %d is missing", i)
+		}
+		if w.StartsAt.Before(r.ReleasedAt) {
+			return fmt.Errorf("Window %d starts before the release", i)
+		}
+		if w.EndsAt != nil && !w.EndsAt.After(w.StartsAt) {
+			return fmt.Errorf("Window %d does not end after it starts", i)
+		}
+		for j, other := range r.Windows[:i] {
+			if other.Kind == w.Kind && other.overlaps(w) {
+				return fmt.Errorf("Window %d overlaps window %d of the same kind", i, j)
+			}
+		}
+	}
+	return nil
+}
+
+// Contains tells whether the movie is available in the window at the time
+func (w Window) Contains(at time.Time) bool {
+	return !at.Before(w.StartsAt) && (w.EndsAt == nil || at.Before(*w.EndsAt))
+}
+
+func (w Window) overlaps(other Window) bool {
+	return (w.EndsAt == nil || other.StartsAt.Before(*w.EndsAt)) &&
+		(other.EndsAt == nil || w.StartsAt.Before(*other.EndsAt))
+}
+
+// IsWindowKind tells whether the kind is one of the kinds of the availability windows
+func IsWindowKind(kind string) bool {
+	return kind == WindowTheatrical || kind == WindowStreaming || kind == WindowRental
+}
@@ -0,0 +1,63 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Moderation statuses of the reviews, new and edited reviews are pending until they are moderated
+const (
+	ReviewPending  = "pending"
+	ReviewApproved = "approved"
+	ReviewRejected = "rejected"
+)
+
+// Ratings are given in stars
+const (
+	MinRating = 1
+	MaxRating = 5
+)
+
+// Review is the rating of a movie by a user, optionally along with a text.
+// The user, the status and the timestamps are set by the service.
+type Review struct {
+	ID        int       `json:"id" xml:"id" yaml:"id"`
+	MovieID   int       `json:"movie_id" xml:"movie_id" yaml:"movie_id"`
+	User      string    `json:"user" xml:"user" yaml:"user"`
+	Rating    int       `json:"rating" xml:"rating" yaml:"rating" validate:"required,min=1,max=5"`
+	Body      string    `json:"body,omitempty" xml:"body,omitempty" yaml:"body,omitempty"`
+	Status    string    `json:"status" xml:"status" yaml:"status"`
+	CreatedAt time.Time `json:"created_at" xml:"created_at" yaml:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" xml:"updated_at" yaml:"updated_at"`
+}
+
+// ReviewFilter narrows down the reviews to the ones of a movie and of a status, zero values are not filtered on
+type ReviewFilter struct {
+	MovieID int
+	Status  string
+}
+
+// Moderation is the decision of a moderator on a review
+type Moderation struct {
+	Status string `json:"status" xml:"status" yaml:"status" validate:"required,oneof=pending approved rejected"`
+}
+
+func (r *Review) Validate() error {
+	if r.Rating < MinRating || r.Rating > MaxRating {
+		return fmt.Errorf("Rating is not between %d and %d", MinRating, MaxRating)
+	}
+	return nil
+}
+
+func (m *Moderation) Validate() error {
+	if !IsReviewStatus(m.Status) {
+		return errors.New("Status is invalid")
+	}
+	return nil
+}
+
+// IsReviewStatus tells whether the status is one of the moderation statuses
+func IsReviewStatus(status string) bool {
+	return status == ReviewPending || status == ReviewApproved || status == ReviewRejected
+}
@@ -0,0 +1,9 @@
+package model
+
+// SearchResult is a movie matching a search. The snippet is its matched name as HTML,
+// escaped except for the <b> elements around the matched words.
+type SearchResult struct {
+	Movie   Movie   `json:"movie" xml:"movie" yaml:"movie"`
+	Rank    float64 `json:"rank" xml:"rank" yaml:"rank"`
+	Snippet string  `json:"snippet" xml:"snippet" yaml:"snippet"`
+}
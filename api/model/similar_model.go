@@ -0,0 +1,8 @@
+package model
+
+// SimilarMovie is a movie ranked by its similarity to another one, between 0 and 1
+type SimilarMovie struct {
+	ID    int     `json:"id" xml:"id" yaml:"id"`
+	Name  string  `json:"name" xml:"name" yaml:"name"`
+	Score float64 `json:"score" xml:"score" yaml:"score"`
+}
@@ -0,0 +1,25 @@
+package model
+
+// Types of the messages exchanged over the collaborative editing socket
+const (
+	SocketSubscribe    = "subscribe"
+	SocketUnsubscribe  = "unsubscribe"
+	SocketUpdate       = "update"
+	SocketSnapshot     = "snapshot"
+	SocketUnsubscribed = "unsubscribed"
+	SocketEvent        = "event"
+	SocketUpdated      = "updated"
+	SocketError        = "error"
+)
+
+// SocketMessage is a message sent in either direction over the collaborative editing socket.
+// Responses carry the Ref of the request they answer, so clients can match them up.
+type SocketMessage struct {
+	Type    string `json:"type"`
+	Ref     string `json:"ref,omitempty"`
+	IDs     []int  `json:"ids,omitempty"`
+	Movie   *Movie `json:"movie,omitempty"`
+	Event   *Event `json:"event,omitempty"`
+	Status  int    `json:"status,omitempty"`
+	Message string `json:"message,omitempty"`
+}
@@ -0,0 +1,16 @@
+package model
+
+import "errors"
+
+// Tag is a free-form editorial label of movies, such as "award-winner"
+type Tag struct {
+	ID   int    `json:"id" xml:"id" yaml:"id"`
+	Slug string `json:"slug" xml:"slug" yaml:"slug" validate:"required,slug"`
+}
+
+func (t *Tag) Validate() error {
+	if !slugRegexp.MatchString(t.Slug) {
+		return errors.New("Slug is invalid")
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+// Translation is the title and the synopsis of a movie in a locale, such as "de" or "pt-BR".
+// The movie and the locale are identified by the path, the timestamp is set by the service.
+type Translation struct {
+	MovieID   int       `json:"movie_id" xml:"movie_id" yaml:"movie_id"`
+	Locale    string    `json:"locale" xml:"locale" yaml:"locale"`
+	Name      string    `json:"name" xml:"name" yaml:"name" validate:"required"`
+	Synopsis  string    `json:"synopsis,omitempty" xml:"synopsis,omitempty" yaml:"synopsis,omitempty"`
+	UpdatedAt time.Time `json:"updated_at" xml:"updated_at" yaml:"updated_at"`
+}
+
+func (t *Translation) Validate() error {
+	if t.Name == "" {
+		return errors.New("Name is missing")
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+// Watchlist is a list of movies owned by a user, the owner and the share token are set by the service.
+// The entries are only included when a single list is requested.
+type Watchlist struct {
+	ID         int              `json:"id" xml:"id" yaml:"id"`
+	Owner      string           `json:"owner,omitempty" xml:"owner,omitempty" yaml:"owner,omitempty"`
+	Name       string           `json:"name" xml:"name" yaml:"name" validate:"required"`
+	ShareToken string           `json:"share_token,omitempty" xml:"share_token,omitempty" yaml:"share_token,omitempty"`
+	CreatedAt  time.Time        `json:"created_at" xml:"created_at" yaml:"created_at"`
+	Entries    []WatchlistEntry `json:"entries,omitempty" xml:"entries>entry,omitempty" yaml:"entries,omitempty"`
+}
+
+// WatchlistEntry is a movie on a list. Movies in the trash stay on the lists flagged as deleted until they are purged.
+type WatchlistEntry struct {
+	MovieID   int       `json:"movie_id" xml:"movie_id" yaml:"movie_id"`
+	MovieName string    `json:"movie_name" xml:"movie_name" yaml:"movie_name"`
+	Position  int       `json:"position" xml:"position" yaml:"position"`
+	AddedAt   time.Time `json:"added_at" xml:"added_at" yaml:"added_at"`
+	Deleted   bool      `json:"deleted,omitempty" xml:"deleted,omitempty" yaml:"deleted,omitempty"`
+}
+
+// WatchlistOrder lists every movie of a list in their new order
+type WatchlistOrder struct {
+	MovieIDs []int `json:"movie_ids" xml:"movie_ids>movie_id" yaml:"movie_ids" validate:"required"`
+}
+
+// WatchlistShare refers to the public view of a shared list
+type WatchlistShare struct {
+	Token string `json:"token" xml:"token"`
+	Path  string `json:"path" xml:"path"`
+}
+
+func (w *Watchlist) Validate() error {
+	if w.Name == "" {
+		return errors.New("Name is missing")
+	}
+	return nil
+}
+
+func (o *WatchlistOrder) Validate() error {
+	if len(o.MovieIDs) == 0 {
+		return errors.New("MovieIDs are missing")
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package model
+
+import (
+	"errors"
+	"net/url"
+	"time"
+)
+
+const (
+	// Pending deliveries are retried until they are delivered or run out of attempts
+	DeliveryStatusPending   = "pending"
+	DeliveryStatusDelivered = "delivered"
+	DeliveryStatusDead      = "dead"
+)
+
+type Subscription struct {
+	ID         int       `json:"id" xml:"id"`
+	URL        string    `json:"url" xml:"url" validate:"required,url"`
+	EventTypes []string  `json:"event_types" xml:"event_types>event_type" validate:"required"`
+	Secret     string    `json:"secret,omitempty" xml:"secret,omitempty" validate:"required"`
+	CreatedAt  time.Time `json:"created_at" xml:"created_at"`
+}
+
+func (s *Subscription) Validate() error {
+	u, err := url.Parse(s.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("URL is invalid")
+	}
+	if len(s.EventTypes) == 0 {
+		return errors.New("EventTypes are missing")
+	}
+	if s.Secret == "" {
+		return errors.New("Secret is missing")
+	}
+	return nil
+}
+
+// Delivery is the outcome of delivering an event to a subscription, including all its retries so far
+type Delivery struct {
+	ID             int64     `json:"id" xml:"id"`
+	SubscriptionID int       `json:"subscription_id" xml:"subscription_id"`
+	EventID        int64     `json:"event_id" xml:"event_id"`
+	EventType      string    `json:"event_type" xml:"event_type"`
+	Status         string    `json:"status" xml:"status"`
+	Attempts       int       `json:"attempts" xml:"attempts"`
+	ResponseCode   int       `json:"response_code" xml:"response_code"`
+	Error          string    `json:"error,omitempty" xml:"error,omitempty"`
+	CreatedAt      time.Time `json:"created_at" xml:"created_at"`
+	// When a pending delivery is attempted next
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty" xml:"next_attempt_at,omitempty"`
+}
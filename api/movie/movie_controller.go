@@ -1,14 +1,16 @@
 package movie
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
 
 	"github.com/gorilla/mux"
 )
@@ -23,8 +25,23 @@ type MovieController interface {
 	CreateMovie(w http.ResponseWriter, r *http.Request)
 	UpdateMovie(w http.ResponseWriter, r *http.Request)
 	DeleteMovie(w http.ResponseWriter, r *http.Request)
+	SearchMovies(w http.ResponseWriter, r *http.Request)
+	GetTrash(w http.ResponseWriter, r *http.Request)
+	RestoreMovie(w http.ResponseWriter, r *http.Request)
+	PurgeTrash(w http.ResponseWriter, r *http.Request)
+	GetAvailableMovies(w http.ResponseWriter, r *http.Request)
 }
 
+const (
+	maxPageSize = 100
+
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+
+	// How long deleted movies are kept in the trash if the purge request does not specify it
+	defaultTrashRetention = 30 * 24 * time.Hour
+)
+
 func NewMovieController(s MovieService) MovieController {
 	return &controller{
 		service: s,
@@ -33,58 +50,131 @@ func NewMovieController(s MovieService) MovieController {
 
 func (c *controller) GetMovies(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		handleMethodNotAllowed(w, fmt.Sprintf("%s method to GetMovies", r.Method))
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to GetMovies", r.Method))
+		return
+	}
+
+	enc, err := util.NewResponseEncoder(r, true)
+	if err != nil {
+		util.HandleNotAcceptable(w, err)
+		return
+	}
+
+	query := r.URL.Query()
+	filter := model.MovieFilter{Genre: query.Get("genre"), Tag: query.Get("tag")}
+
+	// Without a limit or a filter every movie is listed at once
+	if query.Get("limit") == "" && filter == (model.MovieFilter{}) {
+		movies, err := c.service.GetMovies()
+		if err != nil {
+			util.HandleServiceError(w, err)
+			return
+		}
+		if _, err := c.complete(w, r, movies); err != nil {
+			util.HandleServiceError(w, err)
+			return
+		}
+		util.WriteResponse(w, enc, http.StatusOK, movies)
 		return
 	}
 
-	movies, err := c.service.GetMovies()
+	var afterID, limit int
+	if query.Get("limit") != "" {
+		afterID, limit, err = parsePage(r)
+		if err != nil {
+			util.HandleBadRequest(w, "Invalid page", err.Error())
+			return
+		}
+	}
+
+	movies, err := c.service.GetMoviesPage(filter, afterID, limit)
 	if err != nil {
-		handleServerError(w, err, "Service unreachable")
+		util.HandleServiceError(w, err)
+		return
+	}
+	if _, err := c.complete(w, r, movies); err != nil {
+		util.HandleServiceError(w, err)
 		return
 	}
 
-	res, _ := json.Marshal(movies)
-	fmt.Fprintf(w, "%s", res)
+	// A full page may be followed by another one of the same filter
+	if limit > 0 && len(movies) == limit {
+		next := url.Values{}
+		if filter.Genre != "" {
+			next.Set("genre", filter.Genre)
+		}
+		if filter.Tag != "" {
+			next.Set("tag", filter.Tag)
+		}
+		next.Set("after", strconv.Itoa(movies[len(movies)-1].ID))
+		next.Set("limit", strconv.Itoa(limit))
+		w.Header().Set("Link", fmt.Sprintf(`</movies?%s>; rel="next"`, next.Encode()))
+	}
+	util.WriteResponse(w, enc, http.StatusOK, movies)
 }
 
 func (c *controller) GetMovie(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		handleMethodNotAllowed(w, fmt.Sprintf("%s method to GetMovie", r.Method))
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to GetMovie", r.Method))
 		return
 	}
 
 	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 0)
 	if err != nil {
-		handleBadRequest(w, "Invalid ID", err.Error())
+		util.HandleBadRequest(w, "Invalid ID", err.Error())
+		return
+	}
+
+	enc, err := util.NewResponseEncoder(r, false)
+	if err != nil {
+		util.HandleNotAcceptable(w, err)
 		return
 	}
 
 	movie, err := c.service.GetMovie(int(id))
+	var notExisting *util.NotExistingRecordError
+	if errors.As(err, &notExisting) {
+		// Movies merged into others are redirected to the surviving movie, temporarily as a new movie may take the ID back
+		if into, redirectErr := c.service.GetRedirect(int(id)); redirectErr == nil {
+			http.Redirect(w, r, fmt.Sprintf("/movies/%d", into), http.StatusTemporaryRedirect)
+			return
+		}
+	}
 	if err != nil {
-		handleServerError(w, err, "Service unreachable")
+		util.HandleServiceError(w, err)
 		return
 	}
 
-	res, _ := json.Marshal(movie)
-	fmt.Fprintf(w, "%s", res)
+	movies := []model.Movie{movie}
+	locales, err := c.complete(w, r, movies)
+	if err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+	// Untranslated names are in whichever language the catalog was filled in
+	if locales[0] != "" {
+		w.Header().Set("Content-Language", locales[0])
+	}
+
+	util.WriteResponse(w, enc, http.StatusOK, movies[0])
 }
 
 func (c *controller) CreateMovie(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		handleMethodNotAllowed(w, fmt.Sprintf("%s method to CreateMovie", r.Method))
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to CreateMovie", r.Method))
 		return
 	}
 
 	// Extracting Movie object from request-body
 	m, err := parseValidMovie(r)
 	if err != nil {
-		handleBadRequest(w, "Invalid request body", err.Error())
+		util.HandleInvalidBody(w, err)
 		return
 	}
 
 	// Create the Movie object in the database
-	if err := c.service.CreateMovie(m); err != nil {
-		handleServerError(w, err, "Service unreachable")
+	if err := c.service.CreateMovie(r.Context(), m); err != nil {
+		util.HandleServiceError(w, err)
 		return
 	}
 
@@ -94,27 +184,27 @@ func (c *controller) CreateMovie(w http.ResponseWriter, r *http.Request) {
 
 func (c *controller) UpdateMovie(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPut {
-		handleMethodNotAllowed(w, fmt.Sprintf("%s method to UpdateMovie", r.Method))
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to UpdateMovie", r.Method))
 		return
 	}
 
 	// Converting the ID to an integer
 	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 0)
 	if err != nil {
-		handleBadRequest(w, "Invalid ID", err.Error())
+		util.HandleBadRequest(w, "Invalid ID", err.Error())
 		return
 	}
 
 	// Extracting Movie object from request-body
 	m, err := parseValidMovie(r)
 	if err != nil {
-		handleBadRequest(w, "Invalid request body", err.Error())
+		util.HandleInvalidBody(w, err)
 		return
 	}
 
 	// Updating Movie object in the database
-	if err := c.service.UpdateMovie(int(id), m); err != nil {
-		handleServerError(w, err, "Service unreachable")
+	if err := c.service.UpdateMovie(r.Context(), int(id), m); err != nil {
+		util.HandleServiceError(w, err)
 		return
 	}
 
@@ -123,20 +213,20 @@ func (c *controller) UpdateMovie(w http.ResponseWriter, r *http.Request) {
 
 func (c *controller) DeleteMovie(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodDelete {
-		handleMethodNotAllowed(w, fmt.Sprintf("%s method to DeleteMovie", r.Method))
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to DeleteMovie", r.Method))
 		return
 	}
 
 	// Converting the ID to an integer
 	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 0)
 	if err != nil {
-		handleBadRequest(w, "Invalid ID", err.Error())
+		util.HandleBadRequest(w, "Invalid ID", err.Error())
 		return
 	}
 
 	// Deleting Movie object from the database
-	if err := c.service.DeleteMovie(int(id)); err != nil {
-		handleServerError(w, err, "Service unreachable")
+	if err := c.service.DeleteMovie(r.Context(), int(id)); err != nil {
+		util.HandleServiceError(w, err)
 		return
 	}
 
@@ -144,33 +234,234 @@ func (c *controller) DeleteMovie(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprintln(w, "success")
 }
 
+func (c *controller) SearchMovies(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to SearchMovies", r.Method))
+		return
+	}
+
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		util.HandleBadRequest(w, "Missing search query", "empty q parameter")
+		return
+	}
+
+	// Converting the optional limit to an integer
+	limit := defaultSearchLimit
+	if l := r.URL.Query().Get("limit"); l != "" {
+		parsed, err := strconv.ParseInt(l, 10, 0)
+		if err != nil || parsed < 1 || parsed > maxSearchLimit {
+			util.HandleBadRequest(w, "Invalid limit", fmt.Sprintf("limit [%s] out of range", l))
+			return
+		}
+		limit = int(parsed)
+	}
+
+	enc, err := util.NewResponseEncoder(r, true)
+	if err != nil {
+		util.HandleNotAcceptable(w, err)
+		return
+	}
+
+	results, err := c.service.Search(query, limit)
+	if err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	// The snippets keep the matched names, which may be in any locale
+	movies := make([]model.Movie, len(results))
+	for i := range results {
+		movies[i] = results[i].Movie
+	}
+	if _, err := c.complete(w, r, movies); err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+	for i := range results {
+		results[i].Movie = movies[i]
+	}
+
+	util.WriteResponse(w, enc, http.StatusOK, results)
+}
+
+func (c *controller) GetTrash(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to GetTrash", r.Method))
+		return
+	}
+
+	enc, err := util.NewResponseEncoder(r, true)
+	if err != nil {
+		util.HandleNotAcceptable(w, err)
+		return
+	}
+
+	movies, err := c.service.GetTrash()
+	if err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	util.WriteResponse(w, enc, http.StatusOK, movies)
+}
+
+func (c *controller) RestoreMovie(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to RestoreMovie", r.Method))
+		return
+	}
+
+	// Converting the ID to an integer
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 0)
+	if err != nil {
+		util.HandleBadRequest(w, "Invalid ID", err.Error())
+		return
+	}
+
+	// Moving the Movie object out of the trash
+	if err := c.service.RestoreMovie(r.Context(), int(id)); err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	fmt.Fprintln(w, "success")
+}
+
+func (c *controller) PurgeTrash(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to PurgeTrash", r.Method))
+		return
+	}
+
+	// Converting the optional retention period to a duration
+	retention := defaultTrashRetention
+	if rp := r.URL.Query().Get("retention"); rp != "" {
+		parsed, err := time.ParseDuration(rp)
+		if err != nil || parsed < 0 {
+			util.HandleBadRequest(w, "Invalid retention", fmt.Sprintf("retention [%s] is not a valid duration", rp))
+			return
+		}
+		retention = parsed
+	}
+
+	enc, err := util.NewResponseEncoder(r, false)
+	if err != nil {
+		util.HandleNotAcceptable(w, err)
+		return
+	}
+
+	purged, err := c.service.PurgeMovies(r.Context(), retention)
+	if err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	util.WriteResponse(w, enc, http.StatusOK, model.PurgeResult{Purged: purged})
+}
+
+// GetAvailableMovies lists the movies available in the region of the query at its time, now by default
+func (c *controller) GetAvailableMovies(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to GetAvailableMovies", r.Method))
+		return
+	}
+
+	query := r.URL.Query()
+	region, ok := util.CanonicalRegion(query.Get("region"))
+	if !ok {
+		util.HandleBadRequest(w, "Invalid region", fmt.Sprintf("region [%s] is not a two-letter code", query.Get("region")))
+		return
+	}
+	filter := model.AvailabilityFilter{Region: region, At: time.Now(), Kind: query.Get("kind")}
+	if at := query.Get("at"); at != "" {
+		parsed, err := time.Parse(time.RFC3339, at)
+		if err != nil {
+			util.HandleBadRequest(w, "Invalid time", fmt.Sprintf("at [%s] is not an RFC 3339 time", at))
+			return
+		}
+		filter.At = parsed
+	}
+	if filter.Kind != "" && !model.IsWindowKind(filter.Kind) {
+		util.HandleBadRequest(w, "Invalid kind", fmt.Sprintf("kind [%s] is not a window kind", filter.Kind))
+		return
+	}
+
+	enc, err := util.NewResponseEncoder(r, true)
+	if err != nil {
+		util.HandleNotAcceptable(w, err)
+		return
+	}
+
+	movies, err := c.service.GetAvailable(filter)
+	if err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+	if _, err := c.complete(w, r, movies); err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	util.WriteResponse(w, enc, http.StatusOK, movies)
+}
+
+// complete localizes the movies in place and attaches their collections, returning the locale of every name
+func (c *controller) complete(w http.ResponseWriter, r *http.Request, movies []model.Movie) ([]string, error) {
+	locales, err := c.localize(w, r, movies)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.service.AttachCollections(movies); err != nil {
+		return nil, err
+	}
+	return locales, nil
+}
+
+// localize translates the movies in place into the locales of the Accept-Language header, returning the locale of every name.
+// Requests without the header get the untranslated movies.
+func (c *controller) localize(w http.ResponseWriter, r *http.Request, movies []model.Movie) ([]string, error) {
+	w.Header().Add("Vary", "Accept-Language")
+
+	header := r.Header.Get("Accept-Language")
+	if header == "" {
+		return make([]string, len(movies)), nil
+	}
+	return c.service.Localize(movies, util.AcceptedLocales(header))
+}
+
+// parsePage reads the keyset page of the request, the ID the page follows and its size
+func parsePage(r *http.Request) (int, int, error) {
+	query := r.URL.Query()
+
+	limit, err := strconv.ParseInt(query.Get("limit"), 10, 0)
+	if err != nil || limit < 1 || limit > maxPageSize {
+		return 0, 0, fmt.Errorf("limit [%s] out of range", query.Get("limit"))
+	}
+
+	var afterID int64
+	if after := query.Get("after"); after != "" {
+		afterID, err = strconv.ParseInt(after, 10, 0)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	return int(afterID), int(limit), nil
+}
+
 func parseValidMovie(r *http.Request) (*model.Movie, error) {
 	var m model.Movie
 
 	// Return if the request-body cannot be decoded into a Movie object
-	if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
+	if err := util.DecodeRequest(r, &m); err != nil {
 		return nil, err
 	}
 
 	// Return if the requested Movie object is invalid
 	if err := m.Validate(); err != nil {
-		return nil, errors.New("invalid movie-object")
+		return nil, fmt.Errorf("invalid movie-object: %w", err)
 	}
 
 	return &m, nil
 }
-
-func handleMethodNotAllowed(w http.ResponseWriter, logMessage string) {
-	http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-	log.Println("[405 - Method Not Allowed] ", logMessage)
-}
-
-func handleServerError(w http.ResponseWriter, err error, message string) {
-	http.Error(w, message, http.StatusInternalServerError)
-	log.Println("[500 - Internal Server Error] ", err.Error())
-}
-
-func handleBadRequest(w http.ResponseWriter, responseMessage, logMessage string) {
-	http.Error(w, responseMessage, http.StatusBadRequest)
-	log.Println("[400 - Bad Request] ", logMessage)
-}
@@ -2,6 +2,7 @@ package movie_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -9,9 +10,11 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/Hunterlemming/golang-microservice-example/api/model"
 	"github.com/Hunterlemming/golang-microservice-example/api/movie"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
 
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
@@ -28,26 +31,76 @@ func (s *mockServiceStruct) GetMovies() ([]model.Movie, error) {
 	return args.Get(0).([]model.Movie), args.Error(1)
 }
 
+func (s *mockServiceStruct) GetMoviesPage(f model.MovieFilter, afterID, limit int) ([]model.Movie, error) {
+	args := s.Called(f, afterID, limit)
+	return args.Get(0).([]model.Movie), args.Error(1)
+}
+
+func (s *mockServiceStruct) CountMovies(f model.MovieFilter) (int, error) {
+	args := s.Called(f)
+	return args.Int(0), args.Error(1)
+}
+
 func (s *mockServiceStruct) GetMovie(id int) (model.Movie, error) {
 	args := s.Called(id)
 	return args.Get(0).(model.Movie), args.Error(1)
 }
 
-func (s *mockServiceStruct) CreateMovie(m *model.Movie) error {
+func (s *mockServiceStruct) CreateMovie(ctx context.Context, m *model.Movie) error {
 	args := s.Called(m)
 	return args.Error(0)
 }
 
-func (s *mockServiceStruct) UpdateMovie(id int, m *model.Movie) error {
+func (s *mockServiceStruct) UpdateMovie(ctx context.Context, id int, m *model.Movie) error {
 	args := s.Called(id, m)
 	return args.Error(0)
 }
 
-func (s *mockServiceStruct) DeleteMovie(id int) error {
+func (s *mockServiceStruct) DeleteMovie(ctx context.Context, id int) error {
 	args := s.Called(id)
 	return args.Error(0)
 }
 
+func (s *mockServiceStruct) Search(query string, limit int) ([]model.SearchResult, error) {
+	args := s.Called(query, limit)
+	return args.Get(0).([]model.SearchResult), args.Error(1)
+}
+
+func (s *mockServiceStruct) GetTrash() ([]model.Movie, error) {
+	args := s.Called()
+	return args.Get(0).([]model.Movie), args.Error(1)
+}
+
+func (s *mockServiceStruct) RestoreMovie(ctx context.Context, id int) error {
+	args := s.Called(id)
+	return args.Error(0)
+}
+
+func (s *mockServiceStruct) PurgeMovies(ctx context.Context, retention time.Duration) (int64, error) {
+	args := s.Called(retention)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (s *mockServiceStruct) Localize(movies []model.Movie, locales []string) ([]string, error) {
+	args := s.Called(movies, locales)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (s *mockServiceStruct) GetAvailable(f model.AvailabilityFilter) ([]model.Movie, error) {
+	args := s.Called(f)
+	return args.Get(0).([]model.Movie), args.Error(1)
+}
+
+func (s *mockServiceStruct) GetRedirect(id int) (int, error) {
+	args := s.Called(id)
+	return args.Int(0), args.Error(1)
+}
+
+// AttachCollections leaves the movies as they are, the collections being attached by the service
+func (s *mockServiceStruct) AttachCollections(movies []model.Movie) error {
+	return nil
+}
+
 // Setting up mockService and controller
 var mockService = new(mockServiceStruct)
 var controller = movie.NewMovieController(mockService)
@@ -73,6 +126,61 @@ func TestControllerGetMovies(t *testing.T) {
 	assert.Equal(t, jsonString(movies), rr.Body.String(), "The returned json-array should contain all the records in the database")
 }
 
+func TestControllerGetMoviesPage(t *testing.T) {
+	movies := []model.Movie{{ID: 3, Name: "test3"}, {ID: 4, Name: "test4"}}
+	mockService.On("GetMoviesPage", model.MovieFilter{}, 2, 2).Return(movies, nil).Once()
+
+	req, _ := http.NewRequest("GET", "/?after=2&limit=2", nil)
+	rr := execute("/", []string{"GET"}, req, controller.GetMovies)
+
+	status := http.StatusOK
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+	assert.Equal(t, jsonString(movies), rr.Body.String())
+	assert.Equal(t, `</movies?after=4&limit=2>; rel="next"`, rr.Header().Get("Link"), "A full page should link the next one")
+}
+
+func TestControllerGetMoviesLastPage(t *testing.T) {
+	movies := []model.Movie{{ID: 5, Name: "test5"}}
+	mockService.On("GetMoviesPage", model.MovieFilter{}, 4, 2).Return(movies, nil).Once()
+
+	req, _ := http.NewRequest("GET", "/?after=4&limit=2", nil)
+	rr := execute("/", []string{"GET"}, req, controller.GetMovies)
+
+	assert.Equal(t, "", rr.Header().Get("Link"), "The last page should not link any other")
+}
+
+func TestControllerGetMoviesFiltered(t *testing.T) {
+	movies := []model.Movie{{ID: 7, Name: "Lost in Translation"}}
+	filter := model.MovieFilter{Genre: "drama", Tag: "award-winner"}
+	mockService.On("GetMoviesPage", filter, 0, 0).Return(movies, nil).Once()
+
+	req, _ := http.NewRequest("GET", "/?genre=drama&tag=award-winner", nil)
+	rr := execute("/", []string{"GET"}, req, controller.GetMovies)
+
+	status := http.StatusOK
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+	assert.Equal(t, jsonString(movies), rr.Body.String())
+	assert.Equal(t, "", rr.Header().Get("Link"), "An unlimited list should not link any page")
+}
+
+func TestControllerGetMoviesFilteredPage(t *testing.T) {
+	movies := []model.Movie{{ID: 7, Name: "Lost in Translation"}}
+	mockService.On("GetMoviesPage", model.MovieFilter{Genre: "drama"}, 0, 1).Return(movies, nil).Once()
+
+	req, _ := http.NewRequest("GET", "/?genre=drama&limit=1", nil)
+	rr := execute("/", []string{"GET"}, req, controller.GetMovies)
+
+	assert.Equal(t, `</movies?after=7&genre=drama&limit=1>; rel="next"`, rr.Header().Get("Link"), "The next page should keep the filter")
+}
+
+func TestControllerGetMoviesPageParsingError(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/?limit=1000", nil)
+	rr := execute("/", []string{"GET"}, req, controller.GetMovies)
+
+	status := http.StatusBadRequest
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
 func TestControllerGetMoviesInvalidMethodError(t *testing.T) {
 	// Act
 	req, _ := http.NewRequest("POST", "/", nil)
@@ -96,6 +204,29 @@ func TestControllerGetMoviesServiceError(t *testing.T) {
 	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
 }
 
+func TestControllerGetMoviesCsv(t *testing.T) {
+	movies := []model.Movie{{ID: 1, Name: "test1"}}
+	mockService.On("GetMovies").Return(movies, nil).Once()
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", "text/csv")
+	rr := execute("/", []string{"GET"}, req, controller.GetMovies)
+
+	status := http.StatusOK
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+	assert.Equal(t, "text/csv; charset=utf-8", rr.Header().Get("Content-Type"))
+	assert.Equal(t, "id,name,synopsis,version,deleted_at,rating_average,rating_count,collection\n1,test1,,0,,0,0,\n", rr.Body.String())
+}
+
+func TestControllerGetMoviesNotAcceptableError(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", "image/png")
+	rr := execute("/", []string{"GET"}, req, controller.GetMovies)
+
+	status := http.StatusNotAcceptable
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
 func TestControllerGetMovie(t *testing.T) {
 	// Arrange
 	movie := model.Movie{
@@ -116,6 +247,83 @@ func TestControllerGetMovie(t *testing.T) {
 	assert.Equal(t, movie.ID, movieJson(rr.Body.Bytes()).ID, "The returned json should be correct")
 }
 
+func TestControllerGetMovieMergedRedirect(t *testing.T) {
+	mockService.On("GetMovie", 2).Return(model.Movie{}, &util.NotExistingRecordError{Identification: "ID: 2"}).Once()
+	mockService.On("GetRedirect", 2).Return(1, nil).Once()
+
+	req, _ := http.NewRequest("GET", "/movies/2", nil)
+	rr := execute("/movies/{id}", []string{"GET"}, req, controller.GetMovie)
+
+	status := http.StatusTemporaryRedirect
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+	assert.Equal(t, "/movies/1", rr.Header().Get("Location"))
+}
+
+func TestControllerGetMovieNotFoundError(t *testing.T) {
+	mockService.On("GetMovie", 3).Return(model.Movie{}, &util.NotExistingRecordError{Identification: "ID: 3"}).Once()
+	mockService.On("GetRedirect", 3).Return(0, &util.NotExistingRecordError{Identification: "redirect ID: 3"}).Once()
+
+	req, _ := http.NewRequest("GET", "/movies/3", nil)
+	rr := execute("/movies/{id}", []string{"GET"}, req, controller.GetMovie)
+
+	status := http.StatusNotFound
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerGetMovieLocalized(t *testing.T) {
+	mockService.On("GetMovie", 1).Return(model.Movie{ID: 1, Name: "Spirited Away"}, nil).Once()
+	mockService.On("Localize", []model.Movie{{ID: 1, Name: "Spirited Away"}}, []string{"de-AT", "de", "en"}).
+		Run(func(args mock.Arguments) { args.Get(0).([]model.Movie)[0].Name = "Chihiros Reise ins Zauberland" }).
+		Return([]string{"de"}, nil).Once()
+
+	req, _ := http.NewRequest("GET", "/1", nil)
+	req.Header.Set("Accept-Language", "de-AT, en;q=0.5")
+	rr := execute("/{id}", []string{"GET"}, req, controller.GetMovie)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "Chihiros Reise ins Zauberland", movieJson(rr.Body.Bytes()).Name)
+	assert.Equal(t, "de", rr.Header().Get("Content-Language"))
+	assert.Equal(t, "Accept-Language", rr.Header().Get("Vary"))
+}
+
+func TestControllerSearchMoviesLocalized(t *testing.T) {
+	results := []model.SearchResult{{Movie: model.Movie{ID: 1, Name: "Spirited Away"}, Rank: 0.1, Snippet: "<b>Chihiros</b> Reise"}}
+	mockService.On("Search", "chihiros", 20).Return(results, nil).Once()
+	mockService.On("Localize", []model.Movie{{ID: 1, Name: "Spirited Away"}}, []string{"de"}).
+		Run(func(args mock.Arguments) { args.Get(0).([]model.Movie)[0].Name = "Chihiros Reise" }).
+		Return([]string{"de"}, nil).Once()
+
+	req, _ := http.NewRequest("GET", "/search?q=chihiros", nil)
+	req.Header.Set("Accept-Language", "de")
+	rr := execute("/search", []string{"GET"}, req, controller.SearchMovies)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `[{"movie":{"id":1,"name":"Chihiros Reise"},"rank":0.1,"snippet":"\u003cb\u003eChihiros\u003c/b\u003e Reise"}]`, rr.Body.String())
+}
+
+func TestControllerGetMovieXml(t *testing.T) {
+	movie := model.Movie{ID: 1, Name: "test"}
+	mockService.On("GetMovie", 1).Return(movie, nil).Once()
+
+	req, _ := http.NewRequest("GET", "/1", nil)
+	req.Header.Set("Accept", "application/xml")
+	rr := execute("/{id}", []string{"GET"}, req, controller.GetMovie)
+
+	status := http.StatusOK
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+	assert.Equal(t, "application/xml; charset=utf-8", rr.Header().Get("Content-Type"))
+	assert.Equal(t, "<Movie><id>1</id><name>test</name></Movie>", rr.Body.String())
+}
+
+func TestControllerGetMovieNotAcceptableError(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/1", nil)
+	req.Header.Set("Accept", "text/csv")
+	rr := execute("/{id}", []string{"GET"}, req, controller.GetMovie)
+
+	status := http.StatusNotAcceptable
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
 func TestControllerGetMovieInvalidMethodError(t *testing.T) {
 	req, _ := http.NewRequest("POST", "/1", nil)
 	rr := execute("/{id}", []string{"POST"}, req, controller.GetMovie)
@@ -175,6 +383,27 @@ func TestControllerCreateMovieBodyParsingError(t *testing.T) {
 	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
 }
 
+func TestControllerCreateMovieXml(t *testing.T) {
+	movie := model.Movie{ID: 1, Name: "test"}
+	mockService.On("CreateMovie", &movie).Return(nil).Once()
+
+	req, _ := http.NewRequest("POST", "/", bytes.NewBufferString("<Movie><id>1</id><name>test</name></Movie>"))
+	req.Header.Set("Content-Type", "application/xml")
+	rr := execute("/", []string{"POST"}, req, controller.CreateMovie)
+
+	status := http.StatusCreated
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerCreateMovieUnsupportedMediaTypeError(t *testing.T) {
+	req, _ := http.NewRequest("POST", "/", bytes.NewBufferString("id,name\n1,test"))
+	req.Header.Set("Content-Type", "text/csv")
+	rr := execute("/", []string{"POST"}, req, controller.CreateMovie)
+
+	status := http.StatusUnsupportedMediaType
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
 func TestControllerCreateMovieServiceError(t *testing.T) {
 	movieBytes, _ := json.Marshal(model.Movie{ID: 1, Name: "test"})
 	mockService.On("CreateMovie", mock.Anything).Return(errors.New("test-error-message")).Once()
@@ -236,6 +465,17 @@ func TestControllerUpdateMovieServiceError(t *testing.T) {
 	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
 }
 
+func TestControllerUpdateMovieStaleVersionError(t *testing.T) {
+	movieBytes, _ := json.Marshal(model.Movie{Name: "test", Version: 1})
+	mockService.On("UpdateMovie", 1, mock.Anything).Return(&util.StaleRecordError{Identification: "ID: 1", Version: 1}).Once()
+
+	req, _ := http.NewRequest("PUT", "/1", bytes.NewBuffer(movieBytes))
+	rr := execute("/{id}", []string{"PUT"}, req, controller.UpdateMovie)
+
+	status := http.StatusConflict
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
 func TestControllerDeleteMovie(t *testing.T) {
 	mockService.On("DeleteMovie", 1).Return(nil).Once()
 
@@ -249,6 +489,16 @@ func TestControllerDeleteMovie(t *testing.T) {
 	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
 }
 
+func TestControllerDeleteMovieNotFoundError(t *testing.T) {
+	mockService.On("DeleteMovie", 2).Return(&util.NotExistingRecordError{Identification: "ID: 2"}).Once()
+
+	req, _ := http.NewRequest("DELETE", "/2", nil)
+	rr := execute("/{id}", []string{"DELETE"}, req, controller.DeleteMovie)
+
+	status := http.StatusNotFound
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
 func TestControllerDeleteMovieInvalidMethodError(t *testing.T) {
 	req, _ := http.NewRequest("GET", "/1", nil)
 	rr := execute("/{id}", []string{"GET"}, req, controller.DeleteMovie)
@@ -275,6 +525,154 @@ func TestControllerDeleteMovieServiceError(t *testing.T) {
 	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
 }
 
+func TestControllerSearchMovies(t *testing.T) {
+	results := []model.SearchResult{{Movie: model.Movie{ID: 1, Name: "test"}, Rank: 0.5, Snippet: "<b>test</b>"}}
+	mockService.On("Search", "test", 5).Return(results, nil).Once()
+
+	req, _ := http.NewRequest("GET", "/search?q=test&limit=5", nil)
+	rr := execute("/search", []string{"GET"}, req, controller.SearchMovies)
+
+	if !mockService.AssertCalled(t, "Search", "test", 5) {
+		t.Error("The service should be called")
+	}
+	status := http.StatusOK
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+	assert.Equal(t, jsonString(results), rr.Body.String(), "The returned json-array should contain the ranked results")
+}
+
+func TestControllerSearchMoviesInvalidMethodError(t *testing.T) {
+	req, _ := http.NewRequest("POST", "/search?q=test", nil)
+	rr := execute("/search", []string{"POST"}, req, controller.SearchMovies)
+
+	status := http.StatusMethodNotAllowed
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerSearchMoviesMissingQueryError(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/search?q=%20", nil)
+	rr := execute("/search", []string{"GET"}, req, controller.SearchMovies)
+
+	status := http.StatusBadRequest
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerSearchMoviesLimitParsingError(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/search?q=test&limit=1000", nil)
+	rr := execute("/search", []string{"GET"}, req, controller.SearchMovies)
+
+	status := http.StatusBadRequest
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerSearchMoviesServiceError(t *testing.T) {
+	mockService.On("Search", "test", 20).Return([]model.SearchResult{}, errors.New("test-error-message")).Once()
+
+	req, _ := http.NewRequest("GET", "/search?q=test", nil)
+	rr := execute("/search", []string{"GET"}, req, controller.SearchMovies)
+
+	status := http.StatusInternalServerError
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerGetTrash(t *testing.T) {
+	deletedAt := time.Now().UTC()
+	movies := []model.Movie{{ID: 1, Name: "test1", DeletedAt: &deletedAt}}
+	mockService.On("GetTrash").Return(movies, nil).Once()
+
+	req, _ := http.NewRequest("GET", "/trash", nil)
+	rr := execute("/trash", []string{"GET"}, req, controller.GetTrash)
+
+	status := http.StatusOK
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+	assert.Equal(t, jsonString(movies), rr.Body.String(), "The returned json-array should contain the deleted records")
+}
+
+func TestControllerGetTrashServiceError(t *testing.T) {
+	mockService.On("GetTrash").Return([]model.Movie{}, errors.New("test-error-message")).Once()
+
+	req, _ := http.NewRequest("GET", "/trash", nil)
+	rr := execute("/trash", []string{"GET"}, req, controller.GetTrash)
+
+	status := http.StatusInternalServerError
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerRestoreMovie(t *testing.T) {
+	mockService.On("RestoreMovie", 1).Return(nil).Once()
+
+	req, _ := http.NewRequest("POST", "/1:restore", nil)
+	rr := execute("/{id}:restore", []string{"POST"}, req, controller.RestoreMovie)
+
+	if !mockService.AssertCalled(t, "RestoreMovie", 1) {
+		t.Error("The service should be called")
+	}
+	status := http.StatusOK
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerRestoreMovieNotFoundError(t *testing.T) {
+	mockService.On("RestoreMovie", 2).Return(&util.NotExistingRecordError{Identification: "ID: 2"}).Once()
+
+	req, _ := http.NewRequest("POST", "/2:restore", nil)
+	rr := execute("/{id}:restore", []string{"POST"}, req, controller.RestoreMovie)
+
+	status := http.StatusNotFound
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerRestoreMovieIdParsingError(t *testing.T) {
+	req, _ := http.NewRequest("POST", "/not-an-int:restore", nil)
+	rr := execute("/{id}:restore", []string{"POST"}, req, controller.RestoreMovie)
+
+	status := http.StatusBadRequest
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerPurgeTrash(t *testing.T) {
+	mockService.On("PurgeMovies", 48*time.Hour).Return(int64(2), nil).Once()
+
+	req, _ := http.NewRequest("DELETE", "/trash?retention=48h", nil)
+	rr := execute("/trash", []string{"DELETE"}, req, controller.PurgeTrash)
+
+	if !mockService.AssertCalled(t, "PurgeMovies", 48*time.Hour) {
+		t.Error("The service should be called")
+	}
+	status := http.StatusOK
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+	assert.Equal(t, `{"purged":2}`, rr.Body.String())
+}
+
+func TestControllerPurgeTrashRetentionParsingError(t *testing.T) {
+	req, _ := http.NewRequest("DELETE", "/trash?retention=a-while", nil)
+	rr := execute("/trash", []string{"DELETE"}, req, controller.PurgeTrash)
+
+	status := http.StatusBadRequest
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerGetAvailableMovies(t *testing.T) {
+	at := time.Date(2026, 10, 1, 20, 0, 0, 0, time.UTC)
+	movies := []model.Movie{{ID: 1, Name: "Spirited Away"}}
+	mockService.On("GetAvailable", model.AvailabilityFilter{Region: "DE", At: at, Kind: model.WindowRental}).Return(movies, nil).Once()
+
+	req, _ := http.NewRequest("GET", "/available?region=de&at=2026-10-01T20:00:00Z&kind=rental", nil)
+	rr := execute("/available", []string{"GET"}, req, controller.GetAvailableMovies)
+
+	status := http.StatusOK
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+	assert.Equal(t, jsonString(movies), rr.Body.String())
+}
+
+func TestControllerGetAvailableMoviesParsingError(t *testing.T) {
+	for _, query := range []string{"", "?region=DEU", "?region=DE&at=tomorrow", "?region=DE&kind=broadcast"} {
+		req, _ := http.NewRequest("GET", "/available"+query, nil)
+		rr := execute("/available", []string{"GET"}, req, controller.GetAvailableMovies)
+
+		status := http.StatusBadRequest
+		assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d] for %s", status, query))
+	}
+}
+
 func execute(route string, methods []string, req *http.Request, handler func(w http.ResponseWriter, r *http.Request)) *httptest.ResponseRecorder {
 	r := mux.NewRouter()
 	r.HandleFunc(route, handler).Methods(methods...)
@@ -0,0 +1,19 @@
+package movie
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+)
+
+// Exists fails with a NotExistingRecordError if the movie does not exist or is in the trash.
+// It serves the services of the records linked to the movies.
+func Exists(db util.QueryRower, id int) error {
+	const q = "SELECT id FROM movies WHERE id = $1 AND deleted_at IS NULL"
+	err := db.QueryRow(q, id).Scan(&id)
+	if err == sql.ErrNoRows {
+		return &util.NotExistingRecordError{Identification: fmt.Sprintf("ID: %v", id)}
+	}
+	return err
+}
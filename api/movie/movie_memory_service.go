@@ -0,0 +1,286 @@
+package movie
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+)
+
+// Minimal similarity for fuzzy matches, the default threshold of pg_trgm
+const similarityThreshold = 0.3
+
+type memoryService struct {
+	mu     sync.RWMutex
+	movies map[int]model.Movie
+}
+
+// NewInMemoryMovieService returns a MovieService keeping its records in memory.
+// It mirrors the behaviour of the database-backed service, except for the audit log, and is meant for tests.
+func NewInMemoryMovieService(movies ...model.Movie) MovieService {
+	s := &memoryService{movies: make(map[int]model.Movie)}
+	for _, m := range movies {
+		// Seeded records start at the first version, like the rows of the database
+		if m.Version == 0 {
+			m.Version = 1
+		}
+		s.movies[m.ID] = m
+	}
+	return s
+}
+
+func (s *memoryService) GetMovies() ([]model.Movie, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]model.Movie, 0, len(s.movies))
+	for _, m := range s.movies {
+		if m.DeletedAt == nil {
+			result = append(result, m)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result, nil
+}
+
+// GetMoviesPage finds no movies of a genre or tag, as the in-memory movies have none
+func (s *memoryService) GetMoviesPage(f model.MovieFilter, afterID, limit int) ([]model.Movie, error) {
+	result := make([]model.Movie, 0, limit)
+	if f.Genre != "" || f.Tag != "" {
+		return result, nil
+	}
+	movies, _ := s.GetMovies()
+	for _, m := range movies {
+		if !strings.Contains(strings.ToLower(m.Name), strings.ToLower(f.Name)) {
+			continue
+		}
+		if m.ID > afterID && (limit <= 0 || len(result) < limit) {
+			result = append(result, m)
+		}
+	}
+	return result, nil
+}
+
+func (s *memoryService) CountMovies(f model.MovieFilter) (int, error) {
+	movies, err := s.GetMoviesPage(f, 0, 0)
+	return len(movies), err
+}
+
+func (s *memoryService) GetMovie(id int) (model.Movie, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	m, ok := s.movies[id]
+	if !ok || m.DeletedAt != nil {
+		return model.Movie{}, &util.NotExistingRecordError{Identification: fmt.Sprintf("ID: %v", id)}
+	}
+	return m, nil
+}
+
+func (s *memoryService) CreateMovie(ctx context.Context, m *model.Movie) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.movies[m.ID]; ok {
+		return &util.ExistingRecordError{Identification: fmt.Sprintf("ID: %v", m.ID)}
+	}
+	// The ratings and the collection are maintained elsewhere, like the columns left out of the insert
+	m.Version = 1
+	s.movies[m.ID] = model.Movie{ID: m.ID, Name: m.Name, Synopsis: m.Synopsis, Version: m.Version}
+	return nil
+}
+
+func (s *memoryService) UpdateMovie(ctx context.Context, id int, m *model.Movie) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.movies[id]
+	if !ok || existing.DeletedAt != nil {
+		return &util.NotExistingRecordError{Identification: fmt.Sprintf("ID: %v", id)}
+	}
+	if m.Version != 0 && m.Version != existing.Version {
+		return &util.StaleRecordError{Identification: fmt.Sprintf("ID: %v", id), Version: m.Version}
+	}
+	updated := existing
+	updated.Name, updated.Synopsis = m.Name, m.Synopsis
+	updated.Version = existing.Version + 1
+	s.movies[id] = updated
+	m.Version = updated.Version
+	return nil
+}
+
+func (s *memoryService) DeleteMovie(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.movies[id]
+	if !ok || m.DeletedAt != nil {
+		return &util.NotExistingRecordError{Identification: fmt.Sprintf("ID: %v", id)}
+	}
+	now := time.Now()
+	m.DeletedAt = &now
+	s.movies[id] = m
+	return nil
+}
+
+func (s *memoryService) GetTrash() ([]model.Movie, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]model.Movie, 0)
+	for _, m := range s.movies {
+		if m.DeletedAt != nil {
+			result = append(result, m)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].DeletedAt.After(*result[j].DeletedAt) })
+	return result, nil
+}
+
+func (s *memoryService) RestoreMovie(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.movies[id]
+	if !ok || m.DeletedAt == nil {
+		return &util.NotExistingRecordError{Identification: fmt.Sprintf("ID: %v", id)}
+	}
+	m.DeletedAt = nil
+	s.movies[id] = m
+	return nil
+}
+
+func (s *memoryService) PurgeMovies(ctx context.Context, retention time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cutoff := time.Now().Add(-retention)
+	var purged int64
+	for id, m := range s.movies {
+		if m.DeletedAt != nil && m.DeletedAt.Before(cutoff) {
+			delete(s.movies, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// Localize leaves the movies untranslated, as the in-memory catalog keeps no translations
+func (s *memoryService) Localize(movies []model.Movie, locales []string) ([]string, error) {
+	return make([]string, len(movies)), nil
+}
+
+// GetAvailable finds no movies, as the in-memory movies are not released anywhere
+func (s *memoryService) GetAvailable(f model.AvailabilityFilter) ([]model.Movie, error) {
+	return []model.Movie{}, nil
+}
+
+// GetRedirect finds no redirects, as the in-memory movies are not merged
+func (s *memoryService) GetRedirect(id int) (int, error) {
+	return 0, &util.NotExistingRecordError{Identification: fmt.Sprintf("redirect ID: %v", id)}
+}
+
+// AttachCollections leaves the movies without collections, as the in-memory catalog keeps none
+func (s *memoryService) AttachCollections(movies []model.Movie) error {
+	return nil
+}
+
+func (s *memoryService) Search(query string, limit int) ([]model.SearchResult, error) {
+	movies, _ := s.GetMovies()
+	terms := util.Words(query)
+	if len(terms) == 0 {
+		return []model.SearchResult{}, nil
+	}
+
+	// Every term has to be present in the name, like the AND-ed terms of a tsquery
+	result := make([]model.SearchResult, 0)
+	for _, m := range movies {
+		words := util.Words(m.Name)
+		if matched := countMatches(words, terms); matched > 0 {
+			result = append(result, model.SearchResult{
+				Movie:   m,
+				Rank:    float64(matched) / float64(len(words)),
+				Snippet: highlight(m.Name, terms),
+			})
+		}
+	}
+
+	// Falling back to trigram similarity, so misspelled titles are still found
+	if len(result) == 0 {
+		for _, m := range movies {
+			if similarity := util.TrigramSimilarity(m.Name, query); similarity >= similarityThreshold {
+				result = append(result, model.SearchResult{Movie: m, Rank: similarity, Snippet: html.EscapeString(m.Name)})
+			}
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Rank > result[j].Rank })
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+// countMatches returns how many of the words are search terms, or 0 if any of the terms is missing
+func countMatches(words, terms []string) int {
+	present := make(map[string]int)
+	for _, w := range words {
+		present[w]++
+	}
+
+	matched := 0
+	for _, t := range unique(terms) {
+		if present[t] == 0 {
+			return 0
+		}
+		matched += present[t]
+	}
+	return matched
+}
+
+func unique(values []string) []string {
+	seen := make(map[string]bool)
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// highlight wraps the words of the text matching any of the terms the same way ts_headline does, escaping the rest as HTML
+func highlight(text string, terms []string) string {
+	isTerm := make(map[string]bool)
+	for _, t := range terms {
+		isTerm[t] = true
+	}
+
+	var b, word strings.Builder
+	flush := func() {
+		if w := word.String(); isTerm[strings.ToLower(w)] {
+			b.WriteString("<b>" + w + "</b>")
+		} else {
+			b.WriteString(w)
+		}
+		word.Reset()
+	}
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) {
+			word.WriteRune(r)
+			continue
+		}
+		flush()
+		b.WriteString(html.EscapeString(string(r)))
+	}
+	flush()
+	return b.String()
+}
@@ -0,0 +1,123 @@
+package movie_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/movie"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryServiceCrud(t *testing.T) {
+	service := movie.NewInMemoryMovieService(model.Movie{ID: 2, Name: "second"})
+	ctx := context.Background()
+
+	assert.Nil(t, service.CreateMovie(ctx, &model.Movie{ID: 1, Name: "first"}))
+	assert.IsType(t, &util.ExistingRecordError{}, service.CreateMovie(ctx, &model.Movie{ID: 1, Name: "again"}))
+	assert.Nil(t, service.UpdateMovie(ctx, 2, &model.Movie{Name: "updated"}))
+	assert.IsType(t, &util.NotExistingRecordError{}, service.UpdateMovie(ctx, 3, &model.Movie{Name: "missing"}))
+
+	movies, err := service.GetMovies()
+	assert.Nil(t, err)
+	assert.Equal(t, []model.Movie{{ID: 1, Name: "first", Version: 1}, {ID: 2, Name: "updated", Version: 2}}, movies)
+
+	assert.Nil(t, service.DeleteMovie(ctx, 1))
+	_, err = service.GetMovie(1)
+	assert.NotNil(t, err)
+}
+
+func TestMemoryServicePages(t *testing.T) {
+	service := movie.NewInMemoryMovieService(model.Movie{ID: 1, Name: "first"}, model.Movie{ID: 2, Name: "second"}, model.Movie{ID: 3, Name: "third"})
+
+	page, _ := service.GetMoviesPage(model.MovieFilter{}, 0, 2)
+	assert.Equal(t, []model.Movie{{ID: 1, Name: "first", Version: 1}, {ID: 2, Name: "second", Version: 1}}, page)
+	page, _ = service.GetMoviesPage(model.MovieFilter{}, 2, 2)
+	assert.Equal(t, []model.Movie{{ID: 3, Name: "third", Version: 1}}, page)
+	page, _ = service.GetMoviesPage(model.MovieFilter{Genre: "drama"}, 0, 0)
+	assert.Empty(t, page, "The in-memory movies should have no genres")
+}
+
+func TestMemoryServiceUpdateStaleVersion(t *testing.T) {
+	service := movie.NewInMemoryMovieService(model.Movie{ID: 1, Name: "first"})
+	ctx := context.Background()
+
+	assert.Nil(t, service.UpdateMovie(ctx, 1, &model.Movie{Name: "second", Version: 1}))
+	err := service.UpdateMovie(ctx, 1, &model.Movie{Name: "concurrent", Version: 1})
+
+	assert.IsType(t, &util.StaleRecordError{}, err, "Changes to an outdated version should be rejected")
+	m, _ := service.GetMovie(1)
+	assert.Equal(t, model.Movie{ID: 1, Name: "second", Version: 2}, m)
+}
+
+func TestMemoryServiceSearch(t *testing.T) {
+	service := movie.NewInMemoryMovieService(
+		model.Movie{ID: 1, Name: "The Lord of the Rings"},
+		model.Movie{ID: 2, Name: "Lord of War"},
+		model.Movie{ID: 3, Name: "Alien"},
+	)
+
+	res, err := service.Search("lord rings", 10)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(res), "Every term of the query should be matched")
+	assert.Equal(t, "The <b>Lord</b> of the <b>Rings</b>", res[0].Snippet)
+}
+
+func TestMemoryServiceSearchEscapesSnippets(t *testing.T) {
+	service := movie.NewInMemoryMovieService(model.Movie{ID: 1, Name: `Alien <script>alert("x")</script>`})
+
+	res, _ := service.Search("alien", 10)
+
+	assert.Equal(t, "<b>Alien</b> &lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt;", res[0].Snippet, "Only the highlighting should be markup")
+}
+
+func TestMemoryServiceSearchRanking(t *testing.T) {
+	service := movie.NewInMemoryMovieService(
+		model.Movie{ID: 1, Name: "The Lord of the Rings"},
+		model.Movie{ID: 2, Name: "Lord of War"},
+	)
+
+	res, _ := service.Search("lord", 1)
+
+	assert.Equal(t, 1, len(res), "The results should be limited")
+	assert.Equal(t, 2, res[0].Movie.ID, "The denser match should be ranked first")
+}
+
+func TestMemoryServiceSearchFuzzyFallback(t *testing.T) {
+	service := movie.NewInMemoryMovieService(model.Movie{ID: 1, Name: "Matrix"}, model.Movie{ID: 2, Name: "Alien"})
+
+	res, err := service.Search("Matrics", 10)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(res))
+	assert.Equal(t, 1, res[0].Movie.ID)
+}
+
+func TestMemoryServiceTrash(t *testing.T) {
+	service := movie.NewInMemoryMovieService(model.Movie{ID: 1, Name: "first"}, model.Movie{ID: 2, Name: "second"})
+	ctx := context.Background()
+
+	assert.Nil(t, service.DeleteMovie(ctx, 1))
+	assert.IsType(t, &util.NotExistingRecordError{}, service.DeleteMovie(ctx, 1), "Deleting twice should fail")
+	assert.IsType(t, &util.NotExistingRecordError{}, service.DeleteMovie(ctx, 3))
+
+	trash, _ := service.GetTrash()
+	assert.Equal(t, 1, len(trash))
+	assert.Equal(t, 1, trash[0].ID)
+
+	movies, _ := service.GetMovies()
+	assert.Equal(t, []model.Movie{{ID: 2, Name: "second", Version: 1}}, movies)
+
+	assert.Nil(t, service.RestoreMovie(ctx, 1))
+	assert.IsType(t, &util.NotExistingRecordError{}, service.RestoreMovie(ctx, 2), "Only trashed records can be restored")
+
+	assert.Nil(t, service.DeleteMovie(ctx, 2))
+	purged, _ := service.PurgeMovies(ctx, time.Hour)
+	assert.Equal(t, int64(0), purged, "Records within the retention period should be kept")
+	purged, _ = service.PurgeMovies(ctx, 0)
+	assert.Equal(t, int64(1), purged)
+}
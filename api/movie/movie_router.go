@@ -7,7 +7,11 @@ import (
 )
 
 func InitializeMoviesPipeline(api *model.Api) {
-	s := NewMovieService(api.DB)
+	InitializeMoviesPipelineWithService(api, NewMovieService(api.DB))
+}
+
+// InitializeMoviesPipelineWithService serves the movies of the given service, such as the in-memory one
+func InitializeMoviesPipelineWithService(api *model.Api, s MovieService) {
 	c := NewMovieController(s)
 	setRouting(api.Router, c)
 }
@@ -18,6 +22,19 @@ func setRouting(main *mux.Router, c MovieController) {
 	sr.HandleFunc("", c.GetMovies).
 		Methods("GET")
 
+	// Registered ahead of "/{id}", which would match it otherwise
+	sr.HandleFunc("/search", c.SearchMovies).
+		Methods("GET")
+
+	sr.HandleFunc("/available", c.GetAvailableMovies).
+		Methods("GET")
+
+	sr.HandleFunc("/trash", c.GetTrash).
+		Methods("GET")
+
+	sr.HandleFunc("/trash", c.PurgeTrash).
+		Methods("DELETE")
+
 	sr.HandleFunc("/{id}", c.GetMovie).
 		Methods("GET")
 
@@ -29,4 +46,7 @@ func setRouting(main *mux.Router, c MovieController) {
 
 	sr.HandleFunc("/{id}", c.DeleteMovie).
 		Methods("DELETE")
+
+	sr.HandleFunc("/{id}:restore", c.RestoreMovie).
+		Methods("POST")
 }
@@ -6,12 +6,14 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/Hunterlemming/golang-microservice-example/api/model"
 	"github.com/Hunterlemming/golang-microservice-example/api/movie"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/gorilla/mux"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -27,14 +29,18 @@ func TestInitializeMoviesPipeline(t *testing.T) {
 
 	testIntegrationGetAll(t, mock, api.Router)
 	testIntegrationGetOne(t, mock, api.Router)
+	testIntegrationSearch(t, mock, api.Router)
+	testIntegrationAvailable(t, mock, api.Router)
 	testIntegrationCreate(t, mock, api.Router)
 	testIntegrationUpdate(t, mock, api.Router)
 	testIntegrationDelete(t, mock, api.Router)
+	testIntegrationRestore(t, mock, api.Router)
 }
 
 func testIntegrationGetAll(t *testing.T, mock sqlmock.Sqlmock, r *mux.Router) {
 	getAllResult := []model.Movie{{ID: 1, Name: "t1"}, {ID: 2, Name: "t2"}}
 	mock.ExpectQuery(GetAllQuery).WillReturnRows(newRows(&getAllResult))
+	mock.ExpectQuery(CollectionsQuery).WillReturnRows(newCollectionRows())
 
 	req, _ := http.NewRequest("GET", "/movies", nil)
 	rr := executeWithRouter(r, req)
@@ -48,6 +54,8 @@ func testIntegrationGetAll(t *testing.T, mock sqlmock.Sqlmock, r *mux.Router) {
 func testIntegrationGetOne(t *testing.T, mock sqlmock.Sqlmock, r *mux.Router) {
 	getOneResult := model.Movie{ID: 1, Name: "t1"}
 	mock.ExpectQuery(GetOneQuery).WillReturnRows(newRows(&[]model.Movie{getOneResult}))
+	mock.ExpectQuery(CollectionsQuery).WithArgs(pq.Array([]int64{1})).WillReturnRows(newCollectionRows().AddRow(1, 4, "Trilogy", 2))
+	getOneResult.Collection = &model.CollectionRef{ID: 4, Name: "Trilogy", Position: 2}
 
 	req, _ := http.NewRequest("GET", "/movies/1", nil)
 	rr := executeWithRouter(r, req)
@@ -58,12 +66,44 @@ func testIntegrationGetOne(t *testing.T, mock sqlmock.Sqlmock, r *mux.Router) {
 	}
 }
 
+func testIntegrationSearch(t *testing.T, mock sqlmock.Sqlmock, r *mux.Router) {
+	results := []model.SearchResult{{Movie: model.Movie{ID: 1, Name: "t1"}, Rank: 0.1, Snippet: "<b>t1</b>"}}
+	mock.ExpectQuery(SearchQuery).WithArgs("t1", 20).WillReturnRows(newSearchRows(&results))
+	mock.ExpectQuery(CollectionsQuery).WillReturnRows(newCollectionRows())
+
+	req, _ := http.NewRequest("GET", "/movies/search?q=t1", nil)
+	rr := executeWithRouter(r, req)
+
+	assert.Equal(t, jsonString(results), rr.Body.String())
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func testIntegrationAvailable(t *testing.T, mock sqlmock.Sqlmock, r *mux.Router) {
+	movies := []model.Movie{{ID: 1, Name: "t1"}}
+	mock.ExpectQuery(AvailableQuery).WithArgs("DE", time.Date(2026, 10, 1, 20, 0, 0, 0, time.UTC)).WillReturnRows(newRows(&movies))
+	mock.ExpectQuery(CollectionsQuery).WillReturnRows(newCollectionRows())
+
+	req, _ := http.NewRequest("GET", "/movies/available?region=DE&at=2026-10-01T20:00:00Z", nil)
+	rr := executeWithRouter(r, req)
+
+	assert.Equal(t, jsonString(movies), rr.Body.String())
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
 func testIntegrationCreate(t *testing.T, mock sqlmock.Sqlmock, r *mux.Router) {
 	movie := model.Movie{ID: 1, Name: "test"}
 	movieBytes, _ := json.Marshal(movie)
-	mock.ExpectQuery(GetOneQuery).WillReturnRows(newRows(&[]model.Movie{}))
-	mock.ExpectExec(CreateMovieQuery).WithArgs(movie.ID, movie.Name).
+	mock.ExpectBegin()
+	mock.ExpectExec(CreateMovieQuery).WithArgs(movie.ID, movie.Name, movie.Synopsis).
 		WillReturnResult(sqlmock.NewResult(int64(movie.ID), 1))
+	mock.ExpectExec(DropRedirectQuery).WithArgs(movie.ID).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(AuditQuery).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(OutboxQuery).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
 
 	req, _ := http.NewRequest("POST", "/movies", bytes.NewBuffer(movieBytes))
 	rr := executeWithRouter(r, req)
@@ -76,12 +116,16 @@ func testIntegrationCreate(t *testing.T, mock sqlmock.Sqlmock, r *mux.Router) {
 }
 
 func testIntegrationUpdate(t *testing.T, mock sqlmock.Sqlmock, r *mux.Router) {
-	movies := []model.Movie{{Name: "test"}}
+	movies := []model.Movie{{Name: "test", Version: 1}}
 	updatedMovie := model.Movie{ID: 1, Name: "updated"}
 	updatedMovieBytes, _ := json.Marshal(updatedMovie)
 	mock.ExpectQuery(GetOneQuery).WillReturnRows(newRows(&movies))
-	mock.ExpectExec(UpdateQuery).WithArgs(updatedMovie.Name, updatedMovie.ID).
+	mock.ExpectBegin()
+	mock.ExpectExec(UpdateQuery).WithArgs(updatedMovie.Name, updatedMovie.Synopsis, updatedMovie.ID, 1).
 		WillReturnResult(sqlmock.NewResult(int64(updatedMovie.ID), 1))
+	mock.ExpectExec(AuditQuery).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(OutboxQuery).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
 
 	req, _ := http.NewRequest("PUT", "/movies/1", bytes.NewBuffer(updatedMovieBytes))
 	rr := executeWithRouter(r, req)
@@ -95,8 +139,12 @@ func testIntegrationUpdate(t *testing.T, mock sqlmock.Sqlmock, r *mux.Router) {
 
 func testIntegrationDelete(t *testing.T, mock sqlmock.Sqlmock, r *mux.Router) {
 	deletedIndex := 1
-	mock.ExpectExec(DeleteQuery).WithArgs(deletedIndex).
-		WillReturnResult(sqlmock.NewResult(int64(deletedIndex), 1))
+	mock.ExpectBegin()
+	mock.ExpectQuery(DeleteQuery).WithArgs(deletedIndex).
+		WillReturnRows(newRows(&[]model.Movie{{ID: deletedIndex, Name: "test"}}))
+	mock.ExpectExec(AuditQuery).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(OutboxQuery).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
 
 	req, _ := http.NewRequest("DELETE", "/movies/1", nil)
 	rr := executeWithRouter(r, req)
@@ -108,6 +156,25 @@ func testIntegrationDelete(t *testing.T, mock sqlmock.Sqlmock, r *mux.Router) {
 	}
 }
 
+func testIntegrationRestore(t *testing.T, mock sqlmock.Sqlmock, r *mux.Router) {
+	restoredIndex := 1
+	mock.ExpectBegin()
+	mock.ExpectQuery(RestoreQuery).WithArgs(restoredIndex).
+		WillReturnRows(newRows(&[]model.Movie{{ID: restoredIndex, Name: "test"}}))
+	mock.ExpectExec(AuditQuery).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(OutboxQuery).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	req, _ := http.NewRequest("POST", "/movies/1:restore", nil)
+	rr := executeWithRouter(r, req)
+
+	status := http.StatusOK
+	assert.Equal(t, status, rr.Code)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
 func executeWithRouter(router *mux.Router, req *http.Request) *httptest.ResponseRecorder {
 	rr := httptest.NewRecorder()
 	router.ServeHTTP(rr, req)
@@ -1,23 +1,43 @@
 package movie
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/Hunterlemming/golang-microservice-example/api/audit"
+	"github.com/Hunterlemming/golang-microservice-example/api/event"
 	"github.com/Hunterlemming/golang-microservice-example/api/model"
 	"github.com/Hunterlemming/golang-microservice-example/api/util"
+
+	"github.com/lib/pq"
 )
 
+// Columns of the movies as scanned into model.Movie
+const columns = "id, name, synopsis, version, rating_average, rating_count"
+
 type service struct {
 	db *sql.DB
 }
 
 type MovieService interface {
 	GetMovies() ([]model.Movie, error)
+	GetMoviesPage(f model.MovieFilter, afterID, limit int) ([]model.Movie, error)
+	CountMovies(f model.MovieFilter) (int, error)
 	GetMovie(id int) (model.Movie, error)
-	CreateMovie(m *model.Movie) error
-	UpdateMovie(id int, m *model.Movie) error
-	DeleteMovie(id int) error
+	CreateMovie(ctx context.Context, m *model.Movie) error
+	UpdateMovie(ctx context.Context, id int, m *model.Movie) error
+	DeleteMovie(ctx context.Context, id int) error
+	Search(query string, limit int) ([]model.SearchResult, error)
+	GetTrash() ([]model.Movie, error)
+	RestoreMovie(ctx context.Context, id int) error
+	PurgeMovies(ctx context.Context, retention time.Duration) (int64, error)
+	Localize(movies []model.Movie, locales []string) ([]string, error)
+	GetAvailable(f model.AvailabilityFilter) ([]model.Movie, error)
+	GetRedirect(id int) (int, error)
+	AttachCollections(movies []model.Movie) error
 }
 
 func NewMovieService(db *sql.DB) MovieService {
@@ -27,7 +47,7 @@ func NewMovieService(db *sql.DB) MovieService {
 }
 
 func (s *service) GetMovies() ([]model.Movie, error) {
-	const q = "SELECT * FROM movies"
+	const q = "SELECT " + columns + " FROM movies WHERE deleted_at IS NULL"
 	qr, err := s.db.Query(q)
 	if err != nil {
 		return []model.Movie{}, err
@@ -37,7 +57,62 @@ func (s *service) GetMovies() ([]model.Movie, error) {
 	result := make([]model.Movie, 0)
 	for qr.Next() {
 		m := model.Movie{}
-		err = qr.Scan(&m.ID, &m.Name)
+		err = qr.Scan(&m.ID, &m.Name, &m.Synopsis, &m.Version, &m.RatingAverage, &m.RatingCount)
+		if err != nil {
+			return []model.Movie{}, err
+		}
+		result = append(result, m)
+	}
+
+	return result, nil
+}
+
+// Escapes the wildcards of LIKE patterns
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// filterConditions returns the conditions of the movies of the filter along with their arguments
+func filterConditions(f model.MovieFilter) ([]string, []interface{}) {
+	conditions := []string{"deleted_at IS NULL"}
+	args := make([]interface{}, 0)
+	addCondition := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if f.Genre != "" {
+		addCondition("id IN (SELECT mg.movie_id FROM movie_genres mg JOIN genres g ON g.id = mg.genre_id WHERE g.slug = $%d)", f.Genre)
+	}
+	if f.Tag != "" {
+		addCondition("id IN (SELECT mt.movie_id FROM movie_tags mt JOIN tags t ON t.id = mt.tag_id WHERE t.slug = $%d)", f.Tag)
+	}
+	if f.Name != "" {
+		addCondition("name ILIKE $%d", "%"+likeEscaper.Replace(f.Name)+"%")
+	}
+	return conditions, args
+}
+
+// GetMoviesPage returns up to limit movies of the filter following afterID, ordered by their IDs.
+// Without a limit every movie following afterID is returned.
+func (s *service) GetMoviesPage(f model.MovieFilter, afterID, limit int) ([]model.Movie, error) {
+	conditions, args := filterConditions(f)
+	args = append(args, afterID)
+	conditions = append(conditions, fmt.Sprintf("id > $%d", len(args)))
+
+	q := "SELECT " + columns + " FROM movies WHERE " + strings.Join(conditions, " AND ") + " ORDER BY id"
+	if limit > 0 {
+		args = append(args, limit)
+		q += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	qr, err := s.db.Query(q, args...)
+	if err != nil {
+		return []model.Movie{}, err
+	}
+	defer qr.Close()
+
+	result := make([]model.Movie, 0, limit)
+	for qr.Next() {
+		m := model.Movie{}
+		err = qr.Scan(&m.ID, &m.Name, &m.Synopsis, &m.Version, &m.RatingAverage, &m.RatingCount)
 		if err != nil {
 			return []model.Movie{}, err
 		}
@@ -47,12 +122,25 @@ func (s *service) GetMovies() ([]model.Movie, error) {
 	return result, nil
 }
 
+// CountMovies returns the number of movies of the filter
+func (s *service) CountMovies(f model.MovieFilter) (int, error) {
+	conditions, args := filterConditions(f)
+	q := "SELECT COUNT(*) FROM movies WHERE " + strings.Join(conditions, " AND ")
+
+	var count int
+	err := s.db.QueryRow(q, args...).Scan(&count)
+	return count, err
+}
+
 func (s *service) GetMovie(id int) (model.Movie, error) {
-	const q = "SELECT * FROM movies WHERE id = $1"
+	const q = "SELECT " + columns + " FROM movies WHERE id = $1 AND deleted_at IS NULL"
 	qr := s.db.QueryRow(q, id)
 
 	result := model.Movie{}
-	err := qr.Scan(&result.ID, &result.Name)
+	err := qr.Scan(&result.ID, &result.Name, &result.Synopsis, &result.Version, &result.RatingAverage, &result.RatingCount)
+	if err == sql.ErrNoRows {
+		return model.Movie{}, &util.NotExistingRecordError{Identification: fmt.Sprintf("ID: %v", id)}
+	}
 	if err != nil {
 		return model.Movie{}, err
 	}
@@ -60,38 +148,357 @@ func (s *service) GetMovie(id int) (model.Movie, error) {
 	return result, nil
 }
 
-func (s *service) CreateMovie(m *model.Movie) error {
-	// Returning if a record by the parameter id already exists
-	if _, err := s.GetMovie(m.ID); err == nil {
-		return &util.ExistingRecordError{Identification: fmt.Sprintf("ID: %v", m.ID)}
+// GetRedirect returns the ID of the movie the merged movie of the ID was merged into
+func (s *service) GetRedirect(id int) (int, error) {
+	const q = "SELECT movie_id FROM movie_redirects WHERE old_id = $1"
+	var into int
+	err := s.db.QueryRow(q, id).Scan(&into)
+	if err == sql.ErrNoRows {
+		return 0, &util.NotExistingRecordError{Identification: fmt.Sprintf("redirect ID: %v", id)}
 	}
+	return into, err
+}
+
+// CreateMovie inserts the movie, unless a movie of its ID exists, even in the trash
+func (s *service) CreateMovie(ctx context.Context, m *model.Movie) error {
+	return util.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		// Inserting the new record
+		const q = "INSERT INTO movies (id, name, synopsis) VALUES ($1, $2, $3)"
+		_, err := tx.ExecContext(ctx, q, m.ID, m.Name, m.Synopsis)
+		if util.IsViolation(err, util.UniqueViolation) {
+			return &util.ExistingRecordError{Identification: fmt.Sprintf("ID: %v", m.ID)}
+		}
+		if err != nil {
+			return err
+		}
+		// A movie created under the ID of a merged one takes the ID back, rather than being redirected away from
+		const rq = "DELETE FROM movie_redirects WHERE old_id = $1"
+		if _, err := tx.ExecContext(ctx, rq, m.ID); err != nil {
+			return err
+		}
+		// New records start at the first version, the default of the column
+		m.Version = 1
+		if err := audit.Record(ctx, tx, audit.EntityMovie, m.ID, audit.ActionCreate, nil, m); err != nil {
+			return err
+		}
+		return event.Write(ctx, tx, event.TypeMovieCreated, m.ID, m)
+	})
+}
 
-	// Inserting the new record
-	const q = "INSERT INTO movies (id, name) VALUES ($1, $2)"
-	if _, err := s.db.Exec(q, m.ID, m.Name); err != nil {
+// UpdateMovie only applies the change if the record is still at the version the client has read.
+// Clients not sending a version overwrite whatever version the record is currently at.
+func (s *service) UpdateMovie(ctx context.Context, id int, m *model.Movie) error {
+	// Returning if the record to update was not found in the database
+	before, err := s.GetMovie(id)
+	if err != nil {
 		return err
 	}
-	return nil
+
+	version := m.Version
+	if version == 0 {
+		version = before.Version
+	}
+
+	return util.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		// Updating existing record
+		const q = "UPDATE movies SET name = $1, synopsis = $2, version = version + 1 WHERE id = $3 AND version = $4"
+		res, err := tx.ExecContext(ctx, q, m.Name, m.Synopsis, id, version)
+		if err != nil {
+			return err
+		}
+		if affected, err := res.RowsAffected(); err != nil {
+			return err
+		} else if affected == 0 {
+			return &util.StaleRecordError{Identification: fmt.Sprintf("ID: %v", id), Version: version}
+		}
+
+		after := model.Movie{ID: id, Name: m.Name, Synopsis: m.Synopsis, Version: version + 1, RatingAverage: before.RatingAverage, RatingCount: before.RatingCount}
+		if err := audit.Record(ctx, tx, audit.EntityMovie, id, audit.ActionUpdate, before, after); err != nil {
+			return err
+		}
+		if err := event.Write(ctx, tx, event.TypeMovieUpdated, id, after); err != nil {
+			return err
+		}
+		m.Version = after.Version
+		return nil
+	})
 }
 
-func (s *service) UpdateMovie(id int, m *model.Movie) error {
-	// Returning if the record to update was not found in the database
-	if _, err := s.GetMovie(id); err != nil {
-		return &util.NotExistingRecordError{Identification: fmt.Sprintf("ID: %v", m.ID)}
+// DeleteMovie moves the record to the trash, from where it can be restored until it is purged
+func (s *service) DeleteMovie(ctx context.Context, id int) error {
+	return util.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		const q = "UPDATE movies SET deleted_at = now() WHERE id = $1 AND deleted_at IS NULL RETURNING " + columns
+		before, err := s.scanAffectedRecord(tx.QueryRowContext(ctx, q, id), id)
+		if err != nil {
+			return err
+		}
+		if err := audit.Record(ctx, tx, audit.EntityMovie, id, audit.ActionDelete, before, nil); err != nil {
+			return err
+		}
+		return event.Write(ctx, tx, event.TypeMovieDeleted, id, before)
+	})
+}
+
+func (s *service) GetTrash() ([]model.Movie, error) {
+	const q = "SELECT id, name, deleted_at FROM movies WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC"
+	qr, err := s.db.Query(q)
+	if err != nil {
+		return []model.Movie{}, err
 	}
+	defer qr.Close()
 
-	// Updating existing record
-	const q = "UPDATE movies SET name = $1 WHERE id = $2"
-	if _, err := s.db.Exec(q, m.Name, id); err != nil {
-		return err
+	result := make([]model.Movie, 0)
+	for qr.Next() {
+		m := model.Movie{}
+		err = qr.Scan(&m.ID, &m.Name, &m.DeletedAt)
+		if err != nil {
+			return []model.Movie{}, err
+		}
+		result = append(result, m)
 	}
-	return nil
+
+	return result, nil
+}
+
+func (s *service) RestoreMovie(ctx context.Context, id int) error {
+	return util.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		const q = "UPDATE movies SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL RETURNING " + columns
+		after, err := s.scanAffectedRecord(tx.QueryRowContext(ctx, q, id), id)
+		if err != nil {
+			return err
+		}
+		if err := audit.Record(ctx, tx, audit.EntityMovie, id, audit.ActionRestore, nil, after); err != nil {
+			return err
+		}
+		// For downstream services a restored movie is a new one, as its deletion was announced
+		return event.Write(ctx, tx, event.TypeMovieCreated, id, after)
+	})
+}
+
+// PurgeMovies permanently deletes the records which have been in the trash for longer than the retention period
+func (s *service) PurgeMovies(ctx context.Context, retention time.Duration) (int64, error) {
+	var purged int64
+	err := util.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		const q = "DELETE FROM movies WHERE deleted_at < $1 RETURNING id, name, deleted_at"
+		qr, err := tx.QueryContext(ctx, q, time.Now().Add(-retention))
+		if err != nil {
+			return err
+		}
+
+		// Reading all the rows first, as the transaction cannot be used while they are open
+		movies := make([]model.Movie, 0)
+		for qr.Next() {
+			m := model.Movie{}
+			if err := qr.Scan(&m.ID, &m.Name, &m.DeletedAt); err != nil {
+				qr.Close()
+				return err
+			}
+			movies = append(movies, m)
+		}
+		qr.Close()
+
+		for _, m := range movies {
+			if err := audit.Record(ctx, tx, audit.EntityMovie, m.ID, audit.ActionPurge, m, nil); err != nil {
+				return err
+			}
+		}
+		purged = int64(len(movies))
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return purged, nil
+}
+
+// scanAffectedRecord reads the record returned by a statement, failing if no record was affected
+func (s *service) scanAffectedRecord(row *sql.Row, id int) (model.Movie, error) {
+	m := model.Movie{}
+	err := row.Scan(&m.ID, &m.Name, &m.Synopsis, &m.Version, &m.RatingAverage, &m.RatingCount)
+	if err == sql.ErrNoRows {
+		return model.Movie{}, &util.NotExistingRecordError{Identification: fmt.Sprintf("ID: %v", id)}
+	}
+	return m, err
+}
+
+// Search matches the query against the names of the movies and their translations, listing every movie once by its best match.
+// The results carry the untranslated names, the snippets highlight the matched ones as HTML, see model.SearchResult.
+func (s *service) Search(query string, limit int) ([]model.SearchResult, error) {
+	// Full-text search over the GIN-indexed tsvectors of the names, the translated ones are not stemmed.
+	// The names are escaped ahead of the highlighting, whose parser leaves the entities alone.
+	q := `SELECT ` + columns + `, rank, snippet FROM (SELECT DISTINCT ON (id) ` + columns + `, rank, snippet FROM (` +
+		`SELECT ` + matchColumns + `, ts_rank(to_tsvector('english', m.name), query) AS rank, ` +
+		`ts_headline('english', ` + escapedHTML("m.name") + `, query, 'StartSel=<b>, StopSel=</b>') AS snippet ` +
+		`FROM movies m, websearch_to_tsquery('english', $1) query ` +
+		`WHERE to_tsvector('english', m.name) @@ query AND m.deleted_at IS NULL ` +
+		`UNION ALL SELECT ` + matchColumns + `, ts_rank(to_tsvector('simple', t.name), query) AS rank, ` +
+		`ts_headline('simple', ` + escapedHTML("t.name") + `, query, 'StartSel=<b>, StopSel=</b>') AS snippet ` +
+		`FROM movie_translations t JOIN movies m ON m.id = t.movie_id, websearch_to_tsquery('simple', $1) query ` +
+		`WHERE to_tsvector('simple', t.name) @@ query AND m.deleted_at IS NULL` +
+		`) matches ORDER BY id, rank DESC) best ORDER BY rank DESC, id LIMIT $2`
+	result, err := s.querySearchResults(q, query, limit)
+	if err != nil || len(result) > 0 {
+		return result, err
+	}
+
+	// Falling back to trigram similarity, so misspelled titles are still found
+	fq := `SELECT ` + columns + `, rank, snippet FROM (SELECT DISTINCT ON (id) ` + columns + `, rank, snippet FROM (` +
+		`SELECT ` + columns + `, similarity(name, $1) AS rank, ` + escapedHTML("name") + ` AS snippet ` +
+		`FROM movies WHERE name % $1 AND deleted_at IS NULL ` +
+		`UNION ALL SELECT ` + matchColumns + `, similarity(t.name, $1) AS rank, ` + escapedHTML("t.name") + ` AS snippet ` +
+		`FROM movie_translations t JOIN movies m ON m.id = t.movie_id WHERE t.name % $1 AND m.deleted_at IS NULL` +
+		`) matches ORDER BY id, rank DESC) best ORDER BY rank DESC, id LIMIT $2`
+	return s.querySearchResults(fq, query, limit)
+}
+
+// matchColumns are the columns of the movies matched by their own or by their translated names
+const matchColumns = "m.id, m.name, m.synopsis, m.version, m.rating_average, m.rating_count"
+
+// escapedHTML returns the SQL expression of the text column with the characters special to HTML escaped,
+// the same ones html.EscapeString escapes
+func escapedHTML(column string) string {
+	return "replace(replace(replace(replace(replace(" + column + `, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), ` +
+		`'"', '&#34;'), '''', '&#39;')`
+}
+
+// Localize replaces the names of the movies by their translations into the first locale of the chain having one,
+// returning the locale of every name, "" for the untranslated ones. Synopses missing from a translation
+// fall back along the chain as well, down to the untranslated one.
+func (s *service) Localize(movies []model.Movie, locales []string) ([]string, error) {
+	result := make([]string, len(movies))
+	if len(movies) == 0 || len(locales) == 0 {
+		return result, nil
+	}
+
+	ids := make([]int64, len(movies))
+	for i, m := range movies {
+		ids[i] = int64(m.ID)
+	}
+	const q = "SELECT movie_id, locale, name, synopsis FROM movie_translations WHERE movie_id = ANY($1) AND locale = ANY($2)"
+	qr, err := s.db.Query(q, pq.Array(ids), pq.Array(locales))
+	if err != nil {
+		return nil, err
+	}
+	defer qr.Close()
+
+	translations := make(map[int]map[string]model.Translation)
+	for qr.Next() {
+		t := model.Translation{}
+		if err := qr.Scan(&t.MovieID, &t.Locale, &t.Name, &t.Synopsis); err != nil {
+			return nil, err
+		}
+		if translations[t.MovieID] == nil {
+			translations[t.MovieID] = make(map[string]model.Translation)
+		}
+		translations[t.MovieID][t.Locale] = t
+	}
+	if err := qr.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range movies {
+		translated := false
+		for _, locale := range locales {
+			t, ok := translations[movies[i].ID][locale]
+			if !ok {
+				continue
+			}
+			if result[i] == "" {
+				movies[i].Name = t.Name
+				result[i] = locale
+			}
+			if !translated && t.Synopsis != "" {
+				movies[i].Synopsis = t.Synopsis
+				translated = true
+			}
+		}
+	}
+	return result, nil
 }
 
-func (s *service) DeleteMovie(id int) error {
-	const q = "DELETE FROM movies WHERE id = $1"
-	if _, err := s.db.Exec(q, id); err != nil {
+// GetAvailable lists the movies with a window of the filter open in its region at its time, ordered by their IDs
+func (s *service) GetAvailable(f model.AvailabilityFilter) ([]model.Movie, error) {
+	args := []interface{}{f.Region, f.At}
+	window := "SELECT movie_id FROM movie_availability WHERE region = $1 AND starts_at <= $2 AND (ends_at IS NULL OR ends_at > $2)"
+	if f.Kind != "" {
+		args = append(args, f.Kind)
+		window += " AND kind = $3"
+	}
+
+	q := "SELECT " + columns + " FROM movies WHERE deleted_at IS NULL AND id IN (" + window + ") ORDER BY id"
+	qr, err := s.db.Query(q, args...)
+	if err != nil {
+		return []model.Movie{}, err
+	}
+	defer qr.Close()
+
+	result := make([]model.Movie, 0)
+	for qr.Next() {
+		m := model.Movie{}
+		err = qr.Scan(&m.ID, &m.Name, &m.Synopsis, &m.Version, &m.RatingAverage, &m.RatingCount)
+		if err != nil {
+			return []model.Movie{}, err
+		}
+		result = append(result, m)
+	}
+
+	return result, qr.Err()
+}
+
+// AttachCollections sets the collections of the movies belonging to one, along with their places in them
+func (s *service) AttachCollections(movies []model.Movie) error {
+	if len(movies) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(movies))
+	for i, m := range movies {
+		ids[i] = int64(m.ID)
+	}
+	const q = "SELECT cm.movie_id, c.id, c.name, cm.position FROM collection_movies cm " +
+		"JOIN collections c ON c.id = cm.collection_id WHERE cm.movie_id = ANY($1)"
+	qr, err := s.db.Query(q, pq.Array(ids))
+	if err != nil {
 		return err
 	}
+	defer qr.Close()
+
+	collections := make(map[int]*model.CollectionRef)
+	for qr.Next() {
+		var movieID int
+		ref := model.CollectionRef{}
+		if err := qr.Scan(&movieID, &ref.ID, &ref.Name, &ref.Position); err != nil {
+			return err
+		}
+		collections[movieID] = &ref
+	}
+	if err := qr.Err(); err != nil {
+		return err
+	}
+
+	for i := range movies {
+		movies[i].Collection = collections[movies[i].ID]
+	}
 	return nil
 }
+
+func (s *service) querySearchResults(q, query string, limit int) ([]model.SearchResult, error) {
+	qr, err := s.db.Query(q, query, limit)
+	if err != nil {
+		return []model.SearchResult{}, err
+	}
+	defer qr.Close()
+
+	result := make([]model.SearchResult, 0)
+	for qr.Next() {
+		r := model.SearchResult{}
+		m := &r.Movie
+		err = qr.Scan(&m.ID, &m.Name, &m.Synopsis, &m.Version, &m.RatingAverage, &m.RatingCount, &r.Rank, &r.Snippet)
+		if err != nil {
+			return []model.SearchResult{}, err
+		}
+		result = append(result, r)
+	}
+
+	return result, qr.Err()
+}
@@ -1,19 +1,22 @@
 package movie_test
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/Hunterlemming/golang-microservice-example/api/model"
 	"github.com/Hunterlemming/golang-microservice-example/api/movie"
 	"github.com/Hunterlemming/golang-microservice-example/api/util"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 )
 
-const GetAllQuery = `^SELECT \* FROM [\p{L}\p{N}.]+$`
+const GetAllQuery = `^SELECT [\p{L}\p{N}_, ]+ FROM [\p{L}\p{N}.]+ WHERE deleted_at IS NULL$`
 
 func TestServiceGetMovies(t *testing.T) {
 	service, mock, db := initNewService(t)
@@ -48,7 +51,7 @@ func TestServiceGetMoviesRowScanError(t *testing.T) {
 	service, mock, db := initNewService(t)
 	defer db.Close()
 
-	rows := sqlmock.NewRows([]string{"a", "s", "d"}).AddRow(1, 2, 3)
+	rows := sqlmock.NewRows([]string{"a", "s", "d"}).AddRow(1, 2, "d")
 	mock.ExpectQuery(GetAllQuery).WillReturnRows(rows)
 
 	res, err := service.GetMovies()
@@ -57,7 +60,73 @@ func TestServiceGetMoviesRowScanError(t *testing.T) {
 	assert.NotEqual(t, nil, err)
 }
 
-const GetOneQuery = `^SELECT \* FROM [\p{L}\p{N}.]+ WHERE [\p{L}\p{N}.]+ = \$1$`
+const GetPageQuery = `^SELECT [\p{L}\p{N}_, ]+ FROM [\p{L}\p{N}.]+ WHERE deleted_at IS NULL AND id > \$1 ORDER BY id LIMIT \$2$`
+
+func TestServiceGetMoviesPage(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	movies := []model.Movie{{ID: 3, Name: "test3"}, {ID: 4, Name: "test4"}}
+	mock.ExpectQuery(GetPageQuery).WithArgs(2, 2).WillReturnRows(newRows(&movies))
+
+	res, err := service.GetMoviesPage(model.MovieFilter{}, 2, 2)
+
+	assert.Equal(t, movies, res)
+	assert.Equal(t, nil, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceGetMoviesPageQueryError(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	queryError := errors.New("test-error-message")
+	mock.ExpectQuery(GetPageQuery).WillReturnError(queryError)
+
+	res, err := service.GetMoviesPage(model.MovieFilter{}, 0, 10)
+
+	assert.Equal(t, []model.Movie{}, res)
+	assert.Equal(t, queryError, err)
+}
+
+const GetFilteredQuery = `^SELECT [\p{L}\p{N}_, ]+ FROM movies WHERE deleted_at IS NULL AND id IN \(SELECT .+ WHERE g\.slug = \$1\) AND id IN \(SELECT .+ WHERE t\.slug = \$2\) AND id > \$3 ORDER BY id$`
+
+func TestServiceGetMoviesFiltered(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	movies := []model.Movie{{ID: 7, Name: "Lost in Translation"}}
+	mock.ExpectQuery(GetFilteredQuery).WithArgs("drama", "award-winner", 0).WillReturnRows(newRows(&movies))
+
+	res, err := service.GetMoviesPage(model.MovieFilter{Genre: "drama", Tag: "award-winner"}, 0, 0)
+
+	assert.Equal(t, movies, res)
+	assert.Equal(t, nil, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+const CountQuery = `^SELECT COUNT\(\*\) FROM movies WHERE deleted_at IS NULL AND name ILIKE \$1$`
+
+func TestServiceCountMoviesByName(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectQuery(CountQuery).WithArgs(`%100\%%`).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+	count, err := service.CountMovies(model.MovieFilter{Name: "100%"})
+
+	assert.Equal(t, 2, count)
+	assert.Equal(t, nil, err, "The wildcards of the name should be matched literally")
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+const GetOneQuery = `^SELECT [\p{L}\p{N}_, ]+ FROM [\p{L}\p{N}.]+ WHERE [\p{L}\p{N}.]+ = \$1 AND deleted_at IS NULL$`
 
 func TestServiceGetMovie(t *testing.T) {
 	service, mock, db := initNewService(t)
@@ -75,11 +144,22 @@ func TestServiceGetMovie(t *testing.T) {
 	}
 }
 
+func TestServiceGetMovieRecordDoesNotExistError(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectQuery(GetOneQuery).WillReturnRows(newRows(&[]model.Movie{}))
+
+	_, err := service.GetMovie(1)
+
+	assert.IsType(t, &util.NotExistingRecordError{}, err)
+}
+
 func TestServiceGetMovieRowScanError(t *testing.T) {
 	service, mock, db := initNewService(t)
 	defer db.Close()
 
-	rows := sqlmock.NewRows([]string{"a", "s", "d"}).AddRow(1, 2, 3)
+	rows := sqlmock.NewRows([]string{"a", "s", "d"}).AddRow(1, 2, "d")
 	mock.ExpectQuery(GetOneQuery).WillReturnRows(rows)
 
 	res, err := service.GetMovie(1)
@@ -89,16 +169,26 @@ func TestServiceGetMovieRowScanError(t *testing.T) {
 }
 
 const CreateMovieQuery = `^INSERT INTO [\p{L}\p{N}.]+ \([\p{L}\p{N},. ]+\) VALUES \([\p{N}$, ]+\)$`
+const DropRedirectQuery = `^DELETE FROM movie_redirects WHERE old_id = \$1$`
+const AuditQuery = `^INSERT INTO audit_log \(.+\) VALUES \(.+\)$`
+const OutboxQuery = `^INSERT INTO outbox \(.+\) VALUES \(.+\)$`
 
 func TestServiceCreateMovie(t *testing.T) {
 	service, mock, db := initNewService(t)
 	defer db.Close()
 
-	mock.ExpectQuery(GetOneQuery).WillReturnRows(newRows(&[]model.Movie{}))
-	mock.ExpectExec(CreateMovieQuery).WithArgs(2, "test2").
+	mock.ExpectBegin()
+	mock.ExpectExec(CreateMovieQuery).WithArgs(2, "test2", "").
 		WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectExec(DropRedirectQuery).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(AuditQuery).
+		WithArgs("movie", 2, "create", "tester", "test-request", nil, `{"id":2,"name":"test2","version":1}`, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(OutboxQuery).WithArgs("MovieCreated", 2, `{"id":2,"name":"test2","version":1}`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
 
-	err := service.CreateMovie(&model.Movie{ID: 2, Name: "test2"})
+	err := service.CreateMovie(auditContext(), &model.Movie{ID: 2, Name: "test2"})
 
 	assert.Equal(t, nil, err)
 	if err := mock.ExpectationsWereMet(); err != nil {
@@ -110,40 +200,120 @@ func TestServiceCreateMovieRecordExistsError(t *testing.T) {
 	service, mock, db := initNewService(t)
 	defer db.Close()
 
-	mock.ExpectQuery(GetOneQuery).WillReturnRows(newRows(&[]model.Movie{{ID: 2, Name: "asd"}}))
+	mock.ExpectBegin()
+	mock.ExpectExec(CreateMovieQuery).WithArgs(2, "test2", "").WillReturnError(&pq.Error{Code: util.UniqueViolation})
+	mock.ExpectRollback()
 
-	err := service.CreateMovie(&model.Movie{ID: 2, Name: "test2"})
+	err := service.CreateMovie(context.Background(), &model.Movie{ID: 2, Name: "test2"})
 
-	assert.IsType(t, &util.ExistingRecordError{}, err)
+	assert.IsType(t, &util.ExistingRecordError{}, err, "IDs of movies in the trash should be taken as well")
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
 }
 
 func TestServiceCreateMovieInsertError(t *testing.T) {
 	service, mock, db := initNewService(t)
 	defer db.Close()
 
-	mock.ExpectQuery(GetOneQuery).WillReturnRows(newRows(&[]model.Movie{}))
+	mock.ExpectBegin()
 	insertError := errors.New("test-error-message")
-	mock.ExpectExec(CreateMovieQuery).WithArgs(2, "test2").WillReturnError(insertError)
+	mock.ExpectExec(CreateMovieQuery).WithArgs(2, "test2", "").WillReturnError(insertError)
+	mock.ExpectRollback()
 
-	err := service.CreateMovie(&model.Movie{ID: 2, Name: "test2"})
+	err := service.CreateMovie(context.Background(), &model.Movie{ID: 2, Name: "test2"})
 
 	assert.Equal(t, insertError, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceCreateMovieAuditError(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(CreateMovieQuery).WithArgs(2, "test2", "").
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectExec(DropRedirectQuery).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+	auditError := errors.New("test-error-message")
+	mock.ExpectExec(AuditQuery).WillReturnError(auditError)
+	mock.ExpectRollback()
+
+	err := service.CreateMovie(context.Background(), &model.Movie{ID: 2, Name: "test2"})
+
+	assert.Equal(t, auditError, err, "The change should be rolled back if it cannot be audited")
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
 }
 
-const UpdateQuery = `^UPDATE [\p{L}\p{N}.]+ SET ([\p{L}\p{N}.]+ = \$\p{N}+[, ]+)+WHERE [\p{L}\p{N}.]+ = \$\p{N}+$`
+const UpdateQuery = `^UPDATE [\p{L}\p{N}.]+ SET name = \$1, synopsis = \$2, version = version \+ 1 WHERE id = \$3 AND version = \$4$`
 
 func TestServiceUpdateMovie(t *testing.T) {
 	service, mock, db := initNewService(t)
 	defer db.Close()
 
-	movies := []model.Movie{{ID: 1, Name: "test1"}}
+	movies := []model.Movie{{ID: 1, Name: "test1", Version: 1}}
 	mock.ExpectQuery(GetOneQuery).WillReturnRows(newRows(&movies))
-	mock.ExpectExec(UpdateQuery).WithArgs("updated", 1).
+	mock.ExpectBegin()
+	mock.ExpectExec(UpdateQuery).WithArgs("updated", "", 1, 1).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(AuditQuery).
+		WithArgs("movie", 1, "update", "tester", "test-request",
+			`{"id":1,"name":"test1","version":1}`, `{"id":1,"name":"updated","version":2}`,
+			`{"name":{"from":"test1","to":"updated"},"version":{"from":1,"to":2}}`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(OutboxQuery).WithArgs("MovieUpdated", 1, `{"id":1,"name":"updated","version":2}`).
 		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
 
-	err := service.UpdateMovie(1, &model.Movie{ID: 1, Name: "updated"})
+	m := &model.Movie{ID: 1, Name: "updated"}
+	err := service.UpdateMovie(auditContext(), 1, m)
 
 	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, m.Version, "The new version should be handed back to the caller")
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceUpdateMovieStaleVersionError(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	movies := []model.Movie{{ID: 1, Name: "test1", Version: 3}}
+	mock.ExpectQuery(GetOneQuery).WillReturnRows(newRows(&movies))
+	mock.ExpectBegin()
+	mock.ExpectExec(UpdateQuery).WithArgs("updated", "", 1, 2).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	err := service.UpdateMovie(context.Background(), 1, &model.Movie{ID: 1, Name: "updated", Version: 2})
+
+	assert.IsType(t, &util.StaleRecordError{}, err, "Changes to an outdated version should be rejected")
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceCreateMovieOutboxError(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(CreateMovieQuery).WithArgs(2, "test2", "").
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectExec(DropRedirectQuery).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(AuditQuery).WillReturnResult(sqlmock.NewResult(1, 1))
+	outboxError := errors.New("test-error-message")
+	mock.ExpectExec(OutboxQuery).WillReturnError(outboxError)
+	mock.ExpectRollback()
+
+	err := service.CreateMovie(context.Background(), &model.Movie{ID: 2, Name: "test2"})
+
+	assert.Equal(t, outboxError, err, "The change should be rolled back if its event cannot be stored")
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Errorf("there were unfulfilled expectations: %s", err)
 	}
@@ -155,35 +325,56 @@ func TestServiceUpdateMovieRecordDoesNotExistError(t *testing.T) {
 
 	mock.ExpectQuery(GetOneQuery).WillReturnRows(newRows(&[]model.Movie{}))
 
-	err := service.UpdateMovie(1, &model.Movie{ID: 1, Name: "updated"})
+	err := service.UpdateMovie(context.Background(), 1, &model.Movie{ID: 1, Name: "updated"})
 
 	assert.IsType(t, &util.NotExistingRecordError{}, err)
 }
 
+func TestServiceUpdateMovieGetError(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	getError := errors.New("test-error-message")
+	mock.ExpectQuery(GetOneQuery).WillReturnError(getError)
+
+	err := service.UpdateMovie(context.Background(), 1, &model.Movie{ID: 1, Name: "updated"})
+
+	assert.Equal(t, getError, err, "Failures other than a missing movie should not be reported as one")
+}
+
 func TestServiceUpdateMovieUpdateError(t *testing.T) {
 	service, mock, db := initNewService(t)
 	defer db.Close()
 
-	movies := []model.Movie{{ID: 1, Name: "test1"}}
+	movies := []model.Movie{{ID: 1, Name: "test1", Version: 1}}
 	mock.ExpectQuery(GetOneQuery).WillReturnRows(newRows(&movies))
+	mock.ExpectBegin()
 	updateError := errors.New("test-error-message")
-	mock.ExpectExec(UpdateQuery).WithArgs("updated", 1).WillReturnError(updateError)
+	mock.ExpectExec(UpdateQuery).WithArgs("updated", "", 1, 1).WillReturnError(updateError)
+	mock.ExpectRollback()
 
-	err := service.UpdateMovie(1, &model.Movie{ID: 1, Name: "updated"})
+	err := service.UpdateMovie(context.Background(), 1, &model.Movie{ID: 1, Name: "updated"})
 
 	assert.Equal(t, updateError, err)
 }
 
-const DeleteQuery = `^DELETE FROM [\p{L}\p{N}.]+ WHERE [\p{L}\p{N}.]+ = \$1$`
+const DeleteQuery = `^UPDATE [\p{L}\p{N}.]+ SET deleted_at = now\(\) WHERE [\p{L}\p{N}.]+ = \$1 AND deleted_at IS NULL RETURNING id, name, synopsis, version, rating_average, rating_count$`
 
 func TestServiceDeleteMovie(t *testing.T) {
 	service, mock, db := initNewService(t)
 	defer db.Close()
 
-	mock.ExpectExec(DeleteQuery).WithArgs(1).
+	mock.ExpectBegin()
+	mock.ExpectQuery(DeleteQuery).WithArgs(1).
+		WillReturnRows(newRows(&[]model.Movie{{ID: 1, Name: "test1"}}))
+	mock.ExpectExec(AuditQuery).
+		WithArgs("movie", 1, "delete", "tester", "test-request", `{"id":1,"name":"test1"}`, nil, sqlmock.AnyArg()).
 		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(OutboxQuery).WithArgs("MovieDeleted", 1, `{"id":1,"name":"test1"}`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
 
-	err := service.DeleteMovie(1)
+	err := service.DeleteMovie(auditContext(), 1)
 
 	assert.Equal(t, nil, err)
 	if err := mock.ExpectationsWereMet(); err != nil {
@@ -191,19 +382,299 @@ func TestServiceDeleteMovie(t *testing.T) {
 	}
 }
 
+func TestServiceDeleteMovieRecordDoesNotExistError(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(DeleteQuery).WithArgs(1).
+		WillReturnRows(newRows(&[]model.Movie{}))
+	mock.ExpectRollback()
+
+	err := service.DeleteMovie(context.Background(), 1)
+
+	assert.IsType(t, &util.NotExistingRecordError{}, err)
+}
+
 func TestServiceDeleteMovieDeleteError(t *testing.T) {
 	service, mock, db := initNewService(t)
 	defer db.Close()
 
+	mock.ExpectBegin()
 	deleteError := errors.New("test-error-message")
-	mock.ExpectExec(DeleteQuery).WithArgs(1).
+	mock.ExpectQuery(DeleteQuery).WithArgs(1).
 		WillReturnError(deleteError)
+	mock.ExpectRollback()
 
-	err := service.DeleteMovie(1)
+	err := service.DeleteMovie(context.Background(), 1)
 
 	assert.Equal(t, deleteError, err)
 }
 
+const GetTrashQuery = `^SELECT [\p{L}\p{N}_, ]+ FROM [\p{L}\p{N}.]+ WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC$`
+
+func TestServiceGetTrash(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	deletedAt := time.Now()
+	movies := []model.Movie{{ID: 1, Name: "test1", DeletedAt: &deletedAt}}
+	rows := sqlmock.NewRows([]string{"id", "name", "deleted_at"}).AddRow(1, "test1", deletedAt)
+	mock.ExpectQuery(GetTrashQuery).WillReturnRows(rows)
+
+	res, err := service.GetTrash()
+
+	assert.Equal(t, movies, res)
+	assert.Equal(t, nil, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceGetTrashQueryError(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	queryError := errors.New("test-error-message")
+	mock.ExpectQuery(GetTrashQuery).WillReturnError(queryError)
+
+	res, err := service.GetTrash()
+
+	assert.Equal(t, []model.Movie{}, res)
+	assert.Equal(t, queryError, err)
+}
+
+const RestoreQuery = `^UPDATE [\p{L}\p{N}.]+ SET deleted_at = NULL WHERE [\p{L}\p{N}.]+ = \$1 AND deleted_at IS NOT NULL RETURNING id, name, synopsis, version, rating_average, rating_count$`
+
+func TestServiceRestoreMovie(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(RestoreQuery).WithArgs(1).
+		WillReturnRows(newRows(&[]model.Movie{{ID: 1, Name: "test1"}}))
+	mock.ExpectExec(AuditQuery).
+		WithArgs("movie", 1, "restore", "tester", "test-request", nil, `{"id":1,"name":"test1"}`, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(OutboxQuery).WithArgs("MovieCreated", 1, `{"id":1,"name":"test1"}`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err := service.RestoreMovie(auditContext(), 1)
+
+	assert.Equal(t, nil, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceRestoreMovieRecordDoesNotExistError(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(RestoreQuery).WithArgs(1).
+		WillReturnRows(newRows(&[]model.Movie{}))
+	mock.ExpectRollback()
+
+	err := service.RestoreMovie(context.Background(), 1)
+
+	assert.IsType(t, &util.NotExistingRecordError{}, err)
+}
+
+const PurgeQuery = `^DELETE FROM [\p{L}\p{N}.]+ WHERE deleted_at < \$1 RETURNING id, name, deleted_at$`
+
+func TestServicePurgeMovies(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	deletedAt := time.Now()
+	rows := sqlmock.NewRows([]string{"id", "name", "deleted_at"}).
+		AddRow(1, "test1", deletedAt).
+		AddRow(2, "test2", deletedAt)
+	mock.ExpectBegin()
+	mock.ExpectQuery(PurgeQuery).WithArgs(sqlmock.AnyArg()).WillReturnRows(rows)
+	mock.ExpectExec(AuditQuery).WithArgs("movie", 1, "purge", "tester", "test-request", sqlmock.AnyArg(), nil, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(AuditQuery).WithArgs("movie", 2, "purge", "tester", "test-request", sqlmock.AnyArg(), nil, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectCommit()
+
+	purged, err := service.PurgeMovies(auditContext(), time.Hour)
+
+	assert.Equal(t, int64(2), purged)
+	assert.Equal(t, nil, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServicePurgeMoviesDeleteError(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	deleteError := errors.New("test-error-message")
+	mock.ExpectQuery(PurgeQuery).WillReturnError(deleteError)
+	mock.ExpectRollback()
+
+	_, err := service.PurgeMovies(context.Background(), time.Hour)
+
+	assert.Equal(t, deleteError, err)
+}
+
+const SearchQuery = `^SELECT .+ FROM movies m, websearch_to_tsquery\('english', \$1\) query WHERE .+ @@ query AND m.deleted_at IS NULL ` +
+	`UNION ALL .+ FROM movie_translations t JOIN movies m .+ websearch_to_tsquery\('simple', \$1\) query WHERE .+ @@ query AND m.deleted_at IS NULL\) ` +
+	`matches ORDER BY id, rank DESC\) best ORDER BY rank DESC, id LIMIT \$2$`
+const FuzzySearchQuery = `^SELECT .+similarity\(name, \$1\).+ FROM movies WHERE name % \$1 AND deleted_at IS NULL ` +
+	`UNION ALL .+similarity\(t.name, \$1\).+ FROM movie_translations t JOIN movies m .+ WHERE t.name % \$1 AND m.deleted_at IS NULL\) ` +
+	`matches ORDER BY id, rank DESC\) best ORDER BY rank DESC, id LIMIT \$2$`
+
+func TestServiceSearch(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	m := model.Movie{ID: 1, Name: "test", Synopsis: "A test.", Version: 3, RatingAverage: 4.5, RatingCount: 2}
+	results := []model.SearchResult{{Movie: m, Rank: 0.1, Snippet: "<b>test</b>"}}
+	mock.ExpectQuery(SearchQuery).WithArgs("test", 10).WillReturnRows(newSearchRows(&results))
+
+	res, err := service.Search("test", 10)
+
+	assert.Equal(t, results, res)
+	assert.Equal(t, nil, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceSearchFuzzyFallback(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	results := []model.SearchResult{{Movie: model.Movie{ID: 1, Name: "test"}, Rank: 0.4, Snippet: "test"}}
+	mock.ExpectQuery(SearchQuery).WithArgs("tset", 10).WillReturnRows(newSearchRows(&[]model.SearchResult{}))
+	mock.ExpectQuery(FuzzySearchQuery).WithArgs("tset", 10).WillReturnRows(newSearchRows(&results))
+
+	res, err := service.Search("tset", 10)
+
+	assert.Equal(t, results, res)
+	assert.Equal(t, nil, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceSearchQueryError(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	queryError := errors.New("test-error-message")
+	mock.ExpectQuery(SearchQuery).WillReturnError(queryError)
+
+	res, err := service.Search("test", 10)
+
+	assert.Equal(t, []model.SearchResult{}, res)
+	assert.Equal(t, queryError, err)
+}
+
+const LocalizeQuery = `^SELECT movie_id, locale, name, synopsis FROM movie_translations WHERE movie_id = ANY\(\$1\) AND locale = ANY\(\$2\)$`
+
+func TestServiceLocalize(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	movies := []model.Movie{{ID: 1, Name: "Spirited Away", Synopsis: "A girl"}, {ID: 2, Name: "Alien", Synopsis: "A crew"}, {ID: 3, Name: "Heat"}}
+	mock.ExpectQuery(LocalizeQuery).WithArgs(pq.Array([]int64{1, 2, 3}), pq.Array([]string{"de-AT", "de", "fr"})).
+		WillReturnRows(sqlmock.NewRows([]string{"movie_id", "locale", "name", "synopsis"}).
+			AddRow(1, "de", "Chihiros Reise ins Zauberland", "Ein Mädchen").
+			AddRow(1, "de-AT", "Chihiros Reise", "").
+			AddRow(2, "fr", "Alien, le huitième passager", "Un équipage"))
+
+	locales, err := service.Localize(movies, []string{"de-AT", "de", "fr"})
+
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"de-AT", "fr", ""}, locales)
+	assert.Equal(t, []model.Movie{
+		{ID: 1, Name: "Chihiros Reise", Synopsis: "Ein Mädchen"},
+		{ID: 2, Name: "Alien, le huitième passager", Synopsis: "Un équipage"},
+		{ID: 3, Name: "Heat"},
+	}, movies, "Missing synopses should fall back along the chain")
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceLocalizeWithoutLocales(t *testing.T) {
+	service, _, db := initNewService(t)
+	defer db.Close()
+
+	movies := []model.Movie{{ID: 1, Name: "Heat"}}
+	locales, err := service.Localize(movies, []string{})
+
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{""}, locales)
+	assert.Equal(t, "Heat", movies[0].Name)
+}
+
+const AvailableQuery = `^SELECT .+ FROM movies WHERE deleted_at IS NULL AND id IN \(SELECT movie_id FROM movie_availability ` +
+	`WHERE region = \$1 AND starts_at <= \$2 AND \(ends_at IS NULL OR ends_at > \$2\)( AND kind = \$3)?\) ORDER BY id$`
+
+func TestServiceGetAvailable(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	at := time.Date(2026, 10, 1, 20, 0, 0, 0, time.UTC)
+	movies := []model.Movie{{ID: 1, Name: "Spirited Away"}}
+	mock.ExpectQuery(AvailableQuery).WithArgs("DE", at, "streaming").WillReturnRows(newRows(&movies))
+
+	res, err := service.GetAvailable(model.AvailabilityFilter{Region: "DE", At: at, Kind: model.WindowStreaming})
+
+	assert.Equal(t, movies, res)
+	assert.Equal(t, nil, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceGetAvailableQueryError(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	at := time.Date(2026, 10, 1, 20, 0, 0, 0, time.UTC)
+	queryError := errors.New("test-error-message")
+	mock.ExpectQuery(AvailableQuery).WithArgs("DE", at).WillReturnError(queryError)
+
+	res, err := service.GetAvailable(model.AvailabilityFilter{Region: "DE", At: at})
+
+	assert.Equal(t, []model.Movie{}, res)
+	assert.Equal(t, queryError, err)
+}
+
+const GetRedirectQuery = `^SELECT movie_id FROM movie_redirects WHERE old_id = \$1$`
+
+func TestServiceGetRedirect(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectQuery(GetRedirectQuery).WithArgs(2).WillReturnRows(sqlmock.NewRows([]string{"movie_id"}).AddRow(1))
+
+	into, err := service.GetRedirect(2)
+
+	assert.Equal(t, 1, into)
+	assert.Equal(t, nil, err)
+}
+
+func TestServiceGetRedirectMissingError(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectQuery(GetRedirectQuery).WithArgs(2).WillReturnRows(sqlmock.NewRows([]string{"movie_id"}))
+
+	_, err := service.GetRedirect(2)
+
+	assert.IsType(t, &util.NotExistingRecordError{}, err)
+}
+
 func initNewService(t *testing.T) (movie.MovieService, sqlmock.Sqlmock, *sql.DB) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -214,10 +685,61 @@ func initNewService(t *testing.T) (movie.MovieService, sqlmock.Sqlmock, *sql.DB)
 	return service, mock, db
 }
 
+func auditContext() context.Context {
+	return util.WithRequestID(util.WithActor(context.Background(), "tester"), "test-request")
+}
+
 func newRows(movies *[]model.Movie) *sqlmock.Rows {
-	rows := sqlmock.NewRows([]string{"id", "name"})
+	rows := sqlmock.NewRows([]string{"id", "name", "synopsis", "version", "rating_average", "rating_count"})
 	for _, m := range *movies {
-		rows.AddRow(m.ID, m.Name)
+		rows.AddRow(m.ID, m.Name, m.Synopsis, m.Version, m.RatingAverage, m.RatingCount)
 	}
 	return rows
 }
+
+func newSearchRows(results *[]model.SearchResult) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"id", "name", "synopsis", "version", "rating_average", "rating_count", "rank", "snippet"})
+	for _, r := range *results {
+		m := r.Movie
+		rows.AddRow(m.ID, m.Name, m.Synopsis, m.Version, m.RatingAverage, m.RatingCount, r.Rank, r.Snippet)
+	}
+	return rows
+}
+
+const CollectionsQuery = `^SELECT cm.movie_id, c.id, c.name, cm.position FROM collection_movies cm JOIN collections c ON .+ WHERE cm.movie_id = ANY\(\$1\)$`
+
+func TestServiceAttachCollections(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	movies := []model.Movie{{ID: 1, Name: "Alien"}, {ID: 2, Name: "Heat"}}
+	mock.ExpectQuery(CollectionsQuery).WithArgs(pq.Array([]int64{1, 2})).
+		WillReturnRows(newCollectionRows().AddRow(1, 4, "Alien", 1))
+
+	err := service.AttachCollections(movies)
+
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []model.Movie{
+		{ID: 1, Name: "Alien", Collection: &model.CollectionRef{ID: 4, Name: "Alien", Position: 1}},
+		{ID: 2, Name: "Heat"},
+	}, movies, "Movies outside of the collections should be left without one")
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceAttachCollectionsWithoutMovies(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	err := service.AttachCollections([]model.Movie{})
+
+	assert.Equal(t, nil, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func newCollectionRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"movie_id", "id", "name", "position"})
+}
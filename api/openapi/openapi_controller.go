@@ -0,0 +1,64 @@
+package openapi
+
+import (
+	_ "embed"
+	"fmt"
+	"net/http"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+
+	"github.com/gorilla/mux"
+)
+
+//go:embed docs.html
+var docsPage []byte
+
+// The Redoc bundle of the docs page, pinned to a released version
+const redocBundle = "https://cdn.redoc.ly/redoc/v2.1.5/bundles/redoc.standalone.js"
+
+// docsPolicy lets the docs page run no scripts but the pinned bundle, which keeps its styles inline and searches in a blob worker
+const docsPolicy = "default-src 'self'; script-src " + redocBundle + "; style-src 'self' 'unsafe-inline'; img-src 'self' data:; worker-src blob:"
+
+type controller struct {
+	doc *document
+}
+
+type OpenApiController interface {
+	GetSpec(w http.ResponseWriter, r *http.Request)
+	GetDocs(w http.ResponseWriter, r *http.Request)
+}
+
+func NewOpenApiController(router *mux.Router) OpenApiController {
+	return &controller{
+		doc: &document{router: router},
+	}
+}
+
+// GetSpec serves the OpenAPI document of the router
+func (c *controller) GetSpec(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to GetSpec", r.Method))
+		return
+	}
+
+	spec, err := c.doc.json()
+	if err != nil {
+		util.HandleServerError(w, err, "Document unavailable")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Write(spec)
+}
+
+// GetDocs serves a Redoc page rendering the OpenAPI document
+func (c *controller) GetDocs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to GetDocs", r.Method))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", docsPolicy)
+	w.Write(docsPage)
+}
@@ -0,0 +1,37 @@
+package openapi
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gorilla/mux"
+)
+
+// document generates the OpenAPI document of the router on first use, when all the routes have been registered
+type document struct {
+	router  *mux.Router
+	once    sync.Once
+	t       *openapi3.T
+	encoded []byte
+	err     error
+}
+
+func (d *document) generate() {
+	d.once.Do(func() {
+		d.t, d.err = NewSpec(d.router)
+		if d.err == nil {
+			d.encoded, d.err = json.Marshal(d.t)
+		}
+	})
+}
+
+func (d *document) spec() (*openapi3.T, error) {
+	d.generate()
+	return d.t, d.err
+}
+
+func (d *document) json() ([]byte, error) {
+	d.generate()
+	return d.encoded, d.err
+}
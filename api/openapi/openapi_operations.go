@@ -0,0 +1,810 @@
+package openapi
+
+import (
+	"net/http"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+const (
+	tagMovies      = "movies"
+	tagPeople      = "people"
+	tagGenres      = "genres"
+	tagTags        = "tags"
+	tagReviews     = "reviews"
+	tagWatchlists  = "watchlists"
+	tagCollections = "collections"
+	tagPosters     = "posters"
+	tagReleases    = "releases"
+	tagAudit       = "audit"
+	tagWebhooks    = "webhooks"
+	tagGraphql     = "graphql"
+	tagDocs        = "docs"
+)
+
+// operation documents a route, which is looked up by its method and path template
+type operation struct {
+	summary     string
+	description string
+	tag         string
+	query       []*openapi3.Parameter
+	headers     []*openapi3.Parameter
+	// Names of the path parameters which are strings, the others are IDs
+	stringParams []string
+	// Value of the request body type, nil for requests without a body
+	body interface{}
+	// Name of the file field of multipart/form-data uploads, whose bodies are left to their controllers
+	upload string
+	// Limit of the request body in bytes, where it differs from the configured one
+	maxBodySize int64
+	// Read-only properties of the body are ignored rather than refused, so fetched records can be sent back
+	ignoreReadOnly bool
+	status         int
+	// Value of the response body type, negotiated between the supported media types
+	response interface{}
+	// Media type of responses which are not negotiated, such as streams and pages
+	mediaType string
+	errors    []int
+}
+
+// The request and response bodies of the GraphQL endpoint, as understood by its controller
+type graphqlRequest struct {
+	Query         string                 `json:"query" validate:"required"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+type graphqlResponse struct {
+	Data   interface{}   `json:"data,omitempty"`
+	Errors []interface{} `json:"errors,omitempty"`
+}
+
+func limitParameter(def, max float64) *openapi3.Parameter {
+	return openapi3.NewQueryParameter("limit").
+		WithDescription("Maximum number of results").
+		WithSchema(openapi3.NewIntegerSchema().WithMin(1).WithMax(max).WithDefault(def))
+}
+
+// The header selecting the locales the movies are translated into, the untranslated names being the last fallback
+var acceptLanguageHeader = openapi3.NewHeaderParameter("Accept-Language").
+	WithDescription("Locales in order of preference, such as \"de-AT, en;q=0.5\". Every locale falls back to its language.").
+	WithSchema(openapi3.NewStringSchema())
+
+// The query parameters of the review listings
+var reviewsQuery = []*openapi3.Parameter{
+	openapi3.NewQueryParameter("status").
+		WithSchema(openapi3.NewStringSchema().WithEnum(model.ReviewPending, model.ReviewApproved, model.ReviewRejected)),
+	openapi3.NewQueryParameter("after").
+		WithDescription("ID of the last review of the previous page").
+		WithSchema(openapi3.NewIntegerSchema()),
+	limitParameter(20, 100),
+}
+
+// operations documents every route of the API. Routes missing here are left out of the document.
+var operations = map[string]operation{
+	"GET /movies": {
+		summary:     "List the movies",
+		description: "Pages are requested by a limit, the Link header of full pages refers to the next one. Filtered lists only contain the movies of both the genre and the tag.",
+		tag:         tagMovies,
+		query: []*openapi3.Parameter{
+			openapi3.NewQueryParameter("genre").
+				WithDescription("Slug of a genre of the movies").
+				WithSchema(openapi3.NewStringSchema().WithPattern(model.SlugPattern)),
+			openapi3.NewQueryParameter("tag").
+				WithDescription("Slug of a tag of the movies").
+				WithSchema(openapi3.NewStringSchema().WithPattern(model.SlugPattern)),
+			openapi3.NewQueryParameter("limit").
+				WithDescription("Size of the page, every movie is listed without it").
+				WithSchema(openapi3.NewIntegerSchema().WithMin(1).WithMax(100)),
+			openapi3.NewQueryParameter("after").
+				WithDescription("ID of the last movie of the previous page").
+				WithSchema(openapi3.NewIntegerSchema()),
+		},
+		headers:  []*openapi3.Parameter{acceptLanguageHeader},
+		status:   http.StatusOK,
+		response: []model.Movie{},
+		errors:   []int{http.StatusBadRequest},
+	},
+	"POST /movies": {
+		summary:        "Create a movie",
+		tag:            tagMovies,
+		body:           model.Movie{},
+		ignoreReadOnly: true,
+		status:         http.StatusCreated,
+		errors:         []int{http.StatusBadRequest, http.StatusConflict, http.StatusUnsupportedMediaType},
+	},
+	"GET /movies/search": {
+		summary:     "Search the movies by name",
+		description: "Every term of the query has to match the name of a movie or one of its translations, falling back to fuzzy matching if nothing does. The snippets show the matched names as HTML, escaped except for the <b> elements around the matched words.",
+		tag:         tagMovies,
+		query: []*openapi3.Parameter{
+			openapi3.NewQueryParameter("q").WithRequired(true).WithSchema(openapi3.NewStringSchema().WithMinLength(1)),
+			limitParameter(20, 100),
+		},
+		headers:  []*openapi3.Parameter{acceptLanguageHeader},
+		status:   http.StatusOK,
+		response: []model.SearchResult{},
+		errors:   []int{http.StatusBadRequest},
+	},
+	"GET /movies/available": {
+		summary:     "List the movies available in a region",
+		description: "Movies are available while one of their windows in the region is open, its start included and its end excluded.",
+		tag:         tagReleases,
+		query: []*openapi3.Parameter{
+			openapi3.NewQueryParameter("region").WithRequired(true).WithSchema(openapi3.NewStringSchema().WithPattern("^[A-Za-z]{2}$")),
+			openapi3.NewQueryParameter("at").WithDescription("Defaults to now").WithSchema(openapi3.NewDateTimeSchema()),
+			openapi3.NewQueryParameter("kind").WithDescription("Defaults to every kind").
+				WithSchema(openapi3.NewStringSchema().WithEnum(model.WindowTheatrical, model.WindowStreaming, model.WindowRental)),
+		},
+		headers:  []*openapi3.Parameter{acceptLanguageHeader},
+		status:   http.StatusOK,
+		response: []model.Movie{},
+		errors:   []int{http.StatusBadRequest},
+	},
+	"GET /movies/duplicates": {
+		summary:     "List the movies suspected to be duplicates",
+		description: "Pairs of movies with similar titles, as found by the last detection. Movies of different years are not paired.",
+		tag:         tagMovies,
+		status:      http.StatusOK,
+		response:    []model.DuplicatePair{},
+	},
+	"GET /movies/trash": {
+		summary:  "List the deleted movies",
+		tag:      tagMovies,
+		status:   http.StatusOK,
+		response: []model.Movie{},
+	},
+	"DELETE /movies/trash": {
+		summary: "Purge the deleted movies",
+		tag:     tagMovies,
+		query: []*openapi3.Parameter{
+			openapi3.NewQueryParameter("retention").
+				WithDescription("Movies deleted within this period are kept, as a Go duration").
+				WithSchema(openapi3.NewStringSchema().WithDefault("720h")),
+		},
+		status:   http.StatusOK,
+		response: model.PurgeResult{},
+		errors:   []int{http.StatusBadRequest},
+	},
+	"GET /movies/events": {
+		summary:     "Stream the movie changes as Server-Sent Events",
+		description: "Clients reconnecting with a Last-Event-ID header first receive the events they missed, as far as they are still buffered.",
+		tag:         tagMovies,
+		query: []*openapi3.Parameter{
+			openapi3.NewQueryParameter("movie_id").WithSchema(openapi3.NewIntegerSchema()),
+			openapi3.NewQueryParameter("type").
+				WithDescription("Comma-separated event types").
+				WithSchema(openapi3.NewArraySchema().WithItems(openapi3.NewStringSchema())),
+		},
+		headers: []*openapi3.Parameter{
+			openapi3.NewHeaderParameter("Last-Event-ID").WithSchema(openapi3.NewIntegerSchema()),
+		},
+		status:    http.StatusOK,
+		mediaType: "text/event-stream",
+		errors:    []int{http.StatusBadRequest},
+	},
+	"GET /movies/live": {
+		summary:     "Edit the movies collaboratively over a WebSocket",
+		description: "Messages are exchanged as SocketMessage objects. Browsers may pass the token as access_token query parameter.",
+		tag:         tagMovies,
+		status:      http.StatusSwitchingProtocols,
+		errors:      []int{http.StatusUnauthorized},
+	},
+	"GET /movies/{id}": {
+		summary: "Get a movie",
+		description: "Translated movies are answered with the locale of their name as Content-Language. " +
+			"Movies merged into others are redirected to the surviving movie.",
+		tag:      tagMovies,
+		headers:  []*openapi3.Parameter{acceptLanguageHeader},
+		status:   http.StatusOK,
+		response: model.Movie{},
+		errors:   []int{http.StatusTemporaryRedirect, http.StatusBadRequest, http.StatusNotFound},
+	},
+	"PUT /movies/{id}": {
+		summary:        "Update a movie",
+		description:    "Changes based on an outdated version are rejected with a conflict.",
+		tag:            tagMovies,
+		body:           model.Movie{},
+		ignoreReadOnly: true,
+		status:         http.StatusOK,
+		errors:         []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusUnsupportedMediaType},
+	},
+	"DELETE /movies/{id}": {
+		summary: "Move a movie to the trash",
+		tag:     tagMovies,
+		status:  http.StatusNoContent,
+		errors:  []int{http.StatusBadRequest, http.StatusNotFound},
+	},
+	"POST /movies/{id}:restore": {
+		summary: "Restore a movie from the trash",
+		tag:     tagMovies,
+		status:  http.StatusOK,
+		errors:  []int{http.StatusBadRequest, http.StatusNotFound},
+	},
+	"POST /movies/{id}:merge": {
+		summary: "Merge a movie into another one",
+		description: "Moves the credits, genres, tags, reviews, watchlist entries, poster, translations, releases and collection onto the surviving movie, " +
+			"keeping its own where both have one. The merged movie is deleted and redirected to the surviving movie, which is answered.",
+		tag:      tagMovies,
+		body:     model.MergeRequest{},
+		status:   http.StatusOK,
+		response: model.Movie{},
+		errors:   []int{http.StatusBadRequest, http.StatusNotFound, http.StatusUnsupportedMediaType},
+	},
+	"GET /movies/{id}/history": {
+		summary:  "Get the audit history of a movie",
+		tag:      tagAudit,
+		status:   http.StatusOK,
+		response: []model.AuditEntry{},
+		errors:   []int{http.StatusBadRequest},
+	},
+	"GET /movies/{id}/credits": {
+		summary:     "List the cast and crew of a movie",
+		description: "Directors are listed first, then writers and actors.",
+		tag:         tagPeople,
+		status:      http.StatusOK,
+		response:    []model.Credit{},
+		errors:      []int{http.StatusBadRequest, http.StatusNotFound},
+	},
+	"POST /movies/{id}/credits": {
+		summary:     "Credit a person for a movie",
+		description: "Only actors are credited along with a character.",
+		tag:         tagPeople,
+		body:        model.Credit{},
+		status:      http.StatusCreated,
+		response:    model.Credit{},
+		errors:      []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusUnsupportedMediaType},
+	},
+	"DELETE /movies/{id}/credits/{creditId}": {
+		summary: "Remove a credit of a movie",
+		tag:     tagPeople,
+		status:  http.StatusNoContent,
+		errors:  []int{http.StatusBadRequest, http.StatusNotFound},
+	},
+	"GET /people": {
+		summary:  "List the people",
+		tag:      tagPeople,
+		status:   http.StatusOK,
+		response: []model.Person{},
+	},
+	"POST /people": {
+		summary:  "Create a person",
+		tag:      tagPeople,
+		body:     model.Person{},
+		status:   http.StatusCreated,
+		response: model.Person{},
+		errors:   []int{http.StatusBadRequest, http.StatusUnsupportedMediaType},
+	},
+	"GET /people/{id}": {
+		summary:  "Get a person",
+		tag:      tagPeople,
+		status:   http.StatusOK,
+		response: model.Person{},
+		errors:   []int{http.StatusBadRequest, http.StatusNotFound},
+	},
+	"PUT /people/{id}": {
+		summary: "Update a person",
+		tag:     tagPeople,
+		body:    model.Person{},
+		status:  http.StatusOK,
+		errors:  []int{http.StatusBadRequest, http.StatusNotFound, http.StatusUnsupportedMediaType},
+	},
+	"DELETE /people/{id}": {
+		summary:     "Delete a person",
+		description: "People who are still credited for any movie cannot be deleted.",
+		tag:         tagPeople,
+		status:      http.StatusNoContent,
+		errors:      []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict},
+	},
+	"GET /people/{id}/filmography": {
+		summary:     "List the credits of a person",
+		description: "Movies in the trash are left out.",
+		tag:         tagPeople,
+		status:      http.StatusOK,
+		response:    []model.Credit{},
+		errors:      []int{http.StatusBadRequest, http.StatusNotFound},
+	},
+	"GET /movies/{id}/genres": {
+		summary:  "List the genres of a movie",
+		tag:      tagGenres,
+		status:   http.StatusOK,
+		response: []model.Genre{},
+		errors:   []int{http.StatusBadRequest, http.StatusNotFound},
+	},
+	"PUT /movies/{id}/genres/{genreId}": {
+		summary:     "Link a genre to a movie",
+		description: "Linking a genre again changes nothing. Movies in the trash cannot be linked.",
+		tag:         tagGenres,
+		status:      http.StatusNoContent,
+		errors:      []int{http.StatusBadRequest, http.StatusNotFound},
+	},
+	"DELETE /movies/{id}/genres/{genreId}": {
+		summary: "Unlink a genre from a movie",
+		tag:     tagGenres,
+		status:  http.StatusNoContent,
+		errors:  []int{http.StatusBadRequest, http.StatusNotFound},
+	},
+	"GET /genres": {
+		summary:  "List the genres",
+		tag:      tagGenres,
+		status:   http.StatusOK,
+		response: []model.Genre{},
+	},
+	"POST /genres": {
+		summary:  "Create a genre",
+		tag:      tagGenres,
+		body:     model.Genre{},
+		status:   http.StatusCreated,
+		response: model.Genre{},
+		errors:   []int{http.StatusBadRequest, http.StatusConflict, http.StatusUnsupportedMediaType},
+	},
+	"GET /genres/counts": {
+		summary:     "Count the movies of every genre",
+		description: "Movies in the trash are left out.",
+		tag:         tagGenres,
+		status:      http.StatusOK,
+		response:    []model.GenreCount{},
+	},
+	"GET /genres/{id}": {
+		summary:  "Get a genre",
+		tag:      tagGenres,
+		status:   http.StatusOK,
+		response: model.Genre{},
+		errors:   []int{http.StatusBadRequest, http.StatusNotFound},
+	},
+	"PUT /genres/{id}": {
+		summary: "Update a genre",
+		tag:     tagGenres,
+		body:    model.Genre{},
+		status:  http.StatusOK,
+		errors:  []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusUnsupportedMediaType},
+	},
+	"DELETE /genres/{id}": {
+		summary:     "Delete a genre",
+		description: "The genre is unlinked from its movies.",
+		tag:         tagGenres,
+		status:      http.StatusNoContent,
+		errors:      []int{http.StatusBadRequest, http.StatusNotFound},
+	},
+	"GET /collections": {
+		summary:  "List the collections",
+		tag:      tagCollections,
+		status:   http.StatusOK,
+		response: []model.Collection{},
+	},
+	"POST /collections": {
+		summary:  "Create a collection",
+		tag:      tagCollections,
+		body:     model.Collection{},
+		status:   http.StatusCreated,
+		response: model.Collection{},
+		errors:   []int{http.StatusBadRequest, http.StatusConflict, http.StatusUnsupportedMediaType},
+	},
+	"GET /collections/{id}": {
+		summary:  "Get a collection",
+		tag:      tagCollections,
+		status:   http.StatusOK,
+		response: model.Collection{},
+		errors:   []int{http.StatusBadRequest, http.StatusNotFound},
+	},
+	"PUT /collections/{id}": {
+		summary: "Update a collection",
+		tag:     tagCollections,
+		body:    model.Collection{},
+		status:  http.StatusOK,
+		errors:  []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusUnsupportedMediaType},
+	},
+	"DELETE /collections/{id}": {
+		summary:     "Delete a collection",
+		description: "The movies of the collection are left without one.",
+		tag:         tagCollections,
+		status:      http.StatusNoContent,
+		errors:      []int{http.StatusBadRequest, http.StatusNotFound},
+	},
+	"GET /collections/{id}/movies": {
+		summary:     "List the movies of a collection",
+		description: "The movies are listed in their order, movies in the trash are left out.",
+		tag:         tagCollections,
+		status:      http.StatusOK,
+		response:    []model.Movie{},
+		errors:      []int{http.StatusBadRequest, http.StatusNotFound},
+	},
+	"PUT /collections/{id}/movies/{movieId}": {
+		summary:     "Add a movie to a collection",
+		description: "The movie is appended to the collection, adding it again changes nothing. A movie belongs to one collection at most, movies of other collections are conflicting. Movies in the trash cannot be added.",
+		tag:         tagCollections,
+		status:      http.StatusNoContent,
+		errors:      []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict},
+	},
+	"DELETE /collections/{id}/movies/{movieId}": {
+		summary:     "Remove a movie from a collection",
+		description: "The following movies move up to close the gap.",
+		tag:         tagCollections,
+		status:      http.StatusNoContent,
+		errors:      []int{http.StatusBadRequest, http.StatusNotFound},
+	},
+	"PUT /collections/{id}/order": {
+		summary:     "Reorder the movies of a collection",
+		description: "The order lists every movie of the collection once, other orders are conflicting.",
+		tag:         tagCollections,
+		body:        model.CollectionOrder{},
+		status:      http.StatusNoContent,
+		errors:      []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusUnsupportedMediaType},
+	},
+	"GET /movies/{id}/tags": {
+		summary:  "List the tags of a movie",
+		tag:      tagTags,
+		status:   http.StatusOK,
+		response: []model.Tag{},
+		errors:   []int{http.StatusBadRequest, http.StatusNotFound},
+	},
+	"PUT /movies/{id}/tags/{tagId}": {
+		summary:     "Tag a movie",
+		description: "Tagging a movie again changes nothing. Movies in the trash cannot be tagged.",
+		tag:         tagTags,
+		status:      http.StatusNoContent,
+		errors:      []int{http.StatusBadRequest, http.StatusNotFound},
+	},
+	"DELETE /movies/{id}/tags/{tagId}": {
+		summary: "Untag a movie",
+		tag:     tagTags,
+		status:  http.StatusNoContent,
+		errors:  []int{http.StatusBadRequest, http.StatusNotFound},
+	},
+	"GET /tags": {
+		summary:  "List the tags",
+		tag:      tagTags,
+		status:   http.StatusOK,
+		response: []model.Tag{},
+	},
+	"POST /tags": {
+		summary:  "Create a tag",
+		tag:      tagTags,
+		body:     model.Tag{},
+		status:   http.StatusCreated,
+		response: model.Tag{},
+		errors:   []int{http.StatusBadRequest, http.StatusConflict, http.StatusUnsupportedMediaType},
+	},
+	"GET /tags/{id}": {
+		summary:  "Get a tag",
+		tag:      tagTags,
+		status:   http.StatusOK,
+		response: model.Tag{},
+		errors:   []int{http.StatusBadRequest, http.StatusNotFound},
+	},
+	"PUT /tags/{id}": {
+		summary: "Update a tag",
+		tag:     tagTags,
+		body:    model.Tag{},
+		status:  http.StatusOK,
+		errors:  []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusUnsupportedMediaType},
+	},
+	"DELETE /tags/{id}": {
+		summary:     "Delete a tag",
+		description: "The tag is removed from its movies.",
+		tag:         tagTags,
+		status:      http.StatusNoContent,
+		errors:      []int{http.StatusBadRequest, http.StatusNotFound},
+	},
+	"GET /movies/{id}/similar": {
+		summary:     "List the movies similar to a movie",
+		description: "The movies are ranked by the genres, tags and people they share and the words of their names and synopses, the most similar first. The ranking follows changes of the catalog after a short delay.",
+		tag:         tagMovies,
+		query:       []*openapi3.Parameter{limitParameter(10, 50)},
+		status:      http.StatusOK,
+		response:    []model.SimilarMovie{},
+		errors:      []int{http.StatusBadRequest, http.StatusNotFound},
+	},
+	"GET /movies/{id}/translations": {
+		summary:  "List the translations of a movie",
+		tag:      tagMovies,
+		status:   http.StatusOK,
+		response: []model.Translation{},
+		errors:   []int{http.StatusBadRequest, http.StatusNotFound},
+	},
+	"GET /movies/{id}/translations/{locale}": {
+		summary:      "Get the translation of a movie into a locale",
+		tag:          tagMovies,
+		stringParams: []string{"locale"},
+		status:       http.StatusOK,
+		response:     model.Translation{},
+		errors:       []int{http.StatusBadRequest, http.StatusNotFound},
+	},
+	"PUT /movies/{id}/translations/{locale}": {
+		summary:      "Translate a movie into a locale",
+		description:  "Locales are language tags such as \"de\" or \"pt-BR\". Translating a movie again replaces the translation. Translations without a synopsis fall back to the synopsis of a more general locale.",
+		tag:          tagMovies,
+		stringParams: []string{"locale"},
+		body:         model.Translation{},
+		status:       http.StatusCreated,
+		response:     model.Translation{},
+		errors:       []int{http.StatusBadRequest, http.StatusNotFound, http.StatusUnsupportedMediaType},
+	},
+	"DELETE /movies/{id}/translations/{locale}": {
+		summary:      "Delete the translation of a movie into a locale",
+		tag:          tagMovies,
+		stringParams: []string{"locale"},
+		status:       http.StatusNoContent,
+		errors:       []int{http.StatusBadRequest, http.StatusNotFound},
+	},
+	"GET /movies/{id}/releases": {
+		summary:  "List the releases of a movie",
+		tag:      tagReleases,
+		status:   http.StatusOK,
+		response: []model.Release{},
+		errors:   []int{http.StatusBadRequest, http.StatusNotFound},
+	},
+	"GET /movies/{id}/releases/{region}": {
+		summary:      "Get the release of a movie in a region",
+		tag:          tagReleases,
+		stringParams: []string{"region"},
+		status:       http.StatusOK,
+		response:     model.Release{},
+		errors:       []int{http.StatusBadRequest, http.StatusNotFound},
+	},
+	"PUT /movies/{id}/releases/{region}": {
+		summary:      "Release a movie in a region",
+		description:  "Regions are ISO 3166-1 alpha-2 codes such as \"DE\". Releasing a movie again replaces the release along with every window. Windows may not start before the release, and windows of the same kind may not overlap.",
+		tag:          tagReleases,
+		stringParams: []string{"region"},
+		body:         model.Release{},
+		status:       http.StatusCreated,
+		response:     model.Release{},
+		errors:       []int{http.StatusBadRequest, http.StatusNotFound, http.StatusUnsupportedMediaType},
+	},
+	"DELETE /movies/{id}/releases/{region}": {
+		summary:      "Delete the release of a movie in a region",
+		tag:          tagReleases,
+		stringParams: []string{"region"},
+		status:       http.StatusNoContent,
+		errors:       []int{http.StatusBadRequest, http.StatusNotFound},
+	},
+	"GET /movies/{id}/poster": {
+		summary:  "Get the poster of a movie",
+		tag:      tagPosters,
+		status:   http.StatusOK,
+		response: model.Poster{},
+		errors:   []int{http.StatusBadRequest, http.StatusNotFound},
+	},
+	"PUT /movies/{id}/poster": {
+		summary:     "Upload the poster of a movie",
+		description: "JPEG and PNG images of up to 5 MiB and 6000 pixels a side are accepted, telling their type by their content. Thumbnails 154, 342 and 780 pixels wide are generated from them. Answers 201 for the first poster of the movie and 200 for replacing it.",
+		tag:         tagPosters,
+		upload:      "poster",
+		// Leaving room for the multipart framing around the image
+		maxBodySize: model.MaxPosterSize + 64<<10,
+		status:      http.StatusCreated,
+		response:    model.Poster{},
+		errors:      []int{http.StatusBadRequest, http.StatusNotFound, http.StatusUnsupportedMediaType},
+	},
+	"DELETE /movies/{id}/poster": {
+		summary: "Delete the poster of a movie",
+		tag:     tagPosters,
+		status:  http.StatusNoContent,
+		errors:  []int{http.StatusBadRequest, http.StatusNotFound},
+	},
+	"GET /posters/{hash}/{file}": {
+		summary:      "Get an image of a poster",
+		description:  "The images are named original.jpg or original.png for the upload and small.jpg, medium.jpg or large.jpg for the thumbnails. They never change under their path, so they may be cached for good.",
+		tag:          tagPosters,
+		stringParams: []string{"hash", "file"},
+		status:       http.StatusOK,
+		mediaType:    "image/*",
+		errors:       []int{http.StatusNotModified, http.StatusNotFound},
+	},
+	"GET /movies/{id}/reviews": {
+		summary:     "List the reviews of a movie",
+		description: "Only the approved reviews are listed unless another status is asked for. The Link header of full pages refers to the next one.",
+		tag:         tagReviews,
+		query:       reviewsQuery,
+		status:      http.StatusOK,
+		response:    []model.Review{},
+		errors:      []int{http.StatusBadRequest, http.StatusNotFound},
+	},
+	"POST /movies/{id}/reviews": {
+		summary:     "Review a movie",
+		description: "Every user reviews a movie once, reviewing it again replaces the review. New and replaced reviews are pending until they are moderated. Anonymous users cannot review.",
+		tag:         tagReviews,
+		body:        model.Review{},
+		status:      http.StatusCreated,
+		response:    model.Review{},
+		errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusUnsupportedMediaType},
+	},
+	"PUT /movies/{id}/reviews/{reviewId}/status": {
+		summary:     "Moderate a review",
+		description: "Only the moderators configured by APP_MODERATORS may moderate. Rejected reviews do not count towards the rating of the movie.",
+		tag:         tagReviews,
+		body:        model.Moderation{},
+		status:      http.StatusOK,
+		response:    model.Review{},
+		errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound,
+			http.StatusUnsupportedMediaType},
+	},
+	"DELETE /movies/{id}/reviews/{reviewId}": {
+		summary:     "Delete a review",
+		description: "Only the author of a review may delete it.",
+		tag:         tagReviews,
+		status:      http.StatusNoContent,
+		errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound},
+	},
+	"GET /reviews": {
+		summary:     "List the reviews of every movie for moderation",
+		description: "Only the pending reviews are listed unless another status is asked for. The Link header of full pages refers to the next one.",
+		tag:         tagReviews,
+		query:       reviewsQuery,
+		status:      http.StatusOK,
+		response:    []model.Review{},
+		errors:      []int{http.StatusBadRequest},
+	},
+	"GET /watchlists": {
+		summary:     "List the watchlists of the user",
+		description: "The lists are listed without their movies. Anonymous users have no lists.",
+		tag:         tagWatchlists,
+		status:      http.StatusOK,
+		response:    []model.Watchlist{},
+		errors:      []int{http.StatusUnauthorized},
+	},
+	"POST /watchlists": {
+		summary:     "Create a watchlist",
+		description: "The names of the lists of a user are unique.",
+		tag:         tagWatchlists,
+		body:        model.Watchlist{},
+		status:      http.StatusCreated,
+		response:    model.Watchlist{},
+		errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusConflict, http.StatusUnsupportedMediaType},
+	},
+	"GET /watchlists/{id}": {
+		summary:     "Get a watchlist with its movies",
+		description: "Movies in the trash stay on the list flagged as deleted until they are purged.",
+		tag:         tagWatchlists,
+		status:      http.StatusOK,
+		response:    model.Watchlist{},
+		errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound},
+	},
+	"PUT /watchlists/{id}": {
+		summary: "Rename a watchlist",
+		tag:     tagWatchlists,
+		body:    model.Watchlist{},
+		status:  http.StatusOK,
+		errors:  []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusConflict, http.StatusUnsupportedMediaType},
+	},
+	"DELETE /watchlists/{id}": {
+		summary: "Delete a watchlist",
+		tag:     tagWatchlists,
+		status:  http.StatusNoContent,
+		errors:  []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound},
+	},
+	"PUT /watchlists/{id}/movies/{movieId}": {
+		summary:     "Add a movie to a watchlist",
+		description: "The movie is added to the end of the list, movies already on the list keep their position.",
+		tag:         tagWatchlists,
+		status:      http.StatusNoContent,
+		errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound},
+	},
+	"DELETE /watchlists/{id}/movies/{movieId}": {
+		summary: "Remove a movie from a watchlist",
+		tag:     tagWatchlists,
+		status:  http.StatusNoContent,
+		errors:  []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound},
+	},
+	"PUT /watchlists/{id}/order": {
+		summary:     "Reorder the movies of a watchlist",
+		description: "The order lists every movie of the list once, other orders are conflicting.",
+		tag:         tagWatchlists,
+		body:        model.WatchlistOrder{},
+		status:      http.StatusNoContent,
+		errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusConflict, http.StatusUnsupportedMediaType},
+	},
+	"POST /watchlists/{id}/share": {
+		summary:     "Share a watchlist",
+		description: "Anyone knowing the token may read the list. Sharing a shared list answers its current token.",
+		tag:         tagWatchlists,
+		status:      http.StatusOK,
+		response:    model.WatchlistShare{},
+		errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound},
+	},
+	"DELETE /watchlists/{id}/share": {
+		summary:     "Stop sharing a watchlist",
+		description: "The token of the list is revoked, sharing the list again creates a new one.",
+		tag:         tagWatchlists,
+		status:      http.StatusNoContent,
+		errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound},
+	},
+	"GET /shared/watchlists/{token}": {
+		summary:      "Get a shared watchlist",
+		description:  "Shared lists are public, their owner is left out.",
+		tag:          tagWatchlists,
+		stringParams: []string{"token"},
+		status:       http.StatusOK,
+		response:     model.Watchlist{},
+		errors:       []int{http.StatusNotFound},
+	},
+	"GET /audit": {
+		summary: "Get the audit log",
+		tag:     tagAudit,
+		query: []*openapi3.Parameter{
+			openapi3.NewQueryParameter("actor").WithSchema(openapi3.NewStringSchema()),
+			openapi3.NewQueryParameter("from").WithSchema(openapi3.NewDateTimeSchema()),
+			openapi3.NewQueryParameter("to").WithSchema(openapi3.NewDateTimeSchema()),
+			limitParameter(100, 1000),
+		},
+		status:   http.StatusOK,
+		response: []model.AuditEntry{},
+		errors:   []int{http.StatusBadRequest},
+	},
+	"GET /webhooks": {
+		summary:  "List the webhook subscriptions",
+		tag:      tagWebhooks,
+		status:   http.StatusOK,
+		response: []model.Subscription{},
+	},
+	"POST /webhooks": {
+		summary:     "Subscribe a webhook",
+		description: "Requires a bearer token. Private, loopback and link-local receivers are rejected.",
+		tag:         tagWebhooks,
+		body:        model.Subscription{},
+		status:      http.StatusCreated,
+		response:    model.Subscription{},
+		errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusConflict, http.StatusUnsupportedMediaType},
+	},
+	"GET /webhooks/dead-letters": {
+		summary:  "List the deliveries which ran out of retries",
+		tag:      tagWebhooks,
+		status:   http.StatusOK,
+		response: []model.Delivery{},
+	},
+	"GET /webhooks/{id}": {
+		summary:  "Get a webhook subscription",
+		tag:      tagWebhooks,
+		status:   http.StatusOK,
+		response: model.Subscription{},
+		errors:   []int{http.StatusBadRequest, http.StatusNotFound},
+	},
+	"PUT /webhooks/{id}": {
+		summary:     "Update a webhook subscription",
+		description: "Requires a bearer token. Private, loopback and link-local receivers are rejected.",
+		tag:         tagWebhooks,
+		body:        model.Subscription{},
+		status:      http.StatusOK,
+		errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusUnsupportedMediaType},
+	},
+	"DELETE /webhooks/{id}": {
+		summary:     "Unsubscribe a webhook",
+		description: "Requires a bearer token.",
+		tag:         tagWebhooks,
+		status:      http.StatusNoContent,
+		errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound},
+	},
+	"GET /webhooks/{id}/deliveries": {
+		summary:     "List the deliveries of a webhook subscription",
+		description: "Pending deliveries are listed along with when they are attempted next.",
+		tag:         tagWebhooks,
+		status:      http.StatusOK,
+		response:    []model.Delivery{},
+		errors:      []int{http.StatusBadRequest, http.StatusNotFound},
+	},
+	"POST /graphql": {
+		summary:     "Execute a GraphQL operation",
+		description: "Errors of the operation are reported in the response body.",
+		tag:         tagGraphql,
+		body:        graphqlRequest{},
+		status:      http.StatusOK,
+		response:    graphqlResponse{},
+		mediaType:   "application/json",
+		errors:      []int{http.StatusBadRequest},
+	},
+	"GET /openapi.json": {
+		summary:   "Get this document",
+		tag:       tagDocs,
+		status:    http.StatusOK,
+		mediaType: "application/json",
+	},
+	"GET /docs": {
+		summary:   "Browse this document",
+		tag:       tagDocs,
+		status:    http.StatusOK,
+		mediaType: "text/html",
+	},
+}
@@ -0,0 +1,21 @@
+package openapi
+
+import (
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+
+	"github.com/gorilla/mux"
+)
+
+// InitializeOpenApiPipeline has to run after all the other pipelines, as it documents the routes registered so far
+func InitializeOpenApiPipeline(api *model.Api) {
+	c := NewOpenApiController(api.Router)
+	setRouting(api.Router, c)
+}
+
+func setRouting(main *mux.Router, c OpenApiController) {
+	main.HandleFunc("/openapi.json", c.GetSpec).
+		Methods("GET")
+
+	main.HandleFunc("/docs", c.GetDocs).
+		Methods("GET")
+}
@@ -0,0 +1,45 @@
+package openapi_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/openapi"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInitializeOpenApiPipeline(t *testing.T) {
+	api := model.Api{Router: mux.NewRouter()}
+	openapi.InitializeOpenApiPipeline(&api)
+
+	testIntegrationGetSpec(t, api.Router)
+	testIntegrationGetDocs(t, api.Router)
+}
+
+func testIntegrationGetSpec(t *testing.T, r *mux.Router) {
+	req, _ := http.NewRequest("GET", "/openapi.json", nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/json; charset=utf-8", rr.Header().Get("Content-Type"))
+	spec, err := openapi3.NewLoader().LoadFromData(rr.Body.Bytes())
+	assert.Nil(t, err)
+	assert.NotNil(t, spec.Paths.Find("/openapi.json").Get, "The document should describe itself")
+}
+
+func testIntegrationGetDocs(t *testing.T, r *mux.Router) {
+	req, _ := http.NewRequest("GET", "/docs", nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `spec-url="/openapi.json"`)
+	assert.NotContains(t, rr.Body.String(), "/latest/", "The Redoc bundle should be pinned")
+	assert.Contains(t, rr.Header().Get("Content-Security-Policy"), "script-src https://cdn.redoc.ly/redoc/v2.1.5/bundles/redoc.standalone.js;")
+}
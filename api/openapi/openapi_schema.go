@@ -0,0 +1,166 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+const componentsPrefix = "#/components/schemas/"
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// schemaGenerator derives the schemas from the Go types, named structs are collected as components.
+// Properties are named after the JSON tags, the "validate" tags are translated into constraints.
+// Structs do not allow properties besides their fields, so unknown fields are rejected.
+type schemaGenerator struct {
+	schemas openapi3.Schemas
+}
+
+func newSchemaGenerator() *schemaGenerator {
+	return &schemaGenerator{schemas: make(openapi3.Schemas)}
+}
+
+// ref returns the schema of the type, referencing the component of named structs
+func (g *schemaGenerator) ref(t reflect.Type) *openapi3.SchemaRef {
+	nullable := false
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+		nullable = true
+	}
+
+	var ref *openapi3.SchemaRef
+	if t.Kind() == reflect.Struct && t != timeType && t.Name() != "" {
+		ref = g.component(t)
+	} else {
+		ref = openapi3.NewSchemaRef("", g.schema(t))
+	}
+
+	if nullable && ref.Ref == "" {
+		ref.Value.Nullable = true
+	}
+	return ref
+}
+
+func (g *schemaGenerator) component(t reflect.Type) *openapi3.SchemaRef {
+	name := componentName(t)
+	if existing, ok := g.schemas[name]; ok {
+		return openapi3.NewSchemaRef(componentsPrefix+name, existing.Value)
+	}
+
+	// Registered ahead of the properties, so self-referencing types terminate
+	s := openapi3.NewObjectSchema().WithoutAdditionalProperties()
+	g.schemas[name] = openapi3.NewSchemaRef("", s)
+	g.properties(s, t)
+	return openapi3.NewSchemaRef(componentsPrefix+name, s)
+}
+
+func (g *schemaGenerator) schema(t reflect.Type) *openapi3.Schema {
+	switch {
+	case t == timeType:
+		return openapi3.NewDateTimeSchema()
+	case t == rawMessageType:
+		// Arbitrary JSON, such as the audited snapshots
+		return &openapi3.Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return openapi3.NewBoolSchema()
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return openapi3.NewInt32Schema()
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return openapi3.NewInt64Schema()
+	case reflect.Float32, reflect.Float64:
+		return openapi3.NewFloat64Schema()
+	case reflect.String:
+		return openapi3.NewStringSchema()
+	case reflect.Slice, reflect.Array:
+		s := openapi3.NewArraySchema()
+		s.Items = g.ref(t.Elem())
+		return s
+	case reflect.Map:
+		s := openapi3.NewObjectSchema()
+		s.AdditionalProperties = openapi3.AdditionalProperties{Schema: g.ref(t.Elem())}
+		return s
+	case reflect.Struct:
+		s := openapi3.NewObjectSchema().WithoutAdditionalProperties()
+		g.properties(s, t)
+		return s
+	default:
+		// Interfaces may hold any value
+		return &openapi3.Schema{}
+	}
+}
+
+func (g *schemaGenerator) properties(s *openapi3.Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, ok := jsonName(f)
+		if !ok {
+			continue
+		}
+
+		prop := g.ref(f.Type)
+		for _, rule := range strings.Split(f.Tag.Get("validate"), ",") {
+			rule, param, _ := strings.Cut(rule, "=")
+			switch rule {
+			case "required":
+				s.Required = append(s.Required, name)
+				// Empty values are rejected the same way as missing ones
+				if prop.Ref == "" && prop.Value.Type == openapi3.TypeString {
+					prop.Value.MinLength = 1
+				}
+				if prop.Ref == "" && prop.Value.Type == openapi3.TypeArray {
+					prop.Value.MinItems = 1
+				}
+			case "url":
+				prop.Value.Format = "uri"
+				prop.Value.Pattern = "^https?://"
+			case "min":
+				if v, err := strconv.ParseFloat(param, 64); err == nil {
+					prop.Value.Min = &v
+				}
+			case "max":
+				if v, err := strconv.ParseFloat(param, 64); err == nil {
+					prop.Value.Max = &v
+				}
+			case "slug":
+				prop.Value.Pattern = model.SlugPattern
+			case "oneof":
+				for _, value := range strings.Fields(param) {
+					prop.Value.Enum = append(prop.Value.Enum, value)
+				}
+			}
+		}
+		s.Properties[name] = prop
+	}
+}
+
+// jsonName returns the name of the field as encoded to JSON, unexported and ignored fields are skipped
+func jsonName(f reflect.StructField) (string, bool) {
+	if !f.IsExported() {
+		return "", false
+	}
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	switch name {
+	case "-":
+		return "", false
+	case "":
+		return f.Name, true
+	}
+	return name, true
+}
+
+func componentName(t reflect.Type) string {
+	return strings.ToUpper(t.Name()[:1]) + t.Name()[1:]
+}
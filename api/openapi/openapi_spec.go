@@ -0,0 +1,220 @@
+package openapi
+
+import (
+	"log"
+	"net/http"
+	"reflect"
+	"regexp"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/event"
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gorilla/mux"
+)
+
+const (
+	title   = "Movies API"
+	version = "1.0.0"
+)
+
+var pathParameter = regexp.MustCompile(`{([^}:]+)(:[^}]+)?}`)
+
+// NewSpec documents the routes registered on the router, as described by the operations.
+// Routes without a description are logged and left out.
+func NewSpec(router *mux.Router) (*openapi3.T, error) {
+	g := newSchemaGenerator()
+	spec := &openapi3.T{
+		OpenAPI: "3.0.3",
+		Info: &openapi3.Info{
+			Title:       title,
+			Version:     version,
+			Description: "Requests without a bearer token are served anonymously, unknown tokens are rejected.",
+		},
+		Paths: make(openapi3.Paths),
+		Components: &openapi3.Components{
+			Schemas: g.schemas,
+			SecuritySchemes: openapi3.SecuritySchemes{
+				"bearer": &openapi3.SecuritySchemeRef{Value: openapi3.NewSecurityScheme().WithType("http").WithScheme("bearer")},
+			},
+		},
+		// Anonymous requests are allowed as well
+		Security: openapi3.SecurityRequirements{{}, {"bearer": []string{}}},
+	}
+
+	err := router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		// Prefixes of subrouters do not match any method
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+
+		for _, method := range methods {
+			op, ok := operations[method+" "+path]
+			if !ok {
+				log.Println("[OpenAPI] ", "undocumented route ", method, " ", path)
+				continue
+			}
+			if spec.Paths[path] == nil {
+				spec.Paths[path] = &openapi3.PathItem{}
+			}
+			spec.Paths[path].SetOperation(method, g.operation(path, op))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Messages of the collaborative editing socket, which are not part of any HTTP body
+	g.ref(reflect.TypeOf(model.SocketMessage{}))
+	refine(g.schemas)
+
+	return spec, nil
+}
+
+// refine adds the constraints which cannot be expressed by the struct tags
+func refine(schemas openapi3.Schemas) {
+	if s, ok := schemas["Subscription"]; ok {
+		types := make([]interface{}, len(event.Types))
+		for i, t := range event.Types {
+			types[i] = t
+		}
+		s.Value.Properties["event_types"].Value.Items.Value.Enum = types
+		// The secrets are write-only
+		s.Value.Properties["secret"].Value.WriteOnly = true
+	}
+	if s, ok := schemas["Movie"]; ok {
+		// The ratings are maintained by the reviews, requests sending them back have them ignored
+		s.Value.Properties["rating_average"].Value.ReadOnly = true
+		s.Value.Properties["rating_count"].Value.ReadOnly = true
+	}
+	if s, ok := schemas["Review"]; ok {
+		for _, name := range []string{"id", "movie_id", "user", "status", "created_at", "updated_at"} {
+			s.Value.Properties[name].Value.ReadOnly = true
+		}
+	}
+	if s, ok := schemas["Translation"]; ok {
+		// The movie and the locale are identified by the path
+		for _, name := range []string{"movie_id", "locale", "updated_at"} {
+			s.Value.Properties[name].Value.ReadOnly = true
+		}
+	}
+	if s, ok := schemas["Release"]; ok {
+		// The movie and the region are identified by the path
+		for _, name := range []string{"movie_id", "region", "updated_at"} {
+			s.Value.Properties[name].Value.ReadOnly = true
+		}
+	}
+	if s, ok := schemas["Collection"]; ok {
+		s.Value.Properties["id"].Value.ReadOnly = true
+	}
+	if s, ok := schemas["CollectionRef"]; ok {
+		// The collections of the movies are maintained by the collections, movies sending them back have them ignored
+		for _, name := range []string{"id", "name", "position"} {
+			s.Value.Properties[name].Value.ReadOnly = true
+		}
+	}
+	if s, ok := schemas["Watchlist"]; ok {
+		for _, name := range []string{"id", "owner", "share_token", "created_at", "entries"} {
+			s.Value.Properties[name].Value.ReadOnly = true
+		}
+	}
+}
+
+func (g *schemaGenerator) operation(path string, op operation) *openapi3.Operation {
+	o := openapi3.NewOperation()
+	o.Summary = op.summary
+	o.Description = op.description
+	o.Tags = []string{op.tag}
+
+	for _, match := range pathParameter.FindAllStringSubmatch(path, -1) {
+		schema := openapi3.NewIntegerSchema()
+		for _, name := range op.stringParams {
+			if name == match[1] {
+				schema = openapi3.NewStringSchema()
+			}
+		}
+		p := openapi3.NewPathParameter(match[1]).WithSchema(schema)
+		o.Parameters = append(o.Parameters, &openapi3.ParameterRef{Value: p})
+	}
+	for _, p := range op.query {
+		o.Parameters = append(o.Parameters, &openapi3.ParameterRef{Value: p})
+	}
+	for _, p := range op.headers {
+		o.Parameters = append(o.Parameters, &openapi3.ParameterRef{Value: p})
+	}
+
+	if op.body != nil {
+		schema := g.ref(reflect.TypeOf(op.body))
+		mediaTypes := []string{util.MediaTypeJSON, util.MediaTypeXML, util.MediaTypeMsgPack}
+		if op.mediaType != "" {
+			mediaTypes = []string{op.mediaType}
+		}
+		body := openapi3.NewRequestBody().WithRequired(true).WithSchemaRef(schema, mediaTypes)
+		o.RequestBody = &openapi3.RequestBodyRef{Value: body}
+	}
+	if op.upload != "" {
+		schema := openapi3.NewObjectSchema().
+			WithProperty(op.upload, openapi3.NewStringSchema().WithFormat("binary"))
+		schema.Required = []string{op.upload}
+		body := openapi3.NewRequestBody().WithRequired(true).WithFormDataSchema(schema)
+		o.RequestBody = &openapi3.RequestBodyRef{Value: body}
+	}
+
+	o.Responses = openapi3.Responses{}
+	o.AddResponse(op.status, g.response(op))
+	for _, status := range op.errors {
+		o.AddResponse(status, textResponse(status))
+	}
+	if op.body != nil || op.upload != "" {
+		o.AddResponse(http.StatusRequestEntityTooLarge, textResponse(http.StatusRequestEntityTooLarge))
+	}
+	if op.body != nil {
+		o.AddResponse(http.StatusUnprocessableEntity, openapi3.NewResponse().WithDescription(http.StatusText(http.StatusUnprocessableEntity)).
+			WithJSONSchemaRef(g.ref(reflect.TypeOf(validationProblem{}))))
+	}
+	// Such as an unreachable service or a rejected token
+	o.Responses["default"] = &openapi3.ResponseRef{Value: openapi3.NewResponse().WithDescription("Unexpected error").
+		WithContent(openapi3.NewContentWithSchema(openapi3.NewStringSchema(), []string{"text/plain"}))}
+
+	return o
+}
+
+func (g *schemaGenerator) response(op operation) *openapi3.Response {
+	res := openapi3.NewResponse().WithDescription(http.StatusText(op.status))
+	switch {
+	case op.response != nil:
+		t := reflect.TypeOf(op.response)
+		mediaTypes := []string{util.MediaTypeJSON, util.MediaTypeXML, util.MediaTypeMsgPack}
+		if op.mediaType != "" {
+			mediaTypes = []string{op.mediaType}
+		} else if t.Kind() == reflect.Slice {
+			// CSV is only offered for lists
+			mediaTypes = append(mediaTypes, util.MediaTypeCSV)
+		}
+		res.Content = openapi3.NewContentWithSchemaRef(g.ref(t), mediaTypes)
+	case op.mediaType != "":
+		res.Content = openapi3.NewContentWithSchema(openapi3.NewStringSchema(), []string{op.mediaType})
+	case op.status != http.StatusNoContent && op.status != http.StatusSwitchingProtocols:
+		res.Content = openapi3.NewContentWithSchema(openapi3.NewStringSchema(), []string{"text/plain"})
+	}
+	return res
+}
+
+// validationProblem documents the responses of the RequestValidator
+type validationProblem struct {
+	Message string            `json:"message"`
+	Errors  []util.FieldError `json:"errors"`
+}
+
+func textResponse(status int) *openapi3.Response {
+	return openapi3.NewResponse().
+		WithDescription(http.StatusText(status)).
+		WithContent(openapi3.NewContentWithSchema(openapi3.NewStringSchema(), []string{"text/plain"}))
+}
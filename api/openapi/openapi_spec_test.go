@@ -0,0 +1,117 @@
+package openapi_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/openapi"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func newRouter(routes map[string]string) *mux.Router {
+	r := mux.NewRouter()
+	for path, method := range routes {
+		r.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {}).Methods(method)
+	}
+	return r
+}
+
+func TestNewSpec(t *testing.T) {
+	r := newRouter(map[string]string{"/movies": "POST", "/movies/{id}": "GET", "/undocumented": "GET"})
+
+	spec, err := openapi.NewSpec(r)
+
+	assert.Nil(t, err)
+	assert.Nil(t, spec.Validate(context.Background()), "The document should be valid")
+	assert.NotNil(t, spec.Paths.Find("/movies").Post)
+	assert.Nil(t, spec.Paths.Find("/undocumented"), "Undocumented routes should be left out")
+
+	get := spec.Paths.Find("/movies/{id}").Get
+	assert.Equal(t, "id", get.Parameters[0].Value.Name)
+	assert.Equal(t, "path", get.Parameters[0].Value.In)
+	assert.Equal(t, "#/components/schemas/Movie", get.Responses.Get(http.StatusOK).Value.Content.Get("application/json").Schema.Ref)
+	assert.NotNil(t, get.Responses.Get(http.StatusNotFound))
+}
+
+func TestNewSpecMovieSchema(t *testing.T) {
+	spec, _ := openapi.NewSpec(newRouter(map[string]string{"/movies": "POST"}))
+
+	movie := spec.Components.Schemas["Movie"].Value
+	assert.ElementsMatch(t, []string{"id", "name", "synopsis", "version", "deleted_at", "rating_average", "rating_count", "collection"}, keys(movie.Properties), "Properties should be named after the JSON tags")
+	assert.Equal(t, []string{"name"}, movie.Required)
+	assert.Equal(t, uint64(1), movie.Properties["name"].Value.MinLength, "Required names should not be empty")
+	assert.Equal(t, openapi3.TypeInteger, movie.Properties["id"].Value.Type)
+	assert.Equal(t, "date-time", movie.Properties["deleted_at"].Value.Format)
+	assert.True(t, movie.Properties["deleted_at"].Value.Nullable)
+}
+
+func TestNewSpecSubscriptionSchema(t *testing.T) {
+	spec, _ := openapi.NewSpec(newRouter(map[string]string{"/webhooks": "POST"}))
+
+	subscription := spec.Components.Schemas["Subscription"].Value
+	assert.ElementsMatch(t, []string{"url", "event_types", "secret"}, subscription.Required)
+	assert.Equal(t, "uri", subscription.Properties["url"].Value.Format)
+	assert.Equal(t, uint64(1), subscription.Properties["event_types"].Value.MinItems)
+	assert.Equal(t, []interface{}{"MovieCreated", "MovieUpdated", "MovieDeleted"}, subscription.Properties["event_types"].Value.Items.Value.Enum)
+	assert.True(t, subscription.Properties["secret"].Value.WriteOnly)
+}
+
+func TestNewSpecCreditSchema(t *testing.T) {
+	spec, _ := openapi.NewSpec(newRouter(map[string]string{"/movies/{id}/credits": "POST"}))
+
+	credit := spec.Components.Schemas["Credit"].Value
+	assert.ElementsMatch(t, []string{"person_id", "role"}, credit.Required)
+	assert.Equal(t, []interface{}{"director", "actor", "writer"}, credit.Properties["role"].Value.Enum, "The oneof rule should become an enum")
+}
+
+func TestNewSpecGenreSchema(t *testing.T) {
+	spec, _ := openapi.NewSpec(newRouter(map[string]string{"/genres": "POST"}))
+
+	genre := spec.Components.Schemas["Genre"].Value
+	assert.ElementsMatch(t, []string{"slug", "name"}, genre.Required)
+	assert.Equal(t, model.SlugPattern, genre.Properties["slug"].Value.Pattern, "The slug rule should become a pattern")
+}
+
+func TestNewSpecReviewSchema(t *testing.T) {
+	spec, _ := openapi.NewSpec(newRouter(map[string]string{"/movies/{id}/reviews": "POST"}))
+
+	review := spec.Components.Schemas["Review"].Value
+	assert.Equal(t, []string{"rating"}, review.Required)
+	assert.Equal(t, float64(model.MinRating), *review.Properties["rating"].Value.Min, "The min rule should become a minimum")
+	assert.Equal(t, float64(model.MaxRating), *review.Properties["rating"].Value.Max, "The max rule should become a maximum")
+	assert.True(t, review.Properties["status"].Value.ReadOnly, "The status should be left to the moderators")
+}
+
+func TestNewSpecSharedWatchlistParameter(t *testing.T) {
+	spec, _ := openapi.NewSpec(newRouter(map[string]string{"/shared/watchlists/{token}": "GET"}))
+
+	op := spec.Paths["/shared/watchlists/{token}"].Get
+	assert.Equal(t, "token", op.Parameters[0].Value.Name)
+	assert.Equal(t, "string", op.Parameters[0].Value.Schema.Value.Type, "Share tokens should not be documented as IDs")
+}
+
+func TestNewSpecPosterUpload(t *testing.T) {
+	spec, _ := openapi.NewSpec(newRouter(map[string]string{"/movies/{id}/poster": "PUT"}))
+
+	op := spec.Paths["/movies/{id}/poster"].Put
+	upload := op.RequestBody.Value.Content.Get("multipart/form-data")
+	if assert.NotNil(t, upload, "Posters should be uploaded as forms") {
+		assert.Equal(t, "binary", upload.Schema.Value.Properties["poster"].Value.Format)
+		assert.Equal(t, []string{"poster"}, upload.Schema.Value.Required)
+	}
+	assert.NotNil(t, op.Responses.Get(http.StatusRequestEntityTooLarge))
+	assert.Nil(t, op.Responses.Get(http.StatusUnprocessableEntity), "Uploads are not validated against a schema")
+}
+
+func keys(m openapi3.Schemas) []string {
+	k := make([]string, 0, len(m))
+	for key := range m {
+		k = append(k, key)
+	}
+	return k
+}
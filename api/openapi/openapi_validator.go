@@ -0,0 +1,195 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/gorilla/mux"
+)
+
+// RequestValidator rejects the requests violating the OpenAPI document of the router before they reach the controllers.
+// Invalid parameters and malformed bodies are answered with 400, bodies violating their schema with 422,
+// each violation located by the path of the offending field. Bodies above maxBodySize bytes are refused,
+// unless their operation sets a limit of its own. Uploads are left to their controllers once their size is checked.
+// Read-only properties are refused in the bodies, except by the operations ignoring them.
+func RequestValidator(router *mux.Router, maxBodySize int64) mux.MiddlewareFunc {
+	doc := &document{router: router}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			spec, err := doc.spec()
+			if err != nil {
+				util.HandleServerError(w, err, "Document unavailable")
+				return
+			}
+			route, op, ok := findOperation(spec, r)
+
+			// Uploads may be allowed more than the other bodies
+			limit := maxBodySize
+			if op.maxBodySize > 0 {
+				limit = op.maxBodySize
+			}
+			if r.ContentLength > limit {
+				util.HandleRequestTooLarge(w, fmt.Sprintf("%d bytes to %s", r.ContentLength, r.URL.Path))
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+
+			if !ok {
+				// Undocumented routes are left to their controllers
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			input := &openapi3filter.RequestValidationInput{
+				Request:    r,
+				PathParams: mux.Vars(r),
+				Route:      route,
+				Options: &openapi3filter.Options{
+					ExcludeRequestBody:  true,
+					MultiError:          true,
+					AuthenticationFunc:  openapi3filter.NoopAuthenticationFunc,
+					SkipSettingDefaults: true,
+				},
+			}
+			if err := openapi3filter.ValidateRequest(r.Context(), input); err != nil {
+				util.HandleInvalidRequest(w, http.StatusBadRequest, parameterViolations(err))
+				return
+			}
+
+			if route.Operation.RequestBody != nil && op.upload == "" {
+				raw, err := io.ReadAll(r.Body)
+				if err != nil {
+					util.HandleRequestTooLarge(w, err.Error())
+					return
+				}
+				r.Body = io.NopCloser(bytes.NewReader(raw))
+
+				body, err := decodeBody(r, raw, op.body)
+				if err != nil {
+					util.HandleInvalidBody(w, err)
+					return
+				}
+				// The body is restored for the controller, which decodes it once more
+				r.Body = io.NopCloser(bytes.NewReader(raw))
+
+				schema := route.Operation.RequestBody.Value.Content.Get(util.MediaTypeJSON).Schema.Value
+				opts := []openapi3.SchemaValidationOption{openapi3.MultiErrors(), openapi3.VisitAsRequest()}
+				if op.ignoreReadOnly {
+					opts = append(opts, openapi3.DisableReadOnlyValidation())
+				}
+				if err := schema.VisitJSON(body, opts...); err != nil {
+					util.HandleInvalidRequest(w, http.StatusUnprocessableEntity, schemaViolations("body", err))
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func findOperation(spec *openapi3.T, r *http.Request) (*routers.Route, operation, bool) {
+	current := mux.CurrentRoute(r)
+	if current == nil {
+		return nil, operation{}, false
+	}
+	path, err := current.GetPathTemplate()
+	if err != nil {
+		return nil, operation{}, false
+	}
+	item := spec.Paths.Find(path)
+	if item == nil || item.GetOperation(r.Method) == nil {
+		return nil, operation{}, false
+	}
+
+	route := &routers.Route{Spec: spec, Path: path, PathItem: item, Method: r.Method, Operation: item.GetOperation(r.Method)}
+	return route, operations[r.Method+" "+path], true
+}
+
+// decodeBody decodes the body into its JSON representation, which the schema is validated against.
+// Other media types are decoded into the Go type of the body first, and encoded to JSON again.
+func decodeBody(r *http.Request, raw []byte, t interface{}) (interface{}, error) {
+	var body interface{}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "" || mediaType == util.MediaTypeJSON {
+		if err := json.Unmarshal(raw, &body); err != nil {
+			return nil, err
+		}
+		return body, nil
+	}
+
+	v := reflect.New(reflect.TypeOf(t)).Interface()
+	if err := util.DecodeRequest(r, v); err != nil {
+		return nil, err
+	}
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(encoded, &body)
+	return body, err
+}
+
+func parameterViolations(err error) []util.FieldError {
+	var violations []util.FieldError
+	for _, e := range flatten(err) {
+		var reqErr *openapi3filter.RequestError
+		if !errors.As(e, &reqErr) || reqErr.Parameter == nil {
+			violations = append(violations, util.FieldError{Field: "request", Message: e.Error()})
+			continue
+		}
+
+		field := reqErr.Parameter.In + "." + reqErr.Parameter.Name
+		if reqErr.Err == nil {
+			violations = append(violations, util.FieldError{Field: field, Message: reqErr.Reason})
+			continue
+		}
+		violations = append(violations, schemaViolations(field, reqErr.Err)...)
+	}
+	return violations
+}
+
+func schemaViolations(prefix string, err error) []util.FieldError {
+	var violations []util.FieldError
+	for _, e := range flatten(err) {
+		var schemaErr *openapi3.SchemaError
+		if !errors.As(e, &schemaErr) {
+			violations = append(violations, util.FieldError{Field: prefix, Message: e.Error()})
+			continue
+		}
+		path := append([]string{prefix}, schemaErr.JSONPointer()...)
+		// Unknown properties are reported on their object, the path is completed with their name
+		var unknown string
+		if _, err := fmt.Sscanf(schemaErr.Reason, "property %q is unsupported", &unknown); err == nil {
+			path = append(path, unknown)
+		}
+		field := strings.Join(path, ".")
+		violations = append(violations, util.FieldError{Field: field, Message: schemaErr.Reason})
+	}
+	return violations
+}
+
+// flatten unwraps the errors collected by the validation, which may be nested
+func flatten(err error) []error {
+	multi, ok := err.(openapi3.MultiError)
+	if !ok {
+		return []error{err}
+	}
+	var errs []error
+	for _, e := range multi {
+		errs = append(errs, flatten(e)...)
+	}
+	return errs
+}
@@ -0,0 +1,165 @@
+package openapi_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/openapi"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+const maxBodySize = 128
+
+type problem struct {
+	Message string            `json:"message"`
+	Errors  []util.FieldError `json:"errors"`
+}
+
+// newValidatedRouter echoes the bodies of the requests passing the validation
+func newValidatedRouter() *mux.Router {
+	echo := func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body)
+	}
+	r := mux.NewRouter()
+	r.Use(openapi.RequestValidator(r, maxBodySize))
+	r.HandleFunc("/movies", echo).Methods("POST")
+	r.HandleFunc("/movies/search", echo).Methods("GET")
+	r.HandleFunc("/movies/{id}", echo).Methods("GET", "PUT")
+	r.HandleFunc("/movies/{id}/reviews", echo).Methods("POST")
+	r.HandleFunc("/webhooks", echo).Methods("POST")
+	r.HandleFunc("/movies/{id}/poster", echo).Methods("PUT")
+	r.HandleFunc("/undocumented", echo).Methods("POST")
+	return r
+}
+
+func serve(r *mux.Router, method, url, contentType, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, url, strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	return rr
+}
+
+func violations(t *testing.T, rr *httptest.ResponseRecorder) []util.FieldError {
+	var p problem
+	if err := json.Unmarshal(rr.Body.Bytes(), &p); err != nil {
+		t.Fatalf("the response [%s] is not a validation problem: %s", rr.Body.String(), err)
+	}
+	return p.Errors
+}
+
+func fields(errs []util.FieldError) []string {
+	f := make([]string, len(errs))
+	for i, e := range errs {
+		f[i] = e.Field
+	}
+	return f
+}
+
+func TestRequestValidatorValidBody(t *testing.T) {
+	rr := serve(newValidatedRouter(), "POST", "/movies", "", `{"name":"test"}`)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `{"name":"test"}`, rr.Body.String(), "The body should reach the controller unchanged")
+}
+
+func TestRequestValidatorBodyViolations(t *testing.T) {
+	rr := serve(newValidatedRouter(), "POST", "/movies", "application/json", `{"id":"1","rating":5}`)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	assert.ElementsMatch(t, []string{"body.name", "body.id", "body.rating"}, fields(violations(t, rr)))
+}
+
+func TestRequestValidatorReadOnlyProperties(t *testing.T) {
+	r := newValidatedRouter()
+	fetched := `{"id":1,"name":"test","version":2,"rating_average":4.5,"rating_count":2}`
+
+	rr := serve(r, "PUT", "/movies/1", "application/json", fetched)
+	assert.Equal(t, http.StatusOK, rr.Code, "The ratings of a fetched movie should be ignored when it is sent back")
+	rr = serve(r, "POST", "/movies", "application/json", fetched)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	rr = serve(r, "PUT", "/movies/1", "application/json", `{"name":"test","collection":{"id":4,"name":"Trilogy","position":2}}`)
+	assert.Equal(t, http.StatusOK, rr.Code, "The collection of a fetched movie should be ignored when it is sent back")
+
+	rr = serve(r, "POST", "/movies/1/reviews", "application/json", `{"rating":5,"status":"approved"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code, "Other read-only properties should still be refused")
+	assert.Contains(t, violations(t, rr)[0].Message, `"status"`)
+}
+
+func TestRequestValidatorNestedBodyViolations(t *testing.T) {
+	body := `{"url":"https://example.com","event_types":["MovieCreated","MovieRenamed"],"secret":"s"}`
+	rr := serve(newValidatedRouter(), "POST", "/webhooks", "application/json", body)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	assert.Equal(t, []string{"body.event_types.1"}, fields(violations(t, rr)))
+}
+
+func TestRequestValidatorXmlBody(t *testing.T) {
+	r := newValidatedRouter()
+
+	rr := serve(r, "POST", "/movies", "application/xml", "<movie><name>test</name></movie>")
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr = serve(r, "POST", "/movies", "application/xml", "<movie><name></name></movie>")
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	assert.Equal(t, []string{"body.name"}, fields(violations(t, rr)))
+}
+
+func TestRequestValidatorMalformedBody(t *testing.T) {
+	rr := serve(newValidatedRouter(), "POST", "/movies", "", `{"name":`)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestRequestValidatorUnsupportedMediaType(t *testing.T) {
+	rr := serve(newValidatedRouter(), "POST", "/movies", "text/csv", "name\ntest")
+
+	assert.Equal(t, http.StatusUnsupportedMediaType, rr.Code)
+}
+
+func TestRequestValidatorBodyTooLarge(t *testing.T) {
+	rr := serve(newValidatedRouter(), "POST", "/undocumented", "", strings.Repeat("x", maxBodySize+1))
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code, "The limit should apply to every route")
+}
+
+func TestRequestValidatorUpload(t *testing.T) {
+	body := "--boundary\r\nContent-Disposition: form-data; name=\"poster\"; filename=\"p.png\"\r\n\r\n" +
+		strings.Repeat("x", maxBodySize) + "\r\n--boundary--\r\n"
+
+	rr := serve(newValidatedRouter(), "PUT", "/movies/1/poster", "multipart/form-data; boundary=boundary", body)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Uploads should be allowed their own limit")
+	assert.Equal(t, body, rr.Body.String(), "The upload should reach the controller unchanged")
+}
+
+func TestRequestValidatorParameterViolations(t *testing.T) {
+	r := newValidatedRouter()
+
+	rr := serve(r, "GET", "/movies/not-an-int", "", "")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, []string{"path.id"}, fields(violations(t, rr)))
+
+	rr = serve(r, "GET", "/movies/search?limit=1000", "", "")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.ElementsMatch(t, []string{"query.q", "query.limit"}, fields(violations(t, rr)))
+
+	rr = serve(r, "GET", "/movies/search?q=lord&limit=10", "", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestRequestValidatorUndocumentedRoute(t *testing.T) {
+	rr := serve(newValidatedRouter(), "POST", "/undocumented", "", `{"anything":true}`)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/blob"
+	"github.com/Hunterlemming/golang-microservice-example/api/duplicate"
+	"github.com/Hunterlemming/golang-microservice-example/api/event"
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/poster"
+	"github.com/Hunterlemming/golang-microservice-example/api/similar"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+// Fails for routes added to any setRouting without documenting them in the OpenAPI operations
+func TestOpenApiDocumentsAllRoutes(t *testing.T) {
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	api := model.Api{Router: mux.NewRouter(), DB: db}
+	initializePipelines(&api, event.NewBroker(replayBufferSize), similar.NewSimilarService(db), duplicate.NewDuplicateService(db),
+		poster.NewPosterService(db, blob.NewFileStore(t.TempDir())))
+
+	req, _ := http.NewRequest("GET", "/openapi.json", nil)
+	rr := httptest.NewRecorder()
+	api.Router.ServeHTTP(rr, req)
+	spec, err := openapi3.NewLoader().LoadFromData(rr.Body.Bytes())
+	if err != nil {
+		t.Fatalf("the document could not be loaded: %s", err)
+	}
+	assert.Nil(t, spec.Validate(context.Background()), "The document should be valid")
+
+	api.Router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		path, _ := route.GetPathTemplate()
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+		for _, method := range methods {
+			item := spec.Paths.Find(path)
+			if assert.NotNil(t, item, "Route [%s] should be documented", path) {
+				assert.NotNil(t, item.GetOperation(method), "Route [%s %s] should be documented", method, path)
+			}
+		}
+		return nil
+	})
+}
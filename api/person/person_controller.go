@@ -0,0 +1,303 @@
+package person
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+
+	"github.com/gorilla/mux"
+)
+
+type controller struct {
+	service PersonService
+}
+
+type PersonController interface {
+	GetPeople(w http.ResponseWriter, r *http.Request)
+	GetPerson(w http.ResponseWriter, r *http.Request)
+	CreatePerson(w http.ResponseWriter, r *http.Request)
+	UpdatePerson(w http.ResponseWriter, r *http.Request)
+	DeletePerson(w http.ResponseWriter, r *http.Request)
+	GetFilmography(w http.ResponseWriter, r *http.Request)
+	GetCredits(w http.ResponseWriter, r *http.Request)
+	AddCredit(w http.ResponseWriter, r *http.Request)
+	RemoveCredit(w http.ResponseWriter, r *http.Request)
+}
+
+func NewPersonController(s PersonService) PersonController {
+	return &controller{
+		service: s,
+	}
+}
+
+func (c *controller) GetPeople(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to GetPeople", r.Method))
+		return
+	}
+
+	enc, err := util.NewResponseEncoder(r, true)
+	if err != nil {
+		util.HandleNotAcceptable(w, err)
+		return
+	}
+
+	people, err := c.service.GetPeople()
+	if err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	util.WriteResponse(w, enc, http.StatusOK, people)
+}
+
+func (c *controller) GetPerson(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to GetPerson", r.Method))
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 0)
+	if err != nil {
+		util.HandleBadRequest(w, "Invalid ID", err.Error())
+		return
+	}
+
+	enc, err := util.NewResponseEncoder(r, false)
+	if err != nil {
+		util.HandleNotAcceptable(w, err)
+		return
+	}
+
+	person, err := c.service.GetPerson(int(id))
+	if err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	util.WriteResponse(w, enc, http.StatusOK, person)
+}
+
+func (c *controller) CreatePerson(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to CreatePerson", r.Method))
+		return
+	}
+
+	// Extracting Person object from request-body
+	p, err := parseValidPerson(r)
+	if err != nil {
+		util.HandleInvalidBody(w, err)
+		return
+	}
+
+	enc, err := util.NewResponseEncoder(r, false)
+	if err != nil {
+		util.HandleNotAcceptable(w, err)
+		return
+	}
+
+	if err := c.service.CreatePerson(r.Context(), p); err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	util.WriteResponse(w, enc, http.StatusCreated, p)
+}
+
+func (c *controller) UpdatePerson(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to UpdatePerson", r.Method))
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 0)
+	if err != nil {
+		util.HandleBadRequest(w, "Invalid ID", err.Error())
+		return
+	}
+
+	// Extracting Person object from request-body
+	p, err := parseValidPerson(r)
+	if err != nil {
+		util.HandleInvalidBody(w, err)
+		return
+	}
+
+	if err := c.service.UpdatePerson(r.Context(), int(id), p); err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	fmt.Fprintln(w, "success")
+}
+
+// DeletePerson answers 409 for people who are still credited, their credits have to be removed first
+func (c *controller) DeletePerson(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to DeletePerson", r.Method))
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 0)
+	if err != nil {
+		util.HandleBadRequest(w, "Invalid ID", err.Error())
+		return
+	}
+
+	if err := c.service.DeletePerson(r.Context(), int(id)); err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *controller) GetFilmography(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to GetFilmography", r.Method))
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 0)
+	if err != nil {
+		util.HandleBadRequest(w, "Invalid ID", err.Error())
+		return
+	}
+
+	enc, err := util.NewResponseEncoder(r, true)
+	if err != nil {
+		util.HandleNotAcceptable(w, err)
+		return
+	}
+
+	credits, err := c.service.GetFilmography(int(id))
+	if err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	util.WriteResponse(w, enc, http.StatusOK, credits)
+}
+
+func (c *controller) GetCredits(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to GetCredits", r.Method))
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 0)
+	if err != nil {
+		util.HandleBadRequest(w, "Invalid ID", err.Error())
+		return
+	}
+
+	enc, err := util.NewResponseEncoder(r, true)
+	if err != nil {
+		util.HandleNotAcceptable(w, err)
+		return
+	}
+
+	credits, err := c.service.GetCredits(int(id))
+	if err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	util.WriteResponse(w, enc, http.StatusOK, credits)
+}
+
+func (c *controller) AddCredit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to AddCredit", r.Method))
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 0)
+	if err != nil {
+		util.HandleBadRequest(w, "Invalid ID", err.Error())
+		return
+	}
+
+	// Extracting Credit object from request-body
+	credit, err := parseValidCredit(r)
+	if err != nil {
+		util.HandleInvalidBody(w, err)
+		return
+	}
+	// The movie is identified by the path
+	credit.MovieID = int(id)
+
+	enc, err := util.NewResponseEncoder(r, false)
+	if err != nil {
+		util.HandleNotAcceptable(w, err)
+		return
+	}
+
+	if err := c.service.AddCredit(r.Context(), credit); err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	util.WriteResponse(w, enc, http.StatusCreated, credit)
+}
+
+func (c *controller) RemoveCredit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to RemoveCredit", r.Method))
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 0)
+	if err != nil {
+		util.HandleBadRequest(w, "Invalid ID", err.Error())
+		return
+	}
+	creditID, err := strconv.ParseInt(mux.Vars(r)["creditId"], 10, 0)
+	if err != nil {
+		util.HandleBadRequest(w, "Invalid credit ID", err.Error())
+		return
+	}
+
+	if err := c.service.RemoveCredit(r.Context(), int(id), int(creditID)); err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func parseValidPerson(r *http.Request) (*model.Person, error) {
+	var p model.Person
+
+	// Return if the request-body cannot be decoded into a Person object
+	if err := util.DecodeRequest(r, &p); err != nil {
+		return nil, err
+	}
+
+	// Return if the requested Person object is invalid
+	if err := p.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid person-object: %w", err)
+	}
+
+	return &p, nil
+}
+
+func parseValidCredit(r *http.Request) (*model.Credit, error) {
+	var c model.Credit
+
+	// Return if the request-body cannot be decoded into a Credit object
+	if err := util.DecodeRequest(r, &c); err != nil {
+		return nil, err
+	}
+
+	// Return if the requested Credit object is invalid
+	if err := c.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid credit-object: %w", err)
+	}
+
+	return &c, nil
+}
@@ -0,0 +1,222 @@
+package person_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/person"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Defining the mock PersonService
+type mockServiceStruct struct {
+	mock.Mock
+}
+
+func (s *mockServiceStruct) GetPeople() ([]model.Person, error) {
+	args := s.Called()
+	return args.Get(0).([]model.Person), args.Error(1)
+}
+
+func (s *mockServiceStruct) GetPerson(id int) (model.Person, error) {
+	args := s.Called(id)
+	return args.Get(0).(model.Person), args.Error(1)
+}
+
+func (s *mockServiceStruct) CreatePerson(ctx context.Context, p *model.Person) error {
+	args := s.Called(p)
+	return args.Error(0)
+}
+
+func (s *mockServiceStruct) UpdatePerson(ctx context.Context, id int, p *model.Person) error {
+	args := s.Called(id, p)
+	return args.Error(0)
+}
+
+func (s *mockServiceStruct) DeletePerson(ctx context.Context, id int) error {
+	args := s.Called(id)
+	return args.Error(0)
+}
+
+func (s *mockServiceStruct) GetCredits(movieID int) ([]model.Credit, error) {
+	args := s.Called(movieID)
+	return args.Get(0).([]model.Credit), args.Error(1)
+}
+
+func (s *mockServiceStruct) GetFilmography(personID int) ([]model.Credit, error) {
+	args := s.Called(personID)
+	return args.Get(0).([]model.Credit), args.Error(1)
+}
+
+func (s *mockServiceStruct) AddCredit(ctx context.Context, c *model.Credit) error {
+	args := s.Called(c)
+	return args.Error(0)
+}
+
+func (s *mockServiceStruct) RemoveCredit(ctx context.Context, movieID, creditID int) error {
+	args := s.Called(movieID, creditID)
+	return args.Error(0)
+}
+
+// Setting up mockService and controller
+var mockService = new(mockServiceStruct)
+var controller = person.NewPersonController(mockService)
+
+func TestControllerGetPeople(t *testing.T) {
+	people := []model.Person{{ID: 1, Name: "Sofia Coppola"}}
+	mockService.On("GetPeople").Return(people, nil).Once()
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	rr := execute("/", []string{"GET"}, req, controller.GetPeople)
+
+	status := http.StatusOK
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+	assert.Equal(t, jsonString(people), rr.Body.String())
+}
+
+func TestControllerGetPersonNotFoundError(t *testing.T) {
+	mockService.On("GetPerson", 2).Return(model.Person{}, &util.NotExistingRecordError{Identification: "ID: 2"}).Once()
+
+	req, _ := http.NewRequest("GET", "/2", nil)
+	rr := execute("/{id}", []string{"GET"}, req, controller.GetPerson)
+
+	status := http.StatusNotFound
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerCreatePerson(t *testing.T) {
+	p := model.Person{Name: "Bill Murray"}
+	pBytes, _ := json.Marshal(p)
+	mockService.On("CreatePerson", &p).Return(nil).Run(func(args mock.Arguments) {
+		args.Get(0).(*model.Person).ID = 2
+	}).Once()
+
+	req, _ := http.NewRequest("POST", "/", bytes.NewBuffer(pBytes))
+	rr := execute("/", []string{"POST"}, req, controller.CreatePerson)
+
+	status := http.StatusCreated
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+	assert.Equal(t, jsonString(model.Person{ID: 2, Name: "Bill Murray"}), rr.Body.String())
+}
+
+func TestControllerCreatePersonBodyParsingError(t *testing.T) {
+	req, _ := http.NewRequest("POST", "/", bytes.NewBufferString(`{"name": ""}`))
+	rr := execute("/", []string{"POST"}, req, controller.CreatePerson)
+
+	status := http.StatusBadRequest
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerUpdatePerson(t *testing.T) {
+	p := model.Person{Name: "Bill Murray"}
+	pBytes, _ := json.Marshal(p)
+	mockService.On("UpdatePerson", 2, &p).Return(nil).Once()
+
+	req, _ := http.NewRequest("PUT", "/2", bytes.NewBuffer(pBytes))
+	rr := execute("/{id}", []string{"PUT"}, req, controller.UpdatePerson)
+
+	status := http.StatusOK
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerDeletePersonCreditedError(t *testing.T) {
+	mockService.On("DeletePerson", 2).Return(&util.ReferencedRecordError{Identification: "ID: 2", ReferencedBy: "movie credits"}).Once()
+
+	req, _ := http.NewRequest("DELETE", "/2", nil)
+	rr := execute("/{id}", []string{"DELETE"}, req, controller.DeletePerson)
+
+	status := http.StatusConflict
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerGetFilmography(t *testing.T) {
+	credits := []model.Credit{{ID: 2, MovieID: 7, MovieName: "Lost in Translation", PersonID: 2, Role: model.RoleActor, Character: "Bob Harris"}}
+	mockService.On("GetFilmography", 2).Return(credits, nil).Once()
+
+	req, _ := http.NewRequest("GET", "/2/filmography", nil)
+	rr := execute("/{id}/filmography", []string{"GET"}, req, controller.GetFilmography)
+
+	status := http.StatusOK
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+	assert.Equal(t, jsonString(credits), rr.Body.String())
+}
+
+func TestControllerGetCreditsMovieNotFoundError(t *testing.T) {
+	mockService.On("GetCredits", 7).Return([]model.Credit{}, &util.NotExistingRecordError{Identification: "ID: 7"}).Once()
+
+	req, _ := http.NewRequest("GET", "/movies/7/credits", nil)
+	rr := execute("/movies/{id}/credits", []string{"GET"}, req, controller.GetCredits)
+
+	status := http.StatusNotFound
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerAddCredit(t *testing.T) {
+	expected := model.Credit{MovieID: 7, PersonID: 2, Role: model.RoleActor, Character: "Bob Harris"}
+	mockService.On("AddCredit", &expected).Return(nil).Once()
+
+	body := `{"person_id": 2, "role": "actor", "character": "Bob Harris"}`
+	req, _ := http.NewRequest("POST", "/movies/7/credits", bytes.NewBufferString(body))
+	rr := execute("/movies/{id}/credits", []string{"POST"}, req, controller.AddCredit)
+
+	if !mockService.AssertCalled(t, "AddCredit", &expected) {
+		t.Error("The service should be called with the movie of the path")
+	}
+	status := http.StatusCreated
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerAddCreditBodyParsingError(t *testing.T) {
+	for _, body := range []string{
+		`{"role": "director"}`,
+		`{"person_id": 2, "role": "producer"}`,
+		`{"person_id": 2, "role": "director", "character": "Bob Harris"}`,
+	} {
+		req, _ := http.NewRequest("POST", "/movies/7/credits", bytes.NewBufferString(body))
+		rr := execute("/movies/{id}/credits", []string{"POST"}, req, controller.AddCredit)
+
+		status := http.StatusBadRequest
+		assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d] for %s", status, body))
+	}
+}
+
+func TestControllerRemoveCredit(t *testing.T) {
+	mockService.On("RemoveCredit", 7, 3).Return(nil).Once()
+
+	req, _ := http.NewRequest("DELETE", "/movies/7/credits/3", nil)
+	rr := execute("/movies/{id}/credits/{creditId}", []string{"DELETE"}, req, controller.RemoveCredit)
+
+	status := http.StatusNoContent
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerRemoveCreditInvalidIDError(t *testing.T) {
+	req, _ := http.NewRequest("DELETE", "/movies/7/credits/x", nil)
+	rr := execute("/movies/{id}/credits/{creditId}", []string{"DELETE"}, req, controller.RemoveCredit)
+
+	status := http.StatusBadRequest
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func execute(route string, methods []string, req *http.Request, handler func(w http.ResponseWriter, r *http.Request)) *httptest.ResponseRecorder {
+	r := mux.NewRouter()
+	r.HandleFunc(route, handler).Methods(methods...)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	return rr
+}
+
+func jsonString(obj interface{}) string {
+	res, _ := json.Marshal(obj)
+	return string(res)
+}
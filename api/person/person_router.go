@@ -0,0 +1,47 @@
+package person
+
+import (
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+
+	"github.com/gorilla/mux"
+)
+
+func InitializePeoplePipeline(api *model.Api) {
+	s := NewPersonService(api.DB)
+	c := NewPersonController(s)
+	setRouting(api.Router, c)
+}
+
+func setRouting(main *mux.Router, c PersonController) {
+	sr := main.PathPrefix("/people").Subrouter()
+
+	sr.HandleFunc("", c.GetPeople).
+		Methods("GET")
+
+	sr.HandleFunc("/{id}", c.GetPerson).
+		Methods("GET")
+
+	sr.HandleFunc("", c.CreatePerson).
+		Methods("POST")
+
+	sr.HandleFunc("/{id}", c.UpdatePerson).
+		Methods("PUT")
+
+	sr.HandleFunc("/{id}", c.DeletePerson).
+		Methods("DELETE")
+
+	sr.HandleFunc("/{id}/filmography", c.GetFilmography).
+		Methods("GET")
+
+	// The credits link the people to the movies, they are listed and managed under the movie
+	cr := main.PathPrefix("/movies/{id}/credits").Subrouter()
+
+	cr.HandleFunc("", c.GetCredits).
+		Methods("GET")
+
+	cr.HandleFunc("", c.AddCredit).
+		Methods("POST")
+
+	cr.HandleFunc("/{creditId}", c.RemoveCredit).
+		Methods("DELETE")
+}
@@ -0,0 +1,64 @@
+package person_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/movie"
+	"github.com/Hunterlemming/golang-microservice-example/api/person"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInitializePeoplePipeline(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	// The credits share their prefix with the movies, which are routed first
+	api := model.Api{Router: mux.NewRouter(), DB: db}
+	movie.InitializeMoviesPipeline(&api)
+	person.InitializePeoplePipeline(&api)
+
+	testIntegrationGetFilmography(t, mock, api.Router)
+	testIntegrationGetCredits(t, mock, api.Router)
+}
+
+func testIntegrationGetFilmography(t *testing.T, mock sqlmock.Sqlmock, r *mux.Router) {
+	mock.ExpectQuery(GetPersonQuery).WithArgs(2).WillReturnRows(newRows(&[]model.Person{{ID: 2, Name: "Bill Murray"}}))
+	mock.ExpectQuery(FilmographyQuery).WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "movie_id", "name", "person_id", "role", "character_name"}))
+
+	req, _ := http.NewRequest("GET", "/people/2/filmography", nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "[]", rr.Body.String())
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func testIntegrationGetCredits(t *testing.T, mock sqlmock.Sqlmock, r *mux.Router) {
+	credits := []model.Credit{{ID: 1, MovieID: 7, PersonID: 1, PersonName: "Sofia Coppola", Role: model.RoleDirector}}
+	mock.ExpectQuery(CheckMovieQuery).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectQuery(CreditsQuery).WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "movie_id", "person_id", "name", "role", "character_name"}).
+			AddRow(1, 7, 1, "Sofia Coppola", "director", ""))
+
+	req, _ := http.NewRequest("GET", "/movies/7/credits", nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, jsonString(credits), rr.Body.String())
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,209 @@
+package person
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/audit"
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/movie"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+)
+
+type service struct {
+	db *sql.DB
+}
+
+type PersonService interface {
+	GetPeople() ([]model.Person, error)
+	GetPerson(id int) (model.Person, error)
+	CreatePerson(ctx context.Context, p *model.Person) error
+	UpdatePerson(ctx context.Context, id int, p *model.Person) error
+	DeletePerson(ctx context.Context, id int) error
+	GetCredits(movieID int) ([]model.Credit, error)
+	GetFilmography(personID int) ([]model.Credit, error)
+	AddCredit(ctx context.Context, c *model.Credit) error
+	RemoveCredit(ctx context.Context, movieID, creditID int) error
+}
+
+func NewPersonService(db *sql.DB) PersonService {
+	return &service{
+		db: db,
+	}
+}
+
+func (s *service) GetPeople() ([]model.Person, error) {
+	const q = "SELECT id, name FROM people ORDER BY id"
+	qr, err := s.db.Query(q)
+	if err != nil {
+		return []model.Person{}, err
+	}
+	defer qr.Close()
+
+	result := make([]model.Person, 0)
+	for qr.Next() {
+		p := model.Person{}
+		err = qr.Scan(&p.ID, &p.Name)
+		if err != nil {
+			return []model.Person{}, err
+		}
+		result = append(result, p)
+	}
+
+	return result, nil
+}
+
+func (s *service) GetPerson(id int) (model.Person, error) {
+	const q = "SELECT id, name FROM people WHERE id = $1"
+	result := model.Person{}
+	err := util.GetRecord(s.db, q, id, &result.ID, &result.Name)
+	return result, err
+}
+
+// CreatePerson inserts the person and sets its generated ID
+func (s *service) CreatePerson(ctx context.Context, p *model.Person) error {
+	return util.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		const q = "INSERT INTO people (name) VALUES ($1) RETURNING id"
+		if err := tx.QueryRowContext(ctx, q, p.Name).Scan(&p.ID); err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx, audit.EntityPerson, p.ID, audit.ActionCreate, nil, p)
+	})
+}
+
+func (s *service) UpdatePerson(ctx context.Context, id int, p *model.Person) error {
+	before, err := s.GetPerson(id)
+	if err != nil {
+		return err
+	}
+
+	return util.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		const q = "UPDATE people SET name = $1 WHERE id = $2"
+		res, err := tx.ExecContext(ctx, q, p.Name, id)
+		if err != nil {
+			return err
+		}
+		if affected, err := res.RowsAffected(); err != nil {
+			return err
+		} else if affected == 0 {
+			return &util.NotExistingRecordError{Identification: fmt.Sprintf("ID: %v", id)}
+		}
+
+		after := model.Person{ID: id, Name: p.Name}
+		return audit.Record(ctx, tx, audit.EntityPerson, id, audit.ActionUpdate, before, after)
+	})
+}
+
+// DeletePerson removes the person, which is refused as long as the person is credited for any movie
+func (s *service) DeletePerson(ctx context.Context, id int) error {
+	return util.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		const q = "DELETE FROM people WHERE id = $1 RETURNING id, name"
+		before := model.Person{}
+		err := tx.QueryRowContext(ctx, q, id).Scan(&before.ID, &before.Name)
+		if err == sql.ErrNoRows {
+			return &util.NotExistingRecordError{Identification: fmt.Sprintf("ID: %v", id)}
+		}
+		if util.IsViolation(err, util.ForeignKeyViolation) {
+			return &util.ReferencedRecordError{Identification: fmt.Sprintf("ID: %v", id), ReferencedBy: "movie credits"}
+		}
+		if err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx, audit.EntityPerson, id, audit.ActionDelete, before, nil)
+	})
+}
+
+// GetCredits returns the cast and crew of the movie, directors first
+func (s *service) GetCredits(movieID int) ([]model.Credit, error) {
+	if err := movie.Exists(s.db, movieID); err != nil {
+		return []model.Credit{}, err
+	}
+
+	const q = `SELECT c.id, c.movie_id, c.person_id, p.name, c.role, c.character_name ` +
+		`FROM movie_credits c JOIN people p ON p.id = c.person_id ` +
+		`WHERE c.movie_id = $1 ORDER BY array_position(ARRAY['director', 'writer', 'actor'], c.role::text), c.id`
+	qr, err := s.db.Query(q, movieID)
+	if err != nil {
+		return []model.Credit{}, err
+	}
+	defer qr.Close()
+
+	result := make([]model.Credit, 0)
+	for qr.Next() {
+		c := model.Credit{}
+		err = qr.Scan(&c.ID, &c.MovieID, &c.PersonID, &c.PersonName, &c.Role, &c.Character)
+		if err != nil {
+			return []model.Credit{}, err
+		}
+		result = append(result, c)
+	}
+
+	return result, qr.Err()
+}
+
+// GetFilmography returns the credits of the person, leaving out the movies in the trash
+func (s *service) GetFilmography(personID int) ([]model.Credit, error) {
+	if _, err := s.GetPerson(personID); err != nil {
+		return []model.Credit{}, err
+	}
+
+	const q = `SELECT c.id, c.movie_id, m.name, c.person_id, c.role, c.character_name ` +
+		`FROM movie_credits c JOIN movies m ON m.id = c.movie_id ` +
+		`WHERE c.person_id = $1 AND m.deleted_at IS NULL ORDER BY c.movie_id, c.id`
+	qr, err := s.db.Query(q, personID)
+	if err != nil {
+		return []model.Credit{}, err
+	}
+	defer qr.Close()
+
+	result := make([]model.Credit, 0)
+	for qr.Next() {
+		c := model.Credit{}
+		err = qr.Scan(&c.ID, &c.MovieID, &c.MovieName, &c.PersonID, &c.Role, &c.Character)
+		if err != nil {
+			return []model.Credit{}, err
+		}
+		result = append(result, c)
+	}
+
+	return result, qr.Err()
+}
+
+// AddCredit credits the person for the movie and sets the generated ID of the credit.
+// Both have to exist, movies in the trash cannot be credited.
+func (s *service) AddCredit(ctx context.Context, c *model.Credit) error {
+	return util.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := movie.Exists(tx, c.MovieID); err != nil {
+			return err
+		}
+
+		const q = "INSERT INTO movie_credits (movie_id, person_id, role, character_name) VALUES ($1, $2, $3, $4) RETURNING id"
+		err := tx.QueryRowContext(ctx, q, c.MovieID, c.PersonID, c.Role, c.Character).Scan(&c.ID)
+		switch {
+		case util.IsViolation(err, util.ForeignKeyViolation):
+			return &util.NotExistingRecordError{Identification: fmt.Sprintf("ID: %v", c.PersonID)}
+		case util.IsViolation(err, util.UniqueViolation):
+			return &util.ExistingRecordError{Identification: fmt.Sprintf("movie ID: %v, person ID: %v, role: %s", c.MovieID, c.PersonID, c.Role)}
+		case err != nil:
+			return err
+		}
+		return audit.Record(ctx, tx, audit.EntityCredit, c.ID, audit.ActionCreate, nil, c)
+	})
+}
+
+func (s *service) RemoveCredit(ctx context.Context, movieID, creditID int) error {
+	return util.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		const q = "DELETE FROM movie_credits WHERE id = $1 AND movie_id = $2 RETURNING id, movie_id, person_id, role, character_name"
+		before := model.Credit{}
+		err := tx.QueryRowContext(ctx, q, creditID, movieID).
+			Scan(&before.ID, &before.MovieID, &before.PersonID, &before.Role, &before.Character)
+		if err == sql.ErrNoRows {
+			return &util.NotExistingRecordError{Identification: fmt.Sprintf("ID: %v", creditID)}
+		}
+		if err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx, audit.EntityCredit, creditID, audit.ActionDelete, before, nil)
+	})
+}
@@ -0,0 +1,306 @@
+package person_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/person"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	GetPeopleQuery      = `^SELECT id, name FROM people ORDER BY id$`
+	GetPersonQuery      = `^SELECT id, name FROM people WHERE id = \$1$`
+	CreatePersonQuery   = `^INSERT INTO people \(name\) VALUES \(\$1\) RETURNING id$`
+	UpdatePersonQuery   = `^UPDATE people SET name = \$1 WHERE id = \$2$`
+	DeletePersonQuery   = `^DELETE FROM people WHERE id = \$1 RETURNING id, name$`
+	CheckMovieQuery     = `^SELECT id FROM movies WHERE id = \$1 AND deleted_at IS NULL$`
+	CreditsQuery        = `^SELECT c.id, c.movie_id, c.person_id, p.name, c.role, c.character_name FROM movie_credits c JOIN people p .+ WHERE c.movie_id = \$1 ORDER BY .+$`
+	FilmographyQuery    = `^SELECT c.id, c.movie_id, m.name, c.person_id, c.role, c.character_name FROM movie_credits c JOIN movies m .+ WHERE c.person_id = \$1 AND m.deleted_at IS NULL ORDER BY .+$`
+	AddCreditQuery      = `^INSERT INTO movie_credits \(movie_id, person_id, role, character_name\) VALUES \(.+\) RETURNING id$`
+	RemoveCreditQuery   = `^DELETE FROM movie_credits WHERE id = \$1 AND movie_id = \$2 RETURNING .+$`
+	AuditQuery          = `^INSERT INTO audit_log \(.+\) VALUES \(.+\)$`
+	foreignKeyViolation = "23503"
+	uniqueViolation     = "23505"
+)
+
+func TestServiceGetPeople(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	people := []model.Person{{ID: 1, Name: "Sofia Coppola"}, {ID: 2, Name: "Bill Murray"}}
+	mock.ExpectQuery(GetPeopleQuery).WillReturnRows(newRows(&people))
+
+	res, err := service.GetPeople()
+
+	assert.Equal(t, people, res)
+	assert.Equal(t, nil, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceGetPersonNotExistingError(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectQuery(GetPersonQuery).WithArgs(3).WillReturnRows(newRows(&[]model.Person{}))
+
+	_, err := service.GetPerson(3)
+
+	assert.IsType(t, &util.NotExistingRecordError{}, err)
+}
+
+func TestServiceCreatePerson(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(CreatePersonQuery).WithArgs("Bill Murray").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	mock.ExpectExec(AuditQuery).
+		WithArgs("person", 2, "create", "tester", "test-request", nil, `{"id":2,"name":"Bill Murray"}`, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	p := &model.Person{Name: "Bill Murray"}
+	err := service.CreatePerson(auditContext(), p)
+
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, p.ID, "The generated ID should be set")
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceUpdatePerson(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectQuery(GetPersonQuery).WithArgs(2).WillReturnRows(newRows(&[]model.Person{{ID: 2, Name: "Bill Muray"}}))
+	mock.ExpectBegin()
+	mock.ExpectExec(UpdatePersonQuery).WithArgs("Bill Murray", 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(AuditQuery).
+		WithArgs("person", 2, "update", "tester", "test-request",
+			`{"id":2,"name":"Bill Muray"}`, `{"id":2,"name":"Bill Murray"}`, `{"name":{"from":"Bill Muray","to":"Bill Murray"}}`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err := service.UpdatePerson(auditContext(), 2, &model.Person{Name: "Bill Murray"})
+
+	assert.Equal(t, nil, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceDeletePerson(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(DeletePersonQuery).WithArgs(2).WillReturnRows(newRows(&[]model.Person{{ID: 2, Name: "Bill Murray"}}))
+	mock.ExpectExec(AuditQuery).
+		WithArgs("person", 2, "delete", "tester", "test-request", `{"id":2,"name":"Bill Murray"}`, nil, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err := service.DeletePerson(auditContext(), 2)
+
+	assert.Equal(t, nil, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceDeletePersonCreditedError(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(DeletePersonQuery).WithArgs(2).WillReturnError(&pq.Error{Code: foreignKeyViolation})
+	mock.ExpectRollback()
+
+	err := service.DeletePerson(auditContext(), 2)
+
+	assert.IsType(t, &util.ReferencedRecordError{}, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceDeletePersonNotExistingError(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(DeletePersonQuery).WithArgs(2).WillReturnRows(newRows(&[]model.Person{}))
+	mock.ExpectRollback()
+
+	err := service.DeletePerson(auditContext(), 2)
+
+	assert.IsType(t, &util.NotExistingRecordError{}, err)
+}
+
+func TestServiceGetCredits(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	credits := []model.Credit{
+		{ID: 1, MovieID: 7, PersonID: 1, PersonName: "Sofia Coppola", Role: model.RoleDirector},
+		{ID: 2, MovieID: 7, PersonID: 2, PersonName: "Bill Murray", Role: model.RoleActor, Character: "Bob Harris"},
+	}
+	mock.ExpectQuery(CheckMovieQuery).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	rows := sqlmock.NewRows([]string{"id", "movie_id", "person_id", "name", "role", "character_name"})
+	for _, c := range credits {
+		rows.AddRow(c.ID, c.MovieID, c.PersonID, c.PersonName, c.Role, c.Character)
+	}
+	mock.ExpectQuery(CreditsQuery).WithArgs(7).WillReturnRows(rows)
+
+	res, err := service.GetCredits(7)
+
+	assert.Equal(t, credits, res)
+	assert.Equal(t, nil, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceGetCreditsTrashedMovieError(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectQuery(CheckMovieQuery).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	res, err := service.GetCredits(7)
+
+	assert.Equal(t, []model.Credit{}, res)
+	assert.IsType(t, &util.NotExistingRecordError{}, err)
+}
+
+func TestServiceGetFilmography(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	credits := []model.Credit{{ID: 2, MovieID: 7, MovieName: "Lost in Translation", PersonID: 2, Role: model.RoleActor, Character: "Bob Harris"}}
+	mock.ExpectQuery(GetPersonQuery).WithArgs(2).WillReturnRows(newRows(&[]model.Person{{ID: 2, Name: "Bill Murray"}}))
+	rows := sqlmock.NewRows([]string{"id", "movie_id", "name", "person_id", "role", "character_name"}).
+		AddRow(2, 7, "Lost in Translation", 2, "actor", "Bob Harris")
+	mock.ExpectQuery(FilmographyQuery).WithArgs(2).WillReturnRows(rows)
+
+	res, err := service.GetFilmography(2)
+
+	assert.Equal(t, credits, res)
+	assert.Equal(t, nil, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceAddCredit(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(CheckMovieQuery).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectQuery(AddCreditQuery).WithArgs(7, 2, "actor", "Bob Harris").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	mock.ExpectExec(AuditQuery).
+		WithArgs("credit", 3, "create", "tester", "test-request", nil, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	c := &model.Credit{MovieID: 7, PersonID: 2, Role: model.RoleActor, Character: "Bob Harris"}
+	err := service.AddCredit(auditContext(), c)
+
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 3, c.ID, "The generated ID should be set")
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceAddCreditIntegrityError(t *testing.T) {
+	for code, expected := range map[pq.ErrorCode]error{
+		foreignKeyViolation: &util.NotExistingRecordError{},
+		uniqueViolation:     &util.ExistingRecordError{},
+	} {
+		service, mock, db := initNewService(t)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(CheckMovieQuery).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+		mock.ExpectQuery(AddCreditQuery).WillReturnError(&pq.Error{Code: code})
+		mock.ExpectRollback()
+
+		err := service.AddCredit(auditContext(), &model.Credit{MovieID: 7, PersonID: 2, Role: model.RoleDirector})
+
+		assert.IsType(t, expected, err, "Violation %s should be mapped", code)
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+		db.Close()
+	}
+}
+
+func TestServiceRemoveCredit(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(RemoveCreditQuery).WithArgs(3, 7).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "movie_id", "person_id", "role", "character_name"}).AddRow(3, 7, 2, "director", ""))
+	mock.ExpectExec(AuditQuery).
+		WithArgs("credit", 3, "delete", "tester", "test-request", sqlmock.AnyArg(), nil, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err := service.RemoveCredit(auditContext(), 7, 3)
+
+	assert.Equal(t, nil, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceRemoveCreditQueryError(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	queryError := errors.New("test-error-message")
+	mock.ExpectBegin()
+	mock.ExpectQuery(RemoveCreditQuery).WillReturnError(queryError)
+	mock.ExpectRollback()
+
+	err := service.RemoveCredit(auditContext(), 7, 3)
+
+	assert.Equal(t, queryError, err)
+}
+
+func initNewService(t *testing.T) (person.PersonService, sqlmock.Sqlmock, *sql.DB) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	service := person.NewPersonService(db)
+	return service, mock, db
+}
+
+func auditContext() context.Context {
+	return util.WithRequestID(util.WithActor(context.Background(), "tester"), "test-request")
+}
+
+func newRows(people *[]model.Person) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"id", "name"})
+	for _, p := range *people {
+		rows.AddRow(p.ID, p.Name)
+	}
+	return rows
+}
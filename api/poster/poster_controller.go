@@ -0,0 +1,198 @@
+package poster
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	// Name of the form field carrying the upload
+	formField = "poster"
+	// The images never change under their paths, so they may be cached for good
+	imageCacheControl = "public, max-age=31536000, immutable"
+)
+
+type controller struct {
+	service PosterService
+}
+
+type PosterController interface {
+	GetPoster(w http.ResponseWriter, r *http.Request)
+	SavePoster(w http.ResponseWriter, r *http.Request)
+	DeletePoster(w http.ResponseWriter, r *http.Request)
+	GetImage(w http.ResponseWriter, r *http.Request)
+}
+
+func NewPosterController(s PosterService) PosterController {
+	return &controller{
+		service: s,
+	}
+}
+
+func (c *controller) GetPoster(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to GetPoster", r.Method))
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 0)
+	if err != nil {
+		util.HandleBadRequest(w, "Invalid ID", err.Error())
+		return
+	}
+
+	enc, err := util.NewResponseEncoder(r, false)
+	if err != nil {
+		util.HandleNotAcceptable(w, err)
+		return
+	}
+
+	poster, err := c.service.GetPoster(int(id))
+	if err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	util.WriteResponse(w, enc, http.StatusOK, poster)
+}
+
+// SavePoster takes the upload from the "poster" field of a multipart/form-data body,
+// answering 201 for the first poster of the movie and 200 for replacing it
+func (c *controller) SavePoster(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to SavePoster", r.Method))
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 0)
+	if err != nil {
+		util.HandleBadRequest(w, "Invalid ID", err.Error())
+		return
+	}
+
+	// Extracting the upload from request-body
+	data, err := readUpload(r)
+	if err != nil {
+		var tooLarge *uploadTooLargeError
+		if errors.As(err, &tooLarge) {
+			util.HandleRequestTooLarge(w, err.Error())
+			return
+		}
+		util.HandleInvalidBody(w, err)
+		return
+	}
+
+	enc, err := util.NewResponseEncoder(r, false)
+	if err != nil {
+		util.HandleNotAcceptable(w, err)
+		return
+	}
+
+	poster, created, err := c.service.SavePoster(r.Context(), int(id), data)
+	if err != nil {
+		var unsupported *util.UnsupportedMediaTypeError
+		var invalid *util.InvalidImageError
+		if errors.As(err, &unsupported) || errors.As(err, &invalid) {
+			util.HandleInvalidBody(w, err)
+			return
+		}
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	util.WriteResponse(w, enc, status, poster)
+}
+
+func (c *controller) DeletePoster(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to DeletePoster", r.Method))
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 0)
+	if err != nil {
+		util.HandleBadRequest(w, "Invalid ID", err.Error())
+		return
+	}
+
+	if err := c.service.DeletePoster(r.Context(), int(id)); err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetImage serves an image of a poster. The hash of the poster doubles as the entity tag,
+// so conditional and range requests are answered by http.ServeContent.
+func (c *controller) GetImage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to GetImage", r.Method))
+		return
+	}
+
+	vars := mux.Vars(r)
+	img, err := c.service.GetImage(r.Context(), vars["hash"], vars["file"])
+	if err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", img.ContentType)
+	w.Header().Set("Cache-Control", imageCacheControl)
+	w.Header().Set("ETag", fmt.Sprintf(`"%s-%s"`, vars["hash"], vars["file"]))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	http.ServeContent(w, r, vars["file"], img.ModTime, bytes.NewReader(img.Data))
+}
+
+// uploadTooLargeError is returned for uploads above model.MaxPosterSize
+type uploadTooLargeError struct {
+	size int64
+}
+
+func (e *uploadTooLargeError) Error() string {
+	return fmt.Sprintf("poster exceeds %d bytes", e.size)
+}
+
+// readUpload reads the upload of a multipart/form-data body, which is refused with UnsupportedMediaTypeError otherwise
+func readUpload(r *http.Request) ([]byte, error) {
+	ct := r.Header.Get("Content-Type")
+	if mediaType, _, err := mime.ParseMediaType(ct); err != nil || mediaType != "multipart/form-data" {
+		return nil, &util.UnsupportedMediaTypeError{ContentType: ct}
+	}
+
+	// Parts beyond the limit are spilled onto the disk rather than held in memory
+	if err := r.ParseMultipartForm(model.MaxPosterSize); err != nil {
+		return nil, fmt.Errorf("invalid multipart-body: %w", err)
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	f, _, err := r.FormFile(formField)
+	if err != nil {
+		return nil, fmt.Errorf("missing %q field: %w", formField, err)
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, model.MaxPosterSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > model.MaxPosterSize {
+		return nil, &uploadTooLargeError{size: model.MaxPosterSize}
+	}
+	return data, nil
+}
@@ -0,0 +1,184 @@
+package poster_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/blob"
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/poster"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Defining the mock PosterService
+type mockServiceStruct struct {
+	mock.Mock
+}
+
+func (s *mockServiceStruct) GetPoster(movieID int) (model.Poster, error) {
+	args := s.Called(movieID)
+	return args.Get(0).(model.Poster), args.Error(1)
+}
+
+func (s *mockServiceStruct) SavePoster(ctx context.Context, movieID int, data []byte) (model.Poster, bool, error) {
+	args := s.Called(movieID, data)
+	return args.Get(0).(model.Poster), args.Bool(1), args.Error(2)
+}
+
+func (s *mockServiceStruct) DeletePoster(ctx context.Context, movieID int) error {
+	args := s.Called(movieID)
+	return args.Error(0)
+}
+
+func (s *mockServiceStruct) GetImage(ctx context.Context, hash, name string) (blob.Blob, error) {
+	args := s.Called(hash, name)
+	return args.Get(0).(blob.Blob), args.Error(1)
+}
+
+func (s *mockServiceStruct) Sweep(ctx context.Context) (int, error) {
+	args := s.Called()
+	return args.Int(0), args.Error(1)
+}
+
+// Setting up mockService and controller
+var mockService = new(mockServiceStruct)
+var controller = poster.NewPosterController(mockService)
+
+func TestControllerGetPoster(t *testing.T) {
+	p := newPoster(7, pngData(10, 10))
+	mockService.On("GetPoster", 7).Return(p, nil).Once()
+
+	req, _ := http.NewRequest("GET", "/movies/7/poster", nil)
+	rr := execute("/movies/{id}/poster", []string{"GET"}, req, controller.GetPoster)
+
+	status := http.StatusOK
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+	assert.Equal(t, jsonString(p), rr.Body.String())
+}
+
+func TestControllerSavePoster(t *testing.T) {
+	data := pngData(10, 10)
+	p := newPoster(7, data)
+	mockService.On("SavePoster", 7, data).Return(p, true, nil).Once()
+
+	req := newUploadRequest(t, "poster", data)
+	rr := execute("/movies/{id}/poster", []string{"PUT"}, req, controller.SavePoster)
+
+	status := http.StatusCreated
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+	assert.Equal(t, jsonString(p), rr.Body.String())
+
+	mockService.On("SavePoster", 7, data).Return(p, false, nil).Once()
+
+	rr = execute("/movies/{id}/poster", []string{"PUT"}, newUploadRequest(t, "poster", data), controller.SavePoster)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Replacing the poster should not create it")
+}
+
+func TestControllerSavePosterParsingError(t *testing.T) {
+	req, _ := http.NewRequest("PUT", "/movies/7/poster", bytes.NewReader(pngData(10, 10)))
+	req.Header.Set("Content-Type", "image/png")
+	rr := execute("/movies/{id}/poster", []string{"PUT"}, req, controller.SavePoster)
+	assert.Equal(t, http.StatusUnsupportedMediaType, rr.Code, "Uploads should be sent as forms")
+
+	rr = execute("/movies/{id}/poster", []string{"PUT"}, newUploadRequest(t, "image", pngData(10, 10)), controller.SavePoster)
+	assert.Equal(t, http.StatusBadRequest, rr.Code, "Uploads should be sent in the poster field")
+
+	large := make([]byte, model.MaxPosterSize+1)
+	rr = execute("/movies/{id}/poster", []string{"PUT"}, newUploadRequest(t, "poster", large), controller.SavePoster)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+}
+
+func TestControllerSavePosterInvalidImageError(t *testing.T) {
+	data := []byte("not an image")
+	mockService.On("SavePoster", 7, data).Return(model.Poster{}, false, &util.UnsupportedMediaTypeError{ContentType: "text/plain"}).Once()
+
+	rr := execute("/movies/{id}/poster", []string{"PUT"}, newUploadRequest(t, "poster", data), controller.SavePoster)
+	assert.Equal(t, http.StatusUnsupportedMediaType, rr.Code)
+
+	mockService.On("SavePoster", 7, data).Return(model.Poster{}, false, &util.InvalidImageError{Reason: "truncated"}).Once()
+
+	rr = execute("/movies/{id}/poster", []string{"PUT"}, newUploadRequest(t, "poster", data), controller.SavePoster)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestControllerDeletePoster(t *testing.T) {
+	mockService.On("DeletePoster", 7).Return(nil).Once()
+
+	req, _ := http.NewRequest("DELETE", "/movies/7/poster", nil)
+	rr := execute("/movies/{id}/poster", []string{"DELETE"}, req, controller.DeletePoster)
+
+	status := http.StatusNoContent
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerGetImage(t *testing.T) {
+	hash := strings.Repeat("a", 64)
+	img := blob.Blob{Data: []byte("thumbnail"), ContentType: "image/jpeg", ModTime: uploaded}
+	mockService.On("GetImage", hash, "small.jpg").Return(img, nil).Twice()
+
+	req, _ := http.NewRequest("GET", "/posters/"+hash+"/small.jpg", nil)
+	rr := execute("/posters/{hash}/{file}", []string{"GET"}, req, controller.GetImage)
+
+	status := http.StatusOK
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+	assert.Equal(t, "thumbnail", rr.Body.String())
+	assert.Equal(t, "image/jpeg", rr.Header().Get("Content-Type"))
+	assert.Equal(t, "public, max-age=31536000, immutable", rr.Header().Get("Cache-Control"))
+	assert.Equal(t, `"`+hash+`-small.jpg"`, rr.Header().Get("ETag"))
+
+	req.Header.Set("If-None-Match", rr.Header().Get("ETag"))
+	rr = execute("/posters/{hash}/{file}", []string{"GET"}, req, controller.GetImage)
+
+	assert.Equal(t, http.StatusNotModified, rr.Code, "Cached images should not be sent again")
+	assert.Equal(t, "", rr.Body.String())
+}
+
+func TestControllerGetImageNotFound(t *testing.T) {
+	mockService.On("GetImage", "x", "small.jpg").Return(blob.Blob{}, &util.NotExistingRecordError{Identification: "image: x/small.jpg"}).Once()
+
+	req, _ := http.NewRequest("GET", "/posters/x/small.jpg", nil)
+	rr := execute("/posters/{hash}/{file}", []string{"GET"}, req, controller.GetImage)
+
+	status := http.StatusNotFound
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func newUploadRequest(t *testing.T, field string, data []byte) *http.Request {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	part, err := w.CreateFormFile(field, "poster.png")
+	if err != nil {
+		t.Fatalf("the upload could not be written: %s", err)
+	}
+	part.Write(data)
+	w.Close()
+
+	req, _ := http.NewRequest("PUT", "/movies/7/poster", &body)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	return req
+}
+
+func execute(route string, methods []string, req *http.Request, handler func(w http.ResponseWriter, r *http.Request)) *httptest.ResponseRecorder {
+	r := mux.NewRouter()
+	r.HandleFunc(route, handler).Methods(methods...)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	return rr
+}
+
+func jsonString(obj interface{}) string {
+	res, _ := json.Marshal(obj)
+	return string(res)
+}
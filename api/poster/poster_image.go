@@ -0,0 +1,141 @@
+package poster
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"net/http"
+
+	// Registering the decoders of the supported types
+	_ "image/png"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+)
+
+const thumbnailQuality = 85
+
+// The supported types of uploads by the extension of their originals
+var extensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+}
+
+// The thumbnails generated from every upload by their widths, smaller uploads are not scaled up
+var thumbnails = []struct {
+	name  string
+	width int
+}{
+	{"small", 154},
+	{"medium", 342},
+	{"large", 780},
+}
+
+// file is an image of a poster as it is put into the store
+type file struct {
+	name  string
+	width int
+	data  []byte
+}
+
+// processPoster validates the upload and generates its thumbnails, which come after the original.
+// The type is told by the content rather than by what the client claims.
+func processPoster(data []byte) (model.Poster, []file, error) {
+	contentType := http.DetectContentType(data)
+	ext, ok := extensions[contentType]
+	if !ok {
+		return model.Poster{}, nil, &util.UnsupportedMediaTypeError{ContentType: contentType}
+	}
+
+	// The dimensions are checked ahead of decoding, which allocates the whole image
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return model.Poster{}, nil, &util.InvalidImageError{Reason: err.Error()}
+	}
+	if cfg.Width < 1 || cfg.Height < 1 {
+		return model.Poster{}, nil, &util.InvalidImageError{Reason: fmt.Sprintf("%dx%d pixels are empty", cfg.Width, cfg.Height)}
+	}
+	if cfg.Width > model.MaxPosterDimension || cfg.Height > model.MaxPosterDimension {
+		return model.Poster{}, nil, &util.InvalidImageError{
+			Reason: fmt.Sprintf("%dx%d pixels exceed %d pixels", cfg.Width, cfg.Height, model.MaxPosterDimension),
+		}
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return model.Poster{}, nil, &util.InvalidImageError{Reason: err.Error()}
+	}
+
+	hash := sha256.Sum256(data)
+	p := model.Poster{
+		Hash:        hex.EncodeToString(hash[:]),
+		ContentType: contentType,
+		Width:       cfg.Width,
+		Height:      cfg.Height,
+		Size:        len(data),
+	}
+	files := []file{{name: "original" + ext, width: cfg.Width, data: data}}
+
+	// Transparent areas are flattened onto white, as the thumbnails are JPEGs
+	flat := image.NewRGBA(image.Rect(0, 0, cfg.Width, cfg.Height))
+	draw.Draw(flat, flat.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), img, img.Bounds().Min, draw.Over)
+
+	for _, t := range thumbnails {
+		width := t.width
+		if width > cfg.Width {
+			width = cfg.Width
+		}
+		height := (cfg.Height*width + cfg.Width/2) / cfg.Width
+		if height < 1 {
+			height = 1
+		}
+
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, resize(flat, width, height), &jpeg.Options{Quality: thumbnailQuality}); err != nil {
+			return model.Poster{}, nil, err
+		}
+		files = append(files, file{name: t.name + ".jpg", width: width, data: buf.Bytes()})
+	}
+
+	return p, files, nil
+}
+
+// resize scales the image down by averaging the pixels each pixel of the result covers
+func resize(src *image.RGBA, width, height int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+
+	for y := 0; y < height; y++ {
+		y0, y1 := y*sh/height, (y+1)*sh/height
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < width; x++ {
+			x0, x1 := x*sw/width, (x+1)*sw/width
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+
+			var r, g, b, a, n int
+			for sy := y0; sy < y1; sy++ {
+				i := src.PixOffset(x0, sy)
+				for sx := x0; sx < x1; sx++ {
+					r += int(src.Pix[i])
+					g += int(src.Pix[i+1])
+					b += int(src.Pix[i+2])
+					a += int(src.Pix[i+3])
+					i += 4
+					n++
+				}
+			}
+			j := dst.PixOffset(x, y)
+			dst.Pix[j], dst.Pix[j+1], dst.Pix[j+2], dst.Pix[j+3] = uint8(r/n), uint8(g/n), uint8(b/n), uint8(a/n)
+		}
+	}
+	return dst
+}
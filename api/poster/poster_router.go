@@ -0,0 +1,31 @@
+package poster
+
+import (
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+
+	"github.com/gorilla/mux"
+)
+
+// InitializePostersPipeline serves the posters of the service, which is shared with the sweeper of their images
+func InitializePostersPipeline(api *model.Api, s PosterService) {
+	c := NewPosterController(s)
+	setRouting(api.Router, c)
+}
+
+func setRouting(main *mux.Router, c PosterController) {
+	// The posters are uploaded under their movie
+	sr := main.PathPrefix("/movies/{id}/poster").Subrouter()
+
+	sr.HandleFunc("", c.GetPoster).
+		Methods("GET")
+
+	sr.HandleFunc("", c.SavePoster).
+		Methods("PUT")
+
+	sr.HandleFunc("", c.DeletePoster).
+		Methods("DELETE")
+
+	// The images are served by the hash of their poster rather than by the movie, so they can be cached for good
+	main.HandleFunc("/posters/{hash}/{file}", c.GetImage).
+		Methods("GET")
+}
@@ -0,0 +1,75 @@
+package poster_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/blob"
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/movie"
+	"github.com/Hunterlemming/golang-microservice-example/api/poster"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInitializePostersPipeline(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	// The posters share their prefix with the movies, which are routed first
+	api := model.Api{Router: mux.NewRouter(), DB: db}
+	store := blob.NewFileStore(t.TempDir())
+	movie.InitializeMoviesPipeline(&api)
+	poster.InitializePostersPipeline(&api, poster.NewPosterService(db, store))
+
+	testIntegrationGetPoster(t, mock, api.Router)
+	testIntegrationGetImage(t, store, api.Router)
+	testIntegrationSaveEmptyPoster(t, mock, api.Router)
+}
+
+func testIntegrationGetPoster(t *testing.T, mock sqlmock.Sqlmock, r *mux.Router) {
+	p := newPoster(7, pngData(10, 10))
+	mock.ExpectQuery(CheckMovieQuery).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectQuery(GetPosterQuery).WithArgs(7).WillReturnRows(newRows(&[]model.Poster{p}))
+
+	req, _ := http.NewRequest("GET", "/movies/7/poster", nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, jsonString(p), rr.Body.String())
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func testIntegrationGetImage(t *testing.T, store blob.BlobStore, r *mux.Router) {
+	p := newPoster(7, pngData(10, 10))
+	store.Put(context.Background(), "posters/"+p.Hash+"/medium.jpg", []byte("thumbnail"))
+
+	req, _ := http.NewRequest("GET", p.Images[2].Path, nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "thumbnail", rr.Body.String())
+	assert.Equal(t, "image/jpeg", rr.Header().Get("Content-Type"))
+}
+
+func testIntegrationSaveEmptyPoster(t *testing.T, mock sqlmock.Sqlmock, r *mux.Router) {
+	mock.ExpectQuery(CheckMovieQuery).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, newUploadRequest(t, "poster", jpegData(0, 10)))
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code, "Images without pixels should be refused")
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
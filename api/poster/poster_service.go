@@ -0,0 +1,284 @@
+package poster
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/audit"
+	"github.com/Hunterlemming/golang-microservice-example/api/blob"
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/movie"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+)
+
+// Columns of the posters as scanned into model.Poster
+const columns = "movie_id, hash, content_type, width, height, size, updated_at"
+
+var hashRegexp = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// lockClass identifies the advisory locks of the hashes, which keep the images from being swept while they are stored
+const lockClass = 7245003
+
+type service struct {
+	db    *sql.DB
+	store blob.BlobStore
+}
+
+// PosterService keeps the posters of the movies, their images in the blob store and their metadata in the database
+type PosterService interface {
+	GetPoster(movieID int) (model.Poster, error)
+	SavePoster(ctx context.Context, movieID int, data []byte) (model.Poster, bool, error)
+	DeletePoster(ctx context.Context, movieID int) error
+	GetImage(ctx context.Context, hash, name string) (blob.Blob, error)
+	Sweep(ctx context.Context) (int, error)
+}
+
+func NewPosterService(db *sql.DB, store blob.BlobStore) PosterService {
+	return &service{
+		db:    db,
+		store: store,
+	}
+}
+
+func (s *service) GetPoster(movieID int) (model.Poster, error) {
+	if err := movie.Exists(s.db, movieID); err != nil {
+		return model.Poster{}, err
+	}
+
+	const q = "SELECT " + columns + " FROM movie_posters WHERE movie_id = $1"
+	p, err := scanPoster(s.db.QueryRow(q, movieID))
+	if err == sql.ErrNoRows {
+		return model.Poster{}, &util.NotExistingRecordError{Identification: fmt.Sprintf("poster of movie ID: %v", movieID)}
+	}
+	return p, err
+}
+
+// SavePoster validates the upload, stores its images and makes it the poster of the movie, reporting whether it had none.
+// Uploads of other types fail with UnsupportedMediaTypeError, unreadable or oversized images with InvalidImageError.
+func (s *service) SavePoster(ctx context.Context, movieID int, data []byte) (model.Poster, bool, error) {
+	if err := movie.Exists(s.db, movieID); err != nil {
+		return model.Poster{}, false, err
+	}
+
+	p, files, err := processPoster(data)
+	if err != nil {
+		return model.Poster{}, false, err
+	}
+
+	var before model.Poster
+	var created bool
+	err = util.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		// The images are stored ahead of the record, so it never refers to missing ones,
+		// while the hash is locked, so they cannot be swept before the record refers to them
+		if err := lockHash(ctx, tx, p.Hash); err != nil {
+			return err
+		}
+		for _, f := range files {
+			if err := s.store.Put(ctx, key(p.Hash, f.name), f.data); err != nil {
+				return err
+			}
+		}
+		const bq = "INSERT INTO poster_blobs (hash, content_type) VALUES ($1, $2) ON CONFLICT (hash) DO NOTHING"
+		if _, err := tx.ExecContext(ctx, bq, p.Hash, p.ContentType); err != nil {
+			return err
+		}
+
+		const lq = "SELECT " + columns + " FROM movie_posters WHERE movie_id = $1 FOR UPDATE"
+		var err error
+		before, err = scanPoster(tx.QueryRowContext(ctx, lq, movieID))
+		created = err == sql.ErrNoRows
+		if err != nil && !created {
+			return err
+		}
+
+		const q = "INSERT INTO movie_posters (movie_id, hash, content_type, width, height, size) VALUES ($1, $2, $3, $4, $5, $6) " +
+			"ON CONFLICT (movie_id) DO UPDATE SET hash = EXCLUDED.hash, content_type = EXCLUDED.content_type, " +
+			"width = EXCLUDED.width, height = EXCLUDED.height, size = EXCLUDED.size, updated_at = now() " +
+			"RETURNING " + columns
+		p, err = scanPoster(tx.QueryRowContext(ctx, q, movieID, p.Hash, p.ContentType, p.Width, p.Height, p.Size))
+		if err != nil {
+			return err
+		}
+
+		if created {
+			return audit.Record(ctx, tx, audit.EntityPoster, movieID, audit.ActionCreate, nil, p)
+		}
+		return audit.Record(ctx, tx, audit.EntityPoster, movieID, audit.ActionUpdate, before, p)
+	})
+	if err != nil {
+		return model.Poster{}, false, err
+	}
+
+	if !created && before.Hash != p.Hash {
+		s.release(ctx, before.Hash)
+	}
+	return p, created, nil
+}
+
+func (s *service) DeletePoster(ctx context.Context, movieID int) error {
+	var before model.Poster
+	err := util.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		const q = "DELETE FROM movie_posters WHERE movie_id = $1 RETURNING " + columns
+		var err error
+		before, err = scanPoster(tx.QueryRowContext(ctx, q, movieID))
+		if err == sql.ErrNoRows {
+			return &util.NotExistingRecordError{Identification: fmt.Sprintf("poster of movie ID: %v", movieID)}
+		}
+		if err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx, audit.EntityPoster, movieID, audit.ActionDelete, before, nil)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.release(ctx, before.Hash)
+	return nil
+}
+
+// GetImage returns an image of a poster by the hash of the poster and the name of the image, such as "small.jpg"
+func (s *service) GetImage(ctx context.Context, hash, name string) (blob.Blob, error) {
+	if !hashRegexp.MatchString(hash) || !isImageName(name) {
+		return blob.Blob{}, &util.NotExistingRecordError{Identification: fmt.Sprintf("image: %s/%s", hash, name)}
+	}
+	return s.store.Get(ctx, key(hash, name))
+}
+
+// Sweep deletes the images no poster refers to anymore, such as the ones of purged or merged movies.
+// It returns the number of posters whose images were deleted.
+func (s *service) Sweep(ctx context.Context) (int, error) {
+	const q = "SELECT hash FROM poster_blobs b WHERE NOT EXISTS (SELECT 1 FROM movie_posters p WHERE p.hash = b.hash)"
+	qr, err := s.db.QueryContext(ctx, q)
+	if err != nil {
+		return 0, err
+	}
+	hashes := make([]string, 0)
+	for qr.Next() {
+		var hash string
+		if err := qr.Scan(&hash); err != nil {
+			qr.Close()
+			return 0, err
+		}
+		hashes = append(hashes, hash)
+	}
+	qr.Close()
+	if err := qr.Err(); err != nil {
+		return 0, err
+	}
+
+	swept := 0
+	for _, hash := range hashes {
+		deleted, err := s.sweepHash(ctx, hash)
+		if err != nil {
+			return swept, err
+		}
+		if deleted {
+			swept++
+		}
+	}
+	return swept, nil
+}
+
+// release deletes the images of a former poster, unless another movie has the same one.
+// Failing to do so only leaves the images to the next sweep, so the errors are logged rather than returned.
+func (s *service) release(ctx context.Context, hash string) {
+	if _, err := s.sweepHash(ctx, hash); err != nil {
+		log.Println("[Poster Cleanup Error] ", err.Error())
+	}
+}
+
+// sweepHash deletes the images of the hash if no poster refers to them, telling whether it did.
+// The hash stays locked until they are, so a poster of the same images cannot be saved in between.
+func (s *service) sweepHash(ctx context.Context, hash string) (bool, error) {
+	deleted := false
+	err := util.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := lockHash(ctx, tx, hash); err != nil {
+			return err
+		}
+
+		const q = "SELECT content_type FROM poster_blobs b WHERE hash = $1 " +
+			"AND NOT EXISTS (SELECT 1 FROM movie_posters p WHERE p.hash = b.hash)"
+		p := model.Poster{Hash: hash}
+		err := tx.QueryRowContext(ctx, q, hash).Scan(&p.ContentType)
+		if err == sql.ErrNoRows {
+			// Still in use, or swept already
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, img := range images(p) {
+			name := img.Path[strings.LastIndex(img.Path, "/")+1:]
+			if err := s.store.Delete(ctx, key(hash, name)); err != nil {
+				return err
+			}
+		}
+		const dq = "DELETE FROM poster_blobs WHERE hash = $1"
+		if _, err := tx.ExecContext(ctx, dq, hash); err != nil {
+			return err
+		}
+		deleted = true
+		return nil
+	})
+	return deleted, err
+}
+
+// lockHash locks the images of the hash until the transaction ends
+func lockHash(ctx context.Context, tx *sql.Tx, hash string) error {
+	_, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1, hashtext($2))", lockClass, hash)
+	return err
+}
+
+// images lists the original and the thumbnails of the poster, which are derived from its hash and its dimensions
+func images(p model.Poster) []model.PosterImage {
+	result := []model.PosterImage{{Name: "original", Width: p.Width, Path: path(p.Hash, "original"+extensions[p.ContentType])}}
+	for _, t := range thumbnails {
+		width := t.width
+		if width > p.Width {
+			width = p.Width
+		}
+		result = append(result, model.PosterImage{Name: t.name, Width: width, Path: path(p.Hash, t.name+".jpg")})
+	}
+	return result
+}
+
+func isImageName(name string) bool {
+	for _, ext := range extensions {
+		if name == "original"+ext {
+			return true
+		}
+	}
+	for _, t := range thumbnails {
+		if name == t.name+".jpg" {
+			return true
+		}
+	}
+	return false
+}
+
+// key returns the key of an image in the blob store
+func key(hash, name string) string {
+	return "posters/" + hash + "/" + name
+}
+
+// path returns the path an image is served at
+func path(hash, name string) string {
+	return "/posters/" + hash + "/" + name
+}
+
+// scanPoster reads a poster of the columns along with its images
+func scanPoster(row util.Scanner) (model.Poster, error) {
+	p := model.Poster{}
+	err := row.Scan(&p.MovieID, &p.Hash, &p.ContentType, &p.Width, &p.Height, &p.Size, &p.UpdatedAt)
+	if err != nil {
+		return model.Poster{}, err
+	}
+	p.Images = images(p)
+	return p, nil
+}
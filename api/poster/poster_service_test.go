@@ -0,0 +1,360 @@
+package poster_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+	"time"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/blob"
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/poster"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	GetPosterQuery    = `^SELECT movie_id, hash, content_type, width, height, size, updated_at FROM movie_posters WHERE movie_id = \$1$`
+	CheckMovieQuery   = `^SELECT id FROM movies WHERE id = \$1 AND deleted_at IS NULL$`
+	LockPosterQuery   = `^SELECT .+ FROM movie_posters WHERE movie_id = \$1 FOR UPDATE$`
+	UpsertPosterQuery = `^INSERT INTO movie_posters \(movie_id, hash, content_type, width, height, size\) VALUES .+ ON CONFLICT \(movie_id\) DO UPDATE .+ RETURNING .+$`
+	DeletePosterQuery = `^DELETE FROM movie_posters WHERE movie_id = \$1 RETURNING .+$`
+	LockHashQuery     = `^SELECT pg_advisory_xact_lock\(\$1, hashtext\(\$2\)\)$`
+	InsertBlobQuery   = `^INSERT INTO poster_blobs \(hash, content_type\) VALUES \(\$1, \$2\) ON CONFLICT \(hash\) DO NOTHING$`
+	UnusedBlobQuery   = `^SELECT content_type FROM poster_blobs b WHERE hash = \$1 AND NOT EXISTS \(.+\)$`
+	DeleteBlobQuery   = `^DELETE FROM poster_blobs WHERE hash = \$1$`
+	UnusedBlobsQuery  = `^SELECT hash FROM poster_blobs b WHERE NOT EXISTS \(.+\)$`
+	AuditQuery        = `^INSERT INTO audit_log \(.+\) VALUES \(.+\)$`
+)
+
+var uploaded = time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+func TestServiceGetPoster(t *testing.T) {
+	service, mock, db, _ := initNewService(t)
+	defer db.Close()
+
+	p := newPoster(7, pngData(400, 600))
+	mock.ExpectQuery(CheckMovieQuery).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectQuery(GetPosterQuery).WithArgs(7).WillReturnRows(newRows(&[]model.Poster{p}))
+
+	res, err := service.GetPoster(7)
+
+	assert.Equal(t, p, res)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []model.PosterImage{
+		{Name: "original", Width: 400, Path: "/posters/" + p.Hash + "/original.png"},
+		{Name: "small", Width: 154, Path: "/posters/" + p.Hash + "/small.jpg"},
+		{Name: "medium", Width: 342, Path: "/posters/" + p.Hash + "/medium.jpg"},
+		{Name: "large", Width: 400, Path: "/posters/" + p.Hash + "/large.jpg"},
+	}, res.Images, "Thumbnails should not be wider than the upload")
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceGetPosterMissingError(t *testing.T) {
+	service, mock, db, _ := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectQuery(CheckMovieQuery).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectQuery(GetPosterQuery).WithArgs(7).WillReturnRows(newRows(&[]model.Poster{}))
+
+	res, err := service.GetPoster(7)
+
+	assert.Equal(t, model.Poster{}, res)
+	assert.IsType(t, &util.NotExistingRecordError{}, err)
+}
+
+func TestServiceSavePosterCreated(t *testing.T) {
+	service, mock, db, store := initNewService(t)
+	defer db.Close()
+
+	data := pngData(400, 600)
+	p := newPoster(7, data)
+	mock.ExpectQuery(CheckMovieQuery).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectBegin()
+	mock.ExpectExec(LockHashQuery).WithArgs(sqlmock.AnyArg(), p.Hash).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(InsertBlobQuery).WithArgs(p.Hash, "image/png").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(LockPosterQuery).WithArgs(7).WillReturnRows(newRows(&[]model.Poster{}))
+	mock.ExpectQuery(UpsertPosterQuery).WithArgs(7, p.Hash, "image/png", 400, 600, len(data)).WillReturnRows(newRows(&[]model.Poster{p}))
+	mock.ExpectExec(AuditQuery).
+		WithArgs("poster", 7, "create", "tester", "test-request", nil, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	res, created, err := service.SavePoster(auditContext(), 7, data)
+
+	assert.Equal(t, p, res)
+	assert.True(t, created)
+	assert.Equal(t, nil, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+
+	original, err := store.Get(context.Background(), "posters/"+p.Hash+"/original.png")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, data, original.Data, "The upload should be kept as it is")
+	for name, size := range map[string][2]int{"small.jpg": {154, 231}, "medium.jpg": {342, 513}, "large.jpg": {400, 600}} {
+		thumbnail, err := store.Get(context.Background(), "posters/"+p.Hash+"/"+name)
+		if assert.Equal(t, nil, err, "Thumbnail [%s] should be stored", name) {
+			cfg, format, _ := image.DecodeConfig(bytes.NewReader(thumbnail.Data))
+			assert.Equal(t, "jpeg", format)
+			assert.Equal(t, size, [2]int{cfg.Width, cfg.Height}, "Thumbnail [%s] should keep the aspect ratio", name)
+		}
+	}
+}
+
+func TestServiceSavePosterReplaced(t *testing.T) {
+	service, mock, db, store := initNewService(t)
+	defer db.Close()
+
+	old := newPoster(7, jpegData(200, 300))
+	store.Put(context.Background(), "posters/"+old.Hash+"/original.jpg", []byte("old"))
+	store.Put(context.Background(), "posters/"+old.Hash+"/small.jpg", []byte("old"))
+
+	data := pngData(400, 600)
+	p := newPoster(7, data)
+	mock.ExpectQuery(CheckMovieQuery).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectBegin()
+	mock.ExpectExec(LockHashQuery).WithArgs(sqlmock.AnyArg(), p.Hash).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(InsertBlobQuery).WithArgs(p.Hash, "image/png").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(LockPosterQuery).WithArgs(7).WillReturnRows(newRows(&[]model.Poster{old}))
+	mock.ExpectQuery(UpsertPosterQuery).WithArgs(7, p.Hash, "image/png", 400, 600, len(data)).WillReturnRows(newRows(&[]model.Poster{p}))
+	mock.ExpectExec(AuditQuery).
+		WithArgs("poster", 7, "update", "tester", "test-request", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	expectSweep(mock, old.Hash, "image/jpeg")
+
+	res, created, err := service.SavePoster(auditContext(), 7, data)
+
+	assert.Equal(t, p, res)
+	assert.False(t, created)
+	assert.Equal(t, nil, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+	for _, name := range []string{"original.jpg", "small.jpg"} {
+		_, err := store.Get(context.Background(), "posters/"+old.Hash+"/"+name)
+		assert.IsType(t, &util.NotExistingRecordError{}, err, "The images of the former poster should be deleted")
+	}
+}
+
+func TestServiceSavePosterUnsupportedTypeError(t *testing.T) {
+	service, mock, db, _ := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectQuery(CheckMovieQuery).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+
+	_, _, err := service.SavePoster(auditContext(), 7, []byte("GIF89a not quite a poster"))
+
+	assert.IsType(t, &util.UnsupportedMediaTypeError{}, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceSavePosterInvalidImageError(t *testing.T) {
+	service, mock, db, _ := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectQuery(CheckMovieQuery).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectQuery(CheckMovieQuery).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+
+	_, _, err := service.SavePoster(auditContext(), 7, pngData(model.MaxPosterDimension+1, 1))
+	assert.IsType(t, &util.InvalidImageError{}, err, "Oversized images should be refused")
+
+	truncated := pngData(10, 10)
+	_, _, err = service.SavePoster(auditContext(), 7, truncated[:len(truncated)/2])
+	assert.IsType(t, &util.InvalidImageError{}, err, "Unreadable images should be refused")
+}
+
+func TestServiceSavePosterTrashedMovieError(t *testing.T) {
+	service, mock, db, _ := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectQuery(CheckMovieQuery).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	_, _, err := service.SavePoster(auditContext(), 7, pngData(10, 10))
+
+	assert.IsType(t, &util.NotExistingRecordError{}, err)
+}
+
+func TestServiceDeletePoster(t *testing.T) {
+	service, mock, db, store := initNewService(t)
+	defer db.Close()
+
+	p := newPoster(7, pngData(10, 10))
+	store.Put(context.Background(), "posters/"+p.Hash+"/original.png", []byte("old"))
+	mock.ExpectBegin()
+	mock.ExpectQuery(DeletePosterQuery).WithArgs(7).WillReturnRows(newRows(&[]model.Poster{p}))
+	mock.ExpectExec(AuditQuery).
+		WithArgs("poster", 7, "delete", "tester", "test-request", sqlmock.AnyArg(), nil, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	// Another movie has the same poster, whose images are kept
+	expectSweep(mock, p.Hash, "")
+
+	err := service.DeletePoster(auditContext(), 7)
+
+	assert.Equal(t, nil, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+	_, err = store.Get(context.Background(), "posters/"+p.Hash+"/original.png")
+	assert.Equal(t, nil, err, "Images still in use should be kept")
+}
+
+func TestServiceDeletePosterMissingError(t *testing.T) {
+	service, mock, db, _ := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(DeletePosterQuery).WithArgs(7).WillReturnRows(newRows(&[]model.Poster{}))
+	mock.ExpectRollback()
+
+	err := service.DeletePoster(auditContext(), 7)
+
+	assert.IsType(t, &util.NotExistingRecordError{}, err)
+}
+
+func TestServiceSweep(t *testing.T) {
+	service, mock, db, store := initNewService(t)
+	defer db.Close()
+
+	// The poster of a purged movie, which took its record along
+	p := newPoster(7, pngData(10, 10))
+	store.Put(context.Background(), "posters/"+p.Hash+"/original.png", []byte("purged"))
+	store.Put(context.Background(), "posters/"+p.Hash+"/small.jpg", []byte("purged"))
+	mock.ExpectQuery(UnusedBlobsQuery).WillReturnRows(sqlmock.NewRows([]string{"hash"}).AddRow(p.Hash))
+	expectSweep(mock, p.Hash, "image/png")
+
+	swept, err := service.Sweep(context.Background())
+
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, swept)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+	for _, name := range []string{"original.png", "small.jpg"} {
+		_, err := store.Get(context.Background(), "posters/"+p.Hash+"/"+name)
+		assert.IsType(t, &util.NotExistingRecordError{}, err, "The images no poster refers to should be deleted")
+	}
+}
+
+func TestServiceGetImage(t *testing.T) {
+	service, _, db, store := initNewService(t)
+	defer db.Close()
+
+	hash := newPoster(7, pngData(10, 10)).Hash
+	store.Put(context.Background(), "posters/"+hash+"/small.jpg", []byte("thumbnail"))
+
+	img, err := service.GetImage(context.Background(), hash, "small.jpg")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []byte("thumbnail"), img.Data)
+	assert.Equal(t, "image/jpeg", img.ContentType)
+
+	for _, name := range []string{"huge.jpg", "../small.jpg", "original.gif"} {
+		_, err := service.GetImage(context.Background(), hash, name)
+		assert.IsType(t, &util.NotExistingRecordError{}, err, "Image [%s] should not exist", name)
+	}
+	_, err = service.GetImage(context.Background(), "..", "small.jpg")
+	assert.IsType(t, &util.NotExistingRecordError{}, err, "Only hashes should be looked up")
+}
+
+// expectSweep expects the images of the hash to be swept, unless no content type is given as a poster still refers to them
+func expectSweep(mock sqlmock.Sqlmock, hash, contentType string) {
+	mock.ExpectBegin()
+	mock.ExpectExec(LockHashQuery).WithArgs(sqlmock.AnyArg(), hash).WillReturnResult(sqlmock.NewResult(0, 0))
+	rows := sqlmock.NewRows([]string{"content_type"})
+	if contentType == "" {
+		mock.ExpectQuery(UnusedBlobQuery).WithArgs(hash).WillReturnRows(rows)
+		mock.ExpectCommit()
+		return
+	}
+	mock.ExpectQuery(UnusedBlobQuery).WithArgs(hash).WillReturnRows(rows.AddRow(contentType))
+	mock.ExpectExec(DeleteBlobQuery).WithArgs(hash).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+}
+
+func initNewService(t *testing.T) (poster.PosterService, sqlmock.Sqlmock, *sql.DB, blob.BlobStore) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	store := blob.NewFileStore(t.TempDir())
+	service := poster.NewPosterService(db, store)
+	return service, mock, db, store
+}
+
+func auditContext() context.Context {
+	return util.WithRequestID(util.WithActor(context.Background(), "tester"), "test-request")
+}
+
+// newPoster returns the poster the service makes of the upload, along with its images
+func newPoster(movieID int, data []byte) model.Poster {
+	cfg, format, _ := image.DecodeConfig(bytes.NewReader(data))
+	hash := sha256.Sum256(data)
+	p := model.Poster{
+		MovieID:     movieID,
+		Hash:        hex.EncodeToString(hash[:]),
+		ContentType: "image/" + format,
+		Width:       cfg.Width,
+		Height:      cfg.Height,
+		Size:        len(data),
+		UpdatedAt:   uploaded,
+	}
+	ext := map[string]string{"png": ".png", "jpeg": ".jpg"}[format]
+	p.Images = []model.PosterImage{{Name: "original", Width: p.Width, Path: "/posters/" + p.Hash + "/original" + ext}}
+	for _, t := range []struct {
+		name  string
+		width int
+	}{{"small", 154}, {"medium", 342}, {"large", 780}} {
+		if t.width > p.Width {
+			t.width = p.Width
+		}
+		p.Images = append(p.Images, model.PosterImage{Name: t.name, Width: t.width, Path: "/posters/" + p.Hash + "/" + t.name + ".jpg"})
+	}
+	return p
+}
+
+func newRows(posters *[]model.Poster) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"movie_id", "hash", "content_type", "width", "height", "size", "updated_at"})
+	for _, p := range *posters {
+		rows.AddRow(p.MovieID, p.Hash, p.ContentType, p.Width, p.Height, p.Size, p.UpdatedAt)
+	}
+	return rows
+}
+
+func pngData(width, height int) []byte {
+	var buf bytes.Buffer
+	png.Encode(&buf, gradient(width, height))
+	return buf.Bytes()
+}
+
+func jpegData(width, height int) []byte {
+	var buf bytes.Buffer
+	jpeg.Encode(&buf, gradient(width, height), nil)
+	return buf.Bytes()
+}
+
+// gradient returns a half-transparent image, so the uploads differ by their size
+func gradient(width, height int) image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 128, A: 128})
+		}
+	}
+	return img
+}
@@ -0,0 +1,45 @@
+package poster
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Sweeper deletes the images no poster refers to anymore every interval, off the request path.
+// Replaced and deleted posters release their images right away, while those of purged or merged movies wait for it.
+type Sweeper struct {
+	service  PosterService
+	interval time.Duration
+}
+
+func NewSweeper(s PosterService, interval time.Duration) *Sweeper {
+	return &Sweeper{
+		service:  s,
+		interval: interval,
+	}
+}
+
+// Run sweeps every interval until the context is cancelled
+func (s *Sweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.sweep(ctx)
+		}
+	}
+}
+
+func (s *Sweeper) sweep(ctx context.Context) {
+	n, err := s.service.Sweep(ctx)
+	if err != nil {
+		log.Println("[Poster Sweeper Error] ", err.Error())
+	}
+	if n > 0 {
+		log.Printf("[Poster Sweeper] deleted the images of %d posters", n)
+	}
+}
@@ -0,0 +1,174 @@
+package release
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+
+	"github.com/gorilla/mux"
+)
+
+type controller struct {
+	service ReleaseService
+}
+
+type ReleaseController interface {
+	GetReleases(w http.ResponseWriter, r *http.Request)
+	GetRelease(w http.ResponseWriter, r *http.Request)
+	SaveRelease(w http.ResponseWriter, r *http.Request)
+	DeleteRelease(w http.ResponseWriter, r *http.Request)
+}
+
+func NewReleaseController(s ReleaseService) ReleaseController {
+	return &controller{
+		service: s,
+	}
+}
+
+func (c *controller) GetReleases(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to GetReleases", r.Method))
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 0)
+	if err != nil {
+		util.HandleBadRequest(w, "Invalid ID", err.Error())
+		return
+	}
+
+	enc, err := util.NewResponseEncoder(r, true)
+	if err != nil {
+		util.HandleNotAcceptable(w, err)
+		return
+	}
+
+	releases, err := c.service.GetReleases(int(id))
+	if err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	util.WriteResponse(w, enc, http.StatusOK, releases)
+}
+
+func (c *controller) GetRelease(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to GetRelease", r.Method))
+		return
+	}
+
+	id, region, err := parseReleasePath(r)
+	if err != nil {
+		util.HandleBadRequest(w, "Invalid path", err.Error())
+		return
+	}
+
+	enc, err := util.NewResponseEncoder(r, false)
+	if err != nil {
+		util.HandleNotAcceptable(w, err)
+		return
+	}
+
+	release, err := c.service.GetRelease(id, region)
+	if err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	util.WriteResponse(w, enc, http.StatusOK, release)
+}
+
+// SaveRelease creates or replaces the release in the region of the path, answering 201 or 200 respectively
+func (c *controller) SaveRelease(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to SaveRelease", r.Method))
+		return
+	}
+
+	id, region, err := parseReleasePath(r)
+	if err != nil {
+		util.HandleBadRequest(w, "Invalid path", err.Error())
+		return
+	}
+
+	// Extracting Release object from request-body
+	release, err := parseValidRelease(r)
+	if err != nil {
+		util.HandleInvalidBody(w, err)
+		return
+	}
+	// The movie and the region are identified by the path
+	release.MovieID = id
+	release.Region = region
+
+	enc, err := util.NewResponseEncoder(r, false)
+	if err != nil {
+		util.HandleNotAcceptable(w, err)
+		return
+	}
+
+	created, err := c.service.SaveRelease(r.Context(), release)
+	if err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	util.WriteResponse(w, enc, status, release)
+}
+
+func (c *controller) DeleteRelease(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to DeleteRelease", r.Method))
+		return
+	}
+
+	id, region, err := parseReleasePath(r)
+	if err != nil {
+		util.HandleBadRequest(w, "Invalid path", err.Error())
+		return
+	}
+
+	if err := c.service.DeleteRelease(r.Context(), id, region); err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// parseReleasePath returns the ID of the movie and the region in the path, the latter in upper case
+func parseReleasePath(r *http.Request) (int, string, error) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 0)
+	if err != nil {
+		return 0, "", err
+	}
+	region, ok := util.CanonicalRegion(mux.Vars(r)["region"])
+	if !ok {
+		return 0, "", fmt.Errorf("region [%s] is not a two-letter code", mux.Vars(r)["region"])
+	}
+	return int(id), region, nil
+}
+
+func parseValidRelease(r *http.Request) (*model.Release, error) {
+	var rel model.Release
+
+	// Return if the request-body cannot be decoded into a Release object
+	if err := util.DecodeRequest(r, &rel); err != nil {
+		return nil, err
+	}
+
+	// Return if the requested Release object is invalid
+	if err := rel.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid release-object: %w", err)
+	}
+
+	return &rel, nil
+}
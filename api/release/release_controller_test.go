@@ -0,0 +1,180 @@
+package release_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/release"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Defining the mock ReleaseService
+type mockServiceStruct struct {
+	mock.Mock
+}
+
+func (s *mockServiceStruct) GetReleases(movieID int) ([]model.Release, error) {
+	args := s.Called(movieID)
+	return args.Get(0).([]model.Release), args.Error(1)
+}
+
+func (s *mockServiceStruct) GetRelease(movieID int, region string) (model.Release, error) {
+	args := s.Called(movieID, region)
+	return args.Get(0).(model.Release), args.Error(1)
+}
+
+func (s *mockServiceStruct) SaveRelease(ctx context.Context, r *model.Release) (bool, error) {
+	args := s.Called(r)
+	return args.Bool(0), args.Error(1)
+}
+
+func (s *mockServiceStruct) DeleteRelease(ctx context.Context, movieID int, region string) error {
+	args := s.Called(movieID, region)
+	return args.Error(0)
+}
+
+// Setting up mockService and controller
+var mockService = new(mockServiceStruct)
+var controller = release.NewReleaseController(mockService)
+
+func TestControllerGetReleases(t *testing.T) {
+	releases := []model.Release{{MovieID: 7, Region: "DE", ReleasedAt: released, Windows: []model.Window{}}}
+	mockService.On("GetReleases", 7).Return(releases, nil).Once()
+
+	req, _ := http.NewRequest("GET", "/movies/7/releases", nil)
+	rr := execute("/movies/{id}/releases", []string{"GET"}, req, controller.GetReleases)
+
+	status := http.StatusOK
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+	assert.Equal(t, jsonString(releases), rr.Body.String())
+}
+
+func TestControllerGetReleaseCanonicalRegion(t *testing.T) {
+	rel := model.Release{MovieID: 7, Region: "DE", ReleasedAt: released, Windows: []model.Window{}}
+	mockService.On("GetRelease", 7, "DE").Return(rel, nil).Once()
+
+	req, _ := http.NewRequest("GET", "/movies/7/releases/de", nil)
+	rr := execute("/movies/{id}/releases/{region}", []string{"GET"}, req, controller.GetRelease)
+
+	status := http.StatusOK
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+	assert.Equal(t, jsonString(rel), rr.Body.String())
+}
+
+func TestControllerGetReleasePathParsingError(t *testing.T) {
+	for _, path := range []string{"/movies/x/releases/DE", "/movies/7/releases/DEU"} {
+		req, _ := http.NewRequest("GET", path, nil)
+		rr := execute("/movies/{id}/releases/{region}", []string{"GET"}, req, controller.GetRelease)
+
+		status := http.StatusBadRequest
+		assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d] for %s", status, path))
+	}
+}
+
+func TestControllerSaveRelease(t *testing.T) {
+	expected := model.Release{
+		MovieID:    7,
+		Region:     "DE",
+		ReleasedAt: released,
+		Windows:    []model.Window{{Kind: model.WindowTheatrical, StartsAt: released}},
+	}
+	mockService.On("SaveRelease", &expected).Return(true, nil).Once()
+
+	body := `{"released_at": "2026-10-01T00:00:00Z", "windows": [{"kind": "theatrical", "starts_at": "2026-10-01T00:00:00Z"}]}`
+	req, _ := http.NewRequest("PUT", "/movies/7/releases/de", bytes.NewBufferString(body))
+	rr := execute("/movies/{id}/releases/{region}", []string{"PUT"}, req, controller.SaveRelease)
+
+	if !mockService.AssertCalled(t, "SaveRelease", &expected) {
+		t.Error("The service should be called with the movie and the region of the path")
+	}
+	status := http.StatusCreated
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerSaveReleaseReplaced(t *testing.T) {
+	expected := model.Release{MovieID: 8, Region: "AT", ReleasedAt: released, Windows: []model.Window{}}
+	mockService.On("SaveRelease", &expected).Return(false, nil).Once()
+
+	req, _ := http.NewRequest("PUT", "/movies/8/releases/AT", bytes.NewBufferString(`{"released_at": "2026-10-01T00:00:00Z", "windows": []}`))
+	rr := execute("/movies/{id}/releases/{region}", []string{"PUT"}, req, controller.SaveRelease)
+
+	status := http.StatusOK
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerSaveReleaseBodyParsingError(t *testing.T) {
+	for _, body := range []string{
+		`{}`,
+		`{"released_at":`,
+		// Starting before the release
+		`{"released_at": "2026-10-01T00:00:00Z", "windows": [{"kind": "rental", "starts_at": "2026-09-01T00:00:00Z"}]}`,
+		// Ending before it starts
+		`{"released_at": "2026-10-01T00:00:00Z", "windows": [{"kind": "rental", "starts_at": "2026-11-01T00:00:00Z", "ends_at": "2026-10-15T00:00:00Z"}]}`,
+		// Overlapping a window of the same kind
+		`{"released_at": "2026-10-01T00:00:00Z", "windows": [{"kind": "streaming", "starts_at": "2026-10-01T00:00:00Z"}, {"kind": "streaming", "starts_at": "2027-01-01T00:00:00Z"}]}`,
+		`{"released_at": "2026-10-01T00:00:00Z", "windows": [{"kind": "broadcast", "starts_at": "2026-10-01T00:00:00Z"}]}`,
+	} {
+		req, _ := http.NewRequest("PUT", "/movies/7/releases/DE", bytes.NewBufferString(body))
+		rr := execute("/movies/{id}/releases/{region}", []string{"PUT"}, req, controller.SaveRelease)
+
+		status := http.StatusBadRequest
+		assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d] for %s", status, body))
+	}
+}
+
+func TestControllerSaveReleaseOverlappingKinds(t *testing.T) {
+	ends := time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC)
+	expected := model.Release{
+		MovieID:    9,
+		Region:     "DE",
+		ReleasedAt: released,
+		Windows: []model.Window{
+			{Kind: model.WindowTheatrical, StartsAt: released, EndsAt: &ends},
+			{Kind: model.WindowStreaming, StartsAt: released.AddDate(0, 1, 0)},
+		},
+	}
+	mockService.On("SaveRelease", &expected).Return(true, nil).Once()
+
+	body := `{"released_at": "2026-10-01T00:00:00Z", "windows": [` +
+		`{"kind": "theatrical", "starts_at": "2026-10-01T00:00:00Z", "ends_at": "2026-12-01T00:00:00Z"}, ` +
+		`{"kind": "streaming", "starts_at": "2026-11-01T00:00:00Z"}]}`
+	req, _ := http.NewRequest("PUT", "/movies/9/releases/DE", bytes.NewBufferString(body))
+	rr := execute("/movies/{id}/releases/{region}", []string{"PUT"}, req, controller.SaveRelease)
+
+	status := http.StatusCreated
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerDeleteReleaseNotFoundError(t *testing.T) {
+	mockService.On("DeleteRelease", 7, "DE").Return(&util.NotExistingRecordError{Identification: "movie ID: 7, region: DE"}).Once()
+
+	req, _ := http.NewRequest("DELETE", "/movies/7/releases/DE", nil)
+	rr := execute("/movies/{id}/releases/{region}", []string{"DELETE"}, req, controller.DeleteRelease)
+
+	status := http.StatusNotFound
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func execute(route string, methods []string, req *http.Request, handler func(w http.ResponseWriter, r *http.Request)) *httptest.ResponseRecorder {
+	r := mux.NewRouter()
+	r.HandleFunc(route, handler).Methods(methods...)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	return rr
+}
+
+func jsonString(obj interface{}) string {
+	res, _ := json.Marshal(obj)
+	return string(res)
+}
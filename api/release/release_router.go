@@ -0,0 +1,30 @@
+package release
+
+import (
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+
+	"github.com/gorilla/mux"
+)
+
+func InitializeReleasesPipeline(api *model.Api) {
+	s := NewReleaseService(api.DB)
+	c := NewReleaseController(s)
+	setRouting(api.Router, c)
+}
+
+func setRouting(main *mux.Router, c ReleaseController) {
+	// The releases are managed under their movie
+	sr := main.PathPrefix("/movies/{id}/releases").Subrouter()
+
+	sr.HandleFunc("", c.GetReleases).
+		Methods("GET")
+
+	sr.HandleFunc("/{region}", c.GetRelease).
+		Methods("GET")
+
+	sr.HandleFunc("/{region}", c.SaveRelease).
+		Methods("PUT")
+
+	sr.HandleFunc("/{region}", c.DeleteRelease).
+		Methods("DELETE")
+}
@@ -0,0 +1,67 @@
+package release_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/movie"
+	"github.com/Hunterlemming/golang-microservice-example/api/release"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInitializeReleasesPipeline(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	// The releases share their prefix with the movies, which are routed first
+	api := model.Api{Router: mux.NewRouter(), DB: db}
+	movie.InitializeMoviesPipeline(&api)
+	release.InitializeReleasesPipeline(&api)
+
+	testIntegrationGetReleases(t, mock, api.Router)
+	testIntegrationGetRelease(t, mock, api.Router)
+}
+
+func testIntegrationGetReleases(t *testing.T, mock sqlmock.Sqlmock, r *mux.Router) {
+	releases := []model.Release{{MovieID: 7, Region: "DE", ReleasedAt: released, UpdatedAt: released, Windows: []model.Window{}}}
+	mock.ExpectQuery(CheckMovieQuery).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectQuery(GetReleasesQuery).WithArgs(7).WillReturnRows(newRows(&releases))
+	mock.ExpectQuery(GetAllWindowsQuery).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"region", "kind", "starts_at", "ends_at"}))
+
+	req, _ := http.NewRequest("GET", "/movies/7/releases", nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, jsonString(releases), rr.Body.String())
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func testIntegrationGetRelease(t *testing.T, mock sqlmock.Sqlmock, r *mux.Router) {
+	rel := model.Release{MovieID: 7, Region: "DE", ReleasedAt: released, UpdatedAt: released, Windows: []model.Window{
+		{Kind: model.WindowStreaming, StartsAt: released},
+	}}
+	mock.ExpectQuery(CheckMovieQuery).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectQuery(GetReleaseQuery).WithArgs(7, "DE").WillReturnRows(newRows(&[]model.Release{rel}))
+	mock.ExpectQuery(GetWindowsQuery).WithArgs(7, "DE").WillReturnRows(sqlmock.NewRows([]string{"kind", "starts_at", "ends_at"}).
+		AddRow(model.WindowStreaming, released, nil))
+
+	req, _ := http.NewRequest("GET", "/movies/7/releases/de", nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, jsonString(rel), rr.Body.String())
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
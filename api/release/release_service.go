@@ -0,0 +1,217 @@
+package release
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/audit"
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/movie"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+)
+
+// Columns of the releases as scanned into model.Release, the windows being read separately
+const columns = "movie_id, region, released_at, updated_at"
+
+type service struct {
+	db *sql.DB
+}
+
+// ReleaseService keeps the releases of the movies by region along with their availability windows.
+// The movies available in a region are listed by the movie service.
+type ReleaseService interface {
+	GetReleases(movieID int) ([]model.Release, error)
+	GetRelease(movieID int, region string) (model.Release, error)
+	SaveRelease(ctx context.Context, r *model.Release) (bool, error)
+	DeleteRelease(ctx context.Context, movieID int, region string) error
+}
+
+func NewReleaseService(db *sql.DB) ReleaseService {
+	return &service{
+		db: db,
+	}
+}
+
+// GetReleases lists the releases of the movie by their regions
+func (s *service) GetReleases(movieID int) ([]model.Release, error) {
+	if err := movie.Exists(s.db, movieID); err != nil {
+		return []model.Release{}, err
+	}
+
+	const q = "SELECT " + columns + " FROM movie_releases WHERE movie_id = $1 ORDER BY region"
+	qr, err := s.db.Query(q, movieID)
+	if err != nil {
+		return []model.Release{}, err
+	}
+	defer qr.Close()
+
+	result := make([]model.Release, 0)
+	for qr.Next() {
+		r, err := scanRelease(qr)
+		if err != nil {
+			return []model.Release{}, err
+		}
+		result = append(result, r)
+	}
+	if err := qr.Err(); err != nil {
+		return []model.Release{}, err
+	}
+
+	const wq = "SELECT region, kind, starts_at, ends_at FROM movie_availability WHERE movie_id = $1 ORDER BY region, starts_at, kind"
+	wr, err := s.db.Query(wq, movieID)
+	if err != nil {
+		return []model.Release{}, err
+	}
+	defer wr.Close()
+
+	byRegion := make(map[string][]model.Window)
+	for wr.Next() {
+		var region string
+		w := model.Window{}
+		if err := wr.Scan(&region, &w.Kind, &w.StartsAt, &w.EndsAt); err != nil {
+			return []model.Release{}, err
+		}
+		byRegion[region] = append(byRegion[region], w)
+	}
+	for i := range result {
+		if w, ok := byRegion[result[i].Region]; ok {
+			result[i].Windows = w
+		}
+	}
+
+	return result, wr.Err()
+}
+
+func (s *service) GetRelease(movieID int, region string) (model.Release, error) {
+	if err := movie.Exists(s.db, movieID); err != nil {
+		return model.Release{}, err
+	}
+
+	const q = "SELECT " + columns + " FROM movie_releases WHERE movie_id = $1 AND region = $2"
+	r, err := scanRelease(s.db.QueryRow(q, movieID, region))
+	if err == sql.ErrNoRows {
+		return model.Release{}, notExisting(movieID, region)
+	}
+	if err != nil {
+		return model.Release{}, err
+	}
+
+	r.Windows, err = windows(s.db, movieID, region)
+	return r, err
+}
+
+// SaveRelease creates or replaces the release of the movie in its region along with every window, reporting whether it was created
+func (s *service) SaveRelease(ctx context.Context, r *model.Release) (bool, error) {
+	created := false
+	err := util.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := movie.Exists(tx, r.MovieID); err != nil {
+			return err
+		}
+
+		const bq = "SELECT " + columns + " FROM movie_releases WHERE movie_id = $1 AND region = $2 FOR UPDATE"
+		before, err := scanRelease(tx.QueryRowContext(ctx, bq, r.MovieID, r.Region))
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		created = err == sql.ErrNoRows
+		if !created {
+			if before.Windows, err = windows(tx, r.MovieID, r.Region); err != nil {
+				return err
+			}
+		}
+
+		const q = "INSERT INTO movie_releases (movie_id, region, released_at) VALUES ($1, $2, $3) " +
+			"ON CONFLICT (movie_id, region) DO UPDATE SET released_at = EXCLUDED.released_at, updated_at = now() " +
+			"RETURNING " + columns
+		after, err := scanRelease(tx.QueryRowContext(ctx, q, r.MovieID, r.Region, r.ReleasedAt))
+		if err != nil {
+			return err
+		}
+
+		// The windows are replaced as a whole, ordered the way they are read back
+		const dq = "DELETE FROM movie_availability WHERE movie_id = $1 AND region = $2"
+		if _, err := tx.ExecContext(ctx, dq, r.MovieID, r.Region); err != nil {
+			return err
+		}
+		sort.SliceStable(r.Windows, func(i, j int) bool {
+			if !r.Windows[i].StartsAt.Equal(r.Windows[j].StartsAt) {
+				return r.Windows[i].StartsAt.Before(r.Windows[j].StartsAt)
+			}
+			return r.Windows[i].Kind < r.Windows[j].Kind
+		})
+		const wq = "INSERT INTO movie_availability (movie_id, region, kind, starts_at, ends_at) VALUES ($1, $2, $3, $4, $5)"
+		for _, w := range r.Windows {
+			if _, err := tx.ExecContext(ctx, wq, r.MovieID, r.Region, w.Kind, w.StartsAt, w.EndsAt); err != nil {
+				return err
+			}
+		}
+		after.Windows = append(make([]model.Window, 0, len(r.Windows)), r.Windows...)
+
+		*r = after
+		if created {
+			return audit.Record(ctx, tx, audit.EntityRelease, after.MovieID, audit.ActionCreate, nil, after)
+		}
+		return audit.Record(ctx, tx, audit.EntityRelease, after.MovieID, audit.ActionUpdate, before, after)
+	})
+	return created, err
+}
+
+// DeleteRelease removes the release of the movie in the region, its windows are removed along with it
+func (s *service) DeleteRelease(ctx context.Context, movieID int, region string) error {
+	return util.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		// Read ahead of the deletion, so the windows are audited as well
+		before, err := windows(tx, movieID, region)
+		if err != nil {
+			return err
+		}
+
+		const q = "DELETE FROM movie_releases WHERE movie_id = $1 AND region = $2 RETURNING " + columns
+		r, err := scanRelease(tx.QueryRowContext(ctx, q, movieID, region))
+		if err == sql.ErrNoRows {
+			return notExisting(movieID, region)
+		}
+		if err != nil {
+			return err
+		}
+		r.Windows = before
+		return audit.Record(ctx, tx, audit.EntityRelease, movieID, audit.ActionDelete, r, nil)
+	})
+}
+
+func notExisting(movieID int, region string) error {
+	return &util.NotExistingRecordError{Identification: fmt.Sprintf("movie ID: %v, region: %s", movieID, region)}
+}
+
+// querier is implemented by both the database and its transactions
+type querier interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// windows reads the windows of the release in the order they start in
+func windows(db querier, movieID int, region string) ([]model.Window, error) {
+	const q = "SELECT kind, starts_at, ends_at FROM movie_availability WHERE movie_id = $1 AND region = $2 ORDER BY starts_at, kind"
+	qr, err := db.Query(q, movieID, region)
+	if err != nil {
+		return nil, err
+	}
+	defer qr.Close()
+
+	result := make([]model.Window, 0)
+	for qr.Next() {
+		w := model.Window{}
+		if err := qr.Scan(&w.Kind, &w.StartsAt, &w.EndsAt); err != nil {
+			return nil, err
+		}
+		result = append(result, w)
+	}
+	return result, qr.Err()
+}
+
+// scanRelease reads a release without its windows, which are left empty
+func scanRelease(row util.Scanner) (model.Release, error) {
+	r := model.Release{Windows: make([]model.Window, 0)}
+	err := row.Scan(&r.MovieID, &r.Region, &r.ReleasedAt, &r.UpdatedAt)
+	return r, err
+}
@@ -0,0 +1,216 @@
+package release_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/release"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	GetReleasesQuery   = `^SELECT movie_id, region, released_at, updated_at FROM movie_releases WHERE movie_id = \$1 ORDER BY region$`
+	GetReleaseQuery    = `^SELECT .+ FROM movie_releases WHERE movie_id = \$1 AND region = \$2$`
+	GetAllWindowsQuery = `^SELECT region, kind, starts_at, ends_at FROM movie_availability WHERE movie_id = \$1 ORDER BY region, starts_at, kind$`
+	GetWindowsQuery    = `^SELECT kind, starts_at, ends_at FROM movie_availability WHERE movie_id = \$1 AND region = \$2 ORDER BY starts_at, kind$`
+	CheckMovieQuery    = `^SELECT id FROM movies WHERE id = \$1 AND deleted_at IS NULL$`
+	LockReleaseQuery   = `^SELECT .+ FROM movie_releases WHERE movie_id = \$1 AND region = \$2 FOR UPDATE$`
+	UpsertReleaseQuery = `^INSERT INTO movie_releases \(movie_id, region, released_at\) VALUES .+ ON CONFLICT \(movie_id, region\) DO UPDATE .+ RETURNING .+$`
+	DeleteWindowsQuery = `^DELETE FROM movie_availability WHERE movie_id = \$1 AND region = \$2$`
+	InsertWindowQuery  = `^INSERT INTO movie_availability \(movie_id, region, kind, starts_at, ends_at\) VALUES .+$`
+	DeleteReleaseQuery = `^DELETE FROM movie_releases WHERE movie_id = \$1 AND region = \$2 RETURNING .+$`
+	AuditQuery         = `^INSERT INTO audit_log \(.+\) VALUES \(.+\)$`
+)
+
+var released = time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+func TestServiceGetReleases(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	ends := released.AddDate(0, 2, 0)
+	releases := []model.Release{
+		{MovieID: 7, Region: "AT", ReleasedAt: released, UpdatedAt: released, Windows: []model.Window{}},
+		{MovieID: 7, Region: "DE", ReleasedAt: released, UpdatedAt: released, Windows: []model.Window{
+			{Kind: model.WindowTheatrical, StartsAt: released, EndsAt: &ends},
+			{Kind: model.WindowStreaming, StartsAt: ends},
+		}},
+	}
+	mock.ExpectQuery(CheckMovieQuery).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectQuery(GetReleasesQuery).WithArgs(7).WillReturnRows(newRows(&releases))
+	mock.ExpectQuery(GetAllWindowsQuery).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"region", "kind", "starts_at", "ends_at"}).
+		AddRow("DE", model.WindowTheatrical, released, ends).
+		AddRow("DE", model.WindowStreaming, ends, nil))
+
+	res, err := service.GetReleases(7)
+
+	assert.Equal(t, releases, res)
+	assert.Equal(t, nil, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceGetReleasesTrashedMovieError(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectQuery(CheckMovieQuery).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	res, err := service.GetReleases(7)
+
+	assert.Equal(t, []model.Release{}, res)
+	assert.IsType(t, &util.NotExistingRecordError{}, err)
+}
+
+func TestServiceGetReleaseMissingError(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectQuery(CheckMovieQuery).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectQuery(GetReleaseQuery).WithArgs(7, "DE").WillReturnRows(newRows(&[]model.Release{}))
+
+	_, err := service.GetRelease(7, "DE")
+
+	assert.IsType(t, &util.NotExistingRecordError{}, err)
+}
+
+func TestServiceSaveReleaseCreated(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	streaming := model.Window{Kind: model.WindowStreaming, StartsAt: released.AddDate(0, 1, 0)}
+	theatrical := model.Window{Kind: model.WindowTheatrical, StartsAt: released}
+	saved := model.Release{MovieID: 7, Region: "DE", ReleasedAt: released, UpdatedAt: released}
+	mock.ExpectBegin()
+	mock.ExpectQuery(CheckMovieQuery).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectQuery(LockReleaseQuery).WithArgs(7, "DE").WillReturnRows(newRows(&[]model.Release{}))
+	mock.ExpectQuery(UpsertReleaseQuery).WithArgs(7, "DE", released).WillReturnRows(newRows(&[]model.Release{saved}))
+	mock.ExpectExec(DeleteWindowsQuery).WithArgs(7, "DE").WillReturnResult(sqlmock.NewResult(0, 0))
+	// The windows are stored in the order they start in
+	mock.ExpectExec(InsertWindowQuery).WithArgs(7, "DE", theatrical.Kind, theatrical.StartsAt, theatrical.EndsAt).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(InsertWindowQuery).WithArgs(7, "DE", streaming.Kind, streaming.StartsAt, streaming.EndsAt).
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectExec(AuditQuery).
+		WithArgs("release", 7, "create", "tester", "test-request", nil, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	rel := &model.Release{MovieID: 7, Region: "DE", ReleasedAt: released, Windows: []model.Window{streaming, theatrical}}
+	created, err := service.SaveRelease(auditContext(), rel)
+
+	saved.Windows = []model.Window{theatrical, streaming}
+	assert.True(t, created)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, saved, *rel, "The release should be filled in as stored")
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceSaveReleaseReplaced(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	before := model.Release{MovieID: 7, Region: "DE", ReleasedAt: released, UpdatedAt: released}
+	saved := model.Release{MovieID: 7, Region: "DE", ReleasedAt: released.AddDate(0, 0, 7), UpdatedAt: released}
+	mock.ExpectBegin()
+	mock.ExpectQuery(CheckMovieQuery).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectQuery(LockReleaseQuery).WithArgs(7, "DE").WillReturnRows(newRows(&[]model.Release{before}))
+	mock.ExpectQuery(GetWindowsQuery).WithArgs(7, "DE").WillReturnRows(sqlmock.NewRows([]string{"kind", "starts_at", "ends_at"}).
+		AddRow(model.WindowRental, released, nil))
+	mock.ExpectQuery(UpsertReleaseQuery).WithArgs(7, "DE", saved.ReleasedAt).WillReturnRows(newRows(&[]model.Release{saved}))
+	mock.ExpectExec(DeleteWindowsQuery).WithArgs(7, "DE").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(AuditQuery).
+		WithArgs("release", 7, "update", "tester", "test-request", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	created, err := service.SaveRelease(auditContext(), &model.Release{MovieID: 7, Region: "DE", ReleasedAt: saved.ReleasedAt})
+
+	assert.False(t, created)
+	assert.Equal(t, nil, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceSaveReleaseTrashedMovieError(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(CheckMovieQuery).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+
+	_, err := service.SaveRelease(auditContext(), &model.Release{MovieID: 7, Region: "DE", ReleasedAt: released})
+
+	assert.IsType(t, &util.NotExistingRecordError{}, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceDeleteRelease(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	before := model.Release{MovieID: 7, Region: "DE", ReleasedAt: released, UpdatedAt: released}
+	mock.ExpectBegin()
+	mock.ExpectQuery(GetWindowsQuery).WithArgs(7, "DE").WillReturnRows(sqlmock.NewRows([]string{"kind", "starts_at", "ends_at"}))
+	mock.ExpectQuery(DeleteReleaseQuery).WithArgs(7, "DE").WillReturnRows(newRows(&[]model.Release{before}))
+	mock.ExpectExec(AuditQuery).
+		WithArgs("release", 7, "delete", "tester", "test-request", sqlmock.AnyArg(), nil, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err := service.DeleteRelease(auditContext(), 7, "DE")
+
+	assert.Equal(t, nil, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceDeleteReleaseMissingError(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(GetWindowsQuery).WithArgs(7, "DE").WillReturnRows(sqlmock.NewRows([]string{"kind", "starts_at", "ends_at"}))
+	mock.ExpectQuery(DeleteReleaseQuery).WithArgs(7, "DE").WillReturnRows(newRows(&[]model.Release{}))
+	mock.ExpectRollback()
+
+	err := service.DeleteRelease(auditContext(), 7, "DE")
+
+	assert.IsType(t, &util.NotExistingRecordError{}, err)
+}
+
+func initNewService(t *testing.T) (release.ReleaseService, sqlmock.Sqlmock, *sql.DB) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	service := release.NewReleaseService(db)
+	return service, mock, db
+}
+
+func auditContext() context.Context {
+	return util.WithRequestID(util.WithActor(context.Background(), "tester"), "test-request")
+}
+
+func newRows(releases *[]model.Release) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"movie_id", "region", "released_at", "updated_at"})
+	for _, r := range *releases {
+		rows.AddRow(r.MovieID, r.Region, r.ReleasedAt, r.UpdatedAt)
+	}
+	return rows
+}
@@ -0,0 +1,282 @@
+package review
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+
+	"github.com/gorilla/mux"
+)
+
+// Reviews are always listed in pages
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+type controller struct {
+	service ReviewService
+}
+
+type ReviewController interface {
+	GetReviews(w http.ResponseWriter, r *http.Request)
+	GetMovieReviews(w http.ResponseWriter, r *http.Request)
+	SaveReview(w http.ResponseWriter, r *http.Request)
+	ModerateReview(w http.ResponseWriter, r *http.Request)
+	DeleteReview(w http.ResponseWriter, r *http.Request)
+}
+
+func NewReviewController(s ReviewService) ReviewController {
+	return &controller{
+		service: s,
+	}
+}
+
+// GetReviews lists the reviews of every movie for the moderators, the pending ones unless asked otherwise
+func (c *controller) GetReviews(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to GetReviews", r.Method))
+		return
+	}
+
+	c.listReviews(w, r, model.ReviewFilter{}, model.ReviewPending, "/reviews")
+}
+
+// GetMovieReviews lists the reviews of the movie, the approved ones unless asked otherwise
+func (c *controller) GetMovieReviews(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to GetMovieReviews", r.Method))
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 0)
+	if err != nil {
+		util.HandleBadRequest(w, "Invalid ID", err.Error())
+		return
+	}
+
+	c.listReviews(w, r, model.ReviewFilter{MovieID: int(id)}, model.ReviewApproved, fmt.Sprintf("/movies/%d/reviews", id))
+}
+
+// SaveReview creates or replaces the review of the requesting user, answering 201 or 200 respectively.
+// Anonymous users cannot review, as their reviews could not be told apart.
+func (c *controller) SaveReview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to SaveReview", r.Method))
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 0)
+	if err != nil {
+		util.HandleBadRequest(w, "Invalid ID", err.Error())
+		return
+	}
+
+	user := util.ActorFromContext(r.Context())
+	if user == util.AnonymousActor {
+		util.HandleUnauthorized(w, "Anonymous review")
+		return
+	}
+
+	// Extracting Review object from request-body
+	review, err := parseValidReview(r)
+	if err != nil {
+		util.HandleInvalidBody(w, err)
+		return
+	}
+	// The movie is identified by the path, the user by the request
+	review.MovieID = int(id)
+	review.User = user
+
+	enc, err := util.NewResponseEncoder(r, false)
+	if err != nil {
+		util.HandleNotAcceptable(w, err)
+		return
+	}
+
+	created, err := c.service.SaveReview(r.Context(), review)
+	if err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	util.WriteResponse(w, enc, status, review)
+}
+
+// ModerateReview sets the status of a review, which only moderators may do
+func (c *controller) ModerateReview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to ModerateReview", r.Method))
+		return
+	}
+
+	actor := util.ActorFromContext(r.Context())
+	if actor == util.AnonymousActor {
+		util.HandleUnauthorized(w, "Anonymous moderation")
+		return
+	}
+	if !util.HasRole(r.Context(), util.RoleModerator) {
+		util.HandleForbidden(w, fmt.Sprintf("moderation by [%s], who is no moderator", actor))
+		return
+	}
+
+	id, reviewID, err := parseReviewPath(r)
+	if err != nil {
+		util.HandleBadRequest(w, "Invalid ID", err.Error())
+		return
+	}
+
+	// Extracting Moderation object from request-body
+	var m model.Moderation
+	if err := util.DecodeRequest(r, &m); err != nil {
+		util.HandleInvalidBody(w, err)
+		return
+	}
+	if err := m.Validate(); err != nil {
+		util.HandleInvalidBody(w, fmt.Errorf("invalid moderation-object: %w", err))
+		return
+	}
+
+	enc, err := util.NewResponseEncoder(r, false)
+	if err != nil {
+		util.HandleNotAcceptable(w, err)
+		return
+	}
+
+	review, err := c.service.ModerateReview(r.Context(), id, reviewID, m.Status)
+	if err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	util.WriteResponse(w, enc, http.StatusOK, review)
+}
+
+// DeleteReview deletes a review, which only its author may do
+func (c *controller) DeleteReview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to DeleteReview", r.Method))
+		return
+	}
+
+	id, reviewID, err := parseReviewPath(r)
+	if err != nil {
+		util.HandleBadRequest(w, "Invalid ID", err.Error())
+		return
+	}
+
+	user := util.ActorFromContext(r.Context())
+	if user == util.AnonymousActor {
+		util.HandleUnauthorized(w, "Anonymous review deletion")
+		return
+	}
+
+	if err := c.service.DeleteReview(r.Context(), id, reviewID, user); err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// listReviews writes a page of the reviews of the filter, of the status asked for or else of the default one.
+// Full pages link the next one at the path.
+func (c *controller) listReviews(w http.ResponseWriter, r *http.Request, f model.ReviewFilter, defaultStatus, path string) {
+	f.Status = r.URL.Query().Get("status")
+	if f.Status == "" {
+		f.Status = defaultStatus
+	}
+	if !model.IsReviewStatus(f.Status) {
+		util.HandleBadRequest(w, "Invalid status", fmt.Sprintf("status [%s] is unknown", f.Status))
+		return
+	}
+
+	afterID, limit, err := parsePage(r)
+	if err != nil {
+		util.HandleBadRequest(w, "Invalid page", err.Error())
+		return
+	}
+
+	enc, err := util.NewResponseEncoder(r, true)
+	if err != nil {
+		util.HandleNotAcceptable(w, err)
+		return
+	}
+
+	reviews, err := c.service.GetReviews(f, afterID, limit)
+	if err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	// A full page may be followed by another one of the same status
+	if len(reviews) == limit {
+		next := url.Values{}
+		next.Set("status", f.Status)
+		next.Set("after", strconv.Itoa(reviews[len(reviews)-1].ID))
+		next.Set("limit", strconv.Itoa(limit))
+		w.Header().Set("Link", fmt.Sprintf(`<%s?%s>; rel="next"`, path, next.Encode()))
+	}
+	util.WriteResponse(w, enc, http.StatusOK, reviews)
+}
+
+func parsePage(r *http.Request) (int, int, error) {
+	query := r.URL.Query()
+
+	limit := int64(defaultPageSize)
+	if l := query.Get("limit"); l != "" {
+		var err error
+		limit, err = strconv.ParseInt(l, 10, 0)
+		if err != nil || limit < 1 || limit > maxPageSize {
+			return 0, 0, fmt.Errorf("limit [%s] out of range", l)
+		}
+	}
+
+	var afterID int64
+	if after := query.Get("after"); after != "" {
+		var err error
+		afterID, err = strconv.ParseInt(after, 10, 0)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	return int(afterID), int(limit), nil
+}
+
+// parseReviewPath returns the IDs of the movie and the review in the path
+func parseReviewPath(r *http.Request) (int, int, error) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 0)
+	if err != nil {
+		return 0, 0, err
+	}
+	reviewID, err := strconv.ParseInt(mux.Vars(r)["reviewId"], 10, 0)
+	if err != nil {
+		return 0, 0, err
+	}
+	return int(id), int(reviewID), nil
+}
+
+func parseValidReview(r *http.Request) (*model.Review, error) {
+	var review model.Review
+
+	// Return if the request-body cannot be decoded into a Review object
+	if err := util.DecodeRequest(r, &review); err != nil {
+		return nil, err
+	}
+
+	// Return if the requested Review object is invalid
+	if err := review.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid review-object: %w", err)
+	}
+
+	return &review, nil
+}
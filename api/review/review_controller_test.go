@@ -0,0 +1,222 @@
+package review_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/review"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Defining the mock ReviewService
+type mockServiceStruct struct {
+	mock.Mock
+}
+
+func (s *mockServiceStruct) GetReviews(f model.ReviewFilter, afterID, limit int) ([]model.Review, error) {
+	args := s.Called(f, afterID, limit)
+	return args.Get(0).([]model.Review), args.Error(1)
+}
+
+func (s *mockServiceStruct) SaveReview(ctx context.Context, r *model.Review) (bool, error) {
+	args := s.Called(r)
+	return args.Bool(0), args.Error(1)
+}
+
+func (s *mockServiceStruct) ModerateReview(ctx context.Context, movieID, id int, status string) (model.Review, error) {
+	args := s.Called(movieID, id, status)
+	return args.Get(0).(model.Review), args.Error(1)
+}
+
+func (s *mockServiceStruct) DeleteReview(ctx context.Context, movieID, id int, user string) error {
+	args := s.Called(movieID, id, user)
+	return args.Error(0)
+}
+
+// Setting up mockService and controller
+var mockService = new(mockServiceStruct)
+var controller = review.NewReviewController(mockService)
+
+func TestControllerGetMovieReviews(t *testing.T) {
+	reviews := []model.Review{{ID: 3, MovieID: 7, User: "alice", Rating: 5, Status: model.ReviewApproved}}
+	mockService.On("GetReviews", model.ReviewFilter{MovieID: 7, Status: model.ReviewApproved}, 0, 20).Return(reviews, nil).Once()
+
+	req, _ := http.NewRequest("GET", "/movies/7/reviews", nil)
+	rr := execute("/movies/{id}/reviews", []string{"GET"}, req, controller.GetMovieReviews)
+
+	status := http.StatusOK
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+	assert.Equal(t, jsonString(reviews), rr.Body.String())
+	assert.Equal(t, "", rr.Header().Get("Link"), "The last page should not link any other")
+}
+
+func TestControllerGetMovieReviewsPage(t *testing.T) {
+	reviews := []model.Review{{ID: 3, MovieID: 7, User: "alice", Rating: 5, Status: model.ReviewRejected}}
+	mockService.On("GetReviews", model.ReviewFilter{MovieID: 7, Status: model.ReviewRejected}, 2, 1).Return(reviews, nil).Once()
+
+	req, _ := http.NewRequest("GET", "/movies/7/reviews?status=rejected&after=2&limit=1", nil)
+	rr := execute("/movies/{id}/reviews", []string{"GET"}, req, controller.GetMovieReviews)
+
+	assert.Equal(t, `</movies/7/reviews?after=3&limit=1&status=rejected>; rel="next"`, rr.Header().Get("Link"), "A full page should link the next one")
+}
+
+func TestControllerGetReviewsParsingError(t *testing.T) {
+	for _, query := range []string{"?status=spam", "?limit=1000", "?after=x"} {
+		req, _ := http.NewRequest("GET", "/reviews"+query, nil)
+		rr := execute("/reviews", []string{"GET"}, req, controller.GetReviews)
+
+		status := http.StatusBadRequest
+		assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d] for %s", status, query))
+	}
+}
+
+func TestControllerGetReviewsPending(t *testing.T) {
+	mockService.On("GetReviews", model.ReviewFilter{Status: model.ReviewPending}, 0, 20).Return([]model.Review{}, nil).Once()
+
+	req, _ := http.NewRequest("GET", "/reviews", nil)
+	rr := execute("/reviews", []string{"GET"}, req, controller.GetReviews)
+
+	status := http.StatusOK
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+	assert.Equal(t, "[]", rr.Body.String())
+}
+
+func TestControllerSaveReview(t *testing.T) {
+	expected := model.Review{MovieID: 7, User: "alice", Rating: 4, Body: "Quietly funny"}
+	mockService.On("SaveReview", &expected).Return(true, nil).Once()
+
+	req, _ := http.NewRequest("POST", "/movies/7/reviews", bytes.NewBufferString(`{"rating": 4, "body": "Quietly funny"}`))
+	req = req.WithContext(util.WithActor(req.Context(), "alice"))
+	rr := execute("/movies/{id}/reviews", []string{"POST"}, req, controller.SaveReview)
+
+	if !mockService.AssertCalled(t, "SaveReview", &expected) {
+		t.Error("The service should be called with the movie of the path and the user of the request")
+	}
+	status := http.StatusCreated
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerSaveReviewReplaced(t *testing.T) {
+	expected := model.Review{MovieID: 8, User: "alice", Rating: 2}
+	mockService.On("SaveReview", &expected).Return(false, nil).Once()
+
+	req, _ := http.NewRequest("POST", "/movies/8/reviews", bytes.NewBufferString(`{"rating": 2}`))
+	req = req.WithContext(util.WithActor(req.Context(), "alice"))
+	rr := execute("/movies/{id}/reviews", []string{"POST"}, req, controller.SaveReview)
+
+	status := http.StatusOK
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerSaveReviewAnonymousError(t *testing.T) {
+	req, _ := http.NewRequest("POST", "/movies/7/reviews", bytes.NewBufferString(`{"rating": 4}`))
+	rr := execute("/movies/{id}/reviews", []string{"POST"}, req, controller.SaveReview)
+
+	status := http.StatusUnauthorized
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerSaveReviewBodyParsingError(t *testing.T) {
+	for _, body := range []string{`{}`, `{"rating": 0}`, `{"rating": 6}`} {
+		req, _ := http.NewRequest("POST", "/movies/7/reviews", bytes.NewBufferString(body))
+		req = req.WithContext(util.WithActor(req.Context(), "alice"))
+		rr := execute("/movies/{id}/reviews", []string{"POST"}, req, controller.SaveReview)
+
+		status := http.StatusBadRequest
+		assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d] for %s", status, body))
+	}
+}
+
+func TestControllerModerateReview(t *testing.T) {
+	moderated := model.Review{ID: 3, MovieID: 7, User: "alice", Rating: 4, Status: model.ReviewApproved}
+	mockService.On("ModerateReview", 7, 3, model.ReviewApproved).Return(moderated, nil).Once()
+
+	req, _ := http.NewRequest("PUT", "/movies/7/reviews/3/status", bytes.NewBufferString(`{"status": "approved"}`))
+	rr := execute("/movies/{id}/reviews/{reviewId}/status", []string{"PUT"}, asModerator(req), controller.ModerateReview)
+
+	status := http.StatusOK
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+	assert.Equal(t, jsonString(moderated), rr.Body.String())
+}
+
+func TestControllerModerateReviewAuthorizationError(t *testing.T) {
+	req, _ := http.NewRequest("PUT", "/movies/7/reviews/3/status", bytes.NewBufferString(`{"status": "approved"}`))
+	rr := execute("/movies/{id}/reviews/{reviewId}/status", []string{"PUT"}, req, controller.ModerateReview)
+
+	status := http.StatusUnauthorized
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d] for anonymous moderation", status))
+
+	req, _ = http.NewRequest("PUT", "/movies/7/reviews/3/status", bytes.NewBufferString(`{"status": "approved"}`))
+	req = req.WithContext(util.WithActor(req.Context(), "alice"))
+	rr = execute("/movies/{id}/reviews/{reviewId}/status", []string{"PUT"}, req, controller.ModerateReview)
+
+	status = http.StatusForbidden
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d] for users who are no moderators", status))
+}
+
+func TestControllerModerateReviewBodyParsingError(t *testing.T) {
+	req, _ := http.NewRequest("PUT", "/movies/7/reviews/3/status", bytes.NewBufferString(`{"status": "spam"}`))
+	rr := execute("/movies/{id}/reviews/{reviewId}/status", []string{"PUT"}, asModerator(req), controller.ModerateReview)
+
+	status := http.StatusBadRequest
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerDeleteReviewNotFoundError(t *testing.T) {
+	mockService.On("DeleteReview", 7, 3, "alice").Return(&util.NotExistingRecordError{Identification: "ID: 3"}).Once()
+
+	req, _ := http.NewRequest("DELETE", "/movies/7/reviews/3", nil)
+	req = req.WithContext(util.WithActor(req.Context(), "alice"))
+	rr := execute("/movies/{id}/reviews/{reviewId}", []string{"DELETE"}, req, controller.DeleteReview)
+
+	status := http.StatusNotFound
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerDeleteReviewOfOtherUserError(t *testing.T) {
+	mockService.On("DeleteReview", 7, 3, "bob").Return(&util.ForbiddenError{Identification: "ID: 3", Actor: "bob"}).Once()
+
+	req, _ := http.NewRequest("DELETE", "/movies/7/reviews/3", nil)
+	req = req.WithContext(util.WithActor(req.Context(), "bob"))
+	rr := execute("/movies/{id}/reviews/{reviewId}", []string{"DELETE"}, req, controller.DeleteReview)
+
+	status := http.StatusForbidden
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerDeleteReviewAnonymousError(t *testing.T) {
+	req, _ := http.NewRequest("DELETE", "/movies/7/reviews/3", nil)
+	rr := execute("/movies/{id}/reviews/{reviewId}", []string{"DELETE"}, req, controller.DeleteReview)
+
+	status := http.StatusUnauthorized
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+// asModerator authenticates the request as a moderator
+func asModerator(req *http.Request) *http.Request {
+	ctx := util.WithRoles(util.WithActor(req.Context(), "mod"), []string{util.RoleModerator})
+	return req.WithContext(ctx)
+}
+
+func execute(route string, methods []string, req *http.Request, handler func(w http.ResponseWriter, r *http.Request)) *httptest.ResponseRecorder {
+	r := mux.NewRouter()
+	r.HandleFunc(route, handler).Methods(methods...)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	return rr
+}
+
+func jsonString(obj interface{}) string {
+	res, _ := json.Marshal(obj)
+	return string(res)
+}
@@ -0,0 +1,33 @@
+package review
+
+import (
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+
+	"github.com/gorilla/mux"
+)
+
+func InitializeReviewsPipeline(api *model.Api) {
+	s := NewReviewService(api.DB)
+	c := NewReviewController(s)
+	setRouting(api.Router, c)
+}
+
+func setRouting(main *mux.Router, c ReviewController) {
+	main.HandleFunc("/reviews", c.GetReviews).
+		Methods("GET")
+
+	// The reviews are written and moderated under their movie
+	sr := main.PathPrefix("/movies/{id}/reviews").Subrouter()
+
+	sr.HandleFunc("", c.GetMovieReviews).
+		Methods("GET")
+
+	sr.HandleFunc("", c.SaveReview).
+		Methods("POST")
+
+	sr.HandleFunc("/{reviewId}/status", c.ModerateReview).
+		Methods("PUT")
+
+	sr.HandleFunc("/{reviewId}", c.DeleteReview).
+		Methods("DELETE")
+}
@@ -0,0 +1,60 @@
+package review_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/movie"
+	"github.com/Hunterlemming/golang-microservice-example/api/review"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInitializeReviewsPipeline(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	// The reviews share their prefix with the movies, which are routed first
+	api := model.Api{Router: mux.NewRouter(), DB: db}
+	movie.InitializeMoviesPipeline(&api)
+	review.InitializeReviewsPipeline(&api)
+
+	testIntegrationGetMovieReviews(t, mock, api.Router)
+	testIntegrationGetReviews(t, mock, api.Router)
+}
+
+func testIntegrationGetMovieReviews(t *testing.T, mock sqlmock.Sqlmock, r *mux.Router) {
+	reviews := []model.Review{{ID: 3, MovieID: 7, User: "alice", Rating: 5, Status: model.ReviewApproved, CreatedAt: reviewed, UpdatedAt: reviewed}}
+	mock.ExpectQuery(CheckMovieQuery).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectQuery(GetReviewsQuery).WithArgs(7, "approved", 0, 20).WillReturnRows(newRows(&reviews))
+
+	req, _ := http.NewRequest("GET", "/movies/7/reviews", nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, jsonString(reviews), rr.Body.String())
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func testIntegrationGetReviews(t *testing.T, mock sqlmock.Sqlmock, r *mux.Router) {
+	mock.ExpectQuery(GetAllReviewsQuery).WithArgs("pending", 0, 20).WillReturnRows(newRows(&[]model.Review{}))
+
+	req, _ := http.NewRequest("GET", "/reviews", nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "[]", rr.Body.String())
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,204 @@
+package review
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/audit"
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/movie"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+)
+
+// Columns of the reviews as scanned into model.Review
+const columns = "id, movie_id, user_name, rating, body, status, created_at, updated_at"
+
+type service struct {
+	db *sql.DB
+}
+
+type ReviewService interface {
+	GetReviews(f model.ReviewFilter, afterID, limit int) ([]model.Review, error)
+	SaveReview(ctx context.Context, r *model.Review) (bool, error)
+	ModerateReview(ctx context.Context, movieID, id int, status string) (model.Review, error)
+	DeleteReview(ctx context.Context, movieID, id int, user string) error
+}
+
+func NewReviewService(db *sql.DB) ReviewService {
+	return &service{
+		db: db,
+	}
+}
+
+// GetReviews returns up to limit reviews of the filter following afterID, ordered by their IDs.
+// The reviews of a movie are only listed while the movie is not in the trash.
+func (s *service) GetReviews(f model.ReviewFilter, afterID, limit int) ([]model.Review, error) {
+	conditions := make([]string, 0)
+	args := make([]interface{}, 0)
+	addCondition := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if f.MovieID != 0 {
+		if err := movie.Exists(s.db, f.MovieID); err != nil {
+			return []model.Review{}, err
+		}
+		addCondition("movie_id = $%d", f.MovieID)
+	}
+	if f.Status != "" {
+		addCondition("status = $%d", f.Status)
+	}
+	addCondition("id > $%d", afterID)
+	args = append(args, limit)
+
+	q := "SELECT " + columns + " FROM reviews WHERE " + strings.Join(conditions, " AND ") +
+		fmt.Sprintf(" ORDER BY id LIMIT $%d", len(args))
+	qr, err := s.db.Query(q, args...)
+	if err != nil {
+		return []model.Review{}, err
+	}
+	defer qr.Close()
+
+	result := make([]model.Review, 0, limit)
+	for qr.Next() {
+		r, err := scanReview(qr)
+		if err != nil {
+			return []model.Review{}, err
+		}
+		result = append(result, r)
+	}
+
+	return result, qr.Err()
+}
+
+// SaveReview creates the review of the user for the movie, or replaces the one the user has already written.
+// Either way the review is pending until it is moderated again. It tells whether the review was created.
+func (s *service) SaveReview(ctx context.Context, r *model.Review) (bool, error) {
+	created := false
+	err := util.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := lockMovie(ctx, tx, r.MovieID); err != nil {
+			return err
+		}
+
+		const bq = "SELECT " + columns + " FROM reviews WHERE movie_id = $1 AND user_name = $2 FOR UPDATE"
+		before, err := scanReview(tx.QueryRowContext(ctx, bq, r.MovieID, r.User))
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		created = err == sql.ErrNoRows
+
+		const q = "INSERT INTO reviews (movie_id, user_name, rating, body) VALUES ($1, $2, $3, $4) " +
+			"ON CONFLICT (movie_id, user_name) DO UPDATE " +
+			"SET rating = EXCLUDED.rating, body = EXCLUDED.body, status = 'pending', updated_at = now() " +
+			"RETURNING " + columns
+		after, err := scanReview(tx.QueryRowContext(ctx, q, r.MovieID, r.User, r.Rating, r.Body))
+		if err != nil {
+			return err
+		}
+		if err := UpdateRating(ctx, tx, r.MovieID); err != nil {
+			return err
+		}
+
+		*r = after
+		if created {
+			return audit.Record(ctx, tx, audit.EntityReview, after.ID, audit.ActionCreate, nil, after)
+		}
+		return audit.Record(ctx, tx, audit.EntityReview, after.ID, audit.ActionUpdate, before, after)
+	})
+	return created, err
+}
+
+// ModerateReview sets the status of the review, rejected reviews no longer count towards the rating of the movie
+func (s *service) ModerateReview(ctx context.Context, movieID, id int, status string) (model.Review, error) {
+	var after model.Review
+	err := util.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := lockMovie(ctx, tx, movieID); err != nil {
+			return err
+		}
+
+		const bq = "SELECT " + columns + " FROM reviews WHERE id = $1 AND movie_id = $2 FOR UPDATE"
+		before, err := scanReview(tx.QueryRowContext(ctx, bq, id, movieID))
+		if err == sql.ErrNoRows {
+			return &util.NotExistingRecordError{Identification: fmt.Sprintf("ID: %v", id)}
+		}
+		if err != nil {
+			return err
+		}
+
+		const q = "UPDATE reviews SET status = $1 WHERE id = $2 RETURNING " + columns
+		after, err = scanReview(tx.QueryRowContext(ctx, q, status, id))
+		if err != nil {
+			return err
+		}
+		if err := UpdateRating(ctx, tx, movieID); err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx, audit.EntityReview, id, audit.ActionUpdate, before, after)
+	})
+	if err != nil {
+		return model.Review{}, err
+	}
+	return after, nil
+}
+
+// DeleteReview deletes the review, as long as the user has written it
+func (s *service) DeleteReview(ctx context.Context, movieID, id int, user string) error {
+	return util.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := lockMovie(ctx, tx, movieID); err != nil {
+			return err
+		}
+
+		const bq = "SELECT " + columns + " FROM reviews WHERE id = $1 AND movie_id = $2 FOR UPDATE"
+		before, err := scanReview(tx.QueryRowContext(ctx, bq, id, movieID))
+		if err == sql.ErrNoRows {
+			return &util.NotExistingRecordError{Identification: fmt.Sprintf("ID: %v", id)}
+		}
+		if err != nil {
+			return err
+		}
+		if before.User != user {
+			return &util.ForbiddenError{Identification: fmt.Sprintf("ID: %v", id), Actor: user}
+		}
+
+		const q = "DELETE FROM reviews WHERE id = $1"
+		if _, err := tx.ExecContext(ctx, q, id); err != nil {
+			return err
+		}
+		if err := UpdateRating(ctx, tx, movieID); err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx, audit.EntityReview, id, audit.ActionDelete, before, nil)
+	})
+}
+
+// lockMovie locks the movie of the reviews being written, so the recounts of its ratings cannot overtake each other.
+// It fails with a NotExistingRecordError if the movie does not exist or is in the trash.
+func lockMovie(ctx context.Context, tx *sql.Tx, movieID int) error {
+	const q = "SELECT id FROM movies WHERE id = $1 AND deleted_at IS NULL FOR UPDATE"
+	err := tx.QueryRowContext(ctx, q, movieID).Scan(&movieID)
+	if err == sql.ErrNoRows {
+		return &util.NotExistingRecordError{Identification: fmt.Sprintf("ID: %v", movieID)}
+	}
+	return err
+}
+
+// UpdateRating recounts the ratings of the movie after its reviews have changed, leaving out the rejected ones.
+// The movie has to be locked by the transaction before its reviews are written.
+func UpdateRating(ctx context.Context, tx *sql.Tx, movieID int) error {
+	const q = `UPDATE movies SET rating_average = r.average, rating_count = r.count ` +
+		`FROM (SELECT COALESCE(ROUND(AVG(rating), 2), 0) AS average, COUNT(*) AS count ` +
+		`FROM reviews WHERE movie_id = $1 AND status <> 'rejected') r ` +
+		`WHERE movies.id = $1`
+	_, err := tx.ExecContext(ctx, q, movieID)
+	return err
+}
+
+// scanReview reads a review of the columns
+func scanReview(row util.Scanner) (model.Review, error) {
+	r := model.Review{}
+	err := row.Scan(&r.ID, &r.MovieID, &r.User, &r.Rating, &r.Body, &r.Status, &r.CreatedAt, &r.UpdatedAt)
+	return r, err
+}
@@ -0,0 +1,247 @@
+package review_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/review"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	GetReviewsQuery     = `^SELECT id, movie_id, user_name, rating, body, status, created_at, updated_at FROM reviews WHERE movie_id = \$1 AND status = \$2 AND id > \$3 ORDER BY id LIMIT \$4$`
+	GetAllReviewsQuery  = `^SELECT .+ FROM reviews WHERE status = \$1 AND id > \$2 ORDER BY id LIMIT \$3$`
+	CheckMovieQuery     = `^SELECT id FROM movies WHERE id = \$1 AND deleted_at IS NULL$`
+	OwnReviewQuery      = `^SELECT .+ FROM reviews WHERE movie_id = \$1 AND user_name = \$2 FOR UPDATE$`
+	UpsertReviewQuery   = `^INSERT INTO reviews \(movie_id, user_name, rating, body\) VALUES .+ ON CONFLICT \(movie_id, user_name\) DO UPDATE .+ RETURNING .+$`
+	LockReviewQuery     = `^SELECT .+ FROM reviews WHERE id = \$1 AND movie_id = \$2 FOR UPDATE$`
+	ModerateReviewQuery = `^UPDATE reviews SET status = \$1 WHERE id = \$2 RETURNING .+$`
+	DeleteReviewQuery   = `^DELETE FROM reviews WHERE id = \$1$`
+	LockMovieQuery      = `^SELECT id FROM movies WHERE id = \$1 AND deleted_at IS NULL FOR UPDATE$`
+	UpdateRatingQuery   = `^UPDATE movies SET rating_average = r.average, rating_count = r.count FROM \(SELECT .+ FROM reviews WHERE movie_id = \$1 AND status <> 'rejected'\) r WHERE movies.id = \$1$`
+	AuditQuery          = `^INSERT INTO audit_log \(.+\) VALUES \(.+\)$`
+)
+
+var reviewed = time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+func TestServiceGetReviews(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	reviews := []model.Review{{ID: 3, MovieID: 7, User: "alice", Rating: 5, Status: model.ReviewApproved, CreatedAt: reviewed, UpdatedAt: reviewed}}
+	mock.ExpectQuery(CheckMovieQuery).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectQuery(GetReviewsQuery).WithArgs(7, "approved", 2, 20).WillReturnRows(newRows(&reviews))
+
+	res, err := service.GetReviews(model.ReviewFilter{MovieID: 7, Status: model.ReviewApproved}, 2, 20)
+
+	assert.Equal(t, reviews, res)
+	assert.Equal(t, nil, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceGetReviewsOfEveryMovie(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectQuery(GetAllReviewsQuery).WithArgs("pending", 0, 20).WillReturnRows(newRows(&[]model.Review{}))
+
+	res, err := service.GetReviews(model.ReviewFilter{Status: model.ReviewPending}, 0, 20)
+
+	assert.Equal(t, []model.Review{}, res)
+	assert.Equal(t, nil, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceGetReviewsTrashedMovieError(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectQuery(CheckMovieQuery).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	res, err := service.GetReviews(model.ReviewFilter{MovieID: 7, Status: model.ReviewApproved}, 0, 20)
+
+	assert.Equal(t, []model.Review{}, res)
+	assert.IsType(t, &util.NotExistingRecordError{}, err)
+}
+
+func TestServiceSaveReviewCreated(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	saved := model.Review{ID: 3, MovieID: 7, User: "alice", Rating: 4, Status: model.ReviewPending, CreatedAt: reviewed, UpdatedAt: reviewed}
+	mock.ExpectBegin()
+	mock.ExpectQuery(LockMovieQuery).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectQuery(OwnReviewQuery).WithArgs(7, "alice").WillReturnRows(newRows(&[]model.Review{}))
+	mock.ExpectQuery(UpsertReviewQuery).WithArgs(7, "alice", 4, "").WillReturnRows(newRows(&[]model.Review{saved}))
+	mock.ExpectExec(UpdateRatingQuery).WithArgs(7).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(AuditQuery).
+		WithArgs("review", 3, "create", "tester", "test-request", nil, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	r := &model.Review{MovieID: 7, User: "alice", Rating: 4}
+	created, err := service.SaveReview(auditContext(), r)
+
+	assert.Equal(t, nil, err)
+	assert.True(t, created)
+	assert.Equal(t, saved, *r, "The saved review should be returned")
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceSaveReviewReplaced(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	before := model.Review{ID: 3, MovieID: 7, User: "alice", Rating: 4, Status: model.ReviewApproved, CreatedAt: reviewed, UpdatedAt: reviewed}
+	after := before
+	after.Rating, after.Status = 2, model.ReviewPending
+	mock.ExpectBegin()
+	mock.ExpectQuery(LockMovieQuery).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectQuery(OwnReviewQuery).WithArgs(7, "alice").WillReturnRows(newRows(&[]model.Review{before}))
+	mock.ExpectQuery(UpsertReviewQuery).WithArgs(7, "alice", 2, "").WillReturnRows(newRows(&[]model.Review{after}))
+	mock.ExpectExec(UpdateRatingQuery).WithArgs(7).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(AuditQuery).
+		WithArgs("review", 3, "update", "tester", "test-request", sqlmock.AnyArg(), sqlmock.AnyArg(),
+			`{"rating":{"from":4,"to":2},"status":{"from":"approved","to":"pending"}}`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	created, err := service.SaveReview(auditContext(), &model.Review{MovieID: 7, User: "alice", Rating: 2})
+
+	assert.Equal(t, nil, err)
+	assert.False(t, created)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceSaveReviewTrashedMovieError(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(LockMovieQuery).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+
+	_, err := service.SaveReview(auditContext(), &model.Review{MovieID: 7, User: "alice", Rating: 2})
+
+	assert.IsType(t, &util.NotExistingRecordError{}, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceModerateReview(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	before := model.Review{ID: 3, MovieID: 7, User: "alice", Rating: 4, Status: model.ReviewPending, CreatedAt: reviewed, UpdatedAt: reviewed}
+	after := before
+	after.Status = model.ReviewRejected
+	mock.ExpectBegin()
+	mock.ExpectQuery(LockMovieQuery).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectQuery(LockReviewQuery).WithArgs(3, 7).WillReturnRows(newRows(&[]model.Review{before}))
+	mock.ExpectQuery(ModerateReviewQuery).WithArgs("rejected", 3).WillReturnRows(newRows(&[]model.Review{after}))
+	mock.ExpectExec(UpdateRatingQuery).WithArgs(7).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(AuditQuery).
+		WithArgs("review", 3, "update", "tester", "test-request", sqlmock.AnyArg(), sqlmock.AnyArg(), `{"status":{"from":"pending","to":"rejected"}}`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	res, err := service.ModerateReview(auditContext(), 7, 3, model.ReviewRejected)
+
+	assert.Equal(t, nil, err)
+	assert.Equal(t, after, res)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceModerateReviewNotExistingError(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(LockMovieQuery).WithArgs(8).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(8))
+	mock.ExpectQuery(LockReviewQuery).WithArgs(3, 8).WillReturnRows(newRows(&[]model.Review{}))
+	mock.ExpectRollback()
+
+	_, err := service.ModerateReview(auditContext(), 8, 3, model.ReviewApproved)
+
+	assert.IsType(t, &util.NotExistingRecordError{}, err, "Reviews of other movies should not be found")
+}
+
+func TestServiceDeleteReview(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	deleted := model.Review{ID: 3, MovieID: 7, User: "alice", Rating: 4, Status: model.ReviewApproved, CreatedAt: reviewed, UpdatedAt: reviewed}
+	mock.ExpectBegin()
+	mock.ExpectQuery(LockMovieQuery).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectQuery(LockReviewQuery).WithArgs(3, 7).WillReturnRows(newRows(&[]model.Review{deleted}))
+	mock.ExpectExec(DeleteReviewQuery).WithArgs(3).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(UpdateRatingQuery).WithArgs(7).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(AuditQuery).
+		WithArgs("review", 3, "delete", "tester", "test-request", sqlmock.AnyArg(), nil, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err := service.DeleteReview(auditContext(), 7, 3, "alice")
+
+	assert.Equal(t, nil, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceDeleteReviewOfOtherUserError(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	written := model.Review{ID: 3, MovieID: 7, User: "alice", Rating: 4, Status: model.ReviewApproved, CreatedAt: reviewed, UpdatedAt: reviewed}
+	mock.ExpectBegin()
+	mock.ExpectQuery(LockMovieQuery).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectQuery(LockReviewQuery).WithArgs(3, 7).WillReturnRows(newRows(&[]model.Review{written}))
+	mock.ExpectRollback()
+
+	err := service.DeleteReview(auditContext(), 7, 3, "bob")
+
+	assert.IsType(t, &util.ForbiddenError{}, err, "Only the author should delete a review")
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func initNewService(t *testing.T) (review.ReviewService, sqlmock.Sqlmock, *sql.DB) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	service := review.NewReviewService(db)
+	return service, mock, db
+}
+
+func auditContext() context.Context {
+	return util.WithRequestID(util.WithActor(context.Background(), "tester"), "test-request")
+}
+
+func newRows(reviews *[]model.Review) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"id", "movie_id", "user_name", "rating", "body", "status", "created_at", "updated_at"})
+	for _, r := range *reviews {
+		rows.AddRow(r.ID, r.MovieID, r.User, r.Rating, r.Body, r.Status, r.CreatedAt, r.UpdatedAt)
+	}
+	return rows
+}
@@ -0,0 +1,207 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"math"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/event"
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/movie"
+	"github.com/Hunterlemming/golang-microservice-example/api/rpc/moviepb"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// How many events may queue up for a slow watcher before its call is ended
+const subscriberBuffer = 64
+
+type movieServer struct {
+	moviepb.UnimplementedMovieServiceServer
+	service movie.MovieService
+	broker  *event.Broker
+}
+
+// NewMovieServer returns the gRPC MovieService, delegating to the same MovieService as the REST API
+func NewMovieServer(s movie.MovieService, b *event.Broker) moviepb.MovieServiceServer {
+	return &movieServer{
+		service: s,
+		broker:  b,
+	}
+}
+
+func (s *movieServer) GetMovie(ctx context.Context, req *moviepb.GetMovieRequest) (*moviepb.Movie, error) {
+	m, err := s.service.GetMovie(int(req.Id))
+	if err != nil {
+		return nil, serviceError(err)
+	}
+	movies := []model.Movie{m}
+	if err := s.service.AttachCollections(movies); err != nil {
+		return nil, serviceError(err)
+	}
+	return toProto(movies[0]), nil
+}
+
+func (s *movieServer) ListMovies(ctx context.Context, req *moviepb.ListMoviesRequest) (*moviepb.ListMoviesResponse, error) {
+	movies, err := s.service.GetMovies()
+	if err != nil {
+		return nil, serviceError(err)
+	}
+	if err := s.service.AttachCollections(movies); err != nil {
+		return nil, serviceError(err)
+	}
+
+	res := &moviepb.ListMoviesResponse{Movies: make([]*moviepb.Movie, 0, len(movies))}
+	for _, m := range movies {
+		res.Movies = append(res.Movies, toProto(m))
+	}
+	return res, nil
+}
+
+func (s *movieServer) CreateMovie(ctx context.Context, req *moviepb.CreateMovieRequest) (*moviepb.Movie, error) {
+	m, err := fromValidProto(req.Movie)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.service.CreateMovie(ctx, &m); err != nil {
+		return nil, serviceError(err)
+	}
+	return toProto(m), nil
+}
+
+func (s *movieServer) UpdateMovie(ctx context.Context, req *moviepb.UpdateMovieRequest) (*moviepb.Movie, error) {
+	m, err := fromValidProto(req.Movie)
+	if err != nil {
+		return nil, err
+	}
+	// The messages do not carry the synopsis, which is kept
+	if current, err := s.service.GetMovie(m.ID); err == nil {
+		m.Synopsis = current.Synopsis
+	}
+
+	if err := s.service.UpdateMovie(ctx, m.ID, &m); err != nil {
+		return nil, serviceError(err)
+	}
+	return toProto(m), nil
+}
+
+func (s *movieServer) DeleteMovie(ctx context.Context, req *moviepb.DeleteMovieRequest) (*emptypb.Empty, error) {
+	if err := s.service.DeleteMovie(ctx, int(req.Id)); err != nil {
+		return nil, serviceError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// Watch first replays the events after the last one the client has seen, then streams the new ones.
+// Watchers falling behind are cut off with RESOURCE_EXHAUSTED and may resume from their last event.
+func (s *movieServer) Watch(req *moviepb.WatchRequest, stream moviepb.MovieService_WatchServer) error {
+	types := make(map[string]bool)
+	for _, t := range req.Types {
+		types[t] = true
+	}
+	matches := func(e model.Event) bool {
+		if req.MovieId != 0 && e.MovieID != int(req.MovieId) {
+			return false
+		}
+		return len(types) == 0 || types[e.Type]
+	}
+
+	// Watchers without a resume point receive the events from now on
+	lastEventID := req.LastEventId
+	if lastEventID == 0 {
+		lastEventID = math.MaxInt64
+	}
+	sub, replay := s.broker.Subscribe(lastEventID, subscriberBuffer)
+	defer s.broker.Unsubscribe(sub)
+	// The headers are sent once subscribed, so the watcher misses no event published after receiving them
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+
+	for _, e := range replay {
+		if !matches(e) {
+			continue
+		}
+		if err := sendEvent(stream, e); err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return status.FromContextError(stream.Context().Err()).Err()
+		case e, open := <-sub.Events:
+			if !open {
+				return status.Error(codes.ResourceExhausted, "Too slow to keep up with the changes")
+			}
+			if !matches(e) {
+				continue
+			}
+			if err := sendEvent(stream, e); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func sendEvent(stream moviepb.MovieService_WatchServer, e model.Event) error {
+	var m model.Movie
+	if err := json.Unmarshal(e.Payload, &m); err != nil {
+		log.Println("[gRPC - Internal] ", err.Error())
+		return status.Error(codes.Internal, "Invalid event")
+	}
+
+	return stream.Send(&moviepb.MovieEvent{
+		Id:        e.ID,
+		Type:      e.Type,
+		MovieId:   int32(e.MovieID),
+		Movie:     toProto(m),
+		CreatedAt: timestamppb.New(e.CreatedAt),
+	})
+}
+
+// serviceError maps the errors of the service to the matching status codes, like their HTTP counterparts
+func serviceError(err error) error {
+	var notExisting *util.NotExistingRecordError
+	var existing *util.ExistingRecordError
+	var stale *util.StaleRecordError
+	switch {
+	case errors.As(err, &notExisting):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.As(err, &existing):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.As(err, &stale):
+		return status.Error(codes.Aborted, err.Error())
+	}
+	log.Println("[gRPC - Internal] ", err.Error())
+	return status.Error(codes.Internal, "Service unreachable")
+}
+
+func fromValidProto(pm *moviepb.Movie) (model.Movie, error) {
+	if pm == nil {
+		return model.Movie{}, status.Error(codes.InvalidArgument, "Movie is missing")
+	}
+
+	m := model.Movie{ID: int(pm.Id), Name: pm.Name, Version: int(pm.Version)}
+	if err := m.Validate(); err != nil {
+		return model.Movie{}, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return m, nil
+}
+
+func toProto(m model.Movie) *moviepb.Movie {
+	pm := &moviepb.Movie{Id: int32(m.ID), Name: m.Name, Version: int32(m.Version)}
+	if c := m.Collection; c != nil {
+		pm.Collection = &moviepb.CollectionRef{Id: int32(c.ID), Name: c.Name, Position: int32(c.Position)}
+	}
+	return pm
+}
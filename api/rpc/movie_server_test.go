@@ -0,0 +1,211 @@
+package rpc_test
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"testing"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/event"
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/movie"
+	"github.com/Hunterlemming/golang-microservice-example/api/rpc"
+	"github.com/Hunterlemming/golang-microservice-example/api/rpc/moviepb"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestServerGetMovie(t *testing.T) {
+	client := newClient(t, event.NewBroker(10), model.Movie{ID: 1, Name: "test1"})
+
+	res, err := client.GetMovie(context.Background(), &moviepb.GetMovieRequest{Id: 1})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "test1", res.Name)
+	assert.Equal(t, int32(1), res.Version)
+}
+
+func TestServerGetMovieNotFoundError(t *testing.T) {
+	client := newClient(t, event.NewBroker(10))
+
+	_, err := client.GetMovie(context.Background(), &moviepb.GetMovieRequest{Id: 1})
+
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestServerListMovies(t *testing.T) {
+	client := newClient(t, event.NewBroker(10), model.Movie{ID: 1, Name: "test1"}, model.Movie{ID: 2, Name: "test2"})
+
+	res, err := client.ListMovies(context.Background(), &moviepb.ListMoviesRequest{})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(res.Movies))
+	assert.Equal(t, "test2", res.Movies[1].Name)
+}
+
+func TestServerCreateMovie(t *testing.T) {
+	client := newClient(t, event.NewBroker(10), model.Movie{ID: 1, Name: "test1"})
+
+	res, err := client.CreateMovie(context.Background(), &moviepb.CreateMovieRequest{Movie: &moviepb.Movie{Id: 2, Name: "test2"}})
+
+	assert.Nil(t, err)
+	assert.Equal(t, int32(1), res.Version)
+	_, err = client.CreateMovie(context.Background(), &moviepb.CreateMovieRequest{Movie: &moviepb.Movie{Id: 1, Name: "again"}})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+}
+
+func TestServerCreateMovieValidationError(t *testing.T) {
+	client := newClient(t, event.NewBroker(10))
+
+	_, err := client.CreateMovie(context.Background(), &moviepb.CreateMovieRequest{Movie: &moviepb.Movie{Id: 1}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = client.CreateMovie(context.Background(), &moviepb.CreateMovieRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err), "A missing movie should be rejected")
+}
+
+func TestServerUpdateMovie(t *testing.T) {
+	client := newClient(t, event.NewBroker(10), model.Movie{ID: 1, Name: "test1"})
+
+	res, err := client.UpdateMovie(context.Background(), &moviepb.UpdateMovieRequest{Movie: &moviepb.Movie{Id: 1, Name: "updated", Version: 1}})
+
+	assert.Nil(t, err)
+	assert.Equal(t, int32(2), res.Version)
+	_, err = client.UpdateMovie(context.Background(), &moviepb.UpdateMovieRequest{Movie: &moviepb.Movie{Id: 1, Name: "concurrent", Version: 1}})
+	assert.Equal(t, codes.Aborted, status.Code(err), "Updates of an outdated version should be rejected")
+}
+
+func TestServerDeleteMovie(t *testing.T) {
+	client := newClient(t, event.NewBroker(10), model.Movie{ID: 1, Name: "test1"})
+
+	_, err := client.DeleteMovie(context.Background(), &moviepb.DeleteMovieRequest{Id: 1})
+
+	assert.Nil(t, err)
+	_, err = client.DeleteMovie(context.Background(), &moviepb.DeleteMovieRequest{Id: 1})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestServerWatch(t *testing.T) {
+	broker := event.NewBroker(10)
+	broker.Publish(context.Background(), newEvent(1, 2, event.TypeMovieCreated))
+	broker.Publish(context.Background(), newEvent(2, 1, event.TypeMovieCreated))
+	client := newClient(t, broker)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := client.Watch(ctx, &moviepb.WatchRequest{MovieId: 1, LastEventId: 1})
+	assert.Nil(t, err)
+
+	res, err := stream.Recv()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), res.Id, "The missed events should be replayed")
+	assert.Equal(t, "test", res.Movie.Name)
+
+	broker.Publish(context.Background(), newEvent(3, 2, event.TypeMovieUpdated))
+	broker.Publish(context.Background(), newEvent(4, 1, event.TypeMovieUpdated))
+	res, err = stream.Recv()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(4), res.Id, "Events of other movies should be filtered")
+	assert.Equal(t, event.TypeMovieUpdated, res.Type)
+}
+
+func TestServerWatchFromNow(t *testing.T) {
+	broker := event.NewBroker(10)
+	broker.Publish(context.Background(), newEvent(1, 1, event.TypeMovieCreated))
+	client := newClient(t, broker)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := client.Watch(ctx, &moviepb.WatchRequest{})
+	assert.Nil(t, err)
+	// The headers are sent once the server has subscribed
+	_, err = stream.Header()
+	assert.Nil(t, err)
+
+	broker.Publish(context.Background(), newEvent(2, 1, event.TypeMovieUpdated))
+	res, err := stream.Recv()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), res.Id, "The buffered events should not be replayed to new watchers")
+}
+
+func TestServerWatchCollection(t *testing.T) {
+	broker := event.NewBroker(10)
+	client := newClient(t, broker)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := client.Watch(ctx, &moviepb.WatchRequest{})
+	assert.Nil(t, err)
+	_, err = stream.Header()
+	assert.Nil(t, err)
+
+	payload, _ := json.Marshal(model.Movie{ID: 1, Name: "test", Collection: &model.CollectionRef{ID: 5, Name: "Trilogy", Position: 2}})
+	broker.Publish(context.Background(), model.Event{ID: 1, Type: event.TypeMovieUpdated, MovieID: 1, Payload: payload})
+
+	res, err := stream.Recv()
+	assert.Nil(t, err)
+	assert.Equal(t, int32(5), res.Movie.GetCollection().GetId())
+	assert.Equal(t, "Trilogy", res.Movie.GetCollection().GetName())
+	assert.Equal(t, int32(2), res.Movie.GetCollection().GetPosition())
+}
+
+func TestServerUnknownTokenError(t *testing.T) {
+	client := newClient(t, event.NewBroker(10))
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer guessed")
+
+	_, err := client.ListMovies(ctx, &moviepb.ListMoviesRequest{})
+
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestServerRequestID(t *testing.T) {
+	client := newClient(t, event.NewBroker(10))
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer secret", "x-request-id", "test-request")
+
+	var header metadata.MD
+	_, err := client.ListMovies(ctx, &moviepb.ListMoviesRequest{}, grpc.Header(&header))
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"test-request"}, header.Get("x-request-id"))
+}
+
+func TestServerHealthCheck(t *testing.T) {
+	conn := newConn(t, event.NewBroker(10))
+
+	res, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{Service: "movies.v1.MovieService"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, res.Status)
+}
+
+func newClient(t *testing.T, broker *event.Broker, movies ...model.Movie) moviepb.MovieServiceClient {
+	return moviepb.NewMovieServiceClient(newConn(t, broker, movies...))
+}
+
+// newConn serves the API over an in-memory listener until the test is done
+func newConn(t *testing.T, broker *event.Broker, movies ...model.Movie) *grpc.ClientConn {
+	lis := bufconn.Listen(1024 * 1024)
+	server := rpc.NewServer(movie.NewInMemoryMovieService(movies...), broker, map[string]string{"secret": "tester"})
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	dialer := func(context.Context, string) (net.Conn, error) { return lis.Dial() }
+	conn, err := grpc.Dial("bufnet", grpc.WithContextDialer(dialer), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when connecting to the server", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func newEvent(id int64, movieID int, eventType string) model.Event {
+	payload, _ := json.Marshal(model.Movie{ID: movieID, Name: "test"})
+	return model.Event{ID: id, Type: eventType, MovieID: movieID, Payload: payload}
+}
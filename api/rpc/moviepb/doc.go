@@ -0,0 +1,4 @@
+// Package moviepb holds the protobuf definition of the gRPC API and the code generated from it.
+package moviepb
+
+//go:generate buf generate --template buf.gen.yaml .
@@ -0,0 +1,74 @@
+package rpc
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const requestIDKey = "x-request-id"
+
+func unaryRequestContext(tokens map[string]string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := requestContext(ctx, tokens)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func streamRequestContext(tokens map[string]string) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := requestContext(ss.Context(), tokens)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// requestContext stores the request ID and the authenticated actor in the context, like the middleware of the REST API.
+// Calls without a bearer token are served anonymously, unknown tokens are rejected.
+func requestContext(ctx context.Context, tokens map[string]string) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	requestID := firstValue(md, requestIDKey)
+	if requestID == "" {
+		requestID = util.NewRequestID()
+	}
+	grpc.SetHeader(ctx, metadata.Pairs(requestIDKey, requestID))
+	ctx = util.WithRequestID(ctx, requestID)
+
+	if auth := firstValue(md, "authorization"); auth != "" {
+		actor, ok := tokens[strings.TrimPrefix(auth, "Bearer ")]
+		if !ok || !strings.HasPrefix(auth, "Bearer ") {
+			return nil, status.Error(codes.Unauthenticated, "Unauthorized")
+		}
+		ctx = util.WithActor(ctx, actor)
+	}
+	return ctx, nil
+}
+
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// contextStream replaces the context of a stream with the one enriched by the interceptor
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
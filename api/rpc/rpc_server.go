@@ -0,0 +1,29 @@
+package rpc
+
+import (
+	"github.com/Hunterlemming/golang-microservice-example/api/event"
+	"github.com/Hunterlemming/golang-microservice-example/api/movie"
+	"github.com/Hunterlemming/golang-microservice-example/api/rpc/moviepb"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+// NewServer returns a gRPC server offering the MovieService, along with the health-check and reflection services.
+// Calls authenticate with the same bearer tokens as the REST API, passed as "authorization" metadata.
+func NewServer(s movie.MovieService, b *event.Broker, tokens map[string]string) *grpc.Server {
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(unaryRequestContext(tokens)),
+		grpc.StreamInterceptor(streamRequestContext(tokens)),
+	)
+	moviepb.RegisterMovieServiceServer(server, NewMovieServer(s, b))
+
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus(moviepb.MovieService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(server, healthServer)
+
+	reflection.Register(server)
+	return server
+}
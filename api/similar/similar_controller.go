@@ -0,0 +1,66 @@
+package similar
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	defaultLimit = 10
+	maxLimit     = 50
+)
+
+type controller struct {
+	service SimilarService
+}
+
+type SimilarController interface {
+	GetSimilarMovies(w http.ResponseWriter, r *http.Request)
+}
+
+func NewSimilarController(s SimilarService) SimilarController {
+	return &controller{
+		service: s,
+	}
+}
+
+func (c *controller) GetSimilarMovies(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to GetSimilarMovies", r.Method))
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 0)
+	if err != nil {
+		util.HandleBadRequest(w, "Invalid ID", err.Error())
+		return
+	}
+
+	limit := int64(defaultLimit)
+	if l := r.URL.Query().Get("limit"); l != "" {
+		limit, err = strconv.ParseInt(l, 10, 0)
+		if err != nil || limit < 1 || limit > maxLimit {
+			util.HandleBadRequest(w, "Invalid limit", fmt.Sprintf("limit [%s] out of range", l))
+			return
+		}
+	}
+
+	enc, err := util.NewResponseEncoder(r, true)
+	if err != nil {
+		util.HandleNotAcceptable(w, err)
+		return
+	}
+
+	movies, err := c.service.GetSimilarMovies(int(id), int(limit))
+	if err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	util.WriteResponse(w, enc, http.StatusOK, movies)
+}
@@ -0,0 +1,92 @@
+package similar_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/similar"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Defining the mock SimilarService
+type mockServiceStruct struct {
+	mock.Mock
+}
+
+func (s *mockServiceStruct) GetSimilarMovies(id, limit int) ([]model.SimilarMovie, error) {
+	args := s.Called(id, limit)
+	return args.Get(0).([]model.SimilarMovie), args.Error(1)
+}
+
+func (s *mockServiceStruct) Rebuild() (int, error) {
+	args := s.Called()
+	return args.Int(0), args.Error(1)
+}
+
+// Setting up mockService and controller
+var mockService = new(mockServiceStruct)
+var controller = similar.NewSimilarController(mockService)
+
+func TestControllerGetSimilarMovies(t *testing.T) {
+	movies := []model.SimilarMovie{{ID: 2, Name: "Heist Again", Score: 0.8}}
+	mockService.On("GetSimilarMovies", 1, 10).Return(movies, nil).Once()
+
+	req, _ := http.NewRequest("GET", "/movies/1/similar", nil)
+	rr := execute("/movies/{id}/similar", []string{"GET"}, req, controller.GetSimilarMovies)
+
+	status := http.StatusOK
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+	assert.Equal(t, jsonString(movies), rr.Body.String())
+}
+
+func TestControllerGetSimilarMoviesLimit(t *testing.T) {
+	mockService.On("GetSimilarMovies", 1, 3).Return([]model.SimilarMovie{}, nil).Once()
+
+	req, _ := http.NewRequest("GET", "/movies/1/similar?limit=3", nil)
+	rr := execute("/movies/{id}/similar", []string{"GET"}, req, controller.GetSimilarMovies)
+
+	status := http.StatusOK
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+	assert.Equal(t, "[]", rr.Body.String())
+}
+
+func TestControllerGetSimilarMoviesParsingError(t *testing.T) {
+	for _, path := range []string{"/movies/x/similar", "/movies/1/similar?limit=0", "/movies/1/similar?limit=51"} {
+		req, _ := http.NewRequest("GET", path, nil)
+		rr := execute("/movies/{id}/similar", []string{"GET"}, req, controller.GetSimilarMovies)
+
+		status := http.StatusBadRequest
+		assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d] for %s", status, path))
+	}
+}
+
+func TestControllerGetSimilarMoviesNotFoundError(t *testing.T) {
+	mockService.On("GetSimilarMovies", 9, 10).Return([]model.SimilarMovie{}, &util.NotExistingRecordError{Identification: "ID: 9"}).Once()
+
+	req, _ := http.NewRequest("GET", "/movies/9/similar", nil)
+	rr := execute("/movies/{id}/similar", []string{"GET"}, req, controller.GetSimilarMovies)
+
+	status := http.StatusNotFound
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func execute(route string, methods []string, req *http.Request, handler func(w http.ResponseWriter, r *http.Request)) *httptest.ResponseRecorder {
+	r := mux.NewRouter()
+	r.HandleFunc(route, handler).Methods(methods...)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	return rr
+}
+
+func jsonString(obj interface{}) string {
+	res, _ := json.Marshal(obj)
+	return string(res)
+}
@@ -0,0 +1,134 @@
+package similar
+
+import (
+	"math"
+	"sort"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+)
+
+// The words of the names and synopses weigh less than the genres, tags and people,
+// which are picked deliberately rather than written
+const wordWeight = 0.5
+
+// Words too common or too short to tell the movies apart
+var stopWords = map[string]bool{
+	"and": true, "are": true, "but": true, "for": true, "from": true, "has": true, "her": true, "his": true,
+	"into": true, "its": true, "not": true, "one": true, "out": true, "that": true, "the": true, "their": true,
+	"them": true, "they": true, "this": true, "was": true, "when": true, "who": true, "with": true,
+}
+
+// Document holds the features of a movie its similarity is computed over
+type Document struct {
+	MovieID  int
+	Name     string
+	Synopsis string
+	// The genres, tags and credited people of the movie, such as "genre:drama", "tag:heist" or "person:12"
+	Features []string
+}
+
+type posting struct {
+	movieID int
+	weight  float64
+}
+
+// Index ranks the movies by the cosine similarity of their TF-IDF vectors. It is not changed once built,
+// so it may be read concurrently.
+type Index struct {
+	names    map[int]string
+	vectors  map[int]map[string]float64
+	postings map[string][]posting
+}
+
+// BuildIndex weighs the features of the documents by how rare they are across all of them
+func BuildIndex(docs []Document) *Index {
+	idx := &Index{
+		names:    make(map[int]string, len(docs)),
+		vectors:  make(map[int]map[string]float64, len(docs)),
+		postings: make(map[string][]posting),
+	}
+
+	frequencies := make(map[int]map[string]float64, len(docs))
+	documents := make(map[string]int)
+	for _, d := range docs {
+		tf := terms(d)
+		frequencies[d.MovieID] = tf
+		idx.names[d.MovieID] = d.Name
+		for term := range tf {
+			documents[term]++
+		}
+	}
+
+	n := float64(len(docs))
+	for _, d := range docs {
+		vector := make(map[string]float64)
+		var norm float64
+		for term, tf := range frequencies[d.MovieID] {
+			// Smoothed, so features shared by every movie still count a little
+			idf := math.Log((1+n)/(1+float64(documents[term]))) + 1
+			vector[term] = tf * idf
+			norm += vector[term] * vector[term]
+		}
+		norm = math.Sqrt(norm)
+		for term := range vector {
+			vector[term] /= norm
+			idx.postings[term] = append(idx.postings[term], posting{d.MovieID, vector[term]})
+		}
+		idx.vectors[d.MovieID] = vector
+	}
+	return idx
+}
+
+// Similar returns up to limit movies sharing features with the movie, the most similar first.
+// Movies missing from the index have none.
+func (idx *Index) Similar(id, limit int) []model.SimilarMovie {
+	scores := make(map[int]float64)
+	for term, weight := range idx.vectors[id] {
+		for _, p := range idx.postings[term] {
+			if p.movieID != id {
+				scores[p.movieID] += weight * p.weight
+			}
+		}
+	}
+
+	result := make([]model.SimilarMovie, 0, len(scores))
+	for movieID, score := range scores {
+		result = append(result, model.SimilarMovie{ID: movieID, Name: idx.names[movieID], Score: math.Round(score*1e4) / 1e4})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Score != result[j].Score {
+			return result[i].Score > result[j].Score
+		}
+		return result[i].ID < result[j].ID
+	})
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result
+}
+
+// Len returns the number of movies in the index
+func (idx *Index) Len() int {
+	return len(idx.vectors)
+}
+
+// terms returns the term frequencies of the features and the words of the document
+func terms(d Document) map[string]float64 {
+	tf := make(map[string]float64)
+	for _, f := range d.Features {
+		tf[f] = 1
+	}
+
+	counts := make(map[string]int)
+	for _, w := range util.Words(d.Name + " " + d.Synopsis) {
+		if len([]rune(w)) > 2 && !stopWords[w] {
+			counts[w]++
+		}
+	}
+	// Repeating a word tells less than using it at all
+	for w, count := range counts {
+		tf["word:"+w] = wordWeight * (1 + math.Log(float64(count)))
+	}
+	return tf
+}
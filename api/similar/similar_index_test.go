@@ -0,0 +1,59 @@
+package similar_test
+
+import (
+	"testing"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/similar"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var catalog = []similar.Document{
+	{MovieID: 1, Name: "The Heist", Synopsis: "A crew plans a bank heist.", Features: []string{"genre:crime", "tag:heist", "person:1"}},
+	{MovieID: 2, Name: "Heist Again", Synopsis: "The crew returns for another bank job.", Features: []string{"genre:crime", "tag:heist", "person:1"}},
+	{MovieID: 3, Name: "Quiet Fields", Synopsis: "A farmer tends to the fields.", Features: []string{"genre:drama"}},
+	{MovieID: 4, Name: "Crime Scene", Features: []string{"genre:crime"}},
+}
+
+func TestIndexSimilar(t *testing.T) {
+	idx := similar.BuildIndex(catalog)
+
+	res := idx.Similar(1, 10)
+
+	assert.Equal(t, []int{2, 4}, ids(res), "Movies sharing nothing should be left out, the closest should come first")
+	assert.Equal(t, "Heist Again", res[0].Name)
+	assert.Greater(t, res[0].Score, res[1].Score)
+	assert.LessOrEqual(t, res[0].Score, 1.0)
+}
+
+func TestIndexSimilarLimit(t *testing.T) {
+	idx := similar.BuildIndex(catalog)
+
+	assert.Equal(t, []int{2}, ids(idx.Similar(1, 1)))
+}
+
+func TestIndexSimilarWords(t *testing.T) {
+	idx := similar.BuildIndex([]similar.Document{
+		{MovieID: 1, Name: "Storm", Synopsis: "Sailors caught in a storm at sea."},
+		{MovieID: 2, Name: "Deep Sea", Synopsis: "Sailors explore the sea."},
+		{MovieID: 3, Name: "Desert", Synopsis: "The sun and the sand."},
+	})
+
+	assert.Equal(t, []int{2}, ids(idx.Similar(1, 10)), "Stop words like 'the' should not make movies similar")
+}
+
+func TestIndexSimilarMissingMovie(t *testing.T) {
+	idx := similar.BuildIndex(catalog)
+
+	assert.Equal(t, []model.SimilarMovie{}, idx.Similar(9, 10))
+	assert.Equal(t, 4, idx.Len())
+}
+
+func ids(movies []model.SimilarMovie) []int {
+	res := make([]int, len(movies))
+	for i, m := range movies {
+		res[i] = m.ID
+	}
+	return res
+}
@@ -0,0 +1,74 @@
+package similar
+
+import (
+	"context"
+	"log"
+	"math"
+	"time"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/event"
+)
+
+// How many events the rebuilder may fall behind by before it is dropped by the broker
+const subscriberBuffer = 100
+
+// Rebuilder keeps the index of the service in step with the catalog, off the request path.
+// The changes of the movies are announced by their events, a burst of which is rebuilt once after the delay.
+// The genres, tags and credits are not announced, the index catches up on them every interval.
+type Rebuilder struct {
+	service  SimilarService
+	broker   *event.Broker
+	delay    time.Duration
+	interval time.Duration
+}
+
+func NewRebuilder(s SimilarService, b *event.Broker, delay, interval time.Duration) *Rebuilder {
+	return &Rebuilder{
+		service:  s,
+		broker:   b,
+		delay:    delay,
+		interval: interval,
+	}
+}
+
+// Run builds the index, then rebuilds it on the changes of the catalog until the context is cancelled
+func (r *Rebuilder) Run(ctx context.Context) {
+	// Subscribing ahead of the build, so no change slips through in between. The past events are already built in.
+	sub, _ := r.broker.Subscribe(math.MaxInt64, subscriberBuffer)
+	defer func() { r.broker.Unsubscribe(sub) }()
+	r.rebuild()
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	var pending <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case _, ok := <-sub.Events:
+			// Dropped for falling behind, the missed changes are built in by the pending rebuild
+			if !ok {
+				sub, _ = r.broker.Subscribe(math.MaxInt64, subscriberBuffer)
+			}
+			if pending == nil {
+				pending = time.After(r.delay)
+			}
+		case <-pending:
+			pending = nil
+			r.rebuild()
+		case <-ticker.C:
+			r.rebuild()
+		}
+	}
+}
+
+func (r *Rebuilder) rebuild() {
+	started := time.Now()
+	n, err := r.service.Rebuild()
+	if err != nil {
+		log.Println("[Similar Rebuilder Error] ", err.Error())
+		return
+	}
+	log.Printf("[Similar Rebuilder] indexed %d movies in %s", n, time.Since(started))
+}
@@ -0,0 +1,66 @@
+package similar_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/event"
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/similar"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// rebuildCounter signals every rebuild of the index
+type rebuildCounter struct {
+	rebuilds chan struct{}
+}
+
+func (s *rebuildCounter) GetSimilarMovies(id, limit int) ([]model.SimilarMovie, error) {
+	return []model.SimilarMovie{}, nil
+}
+
+func (s *rebuildCounter) Rebuild() (int, error) {
+	s.rebuilds <- struct{}{}
+	return 0, nil
+}
+
+func TestRebuilderRebuildsBurstOnce(t *testing.T) {
+	s := &rebuildCounter{rebuilds: make(chan struct{}, 10)}
+	b := event.NewBroker(10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go similar.NewRebuilder(s, b, 20*time.Millisecond, time.Hour).Run(ctx)
+	waitForRebuild(t, s)
+
+	b.Publish(ctx, model.Event{ID: 1, Type: event.TypeMovieCreated, MovieID: 1})
+	b.Publish(ctx, model.Event{ID: 2, Type: event.TypeMovieUpdated, MovieID: 1})
+	waitForRebuild(t, s)
+
+	select {
+	case <-s.rebuilds:
+		t.Error("A burst of changes should be rebuilt once")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestRebuilderRebuildsEveryInterval(t *testing.T) {
+	s := &rebuildCounter{rebuilds: make(chan struct{}, 10)}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go similar.NewRebuilder(s, event.NewBroker(10), time.Hour, 10*time.Millisecond).Run(ctx)
+
+	waitForRebuild(t, s)
+	waitForRebuild(t, s)
+}
+
+func waitForRebuild(t *testing.T, s *rebuildCounter) {
+	select {
+	case <-s.rebuilds:
+	case <-time.After(time.Second):
+		assert.Fail(t, "The index should have been rebuilt")
+	}
+}
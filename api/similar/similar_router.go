@@ -0,0 +1,18 @@
+package similar
+
+import (
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+
+	"github.com/gorilla/mux"
+)
+
+// InitializeSimilarPipeline serves the similar movies of the service, whose index is kept up to date by a Rebuilder
+func InitializeSimilarPipeline(api *model.Api, s SimilarService) {
+	c := NewSimilarController(s)
+	setRouting(api.Router, c)
+}
+
+func setRouting(main *mux.Router, c SimilarController) {
+	main.HandleFunc("/movies/{id}/similar", c.GetSimilarMovies).
+		Methods("GET")
+}
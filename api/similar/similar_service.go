@@ -0,0 +1,130 @@
+package similar
+
+import (
+	"database/sql"
+	"sync"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/movie"
+)
+
+type service struct {
+	db     *sql.DB
+	movies movie.MovieService
+
+	mu    sync.RWMutex
+	index *Index
+
+	// build runs the rebuilds one at a time, so an index never replaces one read from a newer snapshot
+	build sync.Mutex
+}
+
+// SimilarService ranks the movies by an in-memory index of the catalog, which is only as recent as its last rebuild
+type SimilarService interface {
+	GetSimilarMovies(id, limit int) ([]model.SimilarMovie, error)
+	Rebuild() (int, error)
+}
+
+func NewSimilarService(db *sql.DB) SimilarService {
+	return &service{
+		db:     db,
+		movies: movie.NewMovieService(db),
+	}
+}
+
+// GetSimilarMovies returns up to limit movies similar to the movie. Movies created since the last rebuild have none.
+func (s *service) GetSimilarMovies(id, limit int) ([]model.SimilarMovie, error) {
+	if err := movie.Exists(s.db, id); err != nil {
+		return []model.SimilarMovie{}, err
+	}
+
+	idx, err := s.current()
+	if err != nil {
+		return []model.SimilarMovie{}, err
+	}
+	return idx.Similar(id, limit), nil
+}
+
+// current returns the index. Requests arriving ahead of the first rebuild build it once, the rest wait for that build.
+func (s *service) current() (*Index, error) {
+	s.mu.RLock()
+	idx := s.index
+	s.mu.RUnlock()
+	if idx != nil {
+		return idx, nil
+	}
+
+	s.build.Lock()
+	defer s.build.Unlock()
+	s.mu.RLock()
+	idx = s.index
+	s.mu.RUnlock()
+	if idx != nil {
+		return idx, nil
+	}
+	return s.rebuild()
+}
+
+// Rebuild reads the catalog into a new index, which replaces the current one, and returns the number of movies indexed
+func (s *service) Rebuild() (int, error) {
+	s.build.Lock()
+	defer s.build.Unlock()
+	idx, err := s.rebuild()
+	if err != nil {
+		return 0, err
+	}
+	return idx.Len(), nil
+}
+
+// rebuild builds and swaps in a new index, the caller holding the build lock
+func (s *service) rebuild() (*Index, error) {
+	docs, err := s.documents()
+	if err != nil {
+		return nil, err
+	}
+
+	idx := BuildIndex(docs)
+	s.mu.Lock()
+	s.index = idx
+	s.mu.Unlock()
+	return idx, nil
+}
+
+// documents reads the features of the movies which are not in the trash
+func (s *service) documents() ([]Document, error) {
+	movies, err := s.movies.GetMovies()
+	if err != nil {
+		return nil, err
+	}
+
+	docs := make([]Document, len(movies))
+	byID := make(map[int]*Document, len(movies))
+	for i, m := range movies {
+		docs[i] = Document{MovieID: m.ID, Name: m.Name, Synopsis: m.Synopsis}
+		byID[m.ID] = &docs[i]
+	}
+
+	// People credited in several roles count once
+	const q = "SELECT mg.movie_id, 'genre:' || g.slug FROM movie_genres mg JOIN genres g ON g.id = mg.genre_id " +
+		"UNION ALL SELECT mt.movie_id, 'tag:' || t.slug FROM movie_tags mt JOIN tags t ON t.id = mt.tag_id " +
+		"UNION ALL SELECT DISTINCT movie_id, 'person:' || person_id FROM movie_credits"
+	qr, err := s.db.Query(q)
+	if err != nil {
+		return nil, err
+	}
+	defer qr.Close()
+
+	for qr.Next() {
+		var movieID int
+		var feature string
+		if err := qr.Scan(&movieID, &feature); err != nil {
+			return nil, err
+		}
+		// The features of the movies in the trash are left out along with their movies
+		if d, ok := byID[movieID]; ok {
+			d.Features = append(d.Features, feature)
+		}
+	}
+
+	return docs, qr.Err()
+}
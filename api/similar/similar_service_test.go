@@ -0,0 +1,123 @@
+package similar_test
+
+import (
+	"database/sql"
+	"sync"
+	"testing"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/similar"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	CheckMovieQuery = `^SELECT id FROM movies WHERE id = \$1 AND deleted_at IS NULL$`
+	GetMoviesQuery  = `^SELECT id, name, synopsis, version, rating_average, rating_count FROM movies WHERE deleted_at IS NULL$`
+	FeaturesQuery   = `^SELECT mg.movie_id, 'genre:' \|\| g.slug FROM .+ UNION ALL SELECT mt.movie_id, 'tag:' \|\| t.slug FROM .+ UNION ALL SELECT DISTINCT movie_id, 'person:' \|\| person_id FROM movie_credits$`
+)
+
+func TestServiceGetSimilarMovies(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectQuery(CheckMovieQuery).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	expectRebuild(mock)
+
+	res, err := service.GetSimilarMovies(1, 10)
+
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []int{2}, ids(res), "The movie in the trash should not be indexed")
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceGetSimilarMoviesBuiltIndex(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	expectRebuild(mock)
+	n, err := service.Rebuild()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 3, n)
+
+	mock.ExpectQuery(CheckMovieQuery).WithArgs(2).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+
+	res, err := service.GetSimilarMovies(2, 10)
+
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []int{1}, ids(res))
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceGetSimilarMoviesBuildsOnce(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.MatchExpectationsInOrder(false)
+	const requests = 5
+	for i := 0; i < requests; i++ {
+		mock.ExpectQuery(CheckMovieQuery).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	}
+	expectRebuild(mock)
+
+	var wg sync.WaitGroup
+	errs := make([]error, requests)
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = service.GetSimilarMovies(1, 10)
+		}(i)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		assert.Equal(t, nil, err, "The requests ahead of the first rebuild should share a single build")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceGetSimilarMoviesTrashedMovieError(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectQuery(CheckMovieQuery).WithArgs(4).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	res, err := service.GetSimilarMovies(4, 10)
+
+	assert.Equal(t, []model.SimilarMovie{}, res)
+	assert.IsType(t, &util.NotExistingRecordError{}, err)
+}
+
+func initNewService(t *testing.T) (similar.SimilarService, sqlmock.Sqlmock, *sql.DB) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	service := similar.NewSimilarService(db)
+	return service, mock, db
+}
+
+// expectRebuild reads three movies, the features of a fourth one in the trash are read as well
+func expectRebuild(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery(GetMoviesQuery).WillReturnRows(
+		sqlmock.NewRows([]string{"id", "name", "synopsis", "version", "rating_average", "rating_count"}).
+			AddRow(1, "The Heist", "A crew plans a bank heist.", 1, 0, 0).
+			AddRow(2, "Heist Again", "", 1, 0, 0).
+			AddRow(3, "Quiet Fields", "A farmer tends to the fields.", 1, 0, 0))
+	mock.ExpectQuery(FeaturesQuery).WillReturnRows(
+		sqlmock.NewRows([]string{"movie_id", "feature"}).
+			AddRow(1, "genre:crime").
+			AddRow(2, "genre:crime").
+			AddRow(4, "genre:crime").
+			AddRow(1, "person:1"))
+}
@@ -0,0 +1,139 @@
+package stream
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/event"
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+)
+
+const (
+	// How many events may queue up for a slow client before it is disconnected
+	subscriberBuffer = 64
+	// Comment lines sent while idle, so proxies do not close the connection
+	keepAliveInterval = 15 * time.Second
+)
+
+type controller struct {
+	broker *event.Broker
+}
+
+type StreamController interface {
+	StreamMovieEvents(w http.ResponseWriter, r *http.Request)
+}
+
+func NewStreamController(b *event.Broker) StreamController {
+	return &controller{
+		broker: b,
+	}
+}
+
+type filter struct {
+	movieID int
+	types   map[string]bool
+}
+
+func (f filter) matches(e model.Event) bool {
+	if f.movieID != 0 && e.MovieID != f.movieID {
+		return false
+	}
+	return len(f.types) == 0 || f.types[e.Type]
+}
+
+// StreamMovieEvents streams the movie changes as Server-Sent Events. Clients reconnecting with
+// a Last-Event-ID header first receive the events they missed, as far as they are still buffered.
+func (c *controller) StreamMovieEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to StreamMovieEvents", r.Method))
+		return
+	}
+
+	f, err := parseFilter(r)
+	if err != nil {
+		util.HandleBadRequest(w, "Invalid filter", err.Error())
+		return
+	}
+
+	// Clients connecting for the first time receive the events from now on
+	lastEventID := int64(math.MaxInt64)
+	if id := r.Header.Get("Last-Event-ID"); id != "" {
+		lastEventID, err = strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			util.HandleBadRequest(w, "Invalid Last-Event-ID", err.Error())
+			return
+		}
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		util.HandleServerError(w, fmt.Errorf("response writer does not support flushing"), "Streaming unsupported")
+		return
+	}
+
+	sub, replay := c.broker.Subscribe(lastEventID, subscriberBuffer)
+	defer c.broker.Unsubscribe(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	for _, e := range replay {
+		if f.matches(e) {
+			writeEvent(w, e)
+		}
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		case e, open := <-sub.Events:
+			if !open {
+				log.Println("[SSE] ", "dropping slow client ", r.RemoteAddr)
+				return
+			}
+			if !f.matches(e) {
+				continue
+			}
+			writeEvent(w, e)
+		}
+		flusher.Flush()
+	}
+}
+
+func writeEvent(w http.ResponseWriter, e model.Event) {
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, e.Payload)
+}
+
+func parseFilter(r *http.Request) (filter, error) {
+	query := r.URL.Query()
+	f := filter{types: make(map[string]bool)}
+
+	if id := query.Get("movie_id"); id != "" {
+		parsed, err := strconv.ParseInt(id, 10, 0)
+		if err != nil {
+			return filter{}, err
+		}
+		f.movieID = int(parsed)
+	}
+	for _, types := range query["type"] {
+		for _, t := range strings.Split(types, ",") {
+			f.types[strings.TrimSpace(t)] = true
+		}
+	}
+
+	return f, nil
+}
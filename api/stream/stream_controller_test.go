@@ -0,0 +1,111 @@
+package stream_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/event"
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/stream"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestControllerStreamMovieEvents(t *testing.T) {
+	broker := event.NewBroker(10)
+	server := newServer(t, broker)
+
+	// The handler subscribes before sending the response headers, so no event can be missed
+	lines := connect(t, server.URL+"/movies/events?type=MovieUpdated", "")
+	broker.Publish(context.Background(), newEvent(1, 1, event.TypeMovieCreated))
+	broker.Publish(context.Background(), newEvent(2, 1, event.TypeMovieUpdated))
+
+	assert.Equal(t, "id: 2", <-lines, "Events of other types should be filtered")
+	assert.Equal(t, "event: MovieUpdated", <-lines)
+	assert.Equal(t, `data: {"id":1,"name":"test"}`, <-lines)
+}
+
+func TestControllerStreamMovieEventsResumes(t *testing.T) {
+	broker := event.NewBroker(10)
+	for id := int64(1); id <= 3; id++ {
+		broker.Publish(context.Background(), newEvent(id, int(id), event.TypeMovieCreated))
+	}
+	server := newServer(t, broker)
+
+	lines := connect(t, server.URL+"/movies/events", "1")
+
+	assert.Equal(t, "id: 2", <-lines, "The missed events should be replayed")
+	assert.Equal(t, "event: MovieCreated", <-lines)
+	<-lines
+	assert.Equal(t, "id: 3", <-lines)
+}
+
+func TestControllerStreamMovieEventsFromNow(t *testing.T) {
+	broker := event.NewBroker(10)
+	broker.Publish(context.Background(), newEvent(1, 1, event.TypeMovieCreated))
+	server := newServer(t, broker)
+
+	lines := connect(t, server.URL+"/movies/events", "")
+	broker.Publish(context.Background(), newEvent(2, 1, event.TypeMovieUpdated))
+
+	assert.Equal(t, "id: 2", <-lines, "The buffered events should not be replayed to new clients")
+}
+
+func TestControllerStreamMovieEventsFilterParsingError(t *testing.T) {
+	server := newServer(t, event.NewBroker(10))
+
+	resp, err := http.Get(server.URL + "/movies/events?movie_id=not-an-int")
+
+	assert.Nil(t, err)
+	resp.Body.Close()
+	status := http.StatusBadRequest
+	assert.Equal(t, status, resp.StatusCode, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+// newServer serves the stream until the test is done, after the streams opened by connect are cancelled
+func newServer(t *testing.T, broker *event.Broker) *httptest.Server {
+	api := model.Api{Router: mux.NewRouter()}
+	stream.InitializeStreamPipeline(&api, broker)
+	server := httptest.NewServer(api.Router)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// connect opens the stream and returns its non-empty lines
+func connect(t *testing.T, url, lastEventID string) <-chan string {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	req, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when connecting to the stream", err)
+	}
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	lines := make(chan string, 100)
+	go func() {
+		defer resp.Body.Close()
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			if line := scanner.Text(); line != "" && !strings.HasPrefix(line, ":") {
+				lines <- line
+			}
+		}
+	}()
+	return lines
+}
+
+func newEvent(id int64, movieID int, eventType string) model.Event {
+	return model.Event{ID: id, Type: eventType, MovieID: movieID, Payload: json.RawMessage(`{"id":1,"name":"test"}`)}
+}
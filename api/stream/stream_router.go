@@ -0,0 +1,19 @@
+package stream
+
+import (
+	"github.com/Hunterlemming/golang-microservice-example/api/event"
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+
+	"github.com/gorilla/mux"
+)
+
+// InitializeStreamPipeline has to run ahead of the movies pipeline, whose "/movies/{id}" would match the stream otherwise
+func InitializeStreamPipeline(api *model.Api, b *event.Broker) {
+	c := NewStreamController(b)
+	setRouting(api.Router, c)
+}
+
+func setRouting(main *mux.Router, c StreamController) {
+	main.HandleFunc("/movies/events", c.StreamMovieEvents).
+		Methods("GET")
+}
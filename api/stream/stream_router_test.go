@@ -0,0 +1,42 @@
+package stream_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/event"
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/movie"
+	"github.com/Hunterlemming/golang-microservice-example/api/stream"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInitializeStreamPipeline(t *testing.T) {
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	api := model.Api{Router: mux.NewRouter(), DB: db}
+	stream.InitializeStreamPipeline(&api, event.NewBroker(10))
+	movie.InitializeMoviesPipeline(&api)
+
+	testIntegrationStreamMovieEvents(t, api.Router)
+}
+
+func testIntegrationStreamMovieEvents(t *testing.T, r *mux.Router) {
+	// A cancelled request ends the stream right after its headers were written
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", "/movies/events", nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "text/event-stream", rr.Header().Get("Content-Type"), "The stream should not be shadowed by \"/movies/{id}\"")
+}
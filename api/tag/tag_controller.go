@@ -0,0 +1,252 @@
+package tag
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+
+	"github.com/gorilla/mux"
+)
+
+type controller struct {
+	service TagService
+}
+
+type TagController interface {
+	GetTags(w http.ResponseWriter, r *http.Request)
+	GetTag(w http.ResponseWriter, r *http.Request)
+	CreateTag(w http.ResponseWriter, r *http.Request)
+	UpdateTag(w http.ResponseWriter, r *http.Request)
+	DeleteTag(w http.ResponseWriter, r *http.Request)
+	GetMovieTags(w http.ResponseWriter, r *http.Request)
+	AddMovieTag(w http.ResponseWriter, r *http.Request)
+	RemoveMovieTag(w http.ResponseWriter, r *http.Request)
+}
+
+func NewTagController(s TagService) TagController {
+	return &controller{
+		service: s,
+	}
+}
+
+func (c *controller) GetTags(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to GetTags", r.Method))
+		return
+	}
+
+	enc, err := util.NewResponseEncoder(r, true)
+	if err != nil {
+		util.HandleNotAcceptable(w, err)
+		return
+	}
+
+	tags, err := c.service.GetTags()
+	if err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	util.WriteResponse(w, enc, http.StatusOK, tags)
+}
+
+func (c *controller) GetTag(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to GetTag", r.Method))
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 0)
+	if err != nil {
+		util.HandleBadRequest(w, "Invalid ID", err.Error())
+		return
+	}
+
+	enc, err := util.NewResponseEncoder(r, false)
+	if err != nil {
+		util.HandleNotAcceptable(w, err)
+		return
+	}
+
+	tag, err := c.service.GetTag(int(id))
+	if err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	util.WriteResponse(w, enc, http.StatusOK, tag)
+}
+
+func (c *controller) CreateTag(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to CreateTag", r.Method))
+		return
+	}
+
+	// Extracting Tag object from request-body
+	t, err := parseValidTag(r)
+	if err != nil {
+		util.HandleInvalidBody(w, err)
+		return
+	}
+
+	enc, err := util.NewResponseEncoder(r, false)
+	if err != nil {
+		util.HandleNotAcceptable(w, err)
+		return
+	}
+
+	if err := c.service.CreateTag(r.Context(), t); err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	util.WriteResponse(w, enc, http.StatusCreated, t)
+}
+
+func (c *controller) UpdateTag(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to UpdateTag", r.Method))
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 0)
+	if err != nil {
+		util.HandleBadRequest(w, "Invalid ID", err.Error())
+		return
+	}
+
+	// Extracting Tag object from request-body
+	t, err := parseValidTag(r)
+	if err != nil {
+		util.HandleInvalidBody(w, err)
+		return
+	}
+
+	if err := c.service.UpdateTag(r.Context(), int(id), t); err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	fmt.Fprintln(w, "success")
+}
+
+func (c *controller) DeleteTag(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to DeleteTag", r.Method))
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 0)
+	if err != nil {
+		util.HandleBadRequest(w, "Invalid ID", err.Error())
+		return
+	}
+
+	if err := c.service.DeleteTag(r.Context(), int(id)); err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *controller) GetMovieTags(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to GetMovieTags", r.Method))
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 0)
+	if err != nil {
+		util.HandleBadRequest(w, "Invalid ID", err.Error())
+		return
+	}
+
+	enc, err := util.NewResponseEncoder(r, true)
+	if err != nil {
+		util.HandleNotAcceptable(w, err)
+		return
+	}
+
+	tags, err := c.service.GetMovieTags(int(id))
+	if err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	util.WriteResponse(w, enc, http.StatusOK, tags)
+}
+
+// AddMovieTag links the tag to the movie, linking it again succeeds as well
+func (c *controller) AddMovieTag(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to AddMovieTag", r.Method))
+		return
+	}
+
+	id, tagID, err := parseLink(r)
+	if err != nil {
+		util.HandleBadRequest(w, "Invalid ID", err.Error())
+		return
+	}
+
+	if err := c.service.AddMovieTag(r.Context(), id, tagID); err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *controller) RemoveMovieTag(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to RemoveMovieTag", r.Method))
+		return
+	}
+
+	id, tagID, err := parseLink(r)
+	if err != nil {
+		util.HandleBadRequest(w, "Invalid ID", err.Error())
+		return
+	}
+
+	if err := c.service.RemoveMovieTag(r.Context(), id, tagID); err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// parseLink returns the IDs of the movie and the tag in the path
+func parseLink(r *http.Request) (int, int, error) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 0)
+	if err != nil {
+		return 0, 0, err
+	}
+	tagID, err := strconv.ParseInt(mux.Vars(r)["tagId"], 10, 0)
+	if err != nil {
+		return 0, 0, err
+	}
+	return int(id), int(tagID), nil
+}
+
+func parseValidTag(r *http.Request) (*model.Tag, error) {
+	var t model.Tag
+
+	// Return if the request-body cannot be decoded into a Tag object
+	if err := util.DecodeRequest(r, &t); err != nil {
+		return nil, err
+	}
+
+	// Return if the requested Tag object is invalid
+	if err := t.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid tag-object: %w", err)
+	}
+
+	return &t, nil
+}
@@ -0,0 +1,207 @@
+package tag_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/tag"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Defining the mock TagService
+type mockServiceStruct struct {
+	mock.Mock
+}
+
+func (s *mockServiceStruct) GetTags() ([]model.Tag, error) {
+	args := s.Called()
+	return args.Get(0).([]model.Tag), args.Error(1)
+}
+
+func (s *mockServiceStruct) GetTag(id int) (model.Tag, error) {
+	args := s.Called(id)
+	return args.Get(0).(model.Tag), args.Error(1)
+}
+
+func (s *mockServiceStruct) CreateTag(ctx context.Context, g *model.Tag) error {
+	args := s.Called(g)
+	return args.Error(0)
+}
+
+func (s *mockServiceStruct) UpdateTag(ctx context.Context, id int, g *model.Tag) error {
+	args := s.Called(id, g)
+	return args.Error(0)
+}
+
+func (s *mockServiceStruct) DeleteTag(ctx context.Context, id int) error {
+	args := s.Called(id)
+	return args.Error(0)
+}
+
+func (s *mockServiceStruct) GetMovieTags(movieID int) ([]model.Tag, error) {
+	args := s.Called(movieID)
+	return args.Get(0).([]model.Tag), args.Error(1)
+}
+
+func (s *mockServiceStruct) AddMovieTag(ctx context.Context, movieID, tagID int) error {
+	args := s.Called(movieID, tagID)
+	return args.Error(0)
+}
+
+func (s *mockServiceStruct) RemoveMovieTag(ctx context.Context, movieID, tagID int) error {
+	args := s.Called(movieID, tagID)
+	return args.Error(0)
+}
+
+// Setting up mockService and controller
+var mockService = new(mockServiceStruct)
+var controller = tag.NewTagController(mockService)
+
+func TestControllerGetTags(t *testing.T) {
+	tags := []model.Tag{{ID: 1, Slug: "award-winner"}}
+	mockService.On("GetTags").Return(tags, nil).Once()
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	rr := execute("/", []string{"GET"}, req, controller.GetTags)
+
+	status := http.StatusOK
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+	assert.Equal(t, jsonString(tags), rr.Body.String())
+}
+
+func TestControllerGetTagNotFoundError(t *testing.T) {
+	mockService.On("GetTag", 2).Return(model.Tag{}, &util.NotExistingRecordError{Identification: "ID: 2"}).Once()
+
+	req, _ := http.NewRequest("GET", "/2", nil)
+	rr := execute("/{id}", []string{"GET"}, req, controller.GetTag)
+
+	status := http.StatusNotFound
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerCreateTag(t *testing.T) {
+	g := model.Tag{Slug: "award-winner"}
+	gBytes, _ := json.Marshal(g)
+	mockService.On("CreateTag", &g).Return(nil).Run(func(args mock.Arguments) {
+		args.Get(0).(*model.Tag).ID = 2
+	}).Once()
+
+	req, _ := http.NewRequest("POST", "/", bytes.NewBuffer(gBytes))
+	rr := execute("/", []string{"POST"}, req, controller.CreateTag)
+
+	status := http.StatusCreated
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+	assert.Equal(t, jsonString(model.Tag{ID: 2, Slug: "award-winner"}), rr.Body.String())
+}
+
+func TestControllerCreateTagBodyParsingError(t *testing.T) {
+	for _, body := range []string{
+		`{}`,
+		`{"slug": "Award Winner"}`,
+		`{"slug": "award--winner"}`,
+	} {
+		req, _ := http.NewRequest("POST", "/", bytes.NewBufferString(body))
+		rr := execute("/", []string{"POST"}, req, controller.CreateTag)
+
+		status := http.StatusBadRequest
+		assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d] for %s", status, body))
+	}
+}
+
+func TestControllerCreateTagExistingError(t *testing.T) {
+	g := model.Tag{Slug: "award-winner"}
+	gBytes, _ := json.Marshal(g)
+	mockService.On("CreateTag", &g).Return(&util.ExistingRecordError{Identification: "slug: award-winner"}).Once()
+
+	req, _ := http.NewRequest("POST", "/", bytes.NewBuffer(gBytes))
+	rr := execute("/", []string{"POST"}, req, controller.CreateTag)
+
+	status := http.StatusConflict
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerUpdateTag(t *testing.T) {
+	g := model.Tag{Slug: "award-winner"}
+	gBytes, _ := json.Marshal(g)
+	mockService.On("UpdateTag", 2, &g).Return(nil).Once()
+
+	req, _ := http.NewRequest("PUT", "/2", bytes.NewBuffer(gBytes))
+	rr := execute("/{id}", []string{"PUT"}, req, controller.UpdateTag)
+
+	status := http.StatusOK
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerDeleteTag(t *testing.T) {
+	mockService.On("DeleteTag", 2).Return(nil).Once()
+
+	req, _ := http.NewRequest("DELETE", "/2", nil)
+	rr := execute("/{id}", []string{"DELETE"}, req, controller.DeleteTag)
+
+	status := http.StatusNoContent
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerGetMovieTagsNotFoundError(t *testing.T) {
+	mockService.On("GetMovieTags", 7).Return([]model.Tag{}, &util.NotExistingRecordError{Identification: "ID: 7"}).Once()
+
+	req, _ := http.NewRequest("GET", "/movies/7/tags", nil)
+	rr := execute("/movies/{id}/tags", []string{"GET"}, req, controller.GetMovieTags)
+
+	status := http.StatusNotFound
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerAddMovieTag(t *testing.T) {
+	mockService.On("AddMovieTag", 7, 2).Return(nil).Once()
+
+	req, _ := http.NewRequest("PUT", "/movies/7/tags/2", nil)
+	rr := execute("/movies/{id}/tags/{tagId}", []string{"PUT"}, req, controller.AddMovieTag)
+
+	if !mockService.AssertCalled(t, "AddMovieTag", 7, 2) {
+		t.Error("The service should be called with the IDs of the path")
+	}
+	status := http.StatusNoContent
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerAddMovieTagInvalidIDError(t *testing.T) {
+	req, _ := http.NewRequest("PUT", "/movies/7/tags/award-winner", nil)
+	rr := execute("/movies/{id}/tags/{tagId}", []string{"PUT"}, req, controller.AddMovieTag)
+
+	status := http.StatusBadRequest
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerRemoveMovieTagNotFoundError(t *testing.T) {
+	mockService.On("RemoveMovieTag", 7, 2).Return(&util.NotExistingRecordError{Identification: "movie ID: 7, tag ID: 2"}).Once()
+
+	req, _ := http.NewRequest("DELETE", "/movies/7/tags/2", nil)
+	rr := execute("/movies/{id}/tags/{tagId}", []string{"DELETE"}, req, controller.RemoveMovieTag)
+
+	status := http.StatusNotFound
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func execute(route string, methods []string, req *http.Request, handler func(w http.ResponseWriter, r *http.Request)) *httptest.ResponseRecorder {
+	r := mux.NewRouter()
+	r.HandleFunc(route, handler).Methods(methods...)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	return rr
+}
+
+func jsonString(obj interface{}) string {
+	res, _ := json.Marshal(obj)
+	return string(res)
+}
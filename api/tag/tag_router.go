@@ -0,0 +1,44 @@
+package tag
+
+import (
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+
+	"github.com/gorilla/mux"
+)
+
+func InitializeTagsPipeline(api *model.Api) {
+	s := NewTagService(api.DB)
+	c := NewTagController(s)
+	setRouting(api.Router, c)
+}
+
+func setRouting(main *mux.Router, c TagController) {
+	sr := main.PathPrefix("/tags").Subrouter()
+
+	sr.HandleFunc("", c.GetTags).
+		Methods("GET")
+
+	sr.HandleFunc("/{id}", c.GetTag).
+		Methods("GET")
+
+	sr.HandleFunc("", c.CreateTag).
+		Methods("POST")
+
+	sr.HandleFunc("/{id}", c.UpdateTag).
+		Methods("PUT")
+
+	sr.HandleFunc("/{id}", c.DeleteTag).
+		Methods("DELETE")
+
+	// The tags of a movie are listed and linked under the movie
+	mr := main.PathPrefix("/movies/{id}/tags").Subrouter()
+
+	mr.HandleFunc("", c.GetMovieTags).
+		Methods("GET")
+
+	mr.HandleFunc("/{tagId}", c.AddMovieTag).
+		Methods("PUT")
+
+	mr.HandleFunc("/{tagId}", c.RemoveMovieTag).
+		Methods("DELETE")
+}
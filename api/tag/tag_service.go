@@ -0,0 +1,192 @@
+package tag
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/audit"
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/movie"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+)
+
+type service struct {
+	db *sql.DB
+}
+
+type TagService interface {
+	GetTags() ([]model.Tag, error)
+	GetTag(id int) (model.Tag, error)
+	CreateTag(ctx context.Context, t *model.Tag) error
+	UpdateTag(ctx context.Context, id int, t *model.Tag) error
+	DeleteTag(ctx context.Context, id int) error
+	GetMovieTags(movieID int) ([]model.Tag, error)
+	AddMovieTag(ctx context.Context, movieID, tagID int) error
+	RemoveMovieTag(ctx context.Context, movieID, tagID int) error
+}
+
+func NewTagService(db *sql.DB) TagService {
+	return &service{
+		db: db,
+	}
+}
+
+func (s *service) GetTags() ([]model.Tag, error) {
+	const q = "SELECT id, slug FROM tags ORDER BY id"
+	qr, err := s.db.Query(q)
+	if err != nil {
+		return []model.Tag{}, err
+	}
+	defer qr.Close()
+
+	result := make([]model.Tag, 0)
+	for qr.Next() {
+		t := model.Tag{}
+		err = qr.Scan(&t.ID, &t.Slug)
+		if err != nil {
+			return []model.Tag{}, err
+		}
+		result = append(result, t)
+	}
+
+	return result, nil
+}
+
+func (s *service) GetTag(id int) (model.Tag, error) {
+	return getTag(s.db, id)
+}
+
+// CreateTag inserts the tag and sets its generated ID, slugs are unique
+func (s *service) CreateTag(ctx context.Context, t *model.Tag) error {
+	return util.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		const q = "INSERT INTO tags (slug) VALUES ($1) RETURNING id"
+		err := tx.QueryRowContext(ctx, q, t.Slug).Scan(&t.ID)
+		if util.IsViolation(err, util.UniqueViolation) {
+			return &util.ExistingRecordError{Identification: fmt.Sprintf("slug: %s", t.Slug)}
+		}
+		if err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx, audit.EntityTag, t.ID, audit.ActionCreate, nil, t)
+	})
+}
+
+func (s *service) UpdateTag(ctx context.Context, id int, t *model.Tag) error {
+	before, err := s.GetTag(id)
+	if err != nil {
+		return err
+	}
+
+	return util.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		const q = "UPDATE tags SET slug = $1 WHERE id = $2"
+		res, err := tx.ExecContext(ctx, q, t.Slug, id)
+		if util.IsViolation(err, util.UniqueViolation) {
+			return &util.ExistingRecordError{Identification: fmt.Sprintf("slug: %s", t.Slug)}
+		}
+		if err != nil {
+			return err
+		}
+		if affected, err := res.RowsAffected(); err != nil {
+			return err
+		} else if affected == 0 {
+			return &util.NotExistingRecordError{Identification: fmt.Sprintf("ID: %v", id)}
+		}
+
+		after := model.Tag{ID: id, Slug: t.Slug}
+		return audit.Record(ctx, tx, audit.EntityTag, id, audit.ActionUpdate, before, after)
+	})
+}
+
+// DeleteTag removes the tag along with its links to the movies
+func (s *service) DeleteTag(ctx context.Context, id int) error {
+	return util.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		const q = "DELETE FROM tags WHERE id = $1 RETURNING id, slug"
+		before := model.Tag{}
+		err := tx.QueryRowContext(ctx, q, id).Scan(&before.ID, &before.Slug)
+		if err == sql.ErrNoRows {
+			return &util.NotExistingRecordError{Identification: fmt.Sprintf("ID: %v", id)}
+		}
+		if err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx, audit.EntityTag, id, audit.ActionDelete, before, nil)
+	})
+}
+
+func (s *service) GetMovieTags(movieID int) ([]model.Tag, error) {
+	if err := movie.Exists(s.db, movieID); err != nil {
+		return []model.Tag{}, err
+	}
+
+	const q = `SELECT t.id, t.slug FROM movie_tags mt JOIN tags t ON t.id = mt.tag_id ` +
+		`WHERE mt.movie_id = $1 ORDER BY t.id`
+	qr, err := s.db.Query(q, movieID)
+	if err != nil {
+		return []model.Tag{}, err
+	}
+	defer qr.Close()
+
+	result := make([]model.Tag, 0)
+	for qr.Next() {
+		t := model.Tag{}
+		err = qr.Scan(&t.ID, &t.Slug)
+		if err != nil {
+			return []model.Tag{}, err
+		}
+		result = append(result, t)
+	}
+
+	return result, qr.Err()
+}
+
+// AddMovieTag links the tag to the movie, linking it again changes nothing.
+// Both have to exist, movies in the trash cannot be linked.
+func (s *service) AddMovieTag(ctx context.Context, movieID, tagID int) error {
+	return util.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := movie.Exists(tx, movieID); err != nil {
+			return err
+		}
+		t, err := getTag(tx, tagID)
+		if err != nil {
+			return err
+		}
+
+		const q = "INSERT INTO movie_tags (movie_id, tag_id) VALUES ($1, $2) ON CONFLICT DO NOTHING"
+		res, err := tx.ExecContext(ctx, q, movieID, tagID)
+		if err != nil {
+			return err
+		}
+		// Linking again leaves nothing to record
+		if affected, err := res.RowsAffected(); err != nil {
+			return err
+		} else if affected == 0 {
+			return nil
+		}
+		return audit.Record(ctx, tx, audit.EntityMovieTag, movieID, audit.ActionCreate, nil, t)
+	})
+}
+
+func (s *service) RemoveMovieTag(ctx context.Context, movieID, tagID int) error {
+	return util.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		const q = `DELETE FROM movie_tags mt USING tags t ` +
+			`WHERE t.id = mt.tag_id AND mt.movie_id = $1 AND mt.tag_id = $2 RETURNING t.id, t.slug`
+		before := model.Tag{}
+		err := tx.QueryRowContext(ctx, q, movieID, tagID).Scan(&before.ID, &before.Slug)
+		if err == sql.ErrNoRows {
+			return &util.NotExistingRecordError{Identification: fmt.Sprintf("movie ID: %v, tag ID: %v", movieID, tagID)}
+		}
+		if err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx, audit.EntityMovieTag, movieID, audit.ActionDelete, before, nil)
+	})
+}
+
+// getTag reads the tag from the database or from a transaction
+func getTag(db util.QueryRower, id int) (model.Tag, error) {
+	const q = "SELECT id, slug FROM tags WHERE id = $1"
+	result := model.Tag{}
+	err := util.GetRecord(db, q, id, &result.ID, &result.Slug)
+	return result, err
+}
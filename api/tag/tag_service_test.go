@@ -0,0 +1,201 @@
+package tag_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/tag"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	GetTagsQuery        = `^SELECT id, slug FROM tags ORDER BY id$`
+	GetTagQuery         = `^SELECT id, slug FROM tags WHERE id = \$1$`
+	CreateTagQuery      = `^INSERT INTO tags \(slug\) VALUES \(\$1\) RETURNING id$`
+	UpdateTagQuery      = `^UPDATE tags SET slug = \$1 WHERE id = \$2$`
+	DeleteTagQuery      = `^DELETE FROM tags WHERE id = \$1 RETURNING id, slug$`
+	CheckMovieQuery     = `^SELECT id FROM movies WHERE id = \$1 AND deleted_at IS NULL$`
+	MovieTagsQuery      = `^SELECT t.id, t.slug FROM movie_tags mt JOIN tags t .+ WHERE mt.movie_id = \$1 ORDER BY t.id$`
+	AddMovieTagQuery    = `^INSERT INTO movie_tags \(movie_id, tag_id\) VALUES \(\$1, \$2\) ON CONFLICT DO NOTHING$`
+	RemoveMovieTagQuery = `^DELETE FROM movie_tags mt USING tags t WHERE .+ AND mt.movie_id = \$1 AND mt.tag_id = \$2 RETURNING .+$`
+	AuditQuery          = `^INSERT INTO audit_log \(.+\) VALUES \(.+\)$`
+	uniqueViolation     = "23505"
+)
+
+func TestServiceGetTags(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	tags := []model.Tag{{ID: 1, Slug: "award-winner"}, {ID: 2, Slug: "cult-classic"}}
+	mock.ExpectQuery(GetTagsQuery).WillReturnRows(newRows(&tags))
+
+	res, err := service.GetTags()
+
+	assert.Equal(t, tags, res)
+	assert.Equal(t, nil, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceGetTagNotExistingError(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectQuery(GetTagQuery).WithArgs(3).WillReturnRows(newRows(&[]model.Tag{}))
+
+	_, err := service.GetTag(3)
+
+	assert.IsType(t, &util.NotExistingRecordError{}, err)
+}
+
+func TestServiceCreateTag(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(CreateTagQuery).WithArgs("award-winner").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	mock.ExpectExec(AuditQuery).
+		WithArgs("tag", 2, "create", "tester", "test-request", nil, `{"id":2,"slug":"award-winner"}`, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	tg := &model.Tag{Slug: "award-winner"}
+	err := service.CreateTag(auditContext(), tg)
+
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, tg.ID, "The generated ID should be set")
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceUpdateTagExistingError(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectQuery(GetTagQuery).WithArgs(2).WillReturnRows(newRows(&[]model.Tag{{ID: 2, Slug: "awardwinner"}}))
+	mock.ExpectBegin()
+	mock.ExpectExec(UpdateTagQuery).WithArgs("award-winner", 2).WillReturnError(&pq.Error{Code: uniqueViolation})
+	mock.ExpectRollback()
+
+	err := service.UpdateTag(auditContext(), 2, &model.Tag{Slug: "award-winner"})
+
+	assert.IsType(t, &util.ExistingRecordError{}, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceDeleteTagNotExistingError(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(DeleteTagQuery).WithArgs(2).WillReturnRows(newRows(&[]model.Tag{}))
+	mock.ExpectRollback()
+
+	err := service.DeleteTag(auditContext(), 2)
+
+	assert.IsType(t, &util.NotExistingRecordError{}, err)
+}
+
+func TestServiceAddMovieTag(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(CheckMovieQuery).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectQuery(GetTagQuery).WithArgs(2).WillReturnRows(newRows(&[]model.Tag{{ID: 2, Slug: "award-winner"}}))
+	mock.ExpectExec(AddMovieTagQuery).WithArgs(7, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(AuditQuery).
+		WithArgs("movie_tag", 7, "create", "tester", "test-request", nil, `{"id":2,"slug":"award-winner"}`, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err := service.AddMovieTag(auditContext(), 7, 2)
+
+	assert.Equal(t, nil, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceAddMovieTagTrashedMovieError(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(CheckMovieQuery).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+
+	err := service.AddMovieTag(auditContext(), 7, 2)
+
+	assert.IsType(t, &util.NotExistingRecordError{}, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceRemoveMovieTag(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(RemoveMovieTagQuery).WithArgs(7, 2).WillReturnRows(newRows(&[]model.Tag{{ID: 2, Slug: "award-winner"}}))
+	mock.ExpectExec(AuditQuery).
+		WithArgs("movie_tag", 7, "delete", "tester", "test-request", `{"id":2,"slug":"award-winner"}`, nil, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err := service.RemoveMovieTag(auditContext(), 7, 2)
+
+	assert.Equal(t, nil, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceRemoveMovieTagQueryError(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	queryError := errors.New("test-error-message")
+	mock.ExpectBegin()
+	mock.ExpectQuery(RemoveMovieTagQuery).WillReturnError(queryError)
+	mock.ExpectRollback()
+
+	err := service.RemoveMovieTag(auditContext(), 7, 2)
+
+	assert.Equal(t, queryError, err)
+}
+
+func initNewService(t *testing.T) (tag.TagService, sqlmock.Sqlmock, *sql.DB) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	service := tag.NewTagService(db)
+	return service, mock, db
+}
+
+func auditContext() context.Context {
+	return util.WithRequestID(util.WithActor(context.Background(), "tester"), "test-request")
+}
+
+func newRows(tags *[]model.Tag) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"id", "slug"})
+	for _, t := range *tags {
+		rows.AddRow(t.ID, t.Slug)
+	}
+	return rows
+}
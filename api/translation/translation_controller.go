@@ -0,0 +1,174 @@
+package translation
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+
+	"github.com/gorilla/mux"
+)
+
+type controller struct {
+	service TranslationService
+}
+
+type TranslationController interface {
+	GetTranslations(w http.ResponseWriter, r *http.Request)
+	GetTranslation(w http.ResponseWriter, r *http.Request)
+	SaveTranslation(w http.ResponseWriter, r *http.Request)
+	DeleteTranslation(w http.ResponseWriter, r *http.Request)
+}
+
+func NewTranslationController(s TranslationService) TranslationController {
+	return &controller{
+		service: s,
+	}
+}
+
+func (c *controller) GetTranslations(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to GetTranslations", r.Method))
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 0)
+	if err != nil {
+		util.HandleBadRequest(w, "Invalid ID", err.Error())
+		return
+	}
+
+	enc, err := util.NewResponseEncoder(r, true)
+	if err != nil {
+		util.HandleNotAcceptable(w, err)
+		return
+	}
+
+	translations, err := c.service.GetTranslations(int(id))
+	if err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	util.WriteResponse(w, enc, http.StatusOK, translations)
+}
+
+func (c *controller) GetTranslation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to GetTranslation", r.Method))
+		return
+	}
+
+	id, locale, err := parseTranslationPath(r)
+	if err != nil {
+		util.HandleBadRequest(w, "Invalid path", err.Error())
+		return
+	}
+
+	enc, err := util.NewResponseEncoder(r, false)
+	if err != nil {
+		util.HandleNotAcceptable(w, err)
+		return
+	}
+
+	translation, err := c.service.GetTranslation(id, locale)
+	if err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	util.WriteResponse(w, enc, http.StatusOK, translation)
+}
+
+// SaveTranslation creates or replaces the translation into the locale of the path, answering 201 or 200 respectively
+func (c *controller) SaveTranslation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to SaveTranslation", r.Method))
+		return
+	}
+
+	id, locale, err := parseTranslationPath(r)
+	if err != nil {
+		util.HandleBadRequest(w, "Invalid path", err.Error())
+		return
+	}
+
+	// Extracting Translation object from request-body
+	translation, err := parseValidTranslation(r)
+	if err != nil {
+		util.HandleInvalidBody(w, err)
+		return
+	}
+	// The movie and the locale are identified by the path
+	translation.MovieID = id
+	translation.Locale = locale
+
+	enc, err := util.NewResponseEncoder(r, false)
+	if err != nil {
+		util.HandleNotAcceptable(w, err)
+		return
+	}
+
+	created, err := c.service.SaveTranslation(r.Context(), translation)
+	if err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	util.WriteResponse(w, enc, status, translation)
+}
+
+func (c *controller) DeleteTranslation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to DeleteTranslation", r.Method))
+		return
+	}
+
+	id, locale, err := parseTranslationPath(r)
+	if err != nil {
+		util.HandleBadRequest(w, "Invalid path", err.Error())
+		return
+	}
+
+	if err := c.service.DeleteTranslation(r.Context(), id, locale); err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// parseTranslationPath returns the ID of the movie and the locale in the path, the latter in its conventional case
+func parseTranslationPath(r *http.Request) (int, string, error) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 0)
+	if err != nil {
+		return 0, "", err
+	}
+	locale, ok := util.CanonicalLocale(mux.Vars(r)["locale"])
+	if !ok {
+		return 0, "", fmt.Errorf("locale [%s] is not a language tag", mux.Vars(r)["locale"])
+	}
+	return int(id), locale, nil
+}
+
+func parseValidTranslation(r *http.Request) (*model.Translation, error) {
+	var t model.Translation
+
+	// Return if the request-body cannot be decoded into a Translation object
+	if err := util.DecodeRequest(r, &t); err != nil {
+		return nil, err
+	}
+
+	// Return if the requested Translation object is invalid
+	if err := t.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid translation-object: %w", err)
+	}
+
+	return &t, nil
+}
@@ -0,0 +1,140 @@
+package translation_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/translation"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Defining the mock TranslationService
+type mockServiceStruct struct {
+	mock.Mock
+}
+
+func (s *mockServiceStruct) GetTranslations(movieID int) ([]model.Translation, error) {
+	args := s.Called(movieID)
+	return args.Get(0).([]model.Translation), args.Error(1)
+}
+
+func (s *mockServiceStruct) GetTranslation(movieID int, locale string) (model.Translation, error) {
+	args := s.Called(movieID, locale)
+	return args.Get(0).(model.Translation), args.Error(1)
+}
+
+func (s *mockServiceStruct) SaveTranslation(ctx context.Context, t *model.Translation) (bool, error) {
+	args := s.Called(t)
+	return args.Bool(0), args.Error(1)
+}
+
+func (s *mockServiceStruct) DeleteTranslation(ctx context.Context, movieID int, locale string) error {
+	args := s.Called(movieID, locale)
+	return args.Error(0)
+}
+
+// Setting up mockService and controller
+var mockService = new(mockServiceStruct)
+var controller = translation.NewTranslationController(mockService)
+
+func TestControllerGetTranslations(t *testing.T) {
+	translations := []model.Translation{{MovieID: 7, Locale: "de", Name: "Chihiros Reise"}}
+	mockService.On("GetTranslations", 7).Return(translations, nil).Once()
+
+	req, _ := http.NewRequest("GET", "/movies/7/translations", nil)
+	rr := execute("/movies/{id}/translations", []string{"GET"}, req, controller.GetTranslations)
+
+	status := http.StatusOK
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+	assert.Equal(t, jsonString(translations), rr.Body.String())
+}
+
+func TestControllerGetTranslationCanonicalLocale(t *testing.T) {
+	tr := model.Translation{MovieID: 7, Locale: "pt-BR", Name: "A Viagem de Chihiro"}
+	mockService.On("GetTranslation", 7, "pt-BR").Return(tr, nil).Once()
+
+	req, _ := http.NewRequest("GET", "/movies/7/translations/pt_br", nil)
+	rr := execute("/movies/{id}/translations/{locale}", []string{"GET"}, req, controller.GetTranslation)
+
+	status := http.StatusOK
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+	assert.Equal(t, jsonString(tr), rr.Body.String())
+}
+
+func TestControllerGetTranslationPathParsingError(t *testing.T) {
+	for _, path := range []string{"/movies/x/translations/de", "/movies/7/translations/deutsch!"} {
+		req, _ := http.NewRequest("GET", path, nil)
+		rr := execute("/movies/{id}/translations/{locale}", []string{"GET"}, req, controller.GetTranslation)
+
+		status := http.StatusBadRequest
+		assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d] for %s", status, path))
+	}
+}
+
+func TestControllerSaveTranslation(t *testing.T) {
+	expected := model.Translation{MovieID: 7, Locale: "de", Name: "Chihiros Reise", Synopsis: "Ein Mädchen"}
+	mockService.On("SaveTranslation", &expected).Return(true, nil).Once()
+
+	req, _ := http.NewRequest("PUT", "/movies/7/translations/DE", bytes.NewBufferString(`{"name": "Chihiros Reise", "synopsis": "Ein Mädchen"}`))
+	rr := execute("/movies/{id}/translations/{locale}", []string{"PUT"}, req, controller.SaveTranslation)
+
+	if !mockService.AssertCalled(t, "SaveTranslation", &expected) {
+		t.Error("The service should be called with the movie and the locale of the path")
+	}
+	status := http.StatusCreated
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerSaveTranslationReplaced(t *testing.T) {
+	expected := model.Translation{MovieID: 8, Locale: "fr", Name: "Le Voyage de Chihiro"}
+	mockService.On("SaveTranslation", &expected).Return(false, nil).Once()
+
+	req, _ := http.NewRequest("PUT", "/movies/8/translations/fr", bytes.NewBufferString(`{"name": "Le Voyage de Chihiro"}`))
+	rr := execute("/movies/{id}/translations/{locale}", []string{"PUT"}, req, controller.SaveTranslation)
+
+	status := http.StatusOK
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerSaveTranslationBodyParsingError(t *testing.T) {
+	for _, body := range []string{`{}`, `{"synopsis": "Ein Mädchen"}`, `{"name":`} {
+		req, _ := http.NewRequest("PUT", "/movies/7/translations/de", bytes.NewBufferString(body))
+		rr := execute("/movies/{id}/translations/{locale}", []string{"PUT"}, req, controller.SaveTranslation)
+
+		status := http.StatusBadRequest
+		assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d] for %s", status, body))
+	}
+}
+
+func TestControllerDeleteTranslationNotFoundError(t *testing.T) {
+	mockService.On("DeleteTranslation", 7, "de").Return(&util.NotExistingRecordError{Identification: "movie ID: 7, locale: de"}).Once()
+
+	req, _ := http.NewRequest("DELETE", "/movies/7/translations/de", nil)
+	rr := execute("/movies/{id}/translations/{locale}", []string{"DELETE"}, req, controller.DeleteTranslation)
+
+	status := http.StatusNotFound
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func execute(route string, methods []string, req *http.Request, handler func(w http.ResponseWriter, r *http.Request)) *httptest.ResponseRecorder {
+	r := mux.NewRouter()
+	r.HandleFunc(route, handler).Methods(methods...)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	return rr
+}
+
+func jsonString(obj interface{}) string {
+	res, _ := json.Marshal(obj)
+	return string(res)
+}
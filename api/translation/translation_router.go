@@ -0,0 +1,30 @@
+package translation
+
+import (
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+
+	"github.com/gorilla/mux"
+)
+
+func InitializeTranslationsPipeline(api *model.Api) {
+	s := NewTranslationService(api.DB)
+	c := NewTranslationController(s)
+	setRouting(api.Router, c)
+}
+
+func setRouting(main *mux.Router, c TranslationController) {
+	// The translations are managed under their movie
+	sr := main.PathPrefix("/movies/{id}/translations").Subrouter()
+
+	sr.HandleFunc("", c.GetTranslations).
+		Methods("GET")
+
+	sr.HandleFunc("/{locale}", c.GetTranslation).
+		Methods("GET")
+
+	sr.HandleFunc("/{locale}", c.SaveTranslation).
+		Methods("PUT")
+
+	sr.HandleFunc("/{locale}", c.DeleteTranslation).
+		Methods("DELETE")
+}
@@ -0,0 +1,62 @@
+package translation_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/movie"
+	"github.com/Hunterlemming/golang-microservice-example/api/translation"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInitializeTranslationsPipeline(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	// The translations share their prefix with the movies, which are routed first
+	api := model.Api{Router: mux.NewRouter(), DB: db}
+	movie.InitializeMoviesPipeline(&api)
+	translation.InitializeTranslationsPipeline(&api)
+
+	testIntegrationGetTranslations(t, mock, api.Router)
+	testIntegrationGetTranslation(t, mock, api.Router)
+}
+
+func testIntegrationGetTranslations(t *testing.T, mock sqlmock.Sqlmock, r *mux.Router) {
+	translations := []model.Translation{{MovieID: 7, Locale: "de", Name: "Chihiros Reise", UpdatedAt: translated}}
+	mock.ExpectQuery(CheckMovieQuery).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectQuery(GetTranslationsQuery).WithArgs(7).WillReturnRows(newRows(&translations))
+
+	req, _ := http.NewRequest("GET", "/movies/7/translations", nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, jsonString(translations), rr.Body.String())
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func testIntegrationGetTranslation(t *testing.T, mock sqlmock.Sqlmock, r *mux.Router) {
+	tr := model.Translation{MovieID: 7, Locale: "zh-Hant", Name: "神隱少女", UpdatedAt: translated}
+	mock.ExpectQuery(CheckMovieQuery).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectQuery(GetTranslationQuery).WithArgs(7, "zh-Hant").WillReturnRows(newRows(&[]model.Translation{tr}))
+
+	req, _ := http.NewRequest("GET", "/movies/7/translations/zh-hant", nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, jsonString(tr), rr.Body.String())
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
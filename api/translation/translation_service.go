@@ -0,0 +1,128 @@
+package translation
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/audit"
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/movie"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+)
+
+// Columns of the translations as scanned into model.Translation
+const columns = "movie_id, locale, name, synopsis, updated_at"
+
+type service struct {
+	db *sql.DB
+}
+
+// TranslationService keeps the titles and synopses of the movies by locale.
+// The movies are served translated by the movie service, which reads them along with the Accept-Language header.
+type TranslationService interface {
+	GetTranslations(movieID int) ([]model.Translation, error)
+	GetTranslation(movieID int, locale string) (model.Translation, error)
+	SaveTranslation(ctx context.Context, t *model.Translation) (bool, error)
+	DeleteTranslation(ctx context.Context, movieID int, locale string) error
+}
+
+func NewTranslationService(db *sql.DB) TranslationService {
+	return &service{
+		db: db,
+	}
+}
+
+// GetTranslations lists the translations of the movie by their locales
+func (s *service) GetTranslations(movieID int) ([]model.Translation, error) {
+	if err := movie.Exists(s.db, movieID); err != nil {
+		return []model.Translation{}, err
+	}
+
+	const q = "SELECT " + columns + " FROM movie_translations WHERE movie_id = $1 ORDER BY locale"
+	qr, err := s.db.Query(q, movieID)
+	if err != nil {
+		return []model.Translation{}, err
+	}
+	defer qr.Close()
+
+	result := make([]model.Translation, 0)
+	for qr.Next() {
+		t, err := scanTranslation(qr)
+		if err != nil {
+			return []model.Translation{}, err
+		}
+		result = append(result, t)
+	}
+
+	return result, qr.Err()
+}
+
+func (s *service) GetTranslation(movieID int, locale string) (model.Translation, error) {
+	if err := movie.Exists(s.db, movieID); err != nil {
+		return model.Translation{}, err
+	}
+
+	const q = "SELECT " + columns + " FROM movie_translations WHERE movie_id = $1 AND locale = $2"
+	t, err := scanTranslation(s.db.QueryRow(q, movieID, locale))
+	if err == sql.ErrNoRows {
+		return model.Translation{}, notExisting(movieID, locale)
+	}
+	return t, err
+}
+
+// SaveTranslation creates or replaces the translation of the movie into its locale, reporting whether it was created
+func (s *service) SaveTranslation(ctx context.Context, t *model.Translation) (bool, error) {
+	created := false
+	err := util.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := movie.Exists(tx, t.MovieID); err != nil {
+			return err
+		}
+
+		const bq = "SELECT " + columns + " FROM movie_translations WHERE movie_id = $1 AND locale = $2 FOR UPDATE"
+		before, err := scanTranslation(tx.QueryRowContext(ctx, bq, t.MovieID, t.Locale))
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		created = err == sql.ErrNoRows
+
+		const q = "INSERT INTO movie_translations (movie_id, locale, name, synopsis) VALUES ($1, $2, $3, $4) " +
+			"ON CONFLICT (movie_id, locale) DO UPDATE SET name = EXCLUDED.name, synopsis = EXCLUDED.synopsis, updated_at = now() " +
+			"RETURNING " + columns
+		after, err := scanTranslation(tx.QueryRowContext(ctx, q, t.MovieID, t.Locale, t.Name, t.Synopsis))
+		if err != nil {
+			return err
+		}
+
+		*t = after
+		if created {
+			return audit.Record(ctx, tx, audit.EntityTranslation, after.MovieID, audit.ActionCreate, nil, after)
+		}
+		return audit.Record(ctx, tx, audit.EntityTranslation, after.MovieID, audit.ActionUpdate, before, after)
+	})
+	return created, err
+}
+
+func (s *service) DeleteTranslation(ctx context.Context, movieID int, locale string) error {
+	return util.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		const q = "DELETE FROM movie_translations WHERE movie_id = $1 AND locale = $2 RETURNING " + columns
+		before, err := scanTranslation(tx.QueryRowContext(ctx, q, movieID, locale))
+		if err == sql.ErrNoRows {
+			return notExisting(movieID, locale)
+		}
+		if err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx, audit.EntityTranslation, movieID, audit.ActionDelete, before, nil)
+	})
+}
+
+func notExisting(movieID int, locale string) error {
+	return &util.NotExistingRecordError{Identification: fmt.Sprintf("movie ID: %v, locale: %s", movieID, locale)}
+}
+
+func scanTranslation(row util.Scanner) (model.Translation, error) {
+	t := model.Translation{}
+	err := row.Scan(&t.MovieID, &t.Locale, &t.Name, &t.Synopsis, &t.UpdatedAt)
+	return t, err
+}
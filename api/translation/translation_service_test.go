@@ -0,0 +1,191 @@
+package translation_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/translation"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	GetTranslationsQuery   = `^SELECT movie_id, locale, name, synopsis, updated_at FROM movie_translations WHERE movie_id = \$1 ORDER BY locale$`
+	GetTranslationQuery    = `^SELECT .+ FROM movie_translations WHERE movie_id = \$1 AND locale = \$2$`
+	CheckMovieQuery        = `^SELECT id FROM movies WHERE id = \$1 AND deleted_at IS NULL$`
+	LockTranslationQuery   = `^SELECT .+ FROM movie_translations WHERE movie_id = \$1 AND locale = \$2 FOR UPDATE$`
+	UpsertTranslationQuery = `^INSERT INTO movie_translations \(movie_id, locale, name, synopsis\) VALUES .+ ON CONFLICT \(movie_id, locale\) DO UPDATE .+ RETURNING .+$`
+	DeleteTranslationQuery = `^DELETE FROM movie_translations WHERE movie_id = \$1 AND locale = \$2 RETURNING .+$`
+	AuditQuery             = `^INSERT INTO audit_log \(.+\) VALUES \(.+\)$`
+)
+
+var translated = time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+func TestServiceGetTranslations(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	translations := []model.Translation{
+		{MovieID: 7, Locale: "de", Name: "Chihiros Reise ins Zauberland", UpdatedAt: translated},
+		{MovieID: 7, Locale: "fr", Name: "Le Voyage de Chihiro", Synopsis: "Une fille", UpdatedAt: translated},
+	}
+	mock.ExpectQuery(CheckMovieQuery).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectQuery(GetTranslationsQuery).WithArgs(7).WillReturnRows(newRows(&translations))
+
+	res, err := service.GetTranslations(7)
+
+	assert.Equal(t, translations, res)
+	assert.Equal(t, nil, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceGetTranslationsTrashedMovieError(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectQuery(CheckMovieQuery).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	res, err := service.GetTranslations(7)
+
+	assert.Equal(t, []model.Translation{}, res)
+	assert.IsType(t, &util.NotExistingRecordError{}, err)
+}
+
+func TestServiceGetTranslationMissingError(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectQuery(CheckMovieQuery).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectQuery(GetTranslationQuery).WithArgs(7, "de").WillReturnRows(newRows(&[]model.Translation{}))
+
+	_, err := service.GetTranslation(7, "de")
+
+	assert.IsType(t, &util.NotExistingRecordError{}, err)
+}
+
+func TestServiceSaveTranslationCreated(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	saved := model.Translation{MovieID: 7, Locale: "de", Name: "Chihiros Reise", UpdatedAt: translated}
+	mock.ExpectBegin()
+	mock.ExpectQuery(CheckMovieQuery).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectQuery(LockTranslationQuery).WithArgs(7, "de").WillReturnRows(newRows(&[]model.Translation{}))
+	mock.ExpectQuery(UpsertTranslationQuery).WithArgs(7, "de", "Chihiros Reise", "").WillReturnRows(newRows(&[]model.Translation{saved}))
+	mock.ExpectExec(AuditQuery).
+		WithArgs("translation", 7, "create", "tester", "test-request", nil, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	tr := &model.Translation{MovieID: 7, Locale: "de", Name: "Chihiros Reise"}
+	created, err := service.SaveTranslation(auditContext(), tr)
+
+	assert.True(t, created)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, saved, *tr, "The translation should be filled in as stored")
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceSaveTranslationReplaced(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	before := model.Translation{MovieID: 7, Locale: "de", Name: "Chihiros Reise", UpdatedAt: translated}
+	saved := model.Translation{MovieID: 7, Locale: "de", Name: "Chihiros Reise ins Zauberland", UpdatedAt: translated}
+	mock.ExpectBegin()
+	mock.ExpectQuery(CheckMovieQuery).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectQuery(LockTranslationQuery).WithArgs(7, "de").WillReturnRows(newRows(&[]model.Translation{before}))
+	mock.ExpectQuery(UpsertTranslationQuery).WithArgs(7, "de", saved.Name, "").WillReturnRows(newRows(&[]model.Translation{saved}))
+	mock.ExpectExec(AuditQuery).
+		WithArgs("translation", 7, "update", "tester", "test-request", sqlmock.AnyArg(), sqlmock.AnyArg(), `{"name":{"from":"Chihiros Reise","to":"Chihiros Reise ins Zauberland"}}`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	created, err := service.SaveTranslation(auditContext(), &model.Translation{MovieID: 7, Locale: "de", Name: saved.Name})
+
+	assert.False(t, created)
+	assert.Equal(t, nil, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceSaveTranslationTrashedMovieError(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(CheckMovieQuery).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+
+	_, err := service.SaveTranslation(auditContext(), &model.Translation{MovieID: 7, Locale: "de", Name: "Chihiros Reise"})
+
+	assert.IsType(t, &util.NotExistingRecordError{}, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceDeleteTranslation(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	before := model.Translation{MovieID: 7, Locale: "de", Name: "Chihiros Reise", UpdatedAt: translated}
+	mock.ExpectBegin()
+	mock.ExpectQuery(DeleteTranslationQuery).WithArgs(7, "de").WillReturnRows(newRows(&[]model.Translation{before}))
+	mock.ExpectExec(AuditQuery).
+		WithArgs("translation", 7, "delete", "tester", "test-request", sqlmock.AnyArg(), nil, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err := service.DeleteTranslation(auditContext(), 7, "de")
+
+	assert.Equal(t, nil, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceDeleteTranslationMissingError(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(DeleteTranslationQuery).WithArgs(7, "de").WillReturnRows(newRows(&[]model.Translation{}))
+	mock.ExpectRollback()
+
+	err := service.DeleteTranslation(auditContext(), 7, "de")
+
+	assert.IsType(t, &util.NotExistingRecordError{}, err)
+}
+
+func initNewService(t *testing.T) (translation.TranslationService, sqlmock.Sqlmock, *sql.DB) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	service := translation.NewTranslationService(db)
+	return service, mock, db
+}
+
+func auditContext() context.Context {
+	return util.WithRequestID(util.WithActor(context.Background(), "tester"), "test-request")
+}
+
+func newRows(translations *[]model.Translation) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"movie_id", "locale", "name", "synopsis", "updated_at"})
+	for _, t := range *translations {
+		rows.AddRow(t.MovieID, t.Locale, t.Name, t.Synopsis, t.UpdatedAt)
+	}
+	return rows
+}
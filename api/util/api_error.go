@@ -17,3 +17,60 @@ type NotExistingRecordError struct {
 func (e *NotExistingRecordError) Error() string {
 	return fmt.Sprintf("A record by [%s] does not exist in the database!", e.Identification)
 }
+
+type StaleRecordError struct {
+	Identification string
+	Version        int
+}
+
+func (e *StaleRecordError) Error() string {
+	return fmt.Sprintf("The record by [%s] is no longer at version [%d]!", e.Identification, e.Version)
+}
+
+// ReferencedRecordError is returned for records which cannot be deleted while other records refer to them
+type ReferencedRecordError struct {
+	Identification string
+	ReferencedBy   string
+}
+
+func (e *ReferencedRecordError) Error() string {
+	return fmt.Sprintf("The record by [%s] is still referenced by %s!", e.Identification, e.ReferencedBy)
+}
+
+// MismatchedOrderError is returned for orders which do not list exactly the records being ordered
+type MismatchedOrderError struct {
+	Identification string
+}
+
+func (e *MismatchedOrderError) Error() string {
+	return fmt.Sprintf("The order of [%s] does not match its records!", e.Identification)
+}
+
+// ForbiddenError is returned for changes the actor is not allowed to make to the record
+type ForbiddenError struct {
+	Identification string
+	Actor          string
+}
+
+func (e *ForbiddenError) Error() string {
+	return fmt.Sprintf("The record by [%s] may not be changed by [%s]!", e.Identification, e.Actor)
+}
+
+// FieldError is a violation of a request, located by the path of the offending field such as "body.name"
+type FieldError struct {
+	Field   string `json:"field" xml:"field"`
+	Message string `json:"message" xml:"message"`
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// InvalidImageError is returned for uploads which cannot be read as an image or exceed its limits
+type InvalidImageError struct {
+	Reason string
+}
+
+func (e *InvalidImageError) Error() string {
+	return fmt.Sprintf("The image is invalid: %s!", e.Reason)
+}
@@ -0,0 +1,66 @@
+package util
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// Actor recorded for requests which did not authenticate
+const AnonymousActor = "anonymous"
+
+// Role of the actors moderating the contributions of the users
+const RoleModerator = "moderator"
+
+type contextKey int
+
+const (
+	actorKey contextKey = iota
+	requestIDKey
+	rolesKey
+)
+
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey, actor)
+}
+
+// ActorFromContext returns who is acting on behalf of the request, or AnonymousActor if unknown
+func ActorFromContext(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey).(string); ok && actor != "" {
+		return actor
+	}
+	return AnonymousActor
+}
+
+func WithRoles(ctx context.Context, roles []string) context.Context {
+	return context.WithValue(ctx, rolesKey, roles)
+}
+
+// HasRole tells whether the actor of the request was granted the role
+func HasRole(ctx context.Context, role string) bool {
+	roles, _ := ctx.Value(rolesKey).([]string)
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// NewRequestID returns a random ID for requests arriving without one
+func NewRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
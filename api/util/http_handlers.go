@@ -0,0 +1,118 @@
+package util
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// WriteResponse encodes the value before writing anything, so an encoding failure is answered with a 500 instead of an empty response
+func WriteResponse(w http.ResponseWriter, enc *ResponseEncoder, status int, v interface{}) {
+	body, err := enc.Encode(v)
+	if err != nil {
+		HandleServerError(w, err, "Response encoding failed")
+		return
+	}
+	if err := enc.write(w, status, body); err != nil {
+		log.Println("[Response Writing Error] ", err.Error())
+	}
+}
+
+func HandleInvalidBody(w http.ResponseWriter, err error) {
+	var unsupported *UnsupportedMediaTypeError
+	if errors.As(err, &unsupported) {
+		http.Error(w, "Unsupported media type", http.StatusUnsupportedMediaType)
+		log.Println("[415 - Unsupported Media Type] ", err.Error())
+		return
+	}
+	HandleBadRequest(w, "Invalid request body", err.Error())
+}
+
+// HandleInvalidRequest reports every violation of the request, so clients can fix them at once
+func HandleInvalidRequest(w http.ResponseWriter, status int, violations []FieldError) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(struct {
+		Message string       `json:"message"`
+		Errors  []FieldError `json:"errors"`
+	}{http.StatusText(status), violations})
+
+	messages := make([]string, len(violations))
+	for i := range violations {
+		messages[i] = violations[i].Error()
+	}
+	log.Printf("[%d - %s] %s", status, http.StatusText(status), strings.Join(messages, "; "))
+}
+
+func HandleRequestTooLarge(w http.ResponseWriter, logMessage string) {
+	http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+	log.Println("[413 - Request Entity Too Large] ", logMessage)
+}
+
+func HandleNotAcceptable(w http.ResponseWriter, err error) {
+	http.Error(w, "Not acceptable", http.StatusNotAcceptable)
+	log.Println("[406 - Not Acceptable] ", err.Error())
+}
+
+func HandleUnauthorized(w http.ResponseWriter, logMessage string) {
+	http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	log.Println("[401 - Unauthorized] ", logMessage)
+}
+
+func HandleForbidden(w http.ResponseWriter, logMessage string) {
+	http.Error(w, "Forbidden", http.StatusForbidden)
+	log.Println("[403 - Forbidden] ", logMessage)
+}
+
+func HandleMethodNotAllowed(w http.ResponseWriter, logMessage string) {
+	http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	log.Println("[405 - Method Not Allowed] ", logMessage)
+}
+
+// HandleServiceError maps the errors of the service to the matching status codes
+func HandleServiceError(w http.ResponseWriter, err error) {
+	switch ServiceErrorStatus(err) {
+	case http.StatusForbidden:
+		HandleForbidden(w, err.Error())
+	case http.StatusNotFound:
+		http.Error(w, "Not found", http.StatusNotFound)
+		log.Println("[404 - Not Found] ", err.Error())
+	case http.StatusConflict:
+		http.Error(w, "Conflict", http.StatusConflict)
+		log.Println("[409 - Conflict] ", err.Error())
+	default:
+		HandleServerError(w, err, "Service unreachable")
+	}
+}
+
+// ServiceErrorStatus returns the status code matching an error of the service
+func ServiceErrorStatus(err error) int {
+	var notExisting *NotExistingRecordError
+	var existing *ExistingRecordError
+	var stale *StaleRecordError
+	var referenced *ReferencedRecordError
+	var mismatched *MismatchedOrderError
+	var forbidden *ForbiddenError
+	switch {
+	case errors.As(err, &forbidden):
+		return http.StatusForbidden
+	case errors.As(err, &notExisting):
+		return http.StatusNotFound
+	case errors.As(err, &existing), errors.As(err, &stale), errors.As(err, &referenced), errors.As(err, &mismatched):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+func HandleServerError(w http.ResponseWriter, err error, message string) {
+	http.Error(w, message, http.StatusInternalServerError)
+	log.Println("[500 - Internal Server Error] ", err.Error())
+}
+
+func HandleBadRequest(w http.ResponseWriter, responseMessage, logMessage string) {
+	http.Error(w, responseMessage, http.StatusBadRequest)
+	log.Println("[400 - Bad Request] ", logMessage)
+}
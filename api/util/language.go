@@ -0,0 +1,110 @@
+package util
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LocalePattern matches the language tags the translations are kept by, such as "de" or "pt-BR"
+const LocalePattern = `^[A-Za-z]{2,3}(-[A-Za-z0-9]{2,8})*$`
+
+var localeRegexp = regexp.MustCompile(LocalePattern)
+
+// CanonicalLocale returns the language tag in its conventional case, such as "pt-BR" for "PT-br",
+// and false if it is not a valid tag
+func CanonicalLocale(tag string) (string, bool) {
+	tag = strings.ReplaceAll(strings.TrimSpace(tag), "_", "-")
+	if !localeRegexp.MatchString(tag) {
+		return "", false
+	}
+
+	subtags := strings.Split(strings.ToLower(tag), "-")
+	for i := 1; i < len(subtags); i++ {
+		switch len(subtags[i]) {
+		case 2:
+			// Regions
+			subtags[i] = strings.ToUpper(subtags[i])
+		case 4:
+			// Scripts
+			subtags[i] = strings.ToUpper(subtags[i][:1]) + subtags[i][1:]
+		}
+	}
+	return strings.Join(subtags, "-"), true
+}
+
+type languageRange struct {
+	tag string
+	q   float64
+}
+
+// AcceptedLocales returns the fallback chain of the Accept-Language header, the most preferred locale first.
+// Every locale is followed by its more general ones unless those are listed on their own,
+// so "de-AT, fr;q=0.8" falls back from "de-AT" to "de" before trying "fr".
+// Wildcards and invalid tags are left out, the caller falls back to the untranslated values after the chain.
+func AcceptedLocales(header string) []string {
+	ranges := make([]languageRange, 0)
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		tag, ok := CanonicalLocale(fields[0])
+		if !ok {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range fields[1:] {
+			key, value, found := strings.Cut(strings.TrimSpace(param), "=")
+			if !found || strings.ToLower(key) != "q" {
+				continue
+			}
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+		// Ranges weighted 0 are not acceptable
+		if q > 0 {
+			ranges = append(ranges, languageRange{tag: tag, q: q})
+		}
+	}
+	// Stable ordering keeps the header order for equally weighted ranges
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+
+	listed := make(map[string]bool, len(ranges))
+	for _, r := range ranges {
+		listed[r.tag] = true
+	}
+	chain := make([]string, 0, len(ranges))
+	seen := make(map[string]bool)
+	for _, r := range ranges {
+		for tag := r.tag; tag != ""; tag = parentLocale(tag) {
+			// General locales listed on their own keep their place
+			if seen[tag] || (tag != r.tag && listed[tag]) {
+				continue
+			}
+			seen[tag] = true
+			chain = append(chain, tag)
+		}
+	}
+	return chain
+}
+
+// parentLocale returns the locale without its last subtag, or "" for a bare language
+func parentLocale(tag string) string {
+	if i := strings.LastIndex(tag, "-"); i >= 0 {
+		return tag[:i]
+	}
+	return ""
+}
+
+var regionRegexp = regexp.MustCompile(`^[A-Za-z]{2}$`)
+
+// CanonicalRegion returns the ISO 3166-1 alpha-2 code of a region in upper case, such as "DE" for "de",
+// and false if it is not a two-letter code
+func CanonicalRegion(code string) (string, bool) {
+	code = strings.TrimSpace(code)
+	if !regionRegexp.MatchString(code) {
+		return "", false
+	}
+	return strings.ToUpper(code), true
+}
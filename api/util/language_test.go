@@ -0,0 +1,42 @@
+package util_test
+
+import (
+	"testing"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanonicalLocale(t *testing.T) {
+	for tag, expected := range map[string]string{"de": "de", "PT-br": "pt-BR", "zh_hant_tw": "zh-Hant-TW", " en-GB ": "en-GB"} {
+		locale, ok := util.CanonicalLocale(tag)
+		assert.True(t, ok, "Tag [%s] should be valid", tag)
+		assert.Equal(t, expected, locale)
+	}
+	for _, tag := range []string{"", "*", "d", "de-", "english", "de-AT-x-verylongsubtag"} {
+		_, ok := util.CanonicalLocale(tag)
+		assert.False(t, ok, "Tag [%s] should be invalid", tag)
+	}
+}
+
+func TestAcceptedLocales(t *testing.T) {
+	assert.Equal(t, []string{"de-AT", "de", "fr"}, util.AcceptedLocales("fr;q=0.8, de-at"))
+	assert.Equal(t, []string{"de-AT", "fr", "de"}, util.AcceptedLocales("de-AT, de;q=0.5, fr;q=0.8"),
+		"Listed general locales should keep their place")
+	assert.Equal(t, []string{"en"}, util.AcceptedLocales("*, en;q=0.1, fr;q=0"),
+		"Wildcards and unacceptable locales should be left out")
+	assert.Empty(t, util.AcceptedLocales(""))
+}
+
+func TestCanonicalRegion(t *testing.T) {
+	for code, expected := range map[string]string{"DE": "DE", "at": "AT", " gb ": "GB"} {
+		region, ok := util.CanonicalRegion(code)
+		assert.True(t, ok, "Code [%s] should be valid", code)
+		assert.Equal(t, expected, region)
+	}
+	for _, code := range []string{"", "D", "DEU", "D1", "de-AT"} {
+		_, ok := util.CanonicalRegion(code)
+		assert.False(t, ok, "Code [%s] should be invalid", code)
+	}
+}
@@ -0,0 +1,55 @@
+package util
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// Codes of the integrity violations reported by Postgres
+const (
+	ForeignKeyViolation = "23503"
+	UniqueViolation     = "23505"
+)
+
+// IsViolation tells whether the error is the integrity violation of the code
+func IsViolation(err error, code pq.ErrorCode) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == code
+}
+
+// QueryRower is implemented by both the database and its transactions
+type QueryRower interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// Scanner is implemented by the rows of both queries and single-row statements
+type Scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// WithTx runs fn in a transaction, which is committed if fn succeeds and rolled back otherwise
+func WithTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetRecord scans the record selected by the query and its ID into dest.
+// It fails with a NotExistingRecordError if there is no such record.
+func GetRecord(db QueryRower, query string, id int, dest ...interface{}) error {
+	err := db.QueryRow(query, id).Scan(dest...)
+	if err == sql.ErrNoRows {
+		return &NotExistingRecordError{Identification: fmt.Sprintf("ID: %v", id)}
+	}
+	return err
+}
@@ -0,0 +1,47 @@
+package util
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"net/http"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+type UnsupportedMediaTypeError struct {
+	ContentType string
+}
+
+func (e *UnsupportedMediaTypeError) Error() string {
+	return fmt.Sprintf("Request bodies of type [%s] are not supported!", e.ContentType)
+}
+
+// DecodeRequest decodes the request-body into v based on its Content-Type, defaulting to JSON.
+// Fields unknown to v are rejected where the format allows telling them apart.
+func DecodeRequest(r *http.Request, v interface{}) error {
+	mediaType := MediaTypeJSON
+	if ct := r.Header.Get("Content-Type"); ct != "" {
+		parsed, _, err := mime.ParseMediaType(ct)
+		if err != nil {
+			return &UnsupportedMediaTypeError{ContentType: ct}
+		}
+		mediaType = normalizeMediaType(parsed)
+	}
+
+	switch mediaType {
+	case MediaTypeJSON:
+		dec := json.NewDecoder(r.Body)
+		dec.DisallowUnknownFields()
+		return dec.Decode(v)
+	case MediaTypeXML:
+		return xml.NewDecoder(r.Body).Decode(v)
+	case MediaTypeMsgPack:
+		dec := msgpack.NewDecoder(r.Body)
+		dec.SetCustomStructTag("json")
+		dec.DisallowUnknownFields(true)
+		return dec.Decode(v)
+	}
+	return &UnsupportedMediaTypeError{ContentType: mediaType}
+}
@@ -0,0 +1,65 @@
+package util_test
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func TestDecodeRequestDefaultsToJson(t *testing.T) {
+	req, _ := http.NewRequest("POST", "/", strings.NewReader(`{"id":1,"name":"test"}`))
+
+	var m testRecord
+	err := util.DecodeRequest(req, &m)
+
+	assert.Nil(t, err)
+	assert.Equal(t, testRecord{ID: 1, Name: "test"}, m)
+}
+
+func TestDecodeRequestXml(t *testing.T) {
+	req, _ := http.NewRequest("POST", "/", strings.NewReader("<testRecord><id>1</id><name>test</name></testRecord>"))
+	req.Header.Set("Content-Type", "text/xml; charset=utf-8")
+
+	var m testRecord
+	err := util.DecodeRequest(req, &m)
+
+	assert.Nil(t, err)
+	assert.Equal(t, testRecord{ID: 1, Name: "test"}, m)
+}
+
+func TestDecodeRequestMsgPack(t *testing.T) {
+	body, _ := msgpack.Marshal(map[string]interface{}{"id": 1, "name": "test"})
+	req, _ := http.NewRequest("POST", "/", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/msgpack")
+
+	var m testRecord
+	err := util.DecodeRequest(req, &m)
+
+	assert.Nil(t, err)
+	assert.Equal(t, testRecord{ID: 1, Name: "test"}, m)
+}
+
+func TestDecodeRequestUnsupportedMediaTypeError(t *testing.T) {
+	req, _ := http.NewRequest("POST", "/", strings.NewReader("id,name\n1,test"))
+	req.Header.Set("Content-Type", "text/csv")
+
+	var m testRecord
+	err := util.DecodeRequest(req, &m)
+
+	assert.IsType(t, &util.UnsupportedMediaTypeError{}, err)
+}
+
+func TestDecodeRequestUnknownFieldError(t *testing.T) {
+	req, _ := http.NewRequest("POST", "/", strings.NewReader(`{"id":1,"name":"test","rating":5}`))
+
+	var m testRecord
+	err := util.DecodeRequest(req, &m)
+
+	assert.NotNil(t, err, "Unknown fields should be rejected")
+}
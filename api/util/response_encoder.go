@@ -0,0 +1,295 @@
+package util
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+const (
+	MediaTypeJSON    = "application/json"
+	MediaTypeXML     = "application/xml"
+	MediaTypeCSV     = "text/csv"
+	MediaTypeMsgPack = "application/msgpack"
+)
+
+// Alternative spellings clients commonly send, mapped to the canonical media type
+var mediaTypeAliases = map[string]string{
+	"text/xml":              MediaTypeXML,
+	"application/x-msgpack": MediaTypeMsgPack,
+}
+
+type NotAcceptableError struct {
+	Accept string
+}
+
+func (e *NotAcceptableError) Error() string {
+	return fmt.Sprintf("None of the requested media types [%s] can be produced!", e.Accept)
+}
+
+type ResponseEncoder struct {
+	MediaType string
+	encode    func(w io.Writer, v interface{}) error
+}
+
+// NewResponseEncoder picks the encoder best matching the Accept header of the request.
+// CSV is only offered for list responses, as single objects have no natural tabular form.
+func NewResponseEncoder(r *http.Request, list bool) (*ResponseEncoder, error) {
+	offers := []string{MediaTypeJSON, MediaTypeXML, MediaTypeMsgPack}
+	if list {
+		offers = append(offers, MediaTypeCSV)
+	}
+
+	accept := r.Header.Get("Accept")
+	mediaType := negotiate(accept, offers)
+	if mediaType == "" {
+		return nil, &NotAcceptableError{Accept: accept}
+	}
+
+	encoders := map[string]func(w io.Writer, v interface{}) error{
+		MediaTypeJSON:    encodeJSON,
+		MediaTypeXML:     encodeXML,
+		MediaTypeCSV:     encodeCSV,
+		MediaTypeMsgPack: encodeMsgPack,
+	}
+	return &ResponseEncoder{MediaType: mediaType, encode: encoders[mediaType]}, nil
+}
+
+// Encode encodes the value into a buffer, so an encoding failure does not leave a half-written response
+func (e *ResponseEncoder) Encode(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := e.encode(&buf, v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Write encodes the value first and only then writes the status and the body
+func (e *ResponseEncoder) Write(w http.ResponseWriter, status int, v interface{}) error {
+	body, err := e.Encode(v)
+	if err != nil {
+		return err
+	}
+	return e.write(w, status, body)
+}
+
+func (e *ResponseEncoder) write(w http.ResponseWriter, status int, body []byte) error {
+	w.Header().Set("Content-Type", contentType(e.MediaType))
+	w.WriteHeader(status)
+	_, err := w.Write(body)
+	return err
+}
+
+func contentType(mediaType string) string {
+	switch mediaType {
+	case MediaTypeJSON, MediaTypeXML, MediaTypeCSV:
+		return mediaType + "; charset=utf-8"
+	}
+	return mediaType
+}
+
+type acceptRange struct {
+	mediaType string
+	q         float64
+}
+
+// negotiate returns the offer preferred by the Accept header, or "" if none is acceptable
+func negotiate(accept string, offers []string) string {
+	if strings.TrimSpace(accept) == "" {
+		return offers[0]
+	}
+
+	ranges := parseAccept(accept)
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		q, specificity := 0.0, -1
+		for _, ar := range ranges {
+			s := matchSpecificity(ar.mediaType, offer)
+			if s > specificity {
+				q, specificity = ar.q, s
+			}
+		}
+		if specificity >= 0 && q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best
+}
+
+func parseAccept(accept string) []acceptRange {
+	ranges := make([]acceptRange, 0)
+	for _, part := range strings.Split(accept, ",") {
+		fields := strings.Split(part, ";")
+		mediaType := normalizeMediaType(fields[0])
+		if mediaType == "" {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range fields[1:] {
+			key, value, found := strings.Cut(strings.TrimSpace(param), "=")
+			if !found || strings.ToLower(key) != "q" {
+				continue
+			}
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+		ranges = append(ranges, acceptRange{mediaType: mediaType, q: q})
+	}
+
+	// Stable ordering keeps the header order for equally weighted ranges
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+	return ranges
+}
+
+// matchSpecificity returns -1 if the range does not cover the media type, otherwise how specific the match is
+func matchSpecificity(mediaRange, mediaType string) int {
+	switch {
+	case mediaRange == mediaType:
+		return 2
+	case mediaRange == "*/*":
+		return 0
+	case strings.HasSuffix(mediaRange, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(mediaRange, "*")):
+		return 1
+	}
+	return -1
+}
+
+func normalizeMediaType(mediaType string) string {
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	if alias, ok := mediaTypeAliases[mediaType]; ok {
+		return alias
+	}
+	return mediaType
+}
+
+func encodeJSON(w io.Writer, v interface{}) error {
+	res, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(res)
+	return err
+}
+
+// encodeXML wraps slices into a <list> element, as XML documents need a single root
+func encodeXML(w io.Writer, v interface{}) error {
+	enc := xml.NewEncoder(w)
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice {
+		return enc.Encode(v)
+	}
+
+	list := xml.StartElement{Name: xml.Name{Local: "list"}}
+	if err := enc.EncodeToken(list); err != nil {
+		return err
+	}
+	for i := 0; i < rv.Len(); i++ {
+		if err := enc.Encode(rv.Index(i).Interface()); err != nil {
+			return err
+		}
+	}
+	if err := enc.EncodeToken(list.End()); err != nil {
+		return err
+	}
+	return enc.Flush()
+}
+
+func encodeMsgPack(w io.Writer, v interface{}) error {
+	enc := msgpack.NewEncoder(w)
+	// Reusing the json tags keeps the field names identical across formats
+	enc.SetCustomStructTag("json")
+	return enc.Encode(v)
+}
+
+// encodeCSV writes a slice of structs as a table, using the json field names as the header row
+func encodeCSV(w io.Writer, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice {
+		return fmt.Errorf("csv encoding requires a list, got %T", v)
+	}
+
+	elemType := rv.Type().Elem()
+	if elemType.Kind() == reflect.Pointer {
+		elemType = elemType.Elem()
+	}
+	if elemType.Kind() != reflect.Struct {
+		return fmt.Errorf("csv encoding requires a list of objects, got %T", v)
+	}
+
+	columns, header := csvColumns(elemType)
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for i := 0; i < rv.Len(); i++ {
+		elem := reflect.Indirect(rv.Index(i))
+		record := make([]string, len(columns))
+		if elem.IsValid() {
+			for j, index := range columns {
+				record[j] = csvValue(elem.Field(index))
+			}
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func csvColumns(t reflect.Type) ([]int, []string) {
+	columns := make([]int, 0, t.NumField())
+	header := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name := field.Name
+		if tag, ok := field.Tag.Lookup("json"); ok {
+			tagName, _, _ := strings.Cut(tag, ",")
+			if tagName == "-" {
+				continue
+			}
+			if tagName != "" {
+				name = tagName
+			}
+		}
+		columns = append(columns, i)
+		header = append(header, name)
+	}
+	return columns, header
+}
+
+func csvValue(v reflect.Value) string {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+
+	if t, ok := v.Interface().(time.Time); ok {
+		return t.Format(time.RFC3339)
+	}
+	switch v.Kind() {
+	case reflect.Struct, reflect.Slice, reflect.Map:
+		res, _ := json.Marshal(v.Interface())
+		return string(res)
+	}
+	return fmt.Sprint(v.Interface())
+}
@@ -0,0 +1,125 @@
+package util_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+type testRecord struct {
+	ID   int    `json:"id" xml:"id"`
+	Name string `json:"name" xml:"name"`
+}
+
+var records = []testRecord{{ID: 1, Name: "test1"}, {ID: 2, Name: "test, 2"}}
+
+func TestNewResponseEncoderDefaultsToJson(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/", nil)
+
+	enc, err := util.NewResponseEncoder(req, false)
+
+	assert.Nil(t, err)
+	assert.Equal(t, util.MediaTypeJSON, enc.MediaType)
+}
+
+func TestNewResponseEncoderHonorsQualityValues(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", "application/json;q=0.5, application/xml, */*;q=0.1")
+
+	enc, err := util.NewResponseEncoder(req, false)
+
+	assert.Nil(t, err)
+	assert.Equal(t, util.MediaTypeXML, enc.MediaType)
+}
+
+func TestNewResponseEncoderResolvesWildcards(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", "text/*")
+
+	enc, err := util.NewResponseEncoder(req, true)
+
+	assert.Nil(t, err)
+	assert.Equal(t, util.MediaTypeCSV, enc.MediaType)
+}
+
+func TestNewResponseEncoderCsvOnlyForLists(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", "text/csv")
+
+	_, err := util.NewResponseEncoder(req, false)
+
+	assert.IsType(t, &util.NotAcceptableError{}, err)
+}
+
+func TestNewResponseEncoderNotAcceptableError(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", "image/png, application/json;q=0")
+
+	_, err := util.NewResponseEncoder(req, true)
+
+	assert.IsType(t, &util.NotAcceptableError{}, err)
+}
+
+func TestResponseEncoderWriteJson(t *testing.T) {
+	rr := encode(t, "application/json", records)
+
+	assert.Equal(t, "application/json; charset=utf-8", rr.Header().Get("Content-Type"))
+	assert.Equal(t, `[{"id":1,"name":"test1"},{"id":2,"name":"test, 2"}]`, rr.Body.String())
+}
+
+func TestResponseEncoderWriteXml(t *testing.T) {
+	rr := encode(t, "application/xml", records)
+
+	assert.Equal(t, "application/xml; charset=utf-8", rr.Header().Get("Content-Type"))
+	assert.Equal(t, "<list><testRecord><id>1</id><name>test1</name></testRecord><testRecord><id>2</id><name>test, 2</name></testRecord></list>", rr.Body.String())
+}
+
+func TestResponseEncoderWriteCsv(t *testing.T) {
+	rr := encode(t, "text/csv", records)
+
+	assert.Equal(t, "text/csv; charset=utf-8", rr.Header().Get("Content-Type"))
+	assert.Equal(t, "id,name\n1,test1\n2,\"test, 2\"\n", rr.Body.String())
+}
+
+func TestResponseEncoderWriteMsgPack(t *testing.T) {
+	rr := encode(t, "application/x-msgpack", records)
+
+	var decoded []map[string]interface{}
+	err := msgpack.Unmarshal(rr.Body.Bytes(), &decoded)
+
+	assert.Nil(t, err)
+	assert.Equal(t, util.MediaTypeMsgPack, rr.Header().Get("Content-Type"))
+	assert.Equal(t, "test1", decoded[0]["name"], "The json field names should be reused")
+}
+
+func TestWriteResponseEncodingError(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", "text/csv")
+	enc, _ := util.NewResponseEncoder(req, true)
+
+	rr := httptest.NewRecorder()
+	util.WriteResponse(rr, enc, http.StatusOK, testRecord{ID: 1})
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code, "Values failing to encode should not be sent as an empty response")
+	assert.Equal(t, "text/plain; charset=utf-8", rr.Header().Get("Content-Type"))
+}
+
+func encode(t *testing.T, accept string, v interface{}) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", accept)
+	enc, err := util.NewResponseEncoder(req, true)
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when negotiating the encoder", err)
+	}
+
+	rr := httptest.NewRecorder()
+	if err := enc.Write(rr, http.StatusOK, v); err != nil {
+		t.Fatalf("an error '%s' was not expected when writing the response", err)
+	}
+	return rr
+}
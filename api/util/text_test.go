@@ -0,0 +1,35 @@
+package util_test
+
+import (
+	"testing"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWords(t *testing.T) {
+	assert.Equal(t, []string{"the", "lord", "of", "the", "rings", "2"}, util.Words("The Lord-of the Rings: 2"))
+}
+
+func TestTrigrams(t *testing.T) {
+	trigrams := util.Trigrams("Cat")
+
+	assert.Equal(t, 4, len(trigrams))
+	for _, tri := range []string{"  c", " ca", "cat", "at "} {
+		assert.Contains(t, trigrams, tri)
+	}
+}
+
+func TestTrigramSimilarity(t *testing.T) {
+	assert.Equal(t, 1.0, util.TrigramSimilarity("Matrix", "matrix"))
+	assert.Equal(t, 0.0, util.TrigramSimilarity("Matrix", "Alien"))
+	assert.Equal(t, 0.5, util.TrigramSimilarity("Matrix", "Matrics"))
+}
+
+func TestLevenshtein(t *testing.T) {
+	assert.Equal(t, 0, util.Levenshtein("Heat", "Heat"))
+	assert.Equal(t, 3, util.Levenshtein("kitten", "sitting"))
+	assert.Equal(t, 4, util.Levenshtein("", "Heat"))
+	assert.Equal(t, 1, util.Levenshtein("Amélie", "Amelie"), "Letters should be compared rather than bytes")
+}
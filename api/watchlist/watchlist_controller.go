@@ -0,0 +1,392 @@
+package watchlist
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+
+	"github.com/gorilla/mux"
+)
+
+type controller struct {
+	service WatchlistService
+}
+
+// WatchlistController serves the lists of the requesting user, anonymous users have none.
+// Shared lists are readable by anyone.
+type WatchlistController interface {
+	GetWatchlists(w http.ResponseWriter, r *http.Request)
+	GetWatchlist(w http.ResponseWriter, r *http.Request)
+	GetSharedWatchlist(w http.ResponseWriter, r *http.Request)
+	CreateWatchlist(w http.ResponseWriter, r *http.Request)
+	RenameWatchlist(w http.ResponseWriter, r *http.Request)
+	DeleteWatchlist(w http.ResponseWriter, r *http.Request)
+	AddMovie(w http.ResponseWriter, r *http.Request)
+	RemoveMovie(w http.ResponseWriter, r *http.Request)
+	ReorderMovies(w http.ResponseWriter, r *http.Request)
+	ShareWatchlist(w http.ResponseWriter, r *http.Request)
+	UnshareWatchlist(w http.ResponseWriter, r *http.Request)
+}
+
+func NewWatchlistController(s WatchlistService) WatchlistController {
+	return &controller{
+		service: s,
+	}
+}
+
+func (c *controller) GetWatchlists(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to GetWatchlists", r.Method))
+		return
+	}
+
+	owner, ok := requireOwner(w, r)
+	if !ok {
+		return
+	}
+
+	enc, err := util.NewResponseEncoder(r, true)
+	if err != nil {
+		util.HandleNotAcceptable(w, err)
+		return
+	}
+
+	lists, err := c.service.GetWatchlists(owner)
+	if err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	util.WriteResponse(w, enc, http.StatusOK, lists)
+}
+
+func (c *controller) GetWatchlist(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to GetWatchlist", r.Method))
+		return
+	}
+
+	owner, ok := requireOwner(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 0)
+	if err != nil {
+		util.HandleBadRequest(w, "Invalid ID", err.Error())
+		return
+	}
+
+	enc, err := util.NewResponseEncoder(r, false)
+	if err != nil {
+		util.HandleNotAcceptable(w, err)
+		return
+	}
+
+	list, err := c.service.GetWatchlist(owner, int(id))
+	if err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	util.WriteResponse(w, enc, http.StatusOK, list)
+}
+
+func (c *controller) GetSharedWatchlist(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to GetSharedWatchlist", r.Method))
+		return
+	}
+
+	enc, err := util.NewResponseEncoder(r, false)
+	if err != nil {
+		util.HandleNotAcceptable(w, err)
+		return
+	}
+
+	list, err := c.service.GetSharedWatchlist(mux.Vars(r)["token"])
+	if err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	util.WriteResponse(w, enc, http.StatusOK, list)
+}
+
+func (c *controller) CreateWatchlist(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to CreateWatchlist", r.Method))
+		return
+	}
+
+	owner, ok := requireOwner(w, r)
+	if !ok {
+		return
+	}
+
+	// Extracting Watchlist object from request-body
+	list, err := parseValidWatchlist(r)
+	if err != nil {
+		util.HandleInvalidBody(w, err)
+		return
+	}
+	list.Owner = owner
+
+	enc, err := util.NewResponseEncoder(r, false)
+	if err != nil {
+		util.HandleNotAcceptable(w, err)
+		return
+	}
+
+	if err := c.service.CreateWatchlist(r.Context(), list); err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	util.WriteResponse(w, enc, http.StatusCreated, list)
+}
+
+func (c *controller) RenameWatchlist(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to RenameWatchlist", r.Method))
+		return
+	}
+
+	owner, ok := requireOwner(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 0)
+	if err != nil {
+		util.HandleBadRequest(w, "Invalid ID", err.Error())
+		return
+	}
+
+	// Extracting Watchlist object from request-body
+	list, err := parseValidWatchlist(r)
+	if err != nil {
+		util.HandleInvalidBody(w, err)
+		return
+	}
+
+	if err := c.service.RenameWatchlist(r.Context(), owner, int(id), list.Name); err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	fmt.Fprintln(w, "success")
+}
+
+func (c *controller) DeleteWatchlist(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to DeleteWatchlist", r.Method))
+		return
+	}
+
+	owner, ok := requireOwner(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 0)
+	if err != nil {
+		util.HandleBadRequest(w, "Invalid ID", err.Error())
+		return
+	}
+
+	if err := c.service.DeleteWatchlist(r.Context(), owner, int(id)); err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AddMovie appends the movie to the list, adding it again succeeds as well
+func (c *controller) AddMovie(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to AddMovie", r.Method))
+		return
+	}
+
+	owner, ok := requireOwner(w, r)
+	if !ok {
+		return
+	}
+
+	id, movieID, err := parseEntry(r)
+	if err != nil {
+		util.HandleBadRequest(w, "Invalid ID", err.Error())
+		return
+	}
+
+	if err := c.service.AddMovie(r.Context(), owner, id, movieID); err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *controller) RemoveMovie(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to RemoveMovie", r.Method))
+		return
+	}
+
+	owner, ok := requireOwner(w, r)
+	if !ok {
+		return
+	}
+
+	id, movieID, err := parseEntry(r)
+	if err != nil {
+		util.HandleBadRequest(w, "Invalid ID", err.Error())
+		return
+	}
+
+	if err := c.service.RemoveMovie(r.Context(), owner, id, movieID); err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ReorderMovies answers 409 for orders not listing every movie of the list once, such as outdated ones
+func (c *controller) ReorderMovies(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to ReorderMovies", r.Method))
+		return
+	}
+
+	owner, ok := requireOwner(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 0)
+	if err != nil {
+		util.HandleBadRequest(w, "Invalid ID", err.Error())
+		return
+	}
+
+	// Extracting WatchlistOrder object from request-body
+	var order model.WatchlistOrder
+	if err := util.DecodeRequest(r, &order); err != nil {
+		util.HandleInvalidBody(w, err)
+		return
+	}
+	if err := order.Validate(); err != nil {
+		util.HandleInvalidBody(w, fmt.Errorf("invalid order-object: %w", err))
+		return
+	}
+
+	if err := c.service.ReorderMovies(r.Context(), owner, int(id), order.MovieIDs); err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ShareWatchlist answers with the path of the public view of the list, sharing it again answers the same path
+func (c *controller) ShareWatchlist(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to ShareWatchlist", r.Method))
+		return
+	}
+
+	owner, ok := requireOwner(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 0)
+	if err != nil {
+		util.HandleBadRequest(w, "Invalid ID", err.Error())
+		return
+	}
+
+	enc, err := util.NewResponseEncoder(r, false)
+	if err != nil {
+		util.HandleNotAcceptable(w, err)
+		return
+	}
+
+	token, err := c.service.ShareWatchlist(r.Context(), owner, int(id))
+	if err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	util.WriteResponse(w, enc, http.StatusOK, model.WatchlistShare{Token: token, Path: "/shared/watchlists/" + token})
+}
+
+func (c *controller) UnshareWatchlist(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to UnshareWatchlist", r.Method))
+		return
+	}
+
+	owner, ok := requireOwner(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 0)
+	if err != nil {
+		util.HandleBadRequest(w, "Invalid ID", err.Error())
+		return
+	}
+
+	if err := c.service.UnshareWatchlist(r.Context(), owner, int(id)); err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// requireOwner returns the requesting user, answering 401 for anonymous requests
+func requireOwner(w http.ResponseWriter, r *http.Request) (string, bool) {
+	owner := util.ActorFromContext(r.Context())
+	if owner == util.AnonymousActor {
+		util.HandleUnauthorized(w, "Anonymous watchlist request")
+		return "", false
+	}
+	return owner, true
+}
+
+// parseEntry returns the IDs of the list and the movie in the path
+func parseEntry(r *http.Request) (int, int, error) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 0)
+	if err != nil {
+		return 0, 0, err
+	}
+	movieID, err := strconv.ParseInt(mux.Vars(r)["movieId"], 10, 0)
+	if err != nil {
+		return 0, 0, err
+	}
+	return int(id), int(movieID), nil
+}
+
+func parseValidWatchlist(r *http.Request) (*model.Watchlist, error) {
+	var list model.Watchlist
+
+	// Return if the request-body cannot be decoded into a Watchlist object
+	if err := util.DecodeRequest(r, &list); err != nil {
+		return nil, err
+	}
+
+	// Return if the requested Watchlist object is invalid
+	if err := list.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid watchlist-object: %w", err)
+	}
+
+	return &list, nil
+}
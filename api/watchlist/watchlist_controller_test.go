@@ -0,0 +1,213 @@
+package watchlist_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+	"github.com/Hunterlemming/golang-microservice-example/api/watchlist"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Defining the mock WatchlistService
+type mockServiceStruct struct {
+	mock.Mock
+}
+
+func (s *mockServiceStruct) GetWatchlists(owner string) ([]model.Watchlist, error) {
+	args := s.Called(owner)
+	return args.Get(0).([]model.Watchlist), args.Error(1)
+}
+
+func (s *mockServiceStruct) GetWatchlist(owner string, id int) (model.Watchlist, error) {
+	args := s.Called(owner, id)
+	return args.Get(0).(model.Watchlist), args.Error(1)
+}
+
+func (s *mockServiceStruct) GetSharedWatchlist(token string) (model.Watchlist, error) {
+	args := s.Called(token)
+	return args.Get(0).(model.Watchlist), args.Error(1)
+}
+
+func (s *mockServiceStruct) CreateWatchlist(ctx context.Context, w *model.Watchlist) error {
+	args := s.Called(w)
+	return args.Error(0)
+}
+
+func (s *mockServiceStruct) RenameWatchlist(ctx context.Context, owner string, id int, name string) error {
+	args := s.Called(owner, id, name)
+	return args.Error(0)
+}
+
+func (s *mockServiceStruct) DeleteWatchlist(ctx context.Context, owner string, id int) error {
+	args := s.Called(owner, id)
+	return args.Error(0)
+}
+
+func (s *mockServiceStruct) AddMovie(ctx context.Context, owner string, id, movieID int) error {
+	args := s.Called(owner, id, movieID)
+	return args.Error(0)
+}
+
+func (s *mockServiceStruct) RemoveMovie(ctx context.Context, owner string, id, movieID int) error {
+	args := s.Called(owner, id, movieID)
+	return args.Error(0)
+}
+
+func (s *mockServiceStruct) ReorderMovies(ctx context.Context, owner string, id int, movieIDs []int) error {
+	args := s.Called(owner, id, movieIDs)
+	return args.Error(0)
+}
+
+func (s *mockServiceStruct) ShareWatchlist(ctx context.Context, owner string, id int) (string, error) {
+	args := s.Called(owner, id)
+	return args.String(0), args.Error(1)
+}
+
+func (s *mockServiceStruct) UnshareWatchlist(ctx context.Context, owner string, id int) error {
+	args := s.Called(owner, id)
+	return args.Error(0)
+}
+
+// Setting up mockService and controller
+var mockService = new(mockServiceStruct)
+var controller = watchlist.NewWatchlistController(mockService)
+
+func TestControllerGetWatchlists(t *testing.T) {
+	lists := []model.Watchlist{{ID: 1, Owner: "alice", Name: "Weekend"}}
+	mockService.On("GetWatchlists", "alice").Return(lists, nil).Once()
+
+	req, _ := http.NewRequest("GET", "/watchlists", nil)
+	rr := execute("/watchlists", []string{"GET"}, asAlice(req), controller.GetWatchlists)
+
+	status := http.StatusOK
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+	assert.Equal(t, jsonString(lists), rr.Body.String())
+}
+
+func TestControllerGetWatchlistsAnonymousError(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/watchlists", nil)
+	rr := execute("/watchlists", []string{"GET"}, req, controller.GetWatchlists)
+
+	status := http.StatusUnauthorized
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerGetSharedWatchlist(t *testing.T) {
+	list := model.Watchlist{ID: 1, Name: "Weekend", Entries: []model.WatchlistEntry{{MovieID: 7, MovieName: "test7", Position: 1}}}
+	mockService.On("GetSharedWatchlist", "secret").Return(list, nil).Once()
+
+	req, _ := http.NewRequest("GET", "/shared/watchlists/secret", nil)
+	rr := execute("/shared/watchlists/{token}", []string{"GET"}, req, controller.GetSharedWatchlist)
+
+	status := http.StatusOK
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d] for anonymous users", status))
+	assert.Equal(t, jsonString(list), rr.Body.String())
+}
+
+func TestControllerCreateWatchlist(t *testing.T) {
+	expected := model.Watchlist{Owner: "alice", Name: "Weekend"}
+	mockService.On("CreateWatchlist", &expected).Return(nil).Once()
+
+	req, _ := http.NewRequest("POST", "/watchlists", bytes.NewBufferString(`{"name": "Weekend", "owner": "bob"}`))
+	rr := execute("/watchlists", []string{"POST"}, asAlice(req), controller.CreateWatchlist)
+
+	if !mockService.AssertCalled(t, "CreateWatchlist", &expected) {
+		t.Error("The list should be owned by the user of the request")
+	}
+	status := http.StatusCreated
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerCreateWatchlistBodyParsingError(t *testing.T) {
+	req, _ := http.NewRequest("POST", "/watchlists", bytes.NewBufferString(`{}`))
+	rr := execute("/watchlists", []string{"POST"}, asAlice(req), controller.CreateWatchlist)
+
+	status := http.StatusBadRequest
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerAddMovie(t *testing.T) {
+	mockService.On("AddMovie", "alice", 1, 7).Return(nil).Once()
+
+	req, _ := http.NewRequest("PUT", "/watchlists/1/movies/7", nil)
+	rr := execute("/watchlists/{id}/movies/{movieId}", []string{"PUT"}, asAlice(req), controller.AddMovie)
+
+	status := http.StatusNoContent
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerRemoveMovieParsingError(t *testing.T) {
+	req, _ := http.NewRequest("DELETE", "/watchlists/1/movies/x", nil)
+	rr := execute("/watchlists/{id}/movies/{movieId}", []string{"DELETE"}, asAlice(req), controller.RemoveMovie)
+
+	status := http.StatusBadRequest
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerReorderMovies(t *testing.T) {
+	mockService.On("ReorderMovies", "alice", 1, []int{8, 7}).Return(nil).Once()
+
+	req, _ := http.NewRequest("PUT", "/watchlists/1/order", bytes.NewBufferString(`{"movie_ids": [8, 7]}`))
+	rr := execute("/watchlists/{id}/order", []string{"PUT"}, asAlice(req), controller.ReorderMovies)
+
+	status := http.StatusNoContent
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerReorderMoviesMismatchedError(t *testing.T) {
+	mockService.On("ReorderMovies", "alice", 1, []int{7}).Return(&util.MismatchedOrderError{Identification: "watchlist ID: 1"}).Once()
+
+	req, _ := http.NewRequest("PUT", "/watchlists/1/order", bytes.NewBufferString(`{"movie_ids": [7]}`))
+	rr := execute("/watchlists/{id}/order", []string{"PUT"}, asAlice(req), controller.ReorderMovies)
+
+	status := http.StatusConflict
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerShareWatchlist(t *testing.T) {
+	mockService.On("ShareWatchlist", "alice", 1).Return("secret", nil).Once()
+
+	req, _ := http.NewRequest("POST", "/watchlists/1/share", nil)
+	rr := execute("/watchlists/{id}/share", []string{"POST"}, asAlice(req), controller.ShareWatchlist)
+
+	status := http.StatusOK
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+	assert.Equal(t, `{"token":"secret","path":"/shared/watchlists/secret"}`, rr.Body.String())
+}
+
+func TestControllerDeleteWatchlistNotFoundError(t *testing.T) {
+	mockService.On("DeleteWatchlist", "alice", 2).Return(&util.NotExistingRecordError{Identification: "ID: 2"}).Once()
+
+	req, _ := http.NewRequest("DELETE", "/watchlists/2", nil)
+	rr := execute("/watchlists/{id}", []string{"DELETE"}, asAlice(req), controller.DeleteWatchlist)
+
+	status := http.StatusNotFound
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func asAlice(req *http.Request) *http.Request {
+	return req.WithContext(util.WithActor(req.Context(), "alice"))
+}
+
+func execute(route string, methods []string, req *http.Request, handler func(w http.ResponseWriter, r *http.Request)) *httptest.ResponseRecorder {
+	r := mux.NewRouter()
+	r.HandleFunc(route, handler).Methods(methods...)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	return rr
+}
+
+func jsonString(obj interface{}) string {
+	res, _ := json.Marshal(obj)
+	return string(res)
+}
@@ -0,0 +1,51 @@
+package watchlist
+
+import (
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+
+	"github.com/gorilla/mux"
+)
+
+func InitializeWatchlistsPipeline(api *model.Api) {
+	s := NewWatchlistService(api.DB)
+	c := NewWatchlistController(s)
+	setRouting(api.Router, c)
+}
+
+func setRouting(main *mux.Router, c WatchlistController) {
+	sr := main.PathPrefix("/watchlists").Subrouter()
+
+	sr.HandleFunc("", c.GetWatchlists).
+		Methods("GET")
+
+	sr.HandleFunc("/{id}", c.GetWatchlist).
+		Methods("GET")
+
+	sr.HandleFunc("", c.CreateWatchlist).
+		Methods("POST")
+
+	sr.HandleFunc("/{id}", c.RenameWatchlist).
+		Methods("PUT")
+
+	sr.HandleFunc("/{id}", c.DeleteWatchlist).
+		Methods("DELETE")
+
+	sr.HandleFunc("/{id}/movies/{movieId}", c.AddMovie).
+		Methods("PUT")
+
+	sr.HandleFunc("/{id}/movies/{movieId}", c.RemoveMovie).
+		Methods("DELETE")
+
+	sr.HandleFunc("/{id}/order", c.ReorderMovies).
+		Methods("PUT")
+
+	sr.HandleFunc("/{id}/share", c.ShareWatchlist).
+		Methods("POST")
+
+	sr.HandleFunc("/{id}/share", c.UnshareWatchlist).
+		Methods("DELETE")
+
+	// The shared lists are public, whoever knows the token may read them
+	main.HandleFunc("/shared/watchlists/{token}", c.GetSharedWatchlist).
+		Methods("GET")
+}
@@ -0,0 +1,55 @@
+package watchlist_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/watchlist"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInitializeWatchlistsPipeline(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	api := model.Api{Router: mux.NewRouter(), DB: db}
+	watchlist.InitializeWatchlistsPipeline(&api)
+
+	testIntegrationGetWatchlists(t, mock, api.Router)
+	testIntegrationGetSharedWatchlist(t, mock, api.Router)
+}
+
+func testIntegrationGetWatchlists(t *testing.T, mock sqlmock.Sqlmock, r *mux.Router) {
+	lists := []model.Watchlist{{ID: 1, Owner: "alice", Name: "Weekend", CreatedAt: created}}
+	mock.ExpectQuery(GetWatchlistsQuery).WithArgs("alice").WillReturnRows(newRows(&lists))
+
+	req, _ := http.NewRequest("GET", "/watchlists", nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, asAlice(req))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, jsonString(lists), rr.Body.String())
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func testIntegrationGetSharedWatchlist(t *testing.T, mock sqlmock.Sqlmock, r *mux.Router) {
+	mock.ExpectQuery(GetSharedQuery).WithArgs("unknown").WillReturnRows(newRows(&[]model.Watchlist{}))
+
+	req, _ := http.NewRequest("GET", "/shared/watchlists/unknown", nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
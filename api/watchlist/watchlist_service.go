@@ -0,0 +1,353 @@
+package watchlist
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/audit"
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/movie"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+
+	"github.com/lib/pq"
+)
+
+// Columns of the watchlists as scanned into model.Watchlist
+const columns = "id, owner, name, COALESCE(share_token, ''), created_at"
+
+type service struct {
+	db *sql.DB
+}
+
+// WatchlistService manages the lists of their owners, lists of other owners are reported as not existing
+type WatchlistService interface {
+	GetWatchlists(owner string) ([]model.Watchlist, error)
+	GetWatchlist(owner string, id int) (model.Watchlist, error)
+	GetSharedWatchlist(token string) (model.Watchlist, error)
+	CreateWatchlist(ctx context.Context, w *model.Watchlist) error
+	RenameWatchlist(ctx context.Context, owner string, id int, name string) error
+	DeleteWatchlist(ctx context.Context, owner string, id int) error
+	AddMovie(ctx context.Context, owner string, id, movieID int) error
+	RemoveMovie(ctx context.Context, owner string, id, movieID int) error
+	ReorderMovies(ctx context.Context, owner string, id int, movieIDs []int) error
+	ShareWatchlist(ctx context.Context, owner string, id int) (string, error)
+	UnshareWatchlist(ctx context.Context, owner string, id int) error
+}
+
+func NewWatchlistService(db *sql.DB) WatchlistService {
+	return &service{
+		db: db,
+	}
+}
+
+// GetWatchlists returns the lists of the owner without their entries
+func (s *service) GetWatchlists(owner string) ([]model.Watchlist, error) {
+	const q = "SELECT " + columns + " FROM watchlists WHERE owner = $1 ORDER BY id"
+	qr, err := s.db.Query(q, owner)
+	if err != nil {
+		return []model.Watchlist{}, err
+	}
+	defer qr.Close()
+
+	result := make([]model.Watchlist, 0)
+	for qr.Next() {
+		w, err := scanWatchlist(qr)
+		if err != nil {
+			return []model.Watchlist{}, err
+		}
+		result = append(result, w)
+	}
+
+	return result, qr.Err()
+}
+
+func (s *service) GetWatchlist(owner string, id int) (model.Watchlist, error) {
+	const q = "SELECT " + columns + " FROM watchlists WHERE id = $1 AND owner = $2"
+	w, err := scanWatchlist(s.db.QueryRow(q, id, owner))
+	if err == sql.ErrNoRows {
+		return model.Watchlist{}, &util.NotExistingRecordError{Identification: fmt.Sprintf("ID: %v", id)}
+	}
+	if err != nil {
+		return model.Watchlist{}, err
+	}
+
+	return s.withEntries(w)
+}
+
+// GetSharedWatchlist returns the list shared by the token, leaving out its owner
+func (s *service) GetSharedWatchlist(token string) (model.Watchlist, error) {
+	const q = "SELECT " + columns + " FROM watchlists WHERE share_token = $1"
+	w, err := scanWatchlist(s.db.QueryRow(q, token))
+	if err == sql.ErrNoRows {
+		return model.Watchlist{}, &util.NotExistingRecordError{Identification: "share token"}
+	}
+	if err != nil {
+		return model.Watchlist{}, err
+	}
+
+	w.Owner = ""
+	return s.withEntries(w)
+}
+
+// CreateWatchlist inserts the list of its owner and sets its generated ID, the names of the lists of an owner are unique
+func (s *service) CreateWatchlist(ctx context.Context, w *model.Watchlist) error {
+	return util.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		const q = "INSERT INTO watchlists (owner, name) VALUES ($1, $2) RETURNING " + columns
+		created, err := scanWatchlist(tx.QueryRowContext(ctx, q, w.Owner, w.Name))
+		if util.IsViolation(err, util.UniqueViolation) {
+			return &util.ExistingRecordError{Identification: fmt.Sprintf("name: %s", w.Name)}
+		}
+		if err != nil {
+			return err
+		}
+		*w = created
+		return audit.Record(ctx, tx, audit.EntityWatchlist, w.ID, audit.ActionCreate, nil, redacted(*w))
+	})
+}
+
+func (s *service) RenameWatchlist(ctx context.Context, owner string, id int, name string) error {
+	return util.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		before, err := lockWatchlist(ctx, tx, owner, id)
+		if err != nil {
+			return err
+		}
+
+		const q = "UPDATE watchlists SET name = $1 WHERE id = $2 RETURNING " + columns
+		after, err := scanWatchlist(tx.QueryRowContext(ctx, q, name, id))
+		if util.IsViolation(err, util.UniqueViolation) {
+			return &util.ExistingRecordError{Identification: fmt.Sprintf("name: %s", name)}
+		}
+		if err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx, audit.EntityWatchlist, id, audit.ActionUpdate, redacted(before), redacted(after))
+	})
+}
+
+// DeleteWatchlist removes the list along with its entries
+func (s *service) DeleteWatchlist(ctx context.Context, owner string, id int) error {
+	return util.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		const q = "DELETE FROM watchlists WHERE id = $1 AND owner = $2 RETURNING " + columns
+		before, err := scanWatchlist(tx.QueryRowContext(ctx, q, id, owner))
+		if err == sql.ErrNoRows {
+			return &util.NotExistingRecordError{Identification: fmt.Sprintf("ID: %v", id)}
+		}
+		if err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx, audit.EntityWatchlist, id, audit.ActionDelete, redacted(before), nil)
+	})
+}
+
+// AddMovie appends the movie to the list, adding it again leaves it where it is.
+// Movies in the trash cannot be added.
+func (s *service) AddMovie(ctx context.Context, owner string, id, movieID int) error {
+	return util.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		// The list is locked while the next position is taken
+		if _, err := lockWatchlist(ctx, tx, owner, id); err != nil {
+			return err
+		}
+		if err := movie.Exists(tx, movieID); err != nil {
+			return err
+		}
+
+		const q = "INSERT INTO watchlist_entries (watchlist_id, movie_id, position) " +
+			"SELECT $1, $2, COALESCE(MAX(position), 0) + 1 FROM watchlist_entries WHERE watchlist_id = $1 " +
+			"ON CONFLICT DO NOTHING RETURNING position"
+		after := entryRecord{MovieID: movieID}
+		err := tx.QueryRowContext(ctx, q, id, movieID).Scan(&after.Position)
+		// Adding again leaves nothing to record
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx, audit.EntityWatchlistEntry, id, audit.ActionCreate, nil, after)
+	})
+}
+
+func (s *service) RemoveMovie(ctx context.Context, owner string, id, movieID int) error {
+	return util.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		if _, err := lockWatchlist(ctx, tx, owner, id); err != nil {
+			return err
+		}
+
+		const q = "DELETE FROM watchlist_entries WHERE watchlist_id = $1 AND movie_id = $2 RETURNING position"
+		before := entryRecord{MovieID: movieID}
+		err := tx.QueryRowContext(ctx, q, id, movieID).Scan(&before.Position)
+		if err == sql.ErrNoRows {
+			return &util.NotExistingRecordError{Identification: fmt.Sprintf("watchlist ID: %v, movie ID: %v", id, movieID)}
+		}
+		if err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx, audit.EntityWatchlistEntry, id, audit.ActionDelete, before, nil)
+	})
+}
+
+// ReorderMovies moves the movies of the list into the given order, which has to list every movie of the list once
+func (s *service) ReorderMovies(ctx context.Context, owner string, id int, movieIDs []int) error {
+	return util.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		if _, err := lockWatchlist(ctx, tx, owner, id); err != nil {
+			return err
+		}
+
+		const eq = "SELECT movie_id FROM watchlist_entries WHERE watchlist_id = $1 ORDER BY position, movie_id"
+		qr, err := tx.QueryContext(ctx, eq, id)
+		if err != nil {
+			return err
+		}
+		before := model.WatchlistOrder{MovieIDs: []int{}}
+		entries := make(map[int]bool)
+		for qr.Next() {
+			var movieID int
+			if err := qr.Scan(&movieID); err != nil {
+				qr.Close()
+				return err
+			}
+			before.MovieIDs = append(before.MovieIDs, movieID)
+			entries[movieID] = true
+		}
+		qr.Close()
+		if err := qr.Err(); err != nil {
+			return err
+		}
+
+		if len(movieIDs) != len(entries) {
+			return &util.MismatchedOrderError{Identification: fmt.Sprintf("watchlist ID: %v", id)}
+		}
+		for _, movieID := range movieIDs {
+			if !entries[movieID] {
+				return &util.MismatchedOrderError{Identification: fmt.Sprintf("watchlist ID: %v", id)}
+			}
+			// Listing a movie twice leaves another one out
+			delete(entries, movieID)
+		}
+
+		const q = "UPDATE watchlist_entries e SET position = o.position " +
+			"FROM unnest($2::integer[]) WITH ORDINALITY AS o(movie_id, position) " +
+			"WHERE e.watchlist_id = $1 AND e.movie_id = o.movie_id"
+		if _, err := tx.ExecContext(ctx, q, id, pq.Array(movieIDs)); err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx, audit.EntityWatchlistEntry, id, audit.ActionUpdate, before, model.WatchlistOrder{MovieIDs: movieIDs})
+	})
+}
+
+// ShareWatchlist returns the token sharing the list, which is generated when the list is shared first
+func (s *service) ShareWatchlist(ctx context.Context, owner string, id int) (string, error) {
+	token, err := newShareToken()
+	if err != nil {
+		return "", err
+	}
+
+	err = util.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		before, err := lockWatchlist(ctx, tx, owner, id)
+		if err != nil {
+			return err
+		}
+		if before.ShareToken != "" {
+			token = before.ShareToken
+			return nil
+		}
+
+		const q = "UPDATE watchlists SET share_token = $1 WHERE id = $2 RETURNING " + columns
+		after, err := scanWatchlist(tx.QueryRowContext(ctx, q, token, id))
+		if err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx, audit.EntityWatchlist, id, audit.ActionUpdate, redacted(before), redacted(after))
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// UnshareWatchlist revokes the token sharing the list, sharing it again generates a new one
+func (s *service) UnshareWatchlist(ctx context.Context, owner string, id int) error {
+	return util.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		before, err := lockWatchlist(ctx, tx, owner, id)
+		if err != nil {
+			return err
+		}
+		if before.ShareToken == "" {
+			return nil
+		}
+
+		const q = "UPDATE watchlists SET share_token = NULL WHERE id = $1 RETURNING " + columns
+		after, err := scanWatchlist(tx.QueryRowContext(ctx, q, id))
+		if err != nil {
+			return err
+		}
+		return audit.Record(ctx, tx, audit.EntityWatchlist, id, audit.ActionUpdate, redacted(before), redacted(after))
+	})
+}
+
+// withEntries reads the entries of the list in their order
+func (s *service) withEntries(w model.Watchlist) (model.Watchlist, error) {
+	const q = `SELECT e.movie_id, m.name, e.position, e.added_at, m.deleted_at IS NOT NULL ` +
+		`FROM watchlist_entries e JOIN movies m ON m.id = e.movie_id ` +
+		`WHERE e.watchlist_id = $1 ORDER BY e.position, e.movie_id`
+	qr, err := s.db.Query(q, w.ID)
+	if err != nil {
+		return model.Watchlist{}, err
+	}
+	defer qr.Close()
+
+	w.Entries = make([]model.WatchlistEntry, 0)
+	for qr.Next() {
+		e := model.WatchlistEntry{}
+		err = qr.Scan(&e.MovieID, &e.MovieName, &e.Position, &e.AddedAt, &e.Deleted)
+		if err != nil {
+			return model.Watchlist{}, err
+		}
+		w.Entries = append(w.Entries, e)
+	}
+
+	return w, qr.Err()
+}
+
+// lockWatchlist reads the list of the owner, which stays locked until the transaction ends
+func lockWatchlist(ctx context.Context, tx *sql.Tx, owner string, id int) (model.Watchlist, error) {
+	const q = "SELECT " + columns + " FROM watchlists WHERE id = $1 AND owner = $2 FOR UPDATE"
+	w, err := scanWatchlist(tx.QueryRowContext(ctx, q, id, owner))
+	if err == sql.ErrNoRows {
+		return model.Watchlist{}, &util.NotExistingRecordError{Identification: fmt.Sprintf("ID: %v", id)}
+	}
+	return w, err
+}
+
+// entryRecord is the audit record of an entry, which is recorded by the ID of its list
+type entryRecord struct {
+	MovieID  int `json:"movie_id"`
+	Position int `json:"position"`
+}
+
+// redacted hides the share token of the list from the audit log, which would otherwise share the list with its readers
+func redacted(w model.Watchlist) model.Watchlist {
+	if w.ShareToken != "" {
+		w.ShareToken = "[redacted]"
+	}
+	return w
+}
+
+// scanWatchlist reads a list of the columns
+func scanWatchlist(row util.Scanner) (model.Watchlist, error) {
+	w := model.Watchlist{}
+	err := row.Scan(&w.ID, &w.Owner, &w.Name, &w.ShareToken, &w.CreatedAt)
+	return w, err
+}
+
+// newShareToken generates a token which cannot be guessed
+func newShareToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,341 @@
+package watchlist_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+	"github.com/Hunterlemming/golang-microservice-example/api/watchlist"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	GetWatchlistsQuery   = `^SELECT id, owner, name, COALESCE\(share_token, ''\), created_at FROM watchlists WHERE owner = \$1 ORDER BY id$`
+	GetWatchlistQuery    = `^SELECT .+ FROM watchlists WHERE id = \$1 AND owner = \$2$`
+	GetSharedQuery       = `^SELECT .+ FROM watchlists WHERE share_token = \$1$`
+	GetEntriesQuery      = `^SELECT e.movie_id, m.name, e.position, e.added_at, m.deleted_at IS NOT NULL FROM watchlist_entries e JOIN movies m .+ ORDER BY e.position, e.movie_id$`
+	CreateWatchlistQuery = `^INSERT INTO watchlists \(owner, name\) VALUES \(\$1, \$2\) RETURNING .+$`
+	LockWatchlistQuery   = `^SELECT .+ FROM watchlists WHERE id = \$1 AND owner = \$2 FOR UPDATE$`
+	CheckMovieQuery      = `^SELECT id FROM movies WHERE id = \$1 AND deleted_at IS NULL$`
+	AddMovieQuery        = `^INSERT INTO watchlist_entries \(watchlist_id, movie_id, position\) SELECT \$1, \$2, COALESCE\(MAX\(position\), 0\) \+ 1 .+ ON CONFLICT DO NOTHING RETURNING position$`
+	RemoveMovieQuery     = `^DELETE FROM watchlist_entries WHERE watchlist_id = \$1 AND movie_id = \$2 RETURNING position$`
+	EntryIDsQuery        = `^SELECT movie_id FROM watchlist_entries WHERE watchlist_id = \$1 ORDER BY position, movie_id$`
+	ReorderQuery         = `^UPDATE watchlist_entries e SET position = o.position FROM unnest\(\$2::integer\[\]\) WITH ORDINALITY .+$`
+	ShareQuery           = `^UPDATE watchlists SET share_token = \$1 WHERE id = \$2 RETURNING .+$`
+	AuditQuery           = `^INSERT INTO audit_log \(.+\) VALUES \(.+\)$`
+)
+
+var created = time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+func TestServiceGetWatchlists(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	lists := []model.Watchlist{{ID: 1, Owner: "alice", Name: "Weekend", CreatedAt: created}}
+	mock.ExpectQuery(GetWatchlistsQuery).WithArgs("alice").WillReturnRows(newRows(&lists))
+
+	res, err := service.GetWatchlists("alice")
+
+	assert.Equal(t, lists, res)
+	assert.Equal(t, nil, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceGetWatchlist(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectQuery(GetWatchlistQuery).WithArgs(1, "alice").
+		WillReturnRows(newRows(&[]model.Watchlist{{ID: 1, Owner: "alice", Name: "Weekend", CreatedAt: created}}))
+	mock.ExpectQuery(GetEntriesQuery).WithArgs(1).WillReturnRows(
+		sqlmock.NewRows([]string{"movie_id", "name", "position", "added_at", "deleted"}).
+			AddRow(7, "test7", 1, created, false).
+			AddRow(8, "test8", 2, created, true))
+
+	res, err := service.GetWatchlist("alice", 1)
+
+	expected := model.Watchlist{ID: 1, Owner: "alice", Name: "Weekend", CreatedAt: created, Entries: []model.WatchlistEntry{
+		{MovieID: 7, MovieName: "test7", Position: 1, AddedAt: created},
+		{MovieID: 8, MovieName: "test8", Position: 2, AddedAt: created, Deleted: true},
+	}}
+	assert.Equal(t, nil, err)
+	assert.Equal(t, expected, res, "Movies in the trash should be flagged as deleted")
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceGetWatchlistOfOtherOwnerError(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectQuery(GetWatchlistQuery).WithArgs(1, "bob").WillReturnRows(newRows(&[]model.Watchlist{}))
+
+	_, err := service.GetWatchlist("bob", 1)
+
+	assert.IsType(t, &util.NotExistingRecordError{}, err)
+}
+
+func TestServiceGetSharedWatchlist(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectQuery(GetSharedQuery).WithArgs("secret").
+		WillReturnRows(newRows(&[]model.Watchlist{{ID: 1, Owner: "alice", Name: "Weekend", ShareToken: "secret", CreatedAt: created}}))
+	mock.ExpectQuery(GetEntriesQuery).WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"movie_id", "name", "position", "added_at", "deleted"}))
+
+	res, err := service.GetSharedWatchlist("secret")
+
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "", res.Owner, "The owner of a shared list should be left out")
+	assert.Equal(t, []model.WatchlistEntry{}, res.Entries)
+}
+
+func TestServiceCreateWatchlist(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(CreateWatchlistQuery).WithArgs("alice", "Weekend").
+		WillReturnRows(newRows(&[]model.Watchlist{{ID: 1, Owner: "alice", Name: "Weekend", CreatedAt: created}}))
+	mock.ExpectExec(AuditQuery).
+		WithArgs("watchlist", 1, "create", "tester", "test-request", nil, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	w := &model.Watchlist{Owner: "alice", Name: "Weekend"}
+	err := service.CreateWatchlist(auditContext(), w)
+
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, w.ID, "The generated ID should be set")
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceCreateWatchlistExistingError(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(CreateWatchlistQuery).WithArgs("alice", "Weekend").WillReturnError(&pq.Error{Code: util.UniqueViolation})
+	mock.ExpectRollback()
+
+	err := service.CreateWatchlist(auditContext(), &model.Watchlist{Owner: "alice", Name: "Weekend"})
+
+	assert.IsType(t, &util.ExistingRecordError{}, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceAddMovie(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(LockWatchlistQuery).WithArgs(1, "alice").
+		WillReturnRows(newRows(&[]model.Watchlist{{ID: 1, Owner: "alice", Name: "Weekend", CreatedAt: created}}))
+	mock.ExpectQuery(CheckMovieQuery).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectQuery(AddMovieQuery).WithArgs(1, 7).WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow(3))
+	mock.ExpectExec(AuditQuery).
+		WithArgs("watchlist_entry", 1, "create", "tester", "test-request", nil, `{"movie_id":7,"position":3}`, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err := service.AddMovie(auditContext(), "alice", 1, 7)
+
+	assert.Equal(t, nil, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceAddMovieAgain(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(LockWatchlistQuery).WithArgs(1, "alice").
+		WillReturnRows(newRows(&[]model.Watchlist{{ID: 1, Owner: "alice", Name: "Weekend", CreatedAt: created}}))
+	mock.ExpectQuery(CheckMovieQuery).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectQuery(AddMovieQuery).WithArgs(1, 7).WillReturnRows(sqlmock.NewRows([]string{"position"}))
+	mock.ExpectCommit()
+
+	err := service.AddMovie(auditContext(), "alice", 1, 7)
+
+	assert.Equal(t, nil, err, "Adding the movie again should leave nothing to record")
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceAddMovieTrashedMovieError(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(LockWatchlistQuery).WithArgs(1, "alice").
+		WillReturnRows(newRows(&[]model.Watchlist{{ID: 1, Owner: "alice", Name: "Weekend", CreatedAt: created}}))
+	mock.ExpectQuery(CheckMovieQuery).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+
+	err := service.AddMovie(auditContext(), "alice", 1, 7)
+
+	assert.IsType(t, &util.NotExistingRecordError{}, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceRemoveMovie(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(LockWatchlistQuery).WithArgs(1, "alice").
+		WillReturnRows(newRows(&[]model.Watchlist{{ID: 1, Owner: "alice", Name: "Weekend", CreatedAt: created}}))
+	mock.ExpectQuery(RemoveMovieQuery).WithArgs(1, 7).WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow(2))
+	mock.ExpectExec(AuditQuery).
+		WithArgs("watchlist_entry", 1, "delete", "tester", "test-request", `{"movie_id":7,"position":2}`, nil, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err := service.RemoveMovie(auditContext(), "alice", 1, 7)
+
+	assert.Equal(t, nil, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceRemoveMovieNotExistingError(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(LockWatchlistQuery).WithArgs(1, "alice").
+		WillReturnRows(newRows(&[]model.Watchlist{{ID: 1, Owner: "alice", Name: "Weekend", CreatedAt: created}}))
+	mock.ExpectQuery(RemoveMovieQuery).WithArgs(1, 7).WillReturnRows(sqlmock.NewRows([]string{"position"}))
+	mock.ExpectRollback()
+
+	err := service.RemoveMovie(auditContext(), "alice", 1, 7)
+
+	assert.IsType(t, &util.NotExistingRecordError{}, err)
+}
+
+func TestServiceReorderMovies(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(LockWatchlistQuery).WithArgs(1, "alice").
+		WillReturnRows(newRows(&[]model.Watchlist{{ID: 1, Owner: "alice", Name: "Weekend", CreatedAt: created}}))
+	mock.ExpectQuery(EntryIDsQuery).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"movie_id"}).AddRow(7).AddRow(8))
+	mock.ExpectExec(ReorderQuery).WithArgs(1, sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(AuditQuery).
+		WithArgs("watchlist_entry", 1, "update", "tester", "test-request", `{"movie_ids":[7,8]}`, `{"movie_ids":[8,7]}`,
+			`{"movie_ids":{"from":[7,8],"to":[8,7]}}`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err := service.ReorderMovies(auditContext(), "alice", 1, []int{8, 7})
+
+	assert.Equal(t, nil, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceReorderMoviesMismatchedError(t *testing.T) {
+	for _, order := range [][]int{{7}, {7, 7}, {7, 9}, {7, 8, 9}} {
+		service, mock, db := initNewService(t)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(LockWatchlistQuery).WithArgs(1, "alice").
+			WillReturnRows(newRows(&[]model.Watchlist{{ID: 1, Owner: "alice", Name: "Weekend", CreatedAt: created}}))
+		mock.ExpectQuery(EntryIDsQuery).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"movie_id"}).AddRow(7).AddRow(8))
+		mock.ExpectRollback()
+
+		err := service.ReorderMovies(auditContext(), "alice", 1, order)
+
+		assert.IsType(t, &util.MismatchedOrderError{}, err, "The order %v should not match the list", order)
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+		db.Close()
+	}
+}
+
+func TestServiceShareWatchlist(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(LockWatchlistQuery).WithArgs(1, "alice").
+		WillReturnRows(newRows(&[]model.Watchlist{{ID: 1, Owner: "alice", Name: "Weekend", CreatedAt: created}}))
+	mock.ExpectQuery(ShareQuery).WithArgs(sqlmock.AnyArg(), 1).
+		WillReturnRows(newRows(&[]model.Watchlist{{ID: 1, Owner: "alice", Name: "Weekend", ShareToken: "generated", CreatedAt: created}}))
+	mock.ExpectExec(AuditQuery).
+		WithArgs("watchlist", 1, "update", "tester", "test-request", sqlmock.AnyArg(), sqlmock.AnyArg(),
+			`{"share_token":{"from":null,"to":"[redacted]"}}`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	token, err := service.ShareWatchlist(auditContext(), "alice", 1)
+
+	assert.Equal(t, nil, err)
+	assert.Len(t, token, 48)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceShareWatchlistShared(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(LockWatchlistQuery).WithArgs(1, "alice").
+		WillReturnRows(newRows(&[]model.Watchlist{{ID: 1, Owner: "alice", Name: "Weekend", ShareToken: "secret", CreatedAt: created}}))
+	mock.ExpectCommit()
+
+	token, err := service.ShareWatchlist(auditContext(), "alice", 1)
+
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "secret", token, "The current token should be kept")
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func initNewService(t *testing.T) (watchlist.WatchlistService, sqlmock.Sqlmock, *sql.DB) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	service := watchlist.NewWatchlistService(db)
+	return service, mock, db
+}
+
+func auditContext() context.Context {
+	return util.WithRequestID(util.WithActor(context.Background(), "tester"), "test-request")
+}
+
+func newRows(lists *[]model.Watchlist) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"id", "owner", "name", "share_token", "created_at"})
+	for _, w := range *lists {
+		rows.AddRow(w.ID, w.Owner, w.Name, w.ShareToken, w.CreatedAt)
+	}
+	return rows
+}
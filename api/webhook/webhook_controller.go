@@ -0,0 +1,271 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/event"
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+
+	"github.com/gorilla/mux"
+)
+
+type controller struct {
+	service WebhookService
+}
+
+type WebhookController interface {
+	GetSubscriptions(w http.ResponseWriter, r *http.Request)
+	GetSubscription(w http.ResponseWriter, r *http.Request)
+	CreateSubscription(w http.ResponseWriter, r *http.Request)
+	UpdateSubscription(w http.ResponseWriter, r *http.Request)
+	DeleteSubscription(w http.ResponseWriter, r *http.Request)
+	GetDeliveries(w http.ResponseWriter, r *http.Request)
+	GetDeadLetters(w http.ResponseWriter, r *http.Request)
+}
+
+func NewWebhookController(s WebhookService) WebhookController {
+	return &controller{
+		service: s,
+	}
+}
+
+func (c *controller) GetSubscriptions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to GetSubscriptions", r.Method))
+		return
+	}
+
+	enc, err := util.NewResponseEncoder(r, true)
+	if err != nil {
+		util.HandleNotAcceptable(w, err)
+		return
+	}
+
+	subscriptions, err := c.service.GetSubscriptions()
+	if err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	// The secrets are write-only
+	for i := range subscriptions {
+		subscriptions[i].Secret = ""
+	}
+	util.WriteResponse(w, enc, http.StatusOK, subscriptions)
+}
+
+func (c *controller) GetSubscription(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to GetSubscription", r.Method))
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 0)
+	if err != nil {
+		util.HandleBadRequest(w, "Invalid ID", err.Error())
+		return
+	}
+
+	enc, err := util.NewResponseEncoder(r, false)
+	if err != nil {
+		util.HandleNotAcceptable(w, err)
+		return
+	}
+
+	subscription, err := c.service.GetSubscription(int(id))
+	if err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	subscription.Secret = ""
+	util.WriteResponse(w, enc, http.StatusOK, subscription)
+}
+
+func (c *controller) CreateSubscription(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to CreateSubscription", r.Method))
+		return
+	}
+
+	if !requireActor(w, r) {
+		return
+	}
+
+	// Extracting Subscription object from request-body
+	s, err := parseValidSubscription(r)
+	if err != nil {
+		util.HandleInvalidBody(w, err)
+		return
+	}
+
+	enc, err := util.NewResponseEncoder(r, false)
+	if err != nil {
+		util.HandleNotAcceptable(w, err)
+		return
+	}
+
+	if err := c.service.CreateSubscription(s); err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	created := *s
+	created.Secret = ""
+	util.WriteResponse(w, enc, http.StatusCreated, created)
+}
+
+func (c *controller) UpdateSubscription(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to UpdateSubscription", r.Method))
+		return
+	}
+
+	if !requireActor(w, r) {
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 0)
+	if err != nil {
+		util.HandleBadRequest(w, "Invalid ID", err.Error())
+		return
+	}
+
+	// Extracting Subscription object from request-body
+	s, err := parseValidSubscription(r)
+	if err != nil {
+		util.HandleInvalidBody(w, err)
+		return
+	}
+
+	if err := c.service.UpdateSubscription(int(id), s); err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	fmt.Fprintln(w, "success")
+}
+
+func (c *controller) DeleteSubscription(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to DeleteSubscription", r.Method))
+		return
+	}
+
+	if !requireActor(w, r) {
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 0)
+	if err != nil {
+		util.HandleBadRequest(w, "Invalid ID", err.Error())
+		return
+	}
+
+	if err := c.service.DeleteSubscription(int(id)); err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *controller) GetDeliveries(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to GetDeliveries", r.Method))
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 0)
+	if err != nil {
+		util.HandleBadRequest(w, "Invalid ID", err.Error())
+		return
+	}
+
+	enc, err := util.NewResponseEncoder(r, true)
+	if err != nil {
+		util.HandleNotAcceptable(w, err)
+		return
+	}
+
+	// Returning 404 for unknown subscriptions rather than an empty log
+	if _, err := c.service.GetSubscription(int(id)); err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	deliveries, err := c.service.GetDeliveries(int(id))
+	if err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	util.WriteResponse(w, enc, http.StatusOK, deliveries)
+}
+
+func (c *controller) GetDeadLetters(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		util.HandleMethodNotAllowed(w, fmt.Sprintf("%s method to GetDeadLetters", r.Method))
+		return
+	}
+
+	enc, err := util.NewResponseEncoder(r, true)
+	if err != nil {
+		util.HandleNotAcceptable(w, err)
+		return
+	}
+
+	deliveries, err := c.service.GetDeadLetters()
+	if err != nil {
+		util.HandleServiceError(w, err)
+		return
+	}
+
+	util.WriteResponse(w, enc, http.StatusOK, deliveries)
+}
+
+// requireActor answers 401 for anonymous requests, as the server posts the events to whichever receiver is subscribed
+func requireActor(w http.ResponseWriter, r *http.Request) bool {
+	if util.ActorFromContext(r.Context()) == util.AnonymousActor {
+		util.HandleUnauthorized(w, "Anonymous webhook request")
+		return false
+	}
+	return true
+}
+
+func parseValidSubscription(r *http.Request) (*model.Subscription, error) {
+	var s model.Subscription
+
+	// Return if the request-body cannot be decoded into a Subscription object
+	if err := util.DecodeRequest(r, &s); err != nil {
+		return nil, err
+	}
+
+	// Return if the requested Subscription object is invalid
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	if err := CheckTarget(s.URL); err != nil {
+		return nil, err
+	}
+	for _, t := range s.EventTypes {
+		if !isEventType(t) {
+			return nil, errors.New("unknown event type " + t)
+		}
+	}
+
+	return &s, nil
+}
+
+func isEventType(t string) bool {
+	for _, known := range event.Types {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,216 @@
+package webhook_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+	"github.com/Hunterlemming/golang-microservice-example/api/webhook"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Defining the mock WebhookService
+type mockServiceStruct struct {
+	mock.Mock
+}
+
+func (s *mockServiceStruct) GetSubscriptions() ([]model.Subscription, error) {
+	args := s.Called()
+	return args.Get(0).([]model.Subscription), args.Error(1)
+}
+
+func (s *mockServiceStruct) GetSubscription(id int) (model.Subscription, error) {
+	args := s.Called(id)
+	return args.Get(0).(model.Subscription), args.Error(1)
+}
+
+func (s *mockServiceStruct) GetSubscriptionsFor(eventType string) ([]model.Subscription, error) {
+	args := s.Called(eventType)
+	return args.Get(0).([]model.Subscription), args.Error(1)
+}
+
+func (s *mockServiceStruct) CreateSubscription(sub *model.Subscription) error {
+	args := s.Called(sub)
+	return args.Error(0)
+}
+
+func (s *mockServiceStruct) UpdateSubscription(id int, sub *model.Subscription) error {
+	args := s.Called(id, sub)
+	return args.Error(0)
+}
+
+func (s *mockServiceStruct) DeleteSubscription(id int) error {
+	args := s.Called(id)
+	return args.Error(0)
+}
+
+func (s *mockServiceStruct) GetDeliveries(subscriptionID int) ([]model.Delivery, error) {
+	args := s.Called(subscriptionID)
+	return args.Get(0).([]model.Delivery), args.Error(1)
+}
+
+func (s *mockServiceStruct) GetDeadLetters() ([]model.Delivery, error) {
+	args := s.Called()
+	return args.Get(0).([]model.Delivery), args.Error(1)
+}
+
+func (s *mockServiceStruct) EnqueueDeliveries(e model.Event, payload []byte) error {
+	args := s.Called(e, payload)
+	return args.Error(0)
+}
+
+func (s *mockServiceStruct) ClaimDeliveries(limit int, lease time.Duration) ([]webhook.PendingDelivery, error) {
+	args := s.Called(limit, lease)
+	return args.Get(0).([]webhook.PendingDelivery), args.Error(1)
+}
+
+func (s *mockServiceStruct) UpdateDelivery(d *model.Delivery) error {
+	args := s.Called(d)
+	return args.Error(0)
+}
+
+// Setting up mockService and controller
+var mockService = new(mockServiceStruct)
+var controller = webhook.NewWebhookController(mockService)
+
+func TestControllerGetSubscriptionsHidesSecrets(t *testing.T) {
+	mockService.On("GetSubscriptions").Return([]model.Subscription{newSubscription(1, "http://a.test")}, nil).Once()
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	rr := execute("/", []string{"GET"}, req, controller.GetSubscriptions)
+
+	status := http.StatusOK
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+	assert.NotContains(t, rr.Body.String(), "secret")
+}
+
+func TestControllerGetSubscriptionNotFoundError(t *testing.T) {
+	mockService.On("GetSubscription", 2).Return(model.Subscription{}, &util.NotExistingRecordError{Identification: "ID: 2"}).Once()
+
+	req, _ := http.NewRequest("GET", "/2", nil)
+	rr := execute("/{id}", []string{"GET"}, req, controller.GetSubscription)
+
+	status := http.StatusNotFound
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerCreateSubscription(t *testing.T) {
+	sub := model.Subscription{URL: "https://partner.test/hook", EventTypes: []string{"MovieCreated"}, Secret: "s3cret"}
+	subBytes, _ := json.Marshal(sub)
+	mockService.On("CreateSubscription", &sub).Return(nil).Once()
+
+	req, _ := http.NewRequest("POST", "/", bytes.NewBuffer(subBytes))
+	rr := execute("/", []string{"POST"}, asPartner(req), controller.CreateSubscription)
+
+	if !mockService.AssertCalled(t, "CreateSubscription", &sub) {
+		t.Error("The service should be called")
+	}
+	status := http.StatusCreated
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+	assert.NotContains(t, rr.Body.String(), "s3cret")
+}
+
+func TestControllerCreateSubscriptionBodyParsingError(t *testing.T) {
+	for _, sub := range []model.Subscription{
+		{URL: "not a url", EventTypes: []string{"MovieCreated"}, Secret: "s"},
+		{URL: "https://partner.test/hook", EventTypes: []string{"MovieWatched"}, Secret: "s"},
+		{URL: "https://partner.test/hook", EventTypes: []string{"MovieCreated"}},
+		{URL: "http://127.0.0.1:8080/admin", EventTypes: []string{"MovieCreated"}, Secret: "s"},
+		{URL: "http://10.0.0.5/hook", EventTypes: []string{"MovieCreated"}, Secret: "s"},
+		{URL: "http://169.254.169.254/latest/meta-data", EventTypes: []string{"MovieCreated"}, Secret: "s"},
+		{URL: "http://[::1]/hook", EventTypes: []string{"MovieCreated"}, Secret: "s"},
+		{URL: "http://localhost/hook", EventTypes: []string{"MovieCreated"}, Secret: "s"},
+	} {
+		subBytes, _ := json.Marshal(sub)
+		req, _ := http.NewRequest("POST", "/", bytes.NewBuffer(subBytes))
+		rr := execute("/", []string{"POST"}, asPartner(req), controller.CreateSubscription)
+
+		status := http.StatusBadRequest
+		assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d] for %s", status, sub.URL))
+	}
+}
+
+func TestControllerCreateSubscriptionUnauthorizedError(t *testing.T) {
+	sub := model.Subscription{URL: "https://partner.test/hook", EventTypes: []string{"MovieCreated"}, Secret: "s3cret"}
+	subBytes, _ := json.Marshal(sub)
+
+	req, _ := http.NewRequest("POST", "/", bytes.NewBuffer(subBytes))
+	rr := execute("/", []string{"POST"}, req, controller.CreateSubscription)
+
+	status := http.StatusUnauthorized
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerUpdateSubscriptionServiceError(t *testing.T) {
+	sub := model.Subscription{URL: "https://partner.test/hook", EventTypes: []string{"MovieCreated"}, Secret: "s"}
+	subBytes, _ := json.Marshal(sub)
+	mockService.On("UpdateSubscription", 1, mock.Anything).Return(errors.New("test-error-message")).Once()
+
+	req, _ := http.NewRequest("PUT", "/1", bytes.NewBuffer(subBytes))
+	rr := execute("/{id}", []string{"PUT"}, asPartner(req), controller.UpdateSubscription)
+
+	status := http.StatusInternalServerError
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerDeleteSubscription(t *testing.T) {
+	mockService.On("DeleteSubscription", 1).Return(nil).Once()
+
+	req, _ := http.NewRequest("DELETE", "/1", nil)
+	rr := execute("/{id}", []string{"DELETE"}, asPartner(req), controller.DeleteSubscription)
+
+	status := http.StatusNoContent
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+}
+
+func TestControllerGetDeliveries(t *testing.T) {
+	deliveries := []model.Delivery{newDelivery(1, model.DeliveryStatusDelivered)}
+	mockService.On("GetSubscription", 1).Return(newSubscription(1, "http://a.test"), nil).Once()
+	mockService.On("GetDeliveries", 1).Return(deliveries, nil).Once()
+
+	req, _ := http.NewRequest("GET", "/1/deliveries", nil)
+	rr := execute("/{id}/deliveries", []string{"GET"}, req, controller.GetDeliveries)
+
+	status := http.StatusOK
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+	assert.Equal(t, jsonString(deliveries), rr.Body.String())
+}
+
+func TestControllerGetDeadLetters(t *testing.T) {
+	deliveries := []model.Delivery{newDelivery(1, model.DeliveryStatusDead)}
+	mockService.On("GetDeadLetters").Return(deliveries, nil).Once()
+
+	req, _ := http.NewRequest("GET", "/dead-letters", nil)
+	rr := execute("/dead-letters", []string{"GET"}, req, controller.GetDeadLetters)
+
+	status := http.StatusOK
+	assert.Equal(t, status, rr.Code, fmt.Sprintf("Status code should be [%d]", status))
+	assert.Equal(t, jsonString(deliveries), rr.Body.String())
+}
+
+func asPartner(req *http.Request) *http.Request {
+	return req.WithContext(util.WithActor(req.Context(), "partner"))
+}
+
+func execute(route string, methods []string, req *http.Request, handler func(w http.ResponseWriter, r *http.Request)) *httptest.ResponseRecorder {
+	r := mux.NewRouter()
+	r.HandleFunc(route, handler).Methods(methods...)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	return rr
+}
+
+func jsonString(obj interface{}) string {
+	res, _ := json.Marshal(obj)
+	return string(res)
+}
@@ -0,0 +1,167 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/event"
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+)
+
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	EventHeader     = "X-Webhook-Event"
+	EventIDHeader   = "X-Webhook-Event-ID"
+)
+
+// How long a receiver may take to respond
+const deliveryTimeout = 10 * time.Second
+
+// Dispatcher queues the deliveries of the published events and attempts them apart from the outbox relay,
+// so slow or broken receivers hold back neither the relay nor each other.
+type Dispatcher struct {
+	service WebhookService
+	client  *http.Client
+	// How often a delivery is attempted before it is dead-lettered
+	MaxAttempts int
+	// Delay before the first retry, doubled for every further one
+	BaseDelay time.Duration
+	// How often the due deliveries are polled, and how many of them are attempted at once
+	Interval  time.Duration
+	BatchSize int
+}
+
+var _ event.Publisher = (*Dispatcher)(nil)
+
+// NewDispatcher delivers with the client, which by default refuses to connect to private, loopback and link-local addresses
+func NewDispatcher(s WebhookService, client *http.Client) *Dispatcher {
+	if client == nil {
+		client = newClient(deliveryTimeout)
+	}
+	return &Dispatcher{
+		service:     s,
+		client:      client,
+		MaxAttempts: 5,
+		BaseDelay:   time.Second,
+		Interval:    time.Second,
+		BatchSize:   50,
+	}
+}
+
+// Sign returns the value of the signature header for the body, the hex-encoded HMAC-SHA256 of the secret
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Publish queues a delivery of the event for every subscription of its type, which are attempted by Run
+func (d *Dispatcher) Publish(ctx context.Context, e model.Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return d.service.EnqueueDeliveries(e, body)
+}
+
+// Run attempts the due deliveries until the context is cancelled
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := d.ProcessDue(ctx); err != nil {
+			log.Println("[Webhook Dispatcher Error] ", err.Error())
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessDue attempts the next batch of due deliveries at once and returns how many were delivered.
+// Failed deliveries are retried with exponential backoff, until they run out of attempts and are dead-lettered.
+func (d *Dispatcher) ProcessDue(ctx context.Context) (int, error) {
+	// The claims outlast the attempts, so no delivery is attempted twice at once
+	pending, err := d.service.ClaimDeliveries(d.BatchSize, 2*deliveryTimeout)
+	if err != nil {
+		return 0, err
+	}
+
+	var wg sync.WaitGroup
+	results := make([]model.Delivery, len(pending))
+	for i := range pending {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = d.attempt(ctx, pending[i])
+		}(i)
+	}
+	wg.Wait()
+
+	delivered := 0
+	for i := range results {
+		if err := d.service.UpdateDelivery(&results[i]); err != nil {
+			log.Println("[Webhook Delivery Log Error] ", err.Error())
+			continue
+		}
+		if results[i].Status == model.DeliveryStatusDelivered {
+			delivered++
+		}
+	}
+	return delivered, nil
+}
+
+// attempt posts the payload once, returning the delivery as it stands afterwards
+func (d *Dispatcher) attempt(ctx context.Context, p PendingDelivery) model.Delivery {
+	delivery := p.Delivery
+	delivery.Attempts++
+	delivery.NextAttemptAt = nil
+
+	code, err := d.post(ctx, p)
+	delivery.ResponseCode = code
+	switch {
+	case err == nil:
+		delivery.Status, delivery.Error = model.DeliveryStatusDelivered, ""
+	case delivery.Attempts >= d.MaxAttempts:
+		delivery.Status, delivery.Error = model.DeliveryStatusDead, err.Error()
+	default:
+		next := time.Now().Add(d.BaseDelay << (delivery.Attempts - 1))
+		delivery.Status, delivery.Error, delivery.NextAttemptAt = model.DeliveryStatusPending, err.Error(), &next
+	}
+	return delivery
+}
+
+func (d *Dispatcher) post(ctx context.Context, p PendingDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.URL, bytes.NewReader(p.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, p.EventType)
+	req.Header.Set(EventIDHeader, strconv.FormatInt(p.EventID, 10))
+	req.Header.Set(SignatureHeader, Sign(p.Secret, p.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("receiver responded with status [%d]", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,179 @@
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/webhook"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// receiver is a local webhook endpoint failing the first few requests
+type receiver struct {
+	mu       sync.Mutex
+	failures int
+	bodies   [][]byte
+	headers  []http.Header
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	body, _ := io.ReadAll(r.Body)
+	rc.bodies = append(rc.bodies, body)
+	rc.headers = append(rc.headers, r.Header.Clone())
+	if rc.failures > 0 {
+		rc.failures--
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func TestSign(t *testing.T) {
+	// Reference value computed with: echo -n '{}' | openssl dgst -sha256 -hmac secret
+	assert.Equal(t, "sha256=77325902caca812dc259733aacd046b73817372c777b8d95b402647474516e13", webhook.Sign("secret", []byte("{}")))
+}
+
+func TestDispatcherPublishQueuesDeliveries(t *testing.T) {
+	service := new(mockServiceStruct)
+	e := newEvent()
+	body, _ := json.Marshal(e)
+	service.On("EnqueueDeliveries", e, body).Return(nil).Once()
+
+	err := newDispatcher(service, nil).Publish(context.Background(), e)
+
+	assert.Nil(t, err)
+	service.AssertExpectations(t)
+}
+
+func TestDispatcherProcessDueSignsPayload(t *testing.T) {
+	rc := &receiver{}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	service := new(mockServiceStruct)
+	service.On("ClaimDeliveries", 50, mock.Anything).Return([]webhook.PendingDelivery{newPendingDelivery(server.URL, 0)}, nil).Once()
+	service.On("UpdateDelivery", mock.Anything).Return(nil).Once()
+
+	delivered, err := newDispatcher(service, server.Client()).ProcessDue(context.Background())
+
+	assert.Nil(t, err)
+	assert.Equal(t, 1, delivered)
+	assert.Equal(t, 1, len(rc.bodies))
+	assert.Equal(t, webhook.Sign("secret", rc.bodies[0]), rc.headers[0].Get(webhook.SignatureHeader))
+	assert.Equal(t, "MovieCreated", rc.headers[0].Get(webhook.EventHeader))
+	assert.Equal(t, "7", rc.headers[0].Get(webhook.EventIDHeader))
+	delivery := service.Calls[1].Arguments.Get(0).(*model.Delivery)
+	assert.Equal(t, model.DeliveryStatusDelivered, delivery.Status)
+	assert.Equal(t, http.StatusNoContent, delivery.ResponseCode)
+	assert.Nil(t, delivery.NextAttemptAt)
+}
+
+func TestDispatcherProcessDueRetries(t *testing.T) {
+	rc := &receiver{failures: 1}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	service := new(mockServiceStruct)
+	service.On("ClaimDeliveries", 50, mock.Anything).Return([]webhook.PendingDelivery{newPendingDelivery(server.URL, 1)}, nil).Once()
+	service.On("UpdateDelivery", mock.Anything).Return(nil).Once()
+
+	before := time.Now()
+	delivered, err := newDispatcher(service, server.Client()).ProcessDue(context.Background())
+
+	assert.Nil(t, err)
+	assert.Equal(t, 0, delivered)
+	delivery := service.Calls[1].Arguments.Get(0).(*model.Delivery)
+	assert.Equal(t, model.DeliveryStatusPending, delivery.Status, "The delivery should be retried later rather than right away")
+	assert.Equal(t, 2, delivery.Attempts)
+	assert.Equal(t, http.StatusInternalServerError, delivery.ResponseCode)
+	if assert.NotNil(t, delivery.NextAttemptAt) {
+		assert.True(t, !delivery.NextAttemptAt.Before(before.Add(2*time.Millisecond)), "The delay should double for every retry")
+	}
+}
+
+func TestDispatcherProcessDueDeadLetters(t *testing.T) {
+	rc := &receiver{failures: 10}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	service := new(mockServiceStruct)
+	service.On("ClaimDeliveries", 50, mock.Anything).Return([]webhook.PendingDelivery{newPendingDelivery(server.URL, 2)}, nil).Once()
+	service.On("UpdateDelivery", mock.Anything).Return(nil).Once()
+
+	_, err := newDispatcher(service, server.Client()).ProcessDue(context.Background())
+
+	assert.Nil(t, err, "A failing receiver should not fail the dispatcher")
+	delivery := service.Calls[1].Arguments.Get(0).(*model.Delivery)
+	assert.Equal(t, model.DeliveryStatusDead, delivery.Status)
+	assert.Equal(t, 3, delivery.Attempts)
+	assert.Nil(t, delivery.NextAttemptAt)
+}
+
+func TestDispatcherProcessDueAttemptsAtOnce(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer slow.Close()
+
+	service := new(mockServiceStruct)
+	pending := []webhook.PendingDelivery{newPendingDelivery(slow.URL, 0), newPendingDelivery(slow.URL, 0), newPendingDelivery(slow.URL, 0)}
+	service.On("ClaimDeliveries", 50, mock.Anything).Return(pending, nil).Once()
+	service.On("UpdateDelivery", mock.Anything).Return(nil).Times(3)
+
+	start := time.Now()
+	delivered, err := newDispatcher(service, slow.Client()).ProcessDue(context.Background())
+
+	assert.Nil(t, err)
+	assert.Equal(t, 3, delivered)
+	assert.Less(t, time.Since(start), 500*time.Millisecond, "Slow receivers should not hold back the other deliveries")
+}
+
+func TestDispatcherRefusesLoopbackReceivers(t *testing.T) {
+	rc := &receiver{}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	service := new(mockServiceStruct)
+	service.On("ClaimDeliveries", 50, mock.Anything).Return([]webhook.PendingDelivery{newPendingDelivery(server.URL, 0)}, nil).Once()
+	service.On("UpdateDelivery", mock.Anything).Return(nil).Once()
+
+	_, err := newDispatcher(service, nil).ProcessDue(context.Background())
+
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(rc.bodies), "The default client should not connect to loopback addresses")
+	delivery := service.Calls[1].Arguments.Get(0).(*model.Delivery)
+	assert.Contains(t, delivery.Error, "loopback")
+}
+
+func newDispatcher(service webhook.WebhookService, client *http.Client) *webhook.Dispatcher {
+	dispatcher := webhook.NewDispatcher(service, client)
+	dispatcher.MaxAttempts = 3
+	dispatcher.BaseDelay = time.Millisecond
+	return dispatcher
+}
+
+func newPendingDelivery(url string, attempts int) webhook.PendingDelivery {
+	return webhook.PendingDelivery{
+		Delivery: model.Delivery{ID: 5, SubscriptionID: 1, EventID: 7, EventType: "MovieCreated", Status: model.DeliveryStatusPending, Attempts: attempts},
+		URL:      url,
+		Secret:   "secret",
+		Payload:  []byte(`{"id":7,"event_type":"MovieCreated"}`),
+	}
+}
+
+func newEvent() model.Event {
+	return model.Event{ID: 7, Type: "MovieCreated", MovieID: 1, Payload: json.RawMessage(`{"id":1,"name":"test"}`), CreatedAt: createdAt}
+}
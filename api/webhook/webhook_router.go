@@ -0,0 +1,39 @@
+package webhook
+
+import (
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+
+	"github.com/gorilla/mux"
+)
+
+func InitializeWebhooksPipeline(api *model.Api) {
+	s := NewWebhookService(api.DB)
+	c := NewWebhookController(s)
+	setRouting(api.Router, c)
+}
+
+func setRouting(main *mux.Router, c WebhookController) {
+	sr := main.PathPrefix("/webhooks").Subrouter()
+
+	sr.HandleFunc("", c.GetSubscriptions).
+		Methods("GET")
+
+	// Registered ahead of "/{id}", which would match it otherwise
+	sr.HandleFunc("/dead-letters", c.GetDeadLetters).
+		Methods("GET")
+
+	sr.HandleFunc("/{id}", c.GetSubscription).
+		Methods("GET")
+
+	sr.HandleFunc("", c.CreateSubscription).
+		Methods("POST")
+
+	sr.HandleFunc("/{id}", c.UpdateSubscription).
+		Methods("PUT")
+
+	sr.HandleFunc("/{id}", c.DeleteSubscription).
+		Methods("DELETE")
+
+	sr.HandleFunc("/{id}/deliveries", c.GetDeliveries).
+		Methods("GET")
+}
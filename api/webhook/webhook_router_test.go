@@ -0,0 +1,40 @@
+package webhook_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/webhook"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInitializeWebhooksPipeline(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	api := model.Api{Router: mux.NewRouter(), DB: db}
+	webhook.InitializeWebhooksPipeline(&api)
+
+	testIntegrationGetDeadLetters(t, mock, api.Router)
+}
+
+func testIntegrationGetDeadLetters(t *testing.T, mock sqlmock.Sqlmock, r *mux.Router) {
+	deliveries := []model.Delivery{newDelivery(1, model.DeliveryStatusDead)}
+	mock.ExpectQuery(DeadLettersQuery).WithArgs("dead").WillReturnRows(newDeliveryRows(&deliveries))
+
+	req, _ := http.NewRequest("GET", "/webhooks/dead-letters", nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, jsonString(deliveries), rr.Body.String())
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
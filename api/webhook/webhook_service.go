@@ -0,0 +1,201 @@
+package webhook
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+
+	"github.com/lib/pq"
+)
+
+const (
+	subscriptionColumns = "id, url, event_types, secret, created_at"
+	deliveryColumns     = "id, subscription_id, event_id, event_type, status, attempts, response_code, error, created_at, next_attempt_at"
+)
+
+// PendingDelivery is a delivery claimed for its next attempt, along with where and what to deliver
+type PendingDelivery struct {
+	model.Delivery
+	URL     string
+	Secret  string
+	Payload []byte
+}
+
+type service struct {
+	db *sql.DB
+}
+
+type WebhookService interface {
+	GetSubscriptions() ([]model.Subscription, error)
+	GetSubscription(id int) (model.Subscription, error)
+	GetSubscriptionsFor(eventType string) ([]model.Subscription, error)
+	CreateSubscription(s *model.Subscription) error
+	UpdateSubscription(id int, s *model.Subscription) error
+	DeleteSubscription(id int) error
+	GetDeliveries(subscriptionID int) ([]model.Delivery, error)
+	GetDeadLetters() ([]model.Delivery, error)
+	EnqueueDeliveries(e model.Event, payload []byte) error
+	ClaimDeliveries(limit int, lease time.Duration) ([]PendingDelivery, error)
+	UpdateDelivery(d *model.Delivery) error
+}
+
+func NewWebhookService(db *sql.DB) WebhookService {
+	return &service{
+		db: db,
+	}
+}
+
+func (s *service) GetSubscriptions() ([]model.Subscription, error) {
+	const q = "SELECT " + subscriptionColumns + " FROM webhook_subscriptions ORDER BY id"
+	return s.querySubscriptions(q)
+}
+
+func (s *service) GetSubscription(id int) (model.Subscription, error) {
+	const q = "SELECT " + subscriptionColumns + " FROM webhook_subscriptions WHERE id = $1"
+	result, err := s.querySubscriptions(q, id)
+	if err != nil {
+		return model.Subscription{}, err
+	}
+	if len(result) == 0 {
+		return model.Subscription{}, &util.NotExistingRecordError{Identification: fmt.Sprintf("ID: %v", id)}
+	}
+	return result[0], nil
+}
+
+func (s *service) GetSubscriptionsFor(eventType string) ([]model.Subscription, error) {
+	const q = "SELECT " + subscriptionColumns + " FROM webhook_subscriptions WHERE $1 = ANY(event_types) ORDER BY id"
+	return s.querySubscriptions(q, eventType)
+}
+
+// CreateSubscription inserts the subscription and sets its generated ID and creation time
+func (s *service) CreateSubscription(sub *model.Subscription) error {
+	const q = "INSERT INTO webhook_subscriptions (url, event_types, secret) VALUES ($1, $2, $3) RETURNING id, created_at"
+	return s.db.QueryRow(q, sub.URL, pq.Array(sub.EventTypes), sub.Secret).Scan(&sub.ID, &sub.CreatedAt)
+}
+
+func (s *service) UpdateSubscription(id int, sub *model.Subscription) error {
+	const q = "UPDATE webhook_subscriptions SET url = $1, event_types = $2, secret = $3 WHERE id = $4"
+	return s.execAffectingRecord(q, sub.URL, pq.Array(sub.EventTypes), sub.Secret, id)
+}
+
+// DeleteSubscription removes the subscription together with its delivery log
+func (s *service) DeleteSubscription(id int) error {
+	const q = "DELETE FROM webhook_subscriptions WHERE id = $1"
+	return s.execAffectingRecord(q, id)
+}
+
+func (s *service) GetDeliveries(subscriptionID int) ([]model.Delivery, error) {
+	const q = "SELECT " + deliveryColumns + " FROM webhook_deliveries WHERE subscription_id = $1 ORDER BY id DESC"
+	return s.queryDeliveries(q, subscriptionID)
+}
+
+func (s *service) GetDeadLetters() ([]model.Delivery, error) {
+	const q = "SELECT " + deliveryColumns + " FROM webhook_deliveries WHERE status = $1 ORDER BY id DESC"
+	return s.queryDeliveries(q, model.DeliveryStatusDead)
+}
+
+// EnqueueDeliveries queues a pending delivery of the event for every subscription of its type.
+// Events published again are not queued again for the subscriptions already having a delivery of them.
+func (s *service) EnqueueDeliveries(e model.Event, payload []byte) error {
+	const q = "INSERT INTO webhook_deliveries " +
+		"(subscription_id, event_id, event_type, status, attempts, response_code, error, payload, next_attempt_at) " +
+		"SELECT s.id, $1, $2, $3, 0, 0, '', $4, now() FROM webhook_subscriptions s WHERE $2 = ANY(s.event_types) " +
+		"AND NOT EXISTS (SELECT 1 FROM webhook_deliveries d WHERE d.subscription_id = s.id AND d.event_id = $1)"
+	_, err := s.db.Exec(q, e.ID, e.Type, model.DeliveryStatusPending, payload)
+	return err
+}
+
+// ClaimDeliveries returns up to limit pending deliveries which are due, postponing them by the lease.
+// Deliveries whose attempt is not recorded within the lease, such as the ones of a crashed dispatcher, are claimed again.
+func (s *service) ClaimDeliveries(limit int, lease time.Duration) ([]PendingDelivery, error) {
+	const q = "UPDATE webhook_deliveries d SET next_attempt_at = now() + $2 * interval '1 millisecond' " +
+		"FROM webhook_subscriptions s WHERE s.id = d.subscription_id AND d.id IN (" +
+		"SELECT id FROM webhook_deliveries WHERE status = $3 AND next_attempt_at <= now() " +
+		"ORDER BY next_attempt_at, id LIMIT $1 FOR UPDATE SKIP LOCKED) " +
+		"RETURNING d.id, d.subscription_id, d.event_id, d.event_type, d.status, d.attempts, d.created_at, d.payload, s.url, s.secret"
+	qr, err := s.db.Query(q, limit, lease.Milliseconds(), model.DeliveryStatusPending)
+	if err != nil {
+		return []PendingDelivery{}, err
+	}
+	defer qr.Close()
+
+	result := make([]PendingDelivery, 0)
+	for qr.Next() {
+		p := PendingDelivery{}
+		err = qr.Scan(&p.ID, &p.SubscriptionID, &p.EventID, &p.EventType, &p.Status, &p.Attempts, &p.CreatedAt, &p.Payload, &p.URL, &p.Secret)
+		if err != nil {
+			return []PendingDelivery{}, err
+		}
+		result = append(result, p)
+	}
+
+	return result, qr.Err()
+}
+
+// UpdateDelivery records the outcome of an attempt, pending deliveries are attempted again at their next attempt time.
+// Only pending deliveries keep their payload, which is not needed anymore once they are delivered or dead.
+func (s *service) UpdateDelivery(d *model.Delivery) error {
+	const q = "UPDATE webhook_deliveries SET status = $1, attempts = $2, response_code = $3, error = $4, next_attempt_at = $5, " +
+		"payload = CASE WHEN $1 = $7 THEN payload ELSE NULL END WHERE id = $6"
+	return s.execAffectingRecord(q, d.Status, d.Attempts, d.ResponseCode, d.Error, d.NextAttemptAt, d.ID, model.DeliveryStatusPending)
+}
+
+func (s *service) querySubscriptions(q string, args ...interface{}) ([]model.Subscription, error) {
+	qr, err := s.db.Query(q, args...)
+	if err != nil {
+		return []model.Subscription{}, err
+	}
+	defer qr.Close()
+
+	result := make([]model.Subscription, 0)
+	for qr.Next() {
+		sub := model.Subscription{}
+		err = qr.Scan(&sub.ID, &sub.URL, pq.Array(&sub.EventTypes), &sub.Secret, &sub.CreatedAt)
+		if err != nil {
+			return []model.Subscription{}, err
+		}
+		result = append(result, sub)
+	}
+
+	return result, qr.Err()
+}
+
+func (s *service) queryDeliveries(q string, args ...interface{}) ([]model.Delivery, error) {
+	qr, err := s.db.Query(q, args...)
+	if err != nil {
+		return []model.Delivery{}, err
+	}
+	defer qr.Close()
+
+	result := make([]model.Delivery, 0)
+	for qr.Next() {
+		d := model.Delivery{}
+		err = qr.Scan(&d.ID, &d.SubscriptionID, &d.EventID, &d.EventType, &d.Status, &d.Attempts, &d.ResponseCode, &d.Error, &d.CreatedAt, &d.NextAttemptAt)
+		if err != nil {
+			return []model.Delivery{}, err
+		}
+		result = append(result, d)
+	}
+
+	return result, qr.Err()
+}
+
+// execAffectingRecord runs a statement whose last argument is the record id, failing if no record was affected
+func (s *service) execAffectingRecord(q string, args ...interface{}) error {
+	res, err := s.db.Exec(q, args...)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return &util.NotExistingRecordError{Identification: fmt.Sprintf("ID: %v", args[len(args)-1])}
+	}
+	return nil
+}
@@ -0,0 +1,247 @@
+package webhook_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+	"github.com/Hunterlemming/golang-microservice-example/api/webhook"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var createdAt = time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+const GetAllQuery = `^SELECT .+ FROM webhook_subscriptions ORDER BY id$`
+
+func TestServiceGetSubscriptions(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	subscriptions := []model.Subscription{newSubscription(1, "http://a.test"), newSubscription(2, "http://b.test")}
+	mock.ExpectQuery(GetAllQuery).WillReturnRows(newRows(&subscriptions))
+
+	res, err := service.GetSubscriptions()
+
+	assert.Equal(t, subscriptions, res)
+	assert.Equal(t, nil, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestServiceGetSubscriptionsQueryError(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	queryError := errors.New("test-error-message")
+	mock.ExpectQuery(GetAllQuery).WillReturnError(queryError)
+
+	res, err := service.GetSubscriptions()
+
+	assert.Equal(t, []model.Subscription{}, res)
+	assert.Equal(t, queryError, err)
+}
+
+const GetOneQuery = `^SELECT .+ FROM webhook_subscriptions WHERE id = \$1$`
+
+func TestServiceGetSubscriptionRecordDoesNotExistError(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectQuery(GetOneQuery).WithArgs(1).WillReturnRows(newRows(&[]model.Subscription{}))
+
+	_, err := service.GetSubscription(1)
+
+	assert.IsType(t, &util.NotExistingRecordError{}, err)
+}
+
+const GetForEventQuery = `^SELECT .+ FROM webhook_subscriptions WHERE \$1 = ANY\(event_types\) ORDER BY id$`
+
+func TestServiceGetSubscriptionsFor(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	subscriptions := []model.Subscription{newSubscription(1, "http://a.test")}
+	mock.ExpectQuery(GetForEventQuery).WithArgs("MovieCreated").WillReturnRows(newRows(&subscriptions))
+
+	res, err := service.GetSubscriptionsFor("MovieCreated")
+
+	assert.Equal(t, subscriptions, res)
+	assert.Equal(t, nil, err)
+}
+
+const CreateQuery = `^INSERT INTO webhook_subscriptions \(url, event_types, secret\) VALUES \(\$1, \$2, \$3\) RETURNING id, created_at$`
+
+func TestServiceCreateSubscription(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectQuery(CreateQuery).WithArgs("http://a.test", `{"MovieCreated"}`, "secret").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(3, createdAt))
+
+	sub := model.Subscription{URL: "http://a.test", EventTypes: []string{"MovieCreated"}, Secret: "secret"}
+	err := service.CreateSubscription(&sub)
+
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 3, sub.ID, "The generated ID should be set")
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+const UpdateQuery = `^UPDATE webhook_subscriptions SET url = \$1, event_types = \$2, secret = \$3 WHERE id = \$4$`
+
+func TestServiceUpdateSubscriptionRecordDoesNotExistError(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectExec(UpdateQuery).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := service.UpdateSubscription(1, &model.Subscription{URL: "http://a.test", EventTypes: []string{"MovieCreated"}, Secret: "s"})
+
+	assert.IsType(t, &util.NotExistingRecordError{}, err)
+}
+
+const DeleteQuery = `^DELETE FROM webhook_subscriptions WHERE id = \$1$`
+
+func TestServiceDeleteSubscription(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectExec(DeleteQuery).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := service.DeleteSubscription(1)
+
+	assert.Equal(t, nil, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+const DeliveriesQuery = `^SELECT .+ FROM webhook_deliveries WHERE subscription_id = \$1 ORDER BY id DESC$`
+const DeadLettersQuery = `^SELECT .+ FROM webhook_deliveries WHERE status = \$1 ORDER BY id DESC$`
+
+func TestServiceGetDeliveries(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	deliveries := []model.Delivery{newDelivery(1, model.DeliveryStatusDelivered)}
+	mock.ExpectQuery(DeliveriesQuery).WithArgs(1).WillReturnRows(newDeliveryRows(&deliveries))
+
+	res, err := service.GetDeliveries(1)
+
+	assert.Equal(t, deliveries, res)
+	assert.Equal(t, nil, err)
+}
+
+func TestServiceGetDeadLetters(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	deliveries := []model.Delivery{newDelivery(1, model.DeliveryStatusDead)}
+	mock.ExpectQuery(DeadLettersQuery).WithArgs("dead").WillReturnRows(newDeliveryRows(&deliveries))
+
+	res, err := service.GetDeadLetters()
+
+	assert.Equal(t, deliveries, res)
+	assert.Equal(t, nil, err)
+}
+
+const EnqueueDeliveriesQuery = `^INSERT INTO webhook_deliveries \(.+\) SELECT s.id, \$1, \$2, \$3, 0, 0, '', \$4, now\(\) ` +
+	`FROM webhook_subscriptions s WHERE \$2 = ANY\(s.event_types\) AND NOT EXISTS \(.+\)$`
+
+func TestServiceEnqueueDeliveries(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	payload := []byte(`{"id":7}`)
+	mock.ExpectExec(EnqueueDeliveriesQuery).WithArgs(int64(7), "MovieCreated", "pending", payload).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	err := service.EnqueueDeliveries(model.Event{ID: 7, Type: "MovieCreated"}, payload)
+
+	assert.Equal(t, nil, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+const ClaimDeliveriesQuery = `^UPDATE webhook_deliveries d SET next_attempt_at = now\(\) \+ \$2 \* interval '1 millisecond' ` +
+	`FROM webhook_subscriptions s WHERE .+ FOR UPDATE SKIP LOCKED\) RETURNING .+, s.url, s.secret$`
+
+func TestServiceClaimDeliveries(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectQuery(ClaimDeliveriesQuery).WithArgs(10, int64(20000), "pending").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "subscription_id", "event_id", "event_type", "status", "attempts", "created_at", "payload", "url", "secret"}).
+			AddRow(5, 1, 7, "MovieCreated", "pending", 1, createdAt, []byte(`{"id":7}`), "https://partner.test/hook", "secret"))
+
+	res, err := service.ClaimDeliveries(10, 20*time.Second)
+
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []webhook.PendingDelivery{{
+		Delivery: model.Delivery{ID: 5, SubscriptionID: 1, EventID: 7, EventType: "MovieCreated", Status: "pending", Attempts: 1, CreatedAt: createdAt},
+		URL:      "https://partner.test/hook",
+		Secret:   "secret",
+		Payload:  []byte(`{"id":7}`),
+	}}, res)
+}
+
+const UpdateDeliveryQuery = `^UPDATE webhook_deliveries SET status = \$1, attempts = \$2, response_code = \$3, error = \$4, next_attempt_at = \$5, ` +
+	`payload = CASE WHEN \$1 = \$7 THEN payload ELSE NULL END WHERE id = \$6$`
+
+// The payload is cleared once the delivery is not pending anymore
+func TestServiceUpdateDelivery(t *testing.T) {
+	service, mock, db := initNewService(t)
+	defer db.Close()
+
+	mock.ExpectExec(UpdateDeliveryQuery).WithArgs("delivered", 2, 200, "", nil, int64(5), "pending").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	d := model.Delivery{ID: 5, SubscriptionID: 1, EventID: 7, EventType: "MovieCreated", Status: "delivered", Attempts: 2, ResponseCode: 200}
+	err := service.UpdateDelivery(&d)
+
+	assert.Equal(t, nil, err)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func initNewService(t *testing.T) (webhook.WebhookService, sqlmock.Sqlmock, *sql.DB) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	service := webhook.NewWebhookService(db)
+	return service, mock, db
+}
+
+func newSubscription(id int, url string) model.Subscription {
+	return model.Subscription{ID: id, URL: url, EventTypes: []string{"MovieCreated", "MovieDeleted"}, Secret: "secret", CreatedAt: createdAt}
+}
+
+func newDelivery(id int64, status string) model.Delivery {
+	return model.Delivery{ID: id, SubscriptionID: 1, EventID: 7, EventType: "MovieCreated", Status: status, Attempts: 1, ResponseCode: 200, CreatedAt: createdAt}
+}
+
+func newRows(subscriptions *[]model.Subscription) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"id", "url", "event_types", "secret", "created_at"})
+	for _, s := range *subscriptions {
+		rows.AddRow(s.ID, s.URL, `{"`+s.EventTypes[0]+`","`+s.EventTypes[1]+`"}`, s.Secret, s.CreatedAt)
+	}
+	return rows
+}
+
+func newDeliveryRows(deliveries *[]model.Delivery) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"id", "subscription_id", "event_id", "event_type", "status", "attempts", "response_code", "error", "created_at", "next_attempt_at"})
+	for _, d := range *deliveries {
+		rows.AddRow(d.ID, d.SubscriptionID, d.EventID, d.EventType, d.Status, d.Attempts, d.ResponseCode, d.Error, d.CreatedAt, d.NextAttemptAt)
+	}
+	return rows
+}
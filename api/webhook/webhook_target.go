@@ -0,0 +1,57 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+var errForbiddenTarget = errors.New("webhooks cannot target private, loopback or link-local addresses")
+
+// CheckTarget rejects the URLs of subscriptions which target the network of the server rather than a partner.
+// Hostnames are not resolved here, the addresses they resolve to are checked again by the client when delivering.
+func CheckTarget(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return errForbiddenTarget
+	}
+	if ip := net.ParseIP(host); ip != nil && !isPublic(ip) {
+		return errForbiddenTarget
+	}
+	return nil
+}
+
+// newClient returns a client which refuses to connect to the addresses rejected by CheckTarget.
+// The address is checked when dialing, so hostnames cannot be rebound to such an address after they were checked.
+func newClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !isPublic(ip) {
+				return fmt.Errorf("dialing [%s]: %w", address, errForbiddenTarget)
+			}
+			return nil
+		},
+	}
+	// Proxies would be dialed instead of the receivers, so none are used
+	transport := &http.Transport{DialContext: dialer.DialContext, TLSHandshakeTimeout: timeout}
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+func isPublic(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified())
+}
@@ -0,0 +1,211 @@
+// Package client is a typed Go client of the movie API.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+)
+
+const (
+	defaultTimeout    = 30 * time.Second
+	defaultMaxRetries = 3
+	defaultBackoff    = 100 * time.Millisecond
+	// Upper bound of the delay between two attempts, including the ones asked for by the server
+	maxBackoff = 10 * time.Second
+)
+
+// Client calls the movie API. It is safe for concurrent use.
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	token      string
+	maxRetries int
+	backoff    time.Duration
+}
+
+type Option func(*Client)
+
+// WithHTTPClient sends the requests through the given client, to configure timeouts or transports
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+// WithToken authenticates the requests by the bearer token, they are anonymous otherwise
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// WithRetries sets how often idempotent requests are retried, and the delay before the first retry.
+// The delay doubles with every further retry.
+func WithRetries(maxRetries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.backoff = backoff
+	}
+}
+
+// NewClient returns a client of the API served at baseURL, such as "http://localhost:8080"
+func NewClient(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("base URL [%s] is not absolute", baseURL)
+	}
+
+	c := &Client{
+		baseURL:    u,
+		httpClient: &http.Client{Timeout: defaultTimeout},
+		maxRetries: defaultMaxRetries,
+		backoff:    defaultBackoff,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// do sends the request and decodes the JSON response into v, unless it is nil.
+// Idempotent requests are retried on transport errors and on statuses signalling a transient failure.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, v interface{}) (http.Header, error) {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return nil, err
+		}
+	}
+
+	attempts := 1
+	if isIdempotent(method) {
+		attempts += c.maxRetries
+	}
+
+	var delay time.Duration
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 {
+			if err := sleep(ctx, delay); err != nil {
+				return nil, err
+			}
+		}
+		delay = c.delay(attempt)
+
+		res, err := c.send(ctx, method, c.url(path, query), payload)
+		if err != nil {
+			if ctx.Err() != nil || attempt == attempts {
+				return nil, err
+			}
+			continue
+		}
+
+		if isTransient(res.StatusCode) && attempt < attempts {
+			if after := retryAfter(res); after > delay {
+				delay = after
+			}
+			io.Copy(io.Discard, res.Body)
+			res.Body.Close()
+			continue
+		}
+
+		defer res.Body.Close()
+		if res.StatusCode >= http.StatusBadRequest {
+			return nil, decodeStatusError(res)
+		}
+		if v != nil {
+			if err := json.NewDecoder(res.Body).Decode(v); err != nil {
+				return nil, err
+			}
+		}
+		return res.Header, nil
+	}
+}
+
+func (c *Client) send(ctx context.Context, method, u string, payload []byte) (*http.Response, error) {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", util.MediaTypeJSON)
+	if payload != nil {
+		req.Header.Set("Content-Type", util.MediaTypeJSON)
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	return c.httpClient.Do(req)
+}
+
+func (c *Client) url(path string, query url.Values) string {
+	u := *c.baseURL
+	u.Path = strings.TrimSuffix(u.Path, "/") + path
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// delay returns the exponential backoff following the attempt
+func (c *Client) delay(attempt int) time.Duration {
+	d := c.backoff << (attempt - 1)
+	if d > maxBackoff || d <= 0 {
+		return maxBackoff
+	}
+	return d
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+func isTransient(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryAfter reads the delay the server asked for in seconds, zero if it did not
+func retryAfter(res *http.Response) time.Duration {
+	seconds, err := strconv.Atoi(res.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	if d := time.Duration(seconds) * time.Second; d < maxBackoff {
+		return d
+	}
+	return maxBackoff
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,73 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+)
+
+// StatusError is a failed call which does not map to any error of the service, such as an invalid request
+type StatusError struct {
+	StatusCode int
+	Message    string
+	// The violations of invalid requests, each located by the path of the offending field
+	Violations []util.FieldError
+}
+
+func (e *StatusError) Error() string {
+	if len(e.Violations) == 0 {
+		return fmt.Sprintf("%d %s", e.StatusCode, e.Message)
+	}
+
+	violations := make([]string, len(e.Violations))
+	for i := range e.Violations {
+		violations[i] = e.Violations[i].Error()
+	}
+	return fmt.Sprintf("%d %s: %s", e.StatusCode, e.Message, strings.Join(violations, "; "))
+}
+
+func decodeStatusError(res *http.Response) error {
+	e := &StatusError{StatusCode: res.StatusCode, Message: http.StatusText(res.StatusCode)}
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1<<16))
+	if err != nil {
+		return e
+	}
+
+	// Invalid requests are described in detail, other errors by a line of text
+	if mediaType, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type")); mediaType == util.MediaTypeJSON {
+		var problem struct {
+			Message string            `json:"message"`
+			Errors  []util.FieldError `json:"errors"`
+		}
+		if json.Unmarshal(body, &problem) == nil {
+			e.Violations = problem.Errors
+			return e
+		}
+	}
+	if text := strings.TrimSpace(string(body)); text != "" {
+		e.Message = text
+	}
+	return e
+}
+
+// serviceError maps the statuses caused by errors of the service back to those errors, where the call has them
+func serviceError(err error, notFound, conflict error) error {
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		return err
+	}
+
+	switch {
+	case statusErr.StatusCode == http.StatusNotFound && notFound != nil:
+		return notFound
+	case statusErr.StatusCode == http.StatusConflict && conflict != nil:
+		return conflict
+	}
+	return err
+}
@@ -0,0 +1,98 @@
+package client_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/client"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// flaky fails the first failures requests with the status before passing them on
+func flaky(failures int32, status int, next http.Handler) (http.Handler, *int32) {
+	var calls int32
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) <= failures {
+			http.Error(w, http.StatusText(status), status)
+			return
+		}
+		next.ServeHTTP(w, r)
+	}), &calls
+}
+
+func TestNewClientInvalidBaseURL(t *testing.T) {
+	_, err := client.NewClient("localhost:8080")
+
+	assert.NotNil(t, err, "Base URLs without a scheme should be rejected")
+}
+
+func TestClientRetriesIdempotentCalls(t *testing.T) {
+	handler, calls := flaky(2, http.StatusServiceUnavailable, newServer(t, model.Movie{ID: 1, Name: "first"}).Config.Handler)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	c := newClient(t, server, client.WithRetries(3, time.Millisecond))
+
+	m, err := c.GetMovie(context.Background(), 1)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "first", m.Name)
+	assert.Equal(t, int32(3), atomic.LoadInt32(calls))
+}
+
+func TestClientGivesUpAfterRetries(t *testing.T) {
+	handler, calls := flaky(10, http.StatusBadGateway, http.NotFoundHandler())
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	c := newClient(t, server, client.WithRetries(2, time.Millisecond))
+
+	_, err := c.ListMovies(context.Background())
+
+	assert.IsType(t, &client.StatusError{}, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(calls))
+}
+
+func TestClientDoesNotRetryCreate(t *testing.T) {
+	handler, calls := flaky(1, http.StatusServiceUnavailable, http.NotFoundHandler())
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	c := newClient(t, server, client.WithRetries(3, time.Millisecond))
+
+	err := c.CreateMovie(context.Background(), &model.Movie{ID: 1, Name: "first"})
+
+	assert.IsType(t, &client.StatusError{}, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(calls), "Requests which are not idempotent should be sent once")
+}
+
+func TestClientRetriesStopWithContext(t *testing.T) {
+	handler, _ := flaky(10, http.StatusServiceUnavailable, http.NotFoundHandler())
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	c := newClient(t, server, client.WithRetries(5, time.Hour))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := c.ListMovies(ctx)
+
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestClientWithToken(t *testing.T) {
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		w.Write([]byte("[]"))
+	}))
+	defer server.Close()
+	c := newClient(t, server, client.WithToken("secret"))
+
+	_, err := c.ListMovies(context.Background())
+
+	assert.Nil(t, err)
+	assert.Equal(t, "Bearer secret", auth)
+}
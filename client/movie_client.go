@@ -0,0 +1,135 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+)
+
+const defaultPageSize = 50
+
+// ListMovies returns every movie at once, see Movies for walking through them page by page
+func (c *Client) ListMovies(ctx context.Context) ([]model.Movie, error) {
+	var movies []model.Movie
+	_, err := c.do(ctx, http.MethodGet, "/movies", nil, nil, &movies)
+	return movies, err
+}
+
+func (c *Client) GetMovie(ctx context.Context, id int) (model.Movie, error) {
+	var m model.Movie
+	_, err := c.do(ctx, http.MethodGet, fmt.Sprintf("/movies/%d", id), nil, nil, &m)
+	return m, serviceError(err, notExisting(id), nil)
+}
+
+func (c *Client) CreateMovie(ctx context.Context, m *model.Movie) error {
+	_, err := c.do(ctx, http.MethodPost, "/movies", nil, m, nil)
+	return serviceError(err, nil, &util.ExistingRecordError{Identification: fmt.Sprintf("ID: %v", m.ID)})
+}
+
+// UpdateMovie changes the movie, as long as it is still at the version of m.
+// Without a version the changes are applied to whichever version is current.
+func (c *Client) UpdateMovie(ctx context.Context, id int, m *model.Movie) error {
+	_, err := c.do(ctx, http.MethodPut, fmt.Sprintf("/movies/%d", id), nil, m, nil)
+	return serviceError(err, notExisting(id), &util.StaleRecordError{Identification: fmt.Sprintf("ID: %v", id), Version: m.Version})
+}
+
+// DeleteMovie moves the movie to the trash
+func (c *Client) DeleteMovie(ctx context.Context, id int) error {
+	_, err := c.do(ctx, http.MethodDelete, fmt.Sprintf("/movies/%d", id), nil, nil, nil)
+	return serviceError(err, notExisting(id), nil)
+}
+
+// SearchMovies returns up to limit movies matching the query, the best matches first
+func (c *Client) SearchMovies(ctx context.Context, query string, limit int) ([]model.SearchResult, error) {
+	q := url.Values{"q": {query}}
+	if limit > 0 {
+		q.Set("limit", strconv.Itoa(limit))
+	}
+	var results []model.SearchResult
+	_, err := c.do(ctx, http.MethodGet, "/movies/search", q, nil, &results)
+	return results, err
+}
+
+func notExisting(id int) error {
+	return &util.NotExistingRecordError{Identification: fmt.Sprintf("ID: %v", id)}
+}
+
+// MovieIterator walks through the movies in the order of their IDs, requesting a page at a time:
+//
+//	it := c.Movies(ctx, 100)
+//	for it.Next() {
+//		m := it.Movie()
+//	}
+//	if err := it.Err(); err != nil {
+//		return err
+//	}
+type MovieIterator struct {
+	client   *Client
+	ctx      context.Context
+	pageSize int
+
+	page    []model.Movie
+	index   int
+	afterID int
+	last    bool
+	err     error
+}
+
+// Movies returns an iterator over every movie, requesting pageSize movies at a time.
+// Non-positive page sizes fall back to the default one.
+func (c *Client) Movies(ctx context.Context, pageSize int) *MovieIterator {
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+	return &MovieIterator{client: c, ctx: ctx, pageSize: pageSize, index: -1}
+}
+
+// Next advances to the next movie, reporting false once all are visited or a page could not be requested
+func (it *MovieIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	if it.index+1 < len(it.page) {
+		it.index++
+		return true
+	}
+	if it.last {
+		return false
+	}
+
+	query := url.Values{"limit": {strconv.Itoa(it.pageSize)}}
+	if it.afterID != 0 {
+		query.Set("after", strconv.Itoa(it.afterID))
+	}
+	var page []model.Movie
+	header, err := it.client.do(it.ctx, http.MethodGet, "/movies", query, nil, &page)
+	if err != nil {
+		it.err = err
+		return false
+	}
+
+	// Only full pages link to a following one
+	it.last = !strings.Contains(header.Get("Link"), `rel="next"`)
+	it.page, it.index = page, 0
+	if len(page) == 0 {
+		return false
+	}
+	it.afterID = page[len(page)-1].ID
+	return true
+}
+
+// Movie returns the current movie, valid after Next reported true
+func (it *MovieIterator) Movie() model.Movie {
+	return it.page[it.index]
+}
+
+// Err returns the error which stopped the iteration, if any
+func (it *MovieIterator) Err() error {
+	return it.err
+}
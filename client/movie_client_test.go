@@ -0,0 +1,114 @@
+package client_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/movie"
+	"github.com/Hunterlemming/golang-microservice-example/api/openapi"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+	"github.com/Hunterlemming/golang-microservice-example/client"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+// newServer serves the movies through the router of the API, validating the requests like the API does
+func newServer(t *testing.T, movies ...model.Movie) *httptest.Server {
+	api := model.Api{Router: mux.NewRouter()}
+	api.Router.Use(openapi.RequestValidator(api.Router, 1<<20))
+	movie.InitializeMoviesPipelineWithService(&api, movie.NewInMemoryMovieService(movies...))
+
+	server := httptest.NewServer(api.Router)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newClient(t *testing.T, server *httptest.Server, opts ...client.Option) *client.Client {
+	c, err := client.NewClient(server.URL, opts...)
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when creating the client", err)
+	}
+	return c
+}
+
+func TestClientCrud(t *testing.T) {
+	c := newClient(t, newServer(t, model.Movie{ID: 1, Name: "first"}))
+	ctx := context.Background()
+
+	assert.Nil(t, c.CreateMovie(ctx, &model.Movie{ID: 2, Name: "second"}))
+	assert.Nil(t, c.UpdateMovie(ctx, 1, &model.Movie{Name: "updated", Version: 1}))
+
+	m, err := c.GetMovie(ctx, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, model.Movie{ID: 1, Name: "updated", Version: 2}, m)
+
+	assert.Nil(t, c.DeleteMovie(ctx, 2))
+	movies, err := c.ListMovies(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, []model.Movie{{ID: 1, Name: "updated", Version: 2}}, movies)
+}
+
+func TestClientServiceErrors(t *testing.T) {
+	c := newClient(t, newServer(t, model.Movie{ID: 1, Name: "first", Version: 2}))
+	ctx := context.Background()
+
+	_, err := c.GetMovie(ctx, 2)
+	assert.IsType(t, &util.NotExistingRecordError{}, err)
+	assert.IsType(t, &util.NotExistingRecordError{}, c.DeleteMovie(ctx, 2))
+	assert.IsType(t, &util.ExistingRecordError{}, c.CreateMovie(ctx, &model.Movie{ID: 1, Name: "again"}))
+	assert.Equal(t, &util.StaleRecordError{Identification: "ID: 1", Version: 1}, c.UpdateMovie(ctx, 1, &model.Movie{Name: "outdated", Version: 1}))
+}
+
+func TestClientValidationError(t *testing.T) {
+	c := newClient(t, newServer(t))
+
+	err := c.CreateMovie(context.Background(), &model.Movie{ID: 1})
+
+	var statusErr *client.StatusError
+	if assert.ErrorAs(t, err, &statusErr) {
+		assert.Equal(t, http.StatusUnprocessableEntity, statusErr.StatusCode)
+		assert.Equal(t, []util.FieldError{{Field: "body.name", Message: "minimum string length is 1"}}, statusErr.Violations)
+	}
+}
+
+func TestClientSearchMovies(t *testing.T) {
+	c := newClient(t, newServer(t, model.Movie{ID: 1, Name: "The Lord of the Rings"}, model.Movie{ID: 2, Name: "Alien"}))
+
+	results, err := c.SearchMovies(context.Background(), "lord", 5)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(results))
+	assert.Equal(t, 1, results[0].Movie.ID)
+}
+
+func TestClientMovieIterator(t *testing.T) {
+	movies := []model.Movie{{ID: 1, Name: "a"}, {ID: 2, Name: "b"}, {ID: 3, Name: "c"}, {ID: 4, Name: "d"}}
+	c := newClient(t, newServer(t, movies...))
+
+	var ids []int
+	it := c.Movies(context.Background(), 2)
+	for it.Next() {
+		ids = append(ids, it.Movie().ID)
+	}
+
+	assert.Nil(t, it.Err())
+	assert.Equal(t, []int{1, 2, 3, 4}, ids, "Every page should be visited, including the empty one after the last full page")
+}
+
+func TestClientMovieIteratorError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Service unreachable", http.StatusInternalServerError)
+	}))
+	defer server.Close()
+	c := newClient(t, server)
+
+	it := c.Movies(context.Background(), 2)
+
+	assert.False(t, it.Next())
+	assert.IsType(t, &client.StatusError{}, it.Err())
+	assert.Equal(t, "500 Service unreachable", it.Err().Error())
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+
+	"gopkg.in/yaml.v3"
+)
+
+func runList(ctx context.Context, c *cli, args []string) error {
+	fs := c.flagSet("list")
+	pageSize := fs.Int("page-size", 100, "movies requested at a time")
+	if _, err := c.parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	movies, err := c.allMovies(ctx, *pageSize)
+	if err != nil {
+		return err
+	}
+	return write(c.stdout, c.opts.output, movies, movieTable(movies...))
+}
+
+func runGet(ctx context.Context, c *cli, args []string) error {
+	rest, err := c.parse(c.flagSet("get"), args, 1)
+	if err != nil {
+		return err
+	}
+	id, err := parseID(rest[0])
+	if err != nil {
+		return err
+	}
+
+	return c.printMovie(ctx, id)
+}
+
+func runCreate(ctx context.Context, c *cli, args []string) error {
+	fs := c.flagSet("create")
+	id := fs.Int("id", 0, "ID of the movie")
+	name := fs.String("name", "", "name of the movie")
+	if _, err := c.parse(fs, args, 0); err != nil {
+		return err
+	}
+	if *id == 0 || *name == "" {
+		return &usageError{"the -id and -name flags are required"}
+	}
+
+	if err := c.client.CreateMovie(ctx, &model.Movie{ID: *id, Name: *name}); err != nil {
+		return err
+	}
+	return c.printMovie(ctx, *id)
+}
+
+func runUpdate(ctx context.Context, c *cli, args []string) error {
+	fs := c.flagSet("update")
+	name := fs.String("name", "", "new name of the movie")
+	version := fs.Int("version", 0, "version the changes are based on, rejected if the movie changed since (default: any)")
+	rest, err := c.parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	id, err := parseID(rest[0])
+	if err != nil {
+		return err
+	}
+	if *name == "" {
+		return &usageError{"the -name flag is required"}
+	}
+
+	// The flags do not carry the synopsis, which is kept
+	current, err := c.client.GetMovie(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := c.client.UpdateMovie(ctx, id, &model.Movie{Name: *name, Synopsis: current.Synopsis, Version: *version}); err != nil {
+		return err
+	}
+	return c.printMovie(ctx, id)
+}
+
+func runDelete(ctx context.Context, c *cli, args []string) error {
+	rest, err := c.parse(c.flagSet("delete"), args, 1)
+	if err != nil {
+		return err
+	}
+	id, err := parseID(rest[0])
+	if err != nil {
+		return err
+	}
+
+	if err := c.client.DeleteMovie(ctx, id); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.stderr, "movie %d moved to the trash\n", id)
+	return nil
+}
+
+func runSearch(ctx context.Context, c *cli, args []string) error {
+	fs := c.flagSet("search")
+	limit := fs.Int("limit", 0, "maximum number of results (default: the limit of the API)")
+	rest, err := c.parse(fs, args, -1)
+	if err != nil {
+		return err
+	}
+	if len(rest) == 0 {
+		return &usageError{"the query is missing"}
+	}
+
+	results, err := c.client.SearchMovies(ctx, strings.Join(rest, " "), *limit)
+	if err != nil {
+		return err
+	}
+	return write(c.stdout, c.opts.output, results, searchTable(results))
+}
+
+// runImport creates the movies of the file one by one, and reports the ones which failed.
+// Existing movies are updated instead with the -upsert flag, unless they changed since the version of the file.
+func runImport(ctx context.Context, c *cli, args []string) error {
+	fs := c.flagSet("import")
+	upsert := fs.Bool("upsert", false, "update the movies which already exist instead of failing")
+	format := fs.String("format", "", "format of the file: json or yaml (default: by its extension, json for stdin)")
+	rest, err := c.parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	movies, err := c.readMovies(rest[0], *format)
+	if err != nil {
+		return err
+	}
+
+	var created, updated, failed int
+	for i := range movies {
+		m := &movies[i]
+		err := c.client.CreateMovie(ctx, m)
+		var existing *util.ExistingRecordError
+		if *upsert && errors.As(err, &existing) {
+			err = c.client.UpdateMovie(ctx, m.ID, &model.Movie{Name: m.Name, Synopsis: m.Synopsis, Version: m.Version})
+			if err == nil {
+				updated++
+				continue
+			}
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			fmt.Fprintf(c.stderr, "movie %d: %s\n", m.ID, err)
+			failed++
+			continue
+		}
+		created++
+	}
+
+	fmt.Fprintf(c.stderr, "created %d, updated %d, failed %d\n", created, updated, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d of %d movies could not be imported", failed, len(movies))
+	}
+	return nil
+}
+
+// runExport writes every movie in a form the import reads back
+func runExport(ctx context.Context, c *cli, args []string) error {
+	fs := c.flagSet("export")
+	pageSize := fs.Int("page-size", 100, "movies requested at a time")
+	rest, err := c.parse(fs, args, -1)
+	if err != nil {
+		return err
+	}
+	if len(rest) > 1 {
+		return &usageError{"expected a single file"}
+	}
+
+	movies, err := c.allMovies(ctx, *pageSize)
+	if err != nil {
+		return err
+	}
+
+	format := c.opts.output
+	if format == formatTable {
+		format = formatJSON
+	}
+	if len(rest) == 0 || rest[0] == "-" {
+		return write(c.stdout, format, movies, table{})
+	}
+
+	if f := formatOf(rest[0]); f != "" {
+		format = f
+	}
+	file, err := os.Create(rest[0])
+	if err != nil {
+		return err
+	}
+	if err := write(file, format, movies, table{}); err != nil {
+		file.Close()
+		return err
+	}
+	fmt.Fprintf(c.stderr, "exported %d movies to %s\n", len(movies), rest[0])
+	return file.Close()
+}
+
+func (c *cli) printMovie(ctx context.Context, id int) error {
+	m, err := c.client.GetMovie(ctx, id)
+	if err != nil {
+		return err
+	}
+	return write(c.stdout, c.opts.output, m, movieTable(m))
+}
+
+func (c *cli) allMovies(ctx context.Context, pageSize int) ([]model.Movie, error) {
+	movies := make([]model.Movie, 0)
+	it := c.client.Movies(ctx, pageSize)
+	for it.Next() {
+		movies = append(movies, it.Movie())
+	}
+	return movies, it.Err()
+}
+
+func (c *cli) readMovies(path, format string) ([]model.Movie, error) {
+	var r io.Reader = c.stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		r = file
+		if format == "" {
+			format = formatOf(path)
+		}
+	}
+
+	var movies []model.Movie
+	var err error
+	switch format {
+	case "", formatJSON:
+		err = json.NewDecoder(r).Decode(&movies)
+	case formatYAML:
+		err = yaml.NewDecoder(r).Decode(&movies)
+	default:
+		return nil, &usageError{fmt.Sprintf("unknown file format [%s], expected json or yaml", format)}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading [%s]: %w", path, err)
+	}
+	return movies, nil
+}
+
+// formatOf tells the format of the file by its extension, empty if it is unknown
+func formatOf(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return formatJSON
+	case ".yaml", ".yml":
+		return formatYAML
+	}
+	return ""
+}
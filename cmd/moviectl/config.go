@@ -0,0 +1,84 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	defaultURL     = "http://localhost:8080"
+	defaultProfile = "default"
+)
+
+// options are the settings shared by every command, taken from the flags, the environment and the profile in this order
+type options struct {
+	configFile string
+	profile    string
+	url        string
+	token      string
+	output     string
+}
+
+func (o *options) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.configFile, "config", "", "config file holding the profiles (default $MOVIECTL_CONFIG or ~/.moviectl.yaml)")
+	fs.StringVar(&o.profile, "profile", "", "profile of the environment to talk to (default $MOVIECTL_PROFILE or the current-profile of the config)")
+	fs.StringVar(&o.url, "url", "", "base URL of the API, overriding the profile")
+	fs.StringVar(&o.token, "token", "", "bearer token, overriding the profile")
+	fs.StringVar(&o.output, "o", "", "output format: table, json or yaml")
+}
+
+// resolve completes the options missing from the flags. A config file is only required if it was asked for.
+//
+// The profiles are configured as:
+//
+//	current-profile: local
+//	profiles:
+//	  local:
+//	    url: http://localhost:8080
+//	  staging:
+//	    url: https://movies.staging.example.com
+//	    token: secret
+//	    output: json
+func (o *options) resolve() error {
+	v := viper.New()
+	v.SetConfigType("yaml")
+
+	explicit := o.configFile != "" || os.Getenv("MOVIECTL_CONFIG") != ""
+	file := firstOf(o.configFile, os.Getenv("MOVIECTL_CONFIG"))
+	if file == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			file = filepath.Join(home, ".moviectl.yaml")
+		}
+	}
+	if file != "" {
+		v.SetConfigFile(file)
+		if err := v.ReadInConfig(); err != nil && (explicit || !errors.Is(err, os.ErrNotExist)) {
+			return fmt.Errorf("reading config [%s]: %w", file, err)
+		}
+	}
+
+	o.profile = firstOf(o.profile, os.Getenv("MOVIECTL_PROFILE"), v.GetString("current-profile"), defaultProfile)
+	if file != "" && len(v.GetStringMap("profiles")) > 0 && !v.IsSet("profiles."+o.profile) {
+		return fmt.Errorf("profile [%s] is not configured in [%s]", o.profile, file)
+	}
+
+	key := "profiles." + o.profile + "."
+	o.url = firstOf(o.url, os.Getenv("MOVIECTL_URL"), v.GetString(key+"url"), defaultURL)
+	o.token = firstOf(o.token, os.Getenv("MOVIECTL_TOKEN"), v.GetString(key+"token"))
+	o.output = firstOf(o.output, v.GetString(key+"output"), formatTable)
+	return checkFormat(o.output)
+}
+
+func firstOf(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
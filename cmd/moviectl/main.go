@@ -0,0 +1,161 @@
+// Command moviectl operates the movie catalog through the API.
+//
+//	moviectl <command> [flags] [arguments]
+//
+// The environments are configured as profiles, see the -config flag.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+
+	"github.com/Hunterlemming/golang-microservice-example/client"
+)
+
+type command struct {
+	usage   string
+	summary string
+	run     func(ctx context.Context, c *cli, args []string) error
+}
+
+var commands = map[string]command{
+	"list":   {"list [flags]", "List the movies", runList},
+	"get":    {"get [flags] <id>", "Show a movie", runGet},
+	"create": {"create [flags] -id <id> -name <name>", "Create a movie", runCreate},
+	"update": {"update [flags] <id> -name <name>", "Rename a movie", runUpdate},
+	"delete": {"delete [flags] <id>", "Move a movie to the trash", runDelete},
+	"search": {"search [flags] <query>", "Search the movies by name", runSearch},
+	"import": {"import [flags] <file>", "Create the movies of a JSON or YAML file, - for stdin", runImport},
+	"export": {"export [flags] [file]", "Write every movie to a JSON or YAML file, stdout by default", runExport},
+}
+
+// usageError is answered with the usage of the command and exit code 2
+type usageError struct {
+	message string
+}
+
+func (e *usageError) Error() string {
+	return e.message
+}
+
+// cli holds the streams and the connection of a command
+type cli struct {
+	usage  string
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+	opts   options
+	client *client.Client
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	code := run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+	stop()
+	os.Exit(code)
+}
+
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		printUsage(stderr)
+		if len(args) == 0 {
+			return 2
+		}
+		return 0
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "moviectl: unknown command [%s]\n\n", args[0])
+		printUsage(stderr)
+		return 2
+	}
+
+	c := &cli{usage: cmd.usage, stdin: stdin, stdout: stdout, stderr: stderr}
+	err := cmd.run(ctx, c, args[1:])
+	var usageErr *usageError
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.As(err, &usageErr):
+		fmt.Fprintf(stderr, "moviectl: %s\nusage: moviectl %s\n", err, cmd.usage)
+		return 2
+	}
+	fmt.Fprintf(stderr, "moviectl: %s\n", err)
+	return 1
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "usage: moviectl <command> [flags] [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-8s %s\n", name, commands[name].summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `Run "moviectl <command> -h" for the flags of a command.`)
+}
+
+// flagSet returns the flags of the command, including the ones every command shares
+func (c *cli) flagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(c.stderr, "usage: moviectl %s\n\nflags:\n", c.usage)
+		fs.PrintDefaults()
+	}
+	c.opts.register(fs)
+	return fs
+}
+
+// parse parses the arguments, expecting the given number of positional ones, and connects to the API.
+// Flags may follow the positional arguments.
+func (c *cli) parse(fs *flag.FlagSet, args []string, positional int) ([]string, error) {
+	var rest []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		rest = append(rest, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+	if positional >= 0 && len(rest) != positional {
+		return nil, &usageError{fmt.Sprintf("expected %d argument(s), got %d", positional, len(rest))}
+	}
+
+	if err := c.opts.resolve(); err != nil {
+		return nil, err
+	}
+	cl, err := client.NewClient(c.opts.url, client.WithToken(c.opts.token))
+	if err != nil {
+		return nil, err
+	}
+	c.client = cl
+	return rest, nil
+}
+
+func parseID(arg string) (int, error) {
+	id, err := strconv.Atoi(arg)
+	if err != nil {
+		return 0, &usageError{fmt.Sprintf("invalid ID [%s]", arg)}
+	}
+	return id, nil
+}
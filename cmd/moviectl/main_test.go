@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/movie"
+	"github.com/Hunterlemming/golang-microservice-example/api/openapi"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+// newServer serves the movies through the router of the API, and isolates the test from the local configuration
+func newServer(t *testing.T, movies ...model.Movie) *httptest.Server {
+	t.Setenv("HOME", t.TempDir())
+	for _, key := range []string{"MOVIECTL_CONFIG", "MOVIECTL_PROFILE", "MOVIECTL_URL", "MOVIECTL_TOKEN"} {
+		t.Setenv(key, "")
+	}
+
+	api := model.Api{Router: mux.NewRouter()}
+	api.Router.Use(openapi.RequestValidator(api.Router, 1<<20))
+	movie.InitializeMoviesPipelineWithService(&api, movie.NewInMemoryMovieService(movies...))
+
+	server := httptest.NewServer(api.Router)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func execute(stdin string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), args, strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRunList(t *testing.T) {
+	server := newServer(t, model.Movie{ID: 1, Name: "Alien"}, model.Movie{ID: 2, Name: "Heat"})
+
+	code, stdout, _ := execute("", "list", "-url", server.URL, "-page-size", "1")
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "ID  NAME   VERSION\n1   Alien  1\n2   Heat   1\n", stdout)
+}
+
+func TestRunGetJson(t *testing.T) {
+	server := newServer(t, model.Movie{ID: 1, Name: "Alien"})
+
+	code, stdout, _ := execute("", "get", "1", "-url", server.URL, "-o", "json")
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "{\n  \"id\": 1,\n  \"name\": \"Alien\",\n  \"version\": 1\n}\n", stdout)
+}
+
+func TestRunCreateUpdateDelete(t *testing.T) {
+	server := newServer(t)
+
+	code, stdout, _ := execute("", "create", "-url", server.URL, "-id", "1", "-name", "Alien", "-o", "yaml")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "id: 1\nname: Alien\nversion: 1\n", stdout)
+
+	code, stdout, _ = execute("", "update", "1", "-name", "Aliens", "-version", "1", "-url", server.URL, "-o", "yaml")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "id: 1\nname: Aliens\nversion: 2\n", stdout)
+
+	code, _, stderr := execute("", "update", "1", "-name", "Alien 3", "-version", "1", "-url", server.URL)
+	assert.Equal(t, 1, code, "Changes to an outdated version should fail")
+	assert.Contains(t, stderr, "is no longer at version [1]")
+
+	code, _, _ = execute("", "delete", "1", "-url", server.URL)
+	assert.Equal(t, 0, code)
+	code, _, stderr = execute("", "get", "1", "-url", server.URL)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "does not exist")
+}
+
+func TestRunUpdateKeepsSynopsis(t *testing.T) {
+	server := newServer(t, model.Movie{ID: 1, Name: "Alien", Synopsis: "In space no one can hear you scream."})
+
+	code, stdout, _ := execute("", "update", "1", "-name", "Aliens", "-url", server.URL, "-o", "yaml")
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "id: 1\nname: Aliens\nsynopsis: In space no one can hear you scream.\nversion: 2\n", stdout)
+}
+
+func TestRunSearch(t *testing.T) {
+	server := newServer(t, model.Movie{ID: 1, Name: "The Lord of the Rings"}, model.Movie{ID: 2, Name: "Alien"})
+
+	code, stdout, _ := execute("", "search", "-url", server.URL, "lord", "rings")
+
+	assert.Equal(t, 0, code)
+	assert.True(t, strings.HasPrefix(stdout, "ID  NAME                   RANK\n1   The Lord of the Rings  "), stdout)
+}
+
+func TestRunImportExport(t *testing.T) {
+	source := newServer(t, model.Movie{ID: 1, Name: "Alien"}, model.Movie{ID: 2, Name: "Heat"})
+	file := filepath.Join(t.TempDir(), "movies.yaml")
+
+	code, _, stderr := execute("", "export", file, "-url", source.URL)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "exported 2 movies to "+file+"\n", stderr)
+
+	target := newServer(t, model.Movie{ID: 1, Name: "Old name"})
+	code, _, stderr = execute("", "import", file, "-url", target.URL)
+	assert.Equal(t, 1, code, "Existing movies should fail without -upsert")
+	assert.Contains(t, stderr, "created 1, updated 0, failed 1")
+
+	code, _, stderr = execute("", "import", "-upsert", file, "-url", target.URL)
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stderr, "created 0, updated 2, failed 0")
+
+	_, stdout, _ := execute("", "export", "-url", target.URL, "-o", "json")
+	assert.Equal(t, "[\n  {\n    \"id\": 1,\n    \"name\": \"Alien\",\n    \"version\": 2\n  },\n  {\n    \"id\": 2,\n    \"name\": \"Heat\",\n    \"version\": 2\n  }\n]\n", stdout)
+}
+
+func TestRunImportExportRatings(t *testing.T) {
+	source := newServer(t, model.Movie{ID: 1, Name: "Alien", RatingAverage: 4.5, RatingCount: 2})
+	file := filepath.Join(t.TempDir(), "movies.json")
+
+	code, _, _ := execute("", "export", file, "-url", source.URL)
+	assert.Equal(t, 0, code)
+
+	target := newServer(t)
+	code, _, stderr := execute("", "import", file, "-url", target.URL)
+	assert.Equal(t, 0, code, "The exported ratings should not keep the movies from being imported")
+	assert.Contains(t, stderr, "created 1, updated 0, failed 0")
+
+	code, _, stderr = execute("", "import", "-upsert", file, "-url", target.URL)
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stderr, "created 0, updated 1, failed 0")
+
+	_, stdout, _ := execute("", "get", "1", "-url", target.URL, "-o", "json")
+	assert.Equal(t, "{\n  \"id\": 1,\n  \"name\": \"Alien\",\n  \"version\": 2\n}\n", stdout, "The ratings are maintained by the reviews of the target")
+}
+
+func TestRunImportUpsertSynopsis(t *testing.T) {
+	server := newServer(t, model.Movie{ID: 1, Name: "Alien", Synopsis: "Old synopsis"})
+	file := `[{"id": 1, "name": "Alien", "synopsis": "New synopsis", "version": 1}]`
+
+	code, _, _ := execute(file, "import", "-upsert", "-", "-url", server.URL)
+	assert.Equal(t, 0, code)
+	_, stdout, _ := execute("", "get", "1", "-url", server.URL, "-o", "yaml")
+	assert.Equal(t, "id: 1\nname: Alien\nsynopsis: New synopsis\nversion: 2\n", stdout)
+
+	code, _, stderr := execute(file, "import", "-upsert", "-", "-url", server.URL)
+	assert.Equal(t, 1, code, "Movies changed since the version of the file should fail")
+	assert.Contains(t, stderr, "created 0, updated 0, failed 1")
+}
+
+func TestRunImportStdin(t *testing.T) {
+	server := newServer(t)
+
+	code, _, _ := execute(`[{"id": 1, "name": "Alien"}]`, "import", "-", "-url", server.URL)
+
+	assert.Equal(t, 0, code)
+	_, stdout, _ := execute("", "list", "-url", server.URL)
+	assert.Equal(t, "ID  NAME   VERSION\n1   Alien  1\n", stdout)
+}
+
+func TestRunProfiles(t *testing.T) {
+	server := newServer(t, model.Movie{ID: 1, Name: "Alien"})
+	config := filepath.Join(t.TempDir(), "config.yaml")
+	os.WriteFile(config, []byte(`current-profile: local
+profiles:
+  local:
+    url: http://127.0.0.1:1
+  test:
+    url: `+server.URL+`
+    output: json
+`), 0600)
+	t.Setenv("MOVIECTL_CONFIG", config)
+
+	code, stdout, _ := execute("", "get", "1", "-profile", "test")
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stdout, `"name": "Alien"`, "The output format of the profile should be used")
+
+	t.Setenv("MOVIECTL_PROFILE", "test")
+	code, _, _ = execute("", "get", "1")
+	assert.Equal(t, 0, code, "The profile should be taken from the environment")
+
+	code, _, stderr := execute("", "get", "1", "-profile", "prod")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "profile [prod] is not configured")
+}
+
+func TestRunUsageErrors(t *testing.T) {
+	newServer(t)
+
+	for _, args := range [][]string{{}, {"unknown"}, {"get"}, {"get", "one"}, {"create", "-name", "Alien"}, {"list", "-o", "xml"}} {
+		code, _, _ := execute("", args...)
+		assert.Equal(t, 2, code, "Arguments %v should be rejected", args)
+	}
+}
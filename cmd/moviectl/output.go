@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatYAML  = "yaml"
+)
+
+func checkFormat(format string) error {
+	switch format {
+	case formatTable, formatJSON, formatYAML:
+		return nil
+	}
+	return &usageError{fmt.Sprintf("unknown output format [%s], expected table, json or yaml", format)}
+}
+
+// table is the tabular form of a value, for the table output
+type table struct {
+	header []string
+	rows   [][]string
+}
+
+func movieTable(movies ...model.Movie) table {
+	t := table{header: []string{"ID", "NAME", "VERSION"}}
+	for _, m := range movies {
+		t.rows = append(t.rows, []string{strconv.Itoa(m.ID), m.Name, strconv.Itoa(m.Version)})
+	}
+	return t
+}
+
+func searchTable(results []model.SearchResult) table {
+	t := table{header: []string{"ID", "NAME", "RANK"}}
+	for _, r := range results {
+		t.rows = append(t.rows, []string{strconv.Itoa(r.Movie.ID), r.Movie.Name, strconv.FormatFloat(r.Rank, 'f', 3, 64)})
+	}
+	return t
+}
+
+// write prints v in the format, using its tabular form for the table output
+func write(w io.Writer, format string, v interface{}, t table) error {
+	switch format {
+	case formatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case formatYAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+		return enc.Close()
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(t.header, "\t"))
+	for _, row := range t.rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
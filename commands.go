@@ -0,0 +1,276 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+
+	"github.com/Hunterlemming/golang-microservice-example/api"
+	"github.com/Hunterlemming/golang-microservice-example/api/migration"
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/movie"
+	"github.com/Hunterlemming/golang-microservice-example/api/util"
+
+	"gopkg.in/yaml.v3"
+)
+
+// version is stamped at build time with -ldflags "-X main.version=v1.2.3"
+var version = "dev"
+
+// seedActor is recorded in the audit log as the author of the seeded movies
+const seedActor = "seed"
+
+func runServe(ctx context.Context, c *cli, args []string) error {
+	fs := c.flagSet("serve")
+	migrate := fs.Bool("migrate", false, "apply the pending migrations before serving")
+	if _, err := c.parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	db, err := c.openDatabase(ctx)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	migrator, err := newMigrator(db)
+	if err != nil {
+		return err
+	}
+	if *migrate {
+		if err := applyMigrations(ctx, migrator, c.stderr); err != nil {
+			return err
+		}
+	} else if pending, err := pendingMigrations(ctx, migrator); err != nil {
+		return err
+	} else if pending > 0 {
+		fmt.Fprintf(c.stderr, "%d migration(s) pending, run \"migrate up\" or serve with -migrate\n", pending)
+	}
+
+	return api.Serve(ctx, db)
+}
+
+func runMigrate(ctx context.Context, c *cli, args []string) error {
+	rest, err := c.parse(c.flagSet("migrate"), args, -1)
+	if err != nil {
+		return err
+	}
+	if len(rest) == 0 {
+		return &usageError{"the direction is missing"}
+	}
+
+	steps := 1
+	switch {
+	case rest[0] == "down" && len(rest) == 2:
+		if steps, err = strconv.Atoi(rest[1]); err != nil || steps < 1 {
+			return &usageError{fmt.Sprintf("invalid number of steps [%s]", rest[1])}
+		}
+	case len(rest) > 1:
+		return &usageError{"too many arguments"}
+	case rest[0] != "up" && rest[0] != "down" && rest[0] != "status":
+		return &usageError{fmt.Sprintf("unknown direction [%s], expected up, down or status", rest[0])}
+	}
+
+	db, err := c.openDatabase(ctx)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	migrator, err := newMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	switch rest[0] {
+	case "up":
+		return applyMigrations(ctx, migrator, c.stderr)
+	case "down":
+		reverted, err := migrator.Down(ctx, steps)
+		for _, m := range reverted {
+			fmt.Fprintf(c.stderr, "reverted %s\n", m)
+		}
+		return err
+	}
+
+	status, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+	for _, s := range status {
+		state := "pending"
+		if s.AppliedAt != nil {
+			state = "applied " + s.AppliedAt.Local().Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(c.stdout, "%-32s %s\n", s.Migration, state)
+	}
+	return nil
+}
+
+// runSeed creates the movies of the fixture file, skipping the ones which already exist
+func runSeed(ctx context.Context, c *cli, args []string) error {
+	rest, err := c.parse(c.flagSet("seed"), args, 1)
+	if err != nil {
+		return err
+	}
+
+	movies, err := readFixtures(rest[0])
+	if err != nil {
+		return err
+	}
+	db, err := c.openDatabase(ctx)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return seed(ctx, movie.NewMovieService(db), movies, c.stderr)
+}
+
+func runCheckConfig(ctx context.Context, c *cli, args []string) error {
+	if _, err := c.parse(c.flagSet("check-config"), args, 0); err != nil {
+		return err
+	}
+	if err := api.LoadConfig(c.configFile); err != nil {
+		return err
+	}
+
+	for _, s := range api.EffectiveConfig() {
+		fmt.Fprintf(c.stdout, "%s=%s\n", s.Key, s.Value)
+	}
+	problems := api.CheckConfig()
+	for _, problem := range problems {
+		fmt.Fprintln(c.stderr, problem)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("the configuration has %d problem(s)", len(problems))
+	}
+	return nil
+}
+
+func runVersion(ctx context.Context, c *cli, args []string) error {
+	if len(args) > 0 {
+		return &usageError{"expected no arguments"}
+	}
+
+	fmt.Fprintf(c.stdout, "version:  %s\n", version)
+	fmt.Fprintf(c.stdout, "go:       %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return nil
+	}
+	// The VCS settings are only recorded for binaries built from a checkout
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			fmt.Fprintf(c.stdout, "revision: %s\n", s.Value)
+		case "vcs.time":
+			fmt.Fprintf(c.stdout, "built:    %s\n", s.Value)
+		case "vcs.modified":
+			fmt.Fprintf(c.stdout, "modified: %s\n", s.Value)
+		}
+	}
+	return nil
+}
+
+// openDatabase loads and validates the configuration, then connects to the database
+func (c *cli) openDatabase(ctx context.Context) (*sql.DB, error) {
+	if err := api.LoadConfig(c.configFile); err != nil {
+		return nil, err
+	}
+	if problems := api.CheckConfig(); len(problems) > 0 {
+		for _, problem := range problems {
+			fmt.Fprintln(c.stderr, problem)
+		}
+		return nil, fmt.Errorf("the configuration has %d problem(s), see check-config", len(problems))
+	}
+	return api.OpenDatabase(ctx)
+}
+
+func newMigrator(db *sql.DB) (migration.Migrator, error) {
+	migrations, err := migration.Migrations()
+	if err != nil {
+		return nil, err
+	}
+	return migration.NewMigrator(db, migrations), nil
+}
+
+func applyMigrations(ctx context.Context, migrator migration.Migrator, w io.Writer) error {
+	applied, err := migrator.Up(ctx)
+	for _, m := range applied {
+		fmt.Fprintf(w, "applied %s\n", m)
+	}
+	if err == nil && len(applied) == 0 {
+		fmt.Fprintln(w, "the database is up to date")
+	}
+	return err
+}
+
+func pendingMigrations(ctx context.Context, migrator migration.Migrator) (int, error) {
+	status, err := migrator.Status(ctx)
+	pending := 0
+	for _, s := range status {
+		if s.AppliedAt == nil {
+			pending++
+		}
+	}
+	return pending, err
+}
+
+// readFixtures reads the movies of a JSON or YAML file, told apart by its extension
+func readFixtures(path string) ([]model.Movie, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var movies []model.Movie
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(content, &movies)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &movies)
+	default:
+		return nil, &usageError{fmt.Sprintf("unknown fixture format [%s], expected .json, .yaml or .yml", path)}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading [%s]: %w", path, err)
+	}
+	for i, m := range movies {
+		if m.ID <= 0 || m.Name == "" {
+			return nil, fmt.Errorf("reading [%s]: movie #%d needs a positive id and a name", path, i+1)
+		}
+	}
+	return movies, nil
+}
+
+// seed creates the movies through the service, so they get audited and published like any other.
+// Seeding is repeatable, the movies which already exist are left as they are.
+func seed(ctx context.Context, s movie.MovieService, movies []model.Movie, w io.Writer) error {
+	ctx = util.WithRequestID(util.WithActor(ctx, seedActor), util.NewRequestID())
+
+	var created, skipped int
+	for i := range movies {
+		err := s.CreateMovie(ctx, &movies[i])
+		var existing *util.ExistingRecordError
+		if errors.As(err, &existing) {
+			skipped++
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("movie %d: %w", movies[i].ID, err)
+		}
+		created++
+	}
+
+	fmt.Fprintf(w, "created %d, skipped %d existing\n", created, skipped)
+	return nil
+}
@@ -1,15 +1,131 @@
+// Command golang-microservice-example runs the movie catalog server and manages its database.
+//
+//	golang-microservice-example <command> [flags] [arguments]
+//
+// Without a command the server is started, as with "serve". The settings are read from the -config file
+// and the environment, see "check-config".
 package main
 
 import (
-	"net/http"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+)
 
-	"github.com/Hunterlemming/golang-microservice-example/api"
+const defaultConfigFile = ".env"
 
-	_ "github.com/gorilla/mux"
-)
+type command struct {
+	usage   string
+	summary string
+	run     func(ctx context.Context, c *cli, args []string) error
+}
+
+var commands = map[string]command{
+	"serve":        {"serve [flags]", "Run the REST and gRPC APIs", runServe},
+	"migrate":      {"migrate [flags] up|down [steps]|status", "Apply, revert or list the database migrations", runMigrate},
+	"seed":         {"seed [flags] <file>", "Create the movies of a JSON or YAML fixture file", runSeed},
+	"check-config": {"check-config [flags]", "Validate and print the effective configuration, secrets redacted", runCheckConfig},
+	"version":      {"version", "Print the version and build information", runVersion},
+}
+
+// usageError is answered with the usage of the command and exit code 2
+type usageError struct {
+	message string
+}
+
+func (e *usageError) Error() string {
+	return e.message
+}
+
+// cli holds the streams and the shared flags of a command
+type cli struct {
+	usage      string
+	stdout     io.Writer
+	stderr     io.Writer
+	configFile string
+}
 
 func main() {
-	api := api.Start()
-	defer api.DB.Close()
-	http.ListenAndServe(":8080", api.Router)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	code := run(ctx, os.Args[1:], os.Stdout, os.Stderr)
+	stop()
+	os.Exit(code)
+}
+
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		args = []string{"serve"}
+	}
+	if args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		printUsage(stderr)
+		return 0
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "unknown command [%s]\n\n", args[0])
+		printUsage(stderr)
+		return 2
+	}
+
+	c := &cli{usage: cmd.usage, stdout: stdout, stderr: stderr}
+	err := cmd.run(ctx, c, args[1:])
+	var usageErr *usageError
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.As(err, &usageErr):
+		fmt.Fprintf(stderr, "%s\nusage: %s\n", err, cmd.usage)
+		return 2
+	}
+	fmt.Fprintf(stderr, "%s: %s\n", args[0], err)
+	return 1
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "usage: <command> [flags] [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-13s %s\n", name, commands[name].summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `Run "<command> -h" for the flags of a command.`)
+}
+
+// flagSet returns the flags of the command, including the config file every command but version reads
+func (c *cli) flagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(c.stderr, "usage: %s\n\nflags:\n", c.usage)
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&c.configFile, "config", defaultConfigFile, "file holding the settings, overridden by the environment")
+	return fs
+}
+
+// parse parses the arguments, expecting the given number of positional ones, -1 for any number
+func (c *cli) parse(fs *flag.FlagSet, args []string, positional int) ([]string, error) {
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if positional >= 0 && fs.NArg() != positional {
+		return nil, &usageError{fmt.Sprintf("expected %d argument(s), got %d", positional, fs.NArg())}
+	}
+	return fs.Args(), nil
 }
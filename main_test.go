@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Hunterlemming/golang-microservice-example/api/model"
+	"github.com/Hunterlemming/golang-microservice-example/api/movie"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunVersion(t *testing.T) {
+	code, stdout, _ := execute("version")
+
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stdout, "version:  dev")
+}
+
+func TestRunUnknownCommandError(t *testing.T) {
+	code, _, stderr := execute("start")
+
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, "unknown command [start]")
+}
+
+func TestRunMigrateUsageError(t *testing.T) {
+	for _, args := range [][]string{{"migrate"}, {"migrate", "sideways"}, {"migrate", "down", "0"}, {"migrate", "up", "2"}} {
+		code, _, stderr := execute(args...)
+
+		assert.Equal(t, 2, code, "%v should be refused", args)
+		assert.Contains(t, stderr, "usage: migrate")
+	}
+}
+
+func TestRunCheckConfig(t *testing.T) {
+	defer viper.Reset()
+	file := writeFile(t, ".env", "APP_DB_USERNAME=postgres\nAPP_DB_PASSWORD=secret\nAPP_DB_NAME=movies\n")
+
+	code, stdout, stderr := execute("check-config", "-config", file)
+
+	assert.Equal(t, 0, code, stderr)
+	assert.Contains(t, stdout, "APP_DB_USERNAME=postgres\n")
+	assert.Contains(t, stdout, "APP_DB_PASSWORD=[redacted]\n")
+	assert.NotContains(t, stdout, "secret")
+}
+
+func TestRunCheckConfigInvalidError(t *testing.T) {
+	defer viper.Reset()
+	file := writeFile(t, ".env", "APP_DB_NAME=movies\n")
+
+	code, _, stderr := execute("check-config", "-config", file)
+
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "APP_DB_USERNAME is required")
+	assert.Contains(t, stderr, "the configuration has 1 problem(s)")
+}
+
+func TestReadFixtures(t *testing.T) {
+	file := writeFile(t, "movies.yaml", "- id: 1\n  name: first\n- id: 2\n  name: second\n")
+
+	movies, err := readFixtures(file)
+
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []model.Movie{{ID: 1, Name: "first"}, {ID: 2, Name: "second"}}, movies)
+}
+
+func TestReadFixturesInvalidMovieError(t *testing.T) {
+	file := writeFile(t, "movies.json", `[{"id": 1, "name": "first"}, {"id": 2}]`)
+
+	_, err := readFixtures(file)
+
+	assert.EqualError(t, err, "reading ["+file+"]: movie #2 needs a positive id and a name")
+}
+
+func TestReadFixturesSample(t *testing.T) {
+	movies, err := readFixtures(filepath.Join("fixtures", "movies.yaml"))
+
+	assert.Equal(t, nil, err)
+	assert.NotEmpty(t, movies)
+}
+
+func TestSeed(t *testing.T) {
+	s := movie.NewInMemoryMovieService(model.Movie{ID: 1, Name: "kept"})
+	var out bytes.Buffer
+
+	err := seed(context.Background(), s, []model.Movie{{ID: 1, Name: "first"}, {ID: 2, Name: "second"}}, &out)
+
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "created 1, skipped 1 existing\n", out.String())
+	m, _ := s.GetMovie(1)
+	assert.Equal(t, "kept", m.Name, "Existing movies should be left as they are")
+	m, _ = s.GetMovie(2)
+	assert.Equal(t, "second", m.Name)
+}
+
+func execute(args ...string) (int, string, string) {
+	var stdout, stderr strings.Builder
+	code := run(context.Background(), args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	file := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(file, []byte(content), 0600); err != nil {
+		t.Fatalf("the file could not be written: %s", err)
+	}
+	return file
+}